@@ -0,0 +1,149 @@
+package artifact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var (
+	ErrTooLarge = errors.New("artifact too large")
+)
+
+// Limits is the maximum size of each artifact in bytes, zero means unlimited
+type Limits struct {
+	Module         int64
+	ProviderBinary int64
+	SHASums        int64
+}
+
+// File is the uploaded artifact spooled to the temporary file
+type File struct {
+	*os.File
+	size int64
+}
+
+// Spool copies the body to the temporary file, failing with ErrTooLarge if it exceeds the limit
+func Spool(body io.Reader, limit int64) (*File, error) {
+	f, err := os.CreateTemp("", "kegistry-upload-*")
+	if err != nil {
+		return nil, err
+	}
+
+	r := body
+	if limit > 0 {
+		r = io.LimitReader(body, limit+1)
+	}
+
+	n, err := io.Copy(f, r)
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+
+	if limit > 0 && n > limit {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("%w: exceeds %d bytes", ErrTooLarge, limit)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+
+	return &File{
+		File: f,
+		size: n,
+	}, nil
+}
+
+func (f *File) Size() int64 {
+	return f.size
+}
+
+// Rewind seeks to the beginning of the file to read it again
+func (f *File) Rewind() error {
+	_, err := f.Seek(0, io.SeekStart)
+	return err
+}
+
+// SHA256 returns the hex encoded sha256 sum of the file, and rewinds it
+func (f *File) SHA256() (string, error) {
+	if err := f.Rewind(); err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f.File); err != nil {
+		return "", err
+	}
+
+	if err := f.Rewind(); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashV1 returns the "h1:" hash of the provider zip archive, and rewinds it
+func (f *File) HashV1() (string, error) {
+	h1, err := HashV1(f.File, f.size)
+	if err != nil {
+		return "", err
+	}
+
+	if err := f.Rewind(); err != nil {
+		return "", err
+	}
+
+	return h1, nil
+}
+
+// Identical reports whether the content read from r is identical to the file, and rewinds it
+func (f *File) Identical(r io.Reader) (bool, error) {
+	sum, err := f.SHA256()
+	if err != nil {
+		return false, err
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return false, err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)) == sum, nil
+}
+
+// ReadAll reads the whole file, and rewinds it
+func (f *File) ReadAll() ([]byte, error) {
+	if err := f.Rewind(); err != nil {
+		return nil, err
+	}
+
+	b, err := io.ReadAll(f.File)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.Rewind(); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Close closes and removes the temporary file
+func (f *File) Close() error {
+	err := f.File.Close()
+	if rerr := os.Remove(f.Name()); rerr != nil && err == nil {
+		err = rerr
+	}
+
+	return err
+}
@@ -0,0 +1,78 @@
+package artifact
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSpool(t *testing.T) {
+	cases := map[string]struct {
+		body     string
+		limit    int64
+		tooLarge bool
+	}{
+		"unlimited": {
+			body: strings.Repeat("x", 1024),
+		},
+		"within limit": {
+			body:  strings.Repeat("x", 1024),
+			limit: 1024,
+		},
+		"over limit": {
+			body:     strings.Repeat("x", 1025),
+			limit:    1024,
+			tooLarge: true,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			f, err := Spool(strings.NewReader(tc.body), tc.limit)
+			if tc.tooLarge {
+				if !errors.Is(err, ErrTooLarge) {
+					t.Fatalf("expected %v, got %v", ErrTooLarge, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if f.Size() != int64(len(tc.body)) {
+				t.Fatalf("expected the size %d, got %d", len(tc.body), f.Size())
+			}
+
+			ok, err := f.Identical(strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !ok {
+				t.Fatal("expected the spooled file to be identical to the body")
+			}
+
+			b, err := f.ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(b, []byte(tc.body)) {
+				t.Fatal("expected the spooled file to be read again after rewound")
+			}
+
+			name := f.Name()
+			if err := f.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := os.Stat(name); !os.IsNotExist(err) {
+				t.Fatalf("expected the spooled file to be removed, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package artifact
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// HashV1 returns the "h1:" hash of the provider zip archive used by Terraform in the lock files.
+// It is the hash of the extracted files in the same manner as golang.org/x/mod/sumdb/dirhash.Hash1.
+// https://developer.hashicorp.com/terraform/language/files/dependency-lock#checksum-verification
+func HashV1(r io.ReaderAt, size int64) (string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return "", fmt.Errorf("%w: not a zip: %v", ErrInvalidProviderBinary, err)
+	}
+
+	files := map[string]*zip.File{}
+	names := []string{}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		if strings.Contains(f.Name, "\n") {
+			return "", fmt.Errorf("%w: file name contains newline", ErrInvalidProviderBinary)
+		}
+
+		files[f.Name] = f
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		rc, err := files[name].Open()
+		if err != nil {
+			return "", err
+		}
+
+		fh := sha256.New()
+		_, err = io.Copy(fh, rc)
+		rc.Close()
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(h, "%x  %s\n", fh.Sum(nil), name)
+	}
+
+	return "h1:" + base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashZh returns the legacy "zh:" hash of the provider zip archive from its hex encoded sha256 sum
+func HashZh(sum string) string {
+	return "zh:" + strings.ToLower(sum)
+}
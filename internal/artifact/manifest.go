@@ -0,0 +1,62 @@
+package artifact
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+var (
+	ErrInvalidManifest  = errors.New("invalid terraform-registry-manifest.json")
+	ErrInvalidProtocols = errors.New("invalid protocol versions")
+)
+
+var (
+	protocolPattern = regexp.MustCompile(`^\d+\.\d+$`)
+)
+
+// RegistryManifest is the content of terraform-registry-manifest.json produced by goreleaser
+// https://developer.hashicorp.com/terraform/registry/providers/publishing#terraform-registry-manifest-file
+type RegistryManifest struct {
+	Version  int                      `json:"version"`
+	Metadata RegistryManifestMetadata `json:"metadata"`
+}
+
+type RegistryManifestMetadata struct {
+	ProtocolVersions []string `json:"protocol_versions"`
+}
+
+// ParseRegistryManifest parses terraform-registry-manifest.json of the version 1
+func ParseRegistryManifest(r io.Reader) (*RegistryManifest, error) {
+	var m RegistryManifest
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidManifest, err)
+	}
+
+	if m.Version != 1 {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidManifest, m.Version)
+	}
+
+	if err := ValidateProtocols(m.Metadata.ProtocolVersions); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidManifest, err)
+	}
+
+	return &m, nil
+}
+
+// ValidateProtocols validates the plugin protocol versions are in the form of <major>.<minor> (e.g. 5.0)
+func ValidateProtocols(protocols []string) error {
+	if len(protocols) == 0 {
+		return fmt.Errorf("%w: no protocol version found", ErrInvalidProtocols)
+	}
+
+	for _, p := range protocols {
+		if !protocolPattern.MatchString(p) {
+			return fmt.Errorf("%w: %q is not in the form of <major>.<minor>", ErrInvalidProtocols, p)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package artifact
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+var (
+	ErrInvalidModule = errors.New("invalid module package")
+)
+
+// ValidateModule validates the module package is a gzip tarball with
+// Terraform configuration files and no path escaping the module root.
+func ValidateModule(r io.Reader) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("%w: not a gzip: %v", ErrInvalidModule, err)
+	}
+	defer gr.Close()
+
+	hasTF := false
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%w: not a tarball: %v", ErrInvalidModule, err)
+		}
+
+		if err := validatePath(hdr.Name); err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeSymlink, tar.TypeLink:
+			// The absolute target must be refused before joined, which drops the leading slash
+			if isAbs(hdr.Linkname) {
+				return fmt.Errorf("%w: link %s points to absolute path %s", ErrInvalidModule, hdr.Name, hdr.Linkname)
+			}
+
+			target := hdr.Linkname
+			if hdr.Typeflag == tar.TypeSymlink {
+				target = path.Join(path.Dir(hdr.Name), hdr.Linkname)
+			}
+			if err := validatePath(target); err != nil {
+				return fmt.Errorf("%w: link %s points outside of the module", ErrInvalidModule, hdr.Name)
+			}
+		case tar.TypeReg:
+			if path.Ext(hdr.Name) == ".tf" || strings.HasSuffix(hdr.Name, ".tf.json") {
+				hasTF = true
+			}
+		}
+	}
+
+	if !hasTF {
+		return fmt.Errorf("%w: no .tf file found", ErrInvalidModule)
+	}
+
+	return nil
+}
+
+func validatePath(name string) error {
+	if isAbs(name) {
+		return fmt.Errorf("%w: absolute path %s", ErrInvalidModule, name)
+	}
+
+	for _, elem := range strings.FieldsFunc(name, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if elem == ".." {
+			return fmt.Errorf("%w: path %s contains ..", ErrInvalidModule, name)
+		}
+	}
+
+	return nil
+}
+
+// isAbs reports whether the name is the absolute path of either Unix or Windows
+func isAbs(name string) bool {
+	return path.IsAbs(name) || strings.HasPrefix(name, `\`) || (len(name) > 1 && name[1] == ':')
+}
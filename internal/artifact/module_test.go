@@ -0,0 +1,131 @@
+package artifact
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"testing"
+)
+
+// tarEntry is the entry of the module package built by newModule
+type tarEntry struct {
+	name     string
+	typeflag byte
+	linkname string
+	body     string
+}
+
+func newModule(t *testing.T, entries ...tarEntry) []byte {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	for _, e := range entries {
+		typeflag := e.typeflag
+		if typeflag == 0 {
+			typeflag = tar.TypeReg
+		}
+
+		if err := tw.WriteHeader(&tar.Header{
+			Name:     e.name,
+			Typeflag: typeflag,
+			Linkname: e.linkname,
+			Mode:     0644,
+			Size:     int64(len(e.body)),
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestValidateModule(t *testing.T) {
+	main := tarEntry{name: "main.tf", body: `variable "x" {}`}
+	cases := map[string]struct {
+		body  []byte
+		valid bool
+	}{
+		"valid": {
+			body:  newModule(t, main, tarEntry{name: "modules/sub/main.tf.json", body: "{}"}, tarEntry{name: "README.md"}),
+			valid: true,
+		},
+		"relative symlink": {
+			body:  newModule(t, main, tarEntry{name: "modules/link.tf", typeflag: tar.TypeSymlink, linkname: "../main.tf"}),
+			valid: true,
+		},
+		"hard link": {
+			body:  newModule(t, main, tarEntry{name: "copy.tf", typeflag: tar.TypeLink, linkname: "main.tf"}),
+			valid: true,
+		},
+		"no tf file": {
+			body: newModule(t, tarEntry{name: "README.md"}),
+		},
+		"parent entry": {
+			body: newModule(t, main, tarEntry{name: "../evil.tf"}),
+		},
+		"nested parent entry": {
+			body: newModule(t, main, tarEntry{name: "modules/../../evil.tf"}),
+		},
+		"absolute entry": {
+			body: newModule(t, main, tarEntry{name: "/etc/evil.tf"}),
+		},
+		"windows absolute entry": {
+			body: newModule(t, main, tarEntry{name: `C:\evil.tf`}),
+		},
+		"absolute symlink": {
+			body: newModule(t, main, tarEntry{name: "passwd", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"}),
+		},
+		"escaping symlink": {
+			body: newModule(t, main, tarEntry{name: "modules/passwd", typeflag: tar.TypeSymlink, linkname: "../../etc/passwd"}),
+		},
+		"absolute hard link": {
+			body: newModule(t, main, tarEntry{name: "passwd", typeflag: tar.TypeLink, linkname: "/etc/passwd"}),
+		},
+		"escaping hard link": {
+			body: newModule(t, main, tarEntry{name: "passwd", typeflag: tar.TypeLink, linkname: "../etc/passwd"}),
+		},
+		"not gzip": {
+			body: []byte("main.tf"),
+		},
+		"not tarball": {
+			body: func() []byte {
+				buf := new(bytes.Buffer)
+				gw := gzip.NewWriter(buf)
+				gw.Write(bytes.Repeat([]byte("x"), 1024))
+				gw.Close()
+				return buf.Bytes()
+			}(),
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			err := ValidateModule(bytes.NewReader(tc.body))
+			if tc.valid {
+				if err != nil {
+					t.Fatalf("expected valid, got %v", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrInvalidModule) {
+				t.Fatalf("expected %v, got %v", ErrInvalidModule, err)
+			}
+		})
+	}
+}
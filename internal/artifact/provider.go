@@ -0,0 +1,72 @@
+package artifact
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalidProviderBinary = errors.New("invalid provider binary")
+)
+
+var (
+	// Magic numbers of ELF, PE and Mach-O (32 bit, 64 bit and universal) executables
+	executableMagics = [][]byte{
+		{0x7f, 'E', 'L', 'F'},
+		{'M', 'Z'},
+		{0xfe, 0xed, 0xfa, 0xce},
+		{0xce, 0xfa, 0xed, 0xfe},
+		{0xfe, 0xed, 0xfa, 0xcf},
+		{0xcf, 0xfa, 0xed, 0xfe},
+		{0xca, 0xfe, 0xba, 0xbe},
+	}
+)
+
+// ValidateProviderBinary validates the provider binary is a zip archive with
+// the single executable named terraform-provider-<name>_v<version>.
+// The protocol suffix (e.g. _x5) and the .exe extension are allowed.
+func ValidateProviderBinary(r io.ReaderAt, size int64, name, version string) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("%w: not a zip: %v", ErrInvalidProviderBinary, err)
+	}
+
+	files := []*zip.File{}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		files = append(files, f)
+	}
+
+	if len(files) != 1 {
+		return fmt.Errorf("%w: %d files found, expected a single executable", ErrInvalidProviderBinary, len(files))
+	}
+
+	f := files[0]
+	pattern := fmt.Sprintf(`^terraform-provider-%s_v%s(_x\d+)?(\.exe)?$`, regexp.QuoteMeta(name), regexp.QuoteMeta(strings.TrimPrefix(version, "v")))
+	if !regexp.MustCompile(pattern).MatchString(f.Name) {
+		return fmt.Errorf("%w: unexpected file %s, expected terraform-provider-%s_v%s", ErrInvalidProviderBinary, f.Name, name, strings.TrimPrefix(version, "v"))
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProviderBinary, err)
+	}
+	defer rc.Close()
+
+	magic := make([]byte, 4)
+	n, _ := io.ReadFull(rc, magic)
+	for _, m := range executableMagics {
+		if n >= len(m) && bytes.Equal(magic[:len(m)], m) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s is not an executable", ErrInvalidProviderBinary, f.Name)
+}
@@ -0,0 +1,90 @@
+package artifact
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"testing"
+)
+
+var elf = "\x7fELF\x02\x01\x01"
+
+func newProviderBinary(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for name, body := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestValidateProviderBinary(t *testing.T) {
+	cases := map[string]struct {
+		body  []byte
+		valid bool
+	}{
+		"valid": {
+			body:  newProviderBinary(t, map[string]string{"terraform-provider-foo_v1.0.0": elf}),
+			valid: true,
+		},
+		"protocol suffix and exe": {
+			body:  newProviderBinary(t, map[string]string{"terraform-provider-foo_v1.0.0_x5.exe": "MZ\x90\x00"}),
+			valid: true,
+		},
+		"directory": {
+			body:  newProviderBinary(t, map[string]string{"bin/": "", "terraform-provider-foo_v1.0.0": elf}),
+			valid: true,
+		},
+		"not zip": {
+			body: []byte(elf),
+		},
+		"empty": {
+			body: newProviderBinary(t, map[string]string{}),
+		},
+		"multiple files": {
+			body: newProviderBinary(t, map[string]string{"terraform-provider-foo_v1.0.0": elf, "README.md": "foo"}),
+		},
+		"other name": {
+			body: newProviderBinary(t, map[string]string{"terraform-provider-bar_v1.0.0": elf}),
+		},
+		"other version": {
+			body: newProviderBinary(t, map[string]string{"terraform-provider-foo_v1.0.1": elf}),
+		},
+		"path traversal": {
+			body: newProviderBinary(t, map[string]string{"../terraform-provider-foo_v1.0.0": elf}),
+		},
+		"not executable": {
+			body: newProviderBinary(t, map[string]string{"terraform-provider-foo_v1.0.0": "#!/bin/sh"}),
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			err := ValidateProviderBinary(bytes.NewReader(tc.body), int64(len(tc.body)), "foo", "1.0.0")
+			if tc.valid {
+				if err != nil {
+					t.Fatalf("expected valid, got %v", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrInvalidProviderBinary) {
+				t.Fatalf("expected %v, got %v", ErrInvalidProviderBinary, err)
+			}
+		})
+	}
+}
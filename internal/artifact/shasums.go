@@ -0,0 +1,90 @@
+package artifact
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+var (
+	ErrInvalidSHASums   = errors.New("invalid SHA256SUMS")
+	ErrInvalidSignature = errors.New("invalid SHA256SUMS signature")
+	ErrSHASumMismatch   = errors.New("sha256 sum mismatch")
+	ErrSHASumNotListed  = errors.New("file not listed in SHA256SUMS")
+)
+
+// SHASums is the content of SHA256SUMS, the hex encoded sha256 sum by the filename
+type SHASums map[string]string
+
+// ParseSHASums parses SHA256SUMS in the format of sha256sum(1)
+func ParseSHASums(r io.Reader) (SHASums, error) {
+	sums := SHASums{}
+	scanner := bufio.NewScanner(r)
+	for i := 1; scanner.Scan(); i++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%w: malformed line %d", ErrInvalidSHASums, i)
+		}
+
+		sum, filename := strings.ToLower(fields[0]), strings.TrimPrefix(fields[1], "*")
+		if b, err := hex.DecodeString(sum); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("%w: invalid sha256 sum at line %d", ErrInvalidSHASums, i)
+		}
+		sums[filename] = sum
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSHASums, err)
+	}
+
+	if len(sums) == 0 {
+		return nil, fmt.Errorf("%w: no sum found", ErrInvalidSHASums)
+	}
+
+	return sums, nil
+}
+
+// Verify verifies the sha256 sum of the file matches its line
+func (s SHASums) Verify(filename, sum string) error {
+	expected, ok := s[filename]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrSHASumNotListed, filename)
+	}
+
+	if !strings.EqualFold(expected, sum) {
+		return fmt.Errorf("%w: %s is %s, expected %s", ErrSHASumMismatch, filename, sum, expected)
+	}
+
+	return nil
+}
+
+// VerifySignature verifies the detached signature (binary or armored) of SHA256SUMS by the armored public key
+func VerifySignature(armoredKey, shasums, sig []byte) error {
+	keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(armoredKey))
+	if err != nil {
+		return fmt.Errorf("%w: failed to read key: %v", ErrInvalidSignature, err)
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(sig), []byte("-----BEGIN")) {
+		_, err = openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(shasums), bytes.NewReader(sig), nil)
+	} else {
+		_, err = openpgp.CheckDetachedSignature(keyring, bytes.NewReader(shasums), bytes.NewReader(sig), nil)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,313 @@
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kerraform/kegistry/internal/auth"
+	"github.com/kerraform/kegistry/internal/driver"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrChainBroken = errors.New("audit event chain is broken")
+)
+
+type Action string
+
+const (
+	ActionCreate      Action = "create"
+	ActionDelete      Action = "delete"
+	ActionDeprecate   Action = "deprecate"
+	ActionOverwrite   Action = "overwrite"
+	ActionPublish     Action = "publish"
+	ActionRevoke      Action = "revoke"
+	ActionUndeprecate Action = "undeprecate"
+	ActionUnyank      Action = "unyank"
+	ActionUpdate      Action = "update"
+	ActionUpload      Action = "upload"
+	ActionYank        Action = "yank"
+)
+
+type ResourceType string
+
+const (
+	ResourceTypeGPGKey                   ResourceType = "gpg-key"
+	ResourceTypeModule                   ResourceType = "module"
+	ResourceTypeModuleVersion            ResourceType = "module-version"
+	ResourceTypeProvider                 ResourceType = "provider"
+	ResourceTypeProviderManifest         ResourceType = "provider-manifest"
+	ResourceTypeProviderPlatform         ResourceType = "provider-platform"
+	ResourceTypeProviderSHASums          ResourceType = "provider-shasums"
+	ResourceTypeProviderSHASumsSignature ResourceType = "provider-shasums-signature"
+	ResourceTypeProviderVersion          ResourceType = "provider-version"
+	ResourceTypeToken                    ResourceType = "token"
+)
+
+const (
+	// AnonymousActor is the actor of the request without any credential
+	AnonymousActor = "anonymous"
+
+	// maxAttempts is the number of the attempts to append the event to the chain, which the other replicas append to as well
+	maxAttempts = 5
+)
+
+type Event = driver.AuditEvent
+
+type Resource = driver.AuditResource
+
+// Sink stores the audit events
+type Sink interface {
+	List(ctx context.Context, namespace string) ([]*Event, error)
+
+	// Write stores the event only if no event of the sequence is stored, otherwise returns driver.ErrAuditEventExists
+	Write(ctx context.Context, event *Event) error
+}
+
+// Recorder records the audit events to the sink.
+// The nil recorder records nothing.
+type Recorder struct {
+	key    []byte
+	logger *zap.Logger
+	sink   Sink
+
+	mu     sync.Mutex
+	chains map[string]*chain
+}
+
+// chain holds the head of the chain of the namespace, which is loaded again from the sink
+// once another replica appends to the chain.
+type chain struct {
+	mu       sync.Mutex
+	loaded   bool
+	sequence uint64
+	hash     string
+}
+
+type Config struct {
+	// Key keys the hashes of the chain, without which anyone able to write the sink can forge the chain
+	Key    []byte
+	Logger *zap.Logger
+	Sink   Sink
+}
+
+func New(cfg *Config) *Recorder {
+	return &Recorder{
+		key:    cfg.Key,
+		logger: cfg.Logger,
+		sink:   cfg.Sink,
+		chains: map[string]*chain{},
+	}
+}
+
+// Record records the event of the action by the caller of the context, which is already done.
+// The failure is logged instead of returned, as the action cannot be undone.
+func (r *Recorder) Record(ctx context.Context, action Action, typ ResourceType, res Resource, digests map[string]string) {
+	if r == nil {
+		return
+	}
+
+	if err := r.record(ctx, action, typ, res, digests); err != nil {
+		r.logger.Error("failed to record audit event",
+			zap.String("action", string(action)),
+			zap.String("resourceType", string(typ)),
+			zap.String("namespace", res.Namespace),
+			zap.Error(err),
+		)
+	}
+}
+
+// RecordBefore records the event of the action by the caller of the context before the action is done,
+// which must be refused if the event is not recorded.
+func (r *Recorder) RecordBefore(ctx context.Context, action Action, typ ResourceType, res Resource, digests map[string]string) error {
+	if r == nil {
+		return nil
+	}
+
+	return r.record(ctx, action, typ, res, digests)
+}
+
+func (r *Recorder) record(ctx context.Context, action Action, typ ResourceType, res Resource, digests map[string]string) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	res.Type = string(typ)
+	e := &Event{
+		ID:       id,
+		Time:     time.Now().UTC(),
+		Actor:    AnonymousActor,
+		Action:   string(action),
+		Resource: &res,
+		Digests:  digests,
+	}
+
+	if identity := auth.FromCtx(ctx); identity != nil {
+		e.Actor = identity.Subject
+		e.AuthMethod = string(identity.Method)
+	}
+
+	// Only the events of the same namespace wait for each other
+	c := r.chain(res.Namespace)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		if !c.loaded {
+			if err := r.load(ctx, c, res.Namespace); err != nil {
+				return err
+			}
+		}
+
+		e.Sequence = c.sequence + 1
+		e.PrevHash = c.hash
+		e.Hash, err = Hash(r.key, e)
+		if err != nil {
+			return err
+		}
+
+		err = r.sink.Write(ctx, e)
+		if err == nil {
+			break
+		}
+
+		// Another replica has appended the event of the sequence, so the head is loaded again
+		if errors.Is(err, driver.ErrAuditEventExists) && attempt < maxAttempts {
+			c.loaded = false
+			continue
+		}
+
+		return err
+	}
+	c.sequence = e.Sequence
+	c.hash = e.Hash
+
+	r.logger.Info("recorded audit event",
+		zap.String("id", e.ID),
+		zap.Uint64("sequence", e.Sequence),
+		zap.String("actor", e.Actor),
+		zap.String("action", e.Action),
+		zap.String("resourceType", res.Type),
+		zap.String("namespace", res.Namespace),
+	)
+	return nil
+}
+
+// chain returns the chain of the namespace
+func (r *Recorder) chain(namespace string) *chain {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.chains[namespace]
+	if !ok {
+		c = &chain{}
+		r.chains[namespace] = c
+	}
+
+	return c
+}
+
+// load loads the head of the chain from the last event stored in the sink
+func (r *Recorder) load(ctx context.Context, c *chain, namespace string) error {
+	es, err := r.sink.List(ctx, namespace)
+	if err != nil {
+		return err
+	}
+
+	c.sequence = 0
+	c.hash = ""
+	if len(es) > 0 {
+		c.sequence = es[len(es)-1].Sequence
+		c.hash = es[len(es)-1].Hash
+	}
+	c.loaded = true
+	return nil
+}
+
+// List returns the events of the namespace in the recorded order
+func (r *Recorder) List(ctx context.Context, namespace string) ([]*Event, error) {
+	return r.sink.List(ctx, namespace)
+}
+
+// Verify verifies the chain of the events of the namespace listed by List with the key of the recorder.
+// The removal of the latest events is not detected by the chain itself, so the events are also checked to reach
+// the head which the recorder has appended or loaded.
+func (r *Recorder) Verify(namespace string, es []*Event) error {
+	if err := Verify(r.key, es); err != nil {
+		return err
+	}
+
+	c := r.chain(namespace)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.loaded && c.sequence > uint64(len(es)) {
+		return fmt.Errorf("%w: %d events after sequence %d are missing", ErrChainBroken, c.sequence-uint64(len(es)), len(es))
+	}
+
+	return nil
+}
+
+// Hash calculates the hash of the event, which covers every field except the hash itself.
+// It is the HMAC-SHA256 of the key unless the key is empty.
+func Hash(key []byte, e *Event) (string, error) {
+	c := *e
+	c.Hash = ""
+	b, err := json.Marshal(&c)
+	if err != nil {
+		return "", err
+	}
+
+	if len(key) == 0 {
+		sum := sha256.Sum256(b)
+		return hex.EncodeToString(sum[:]), nil
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(b)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Verify verifies the chain of the events of the namespace with the key
+func Verify(key []byte, es []*Event) error {
+	prev := ""
+	for i, e := range es {
+		if e.Sequence != uint64(i+1) {
+			return fmt.Errorf("%w: unexpected sequence of event %s", ErrChainBroken, e.ID)
+		}
+
+		if e.PrevHash != prev {
+			return fmt.Errorf("%w: unexpected previous hash of event %s", ErrChainBroken, e.ID)
+		}
+
+		h, err := Hash(key, e)
+		if err != nil {
+			return err
+		}
+
+		if !hmac.Equal([]byte(h), []byte(e.Hash)) {
+			return fmt.Errorf("%w: hash mismatch of event %s", ErrChainBroken, e.ID)
+		}
+		prev = e.Hash
+	}
+
+	return nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,41 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+)
+
+// DigestReader calculates the digests of the artifact while it is read
+type DigestReader struct {
+	r      io.Reader
+	sha256 hash.Hash
+}
+
+func NewDigestReader(r io.Reader) *DigestReader {
+	h := sha256.New()
+	return &DigestReader{
+		r:      io.TeeReader(r, h),
+		sha256: h,
+	}
+}
+
+func (d *DigestReader) Read(p []byte) (int, error) {
+	return d.r.Read(p)
+}
+
+// Digests returns the digests of the bytes read so far
+func (d *DigestReader) Digests() map[string]string {
+	return map[string]string{
+		"sha256": hex.EncodeToString(d.sha256.Sum(nil)),
+	}
+}
+
+// Digests returns the digests of the bytes
+func Digests(b []byte) map[string]string {
+	sum := sha256.Sum256(b)
+	return map[string]string{
+		"sha256": hex.EncodeToString(sum[:]),
+	}
+}
@@ -0,0 +1,137 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/kerraform/kegistry/internal/driver"
+)
+
+type SinkType string
+
+const (
+	SinkTypeBackend SinkType = "backend"
+	SinkTypeFile    SinkType = "file"
+)
+
+// FileSink appends the events to the file as JSON lines.
+// The file is owned by the single replica, which is the only writer of the chains.
+type FileSink struct {
+	path string
+
+	mu sync.Mutex
+	f  *os.File
+	// sequences holds the sequence of the last event written per namespace
+	sequences map[string]uint64
+}
+
+var _ Sink = (*FileSink)(nil)
+
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileSink{
+		path:      path,
+		f:         f,
+		sequences: map[string]uint64{},
+	}, nil
+}
+
+func (s *FileSink) List(ctx context.Context, namespace string) ([]*Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list(namespace)
+}
+
+func (s *FileSink) list(namespace string) ([]*Event, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	es := []*Event{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, err
+		}
+
+		if e.Resource != nil && e.Resource.Namespace == namespace {
+			es = append(es, &e)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return es, nil
+}
+
+func (s *FileSink) Write(ctx context.Context, e *Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	namespace := e.Resource.Namespace
+	last, ok := s.sequences[namespace]
+	if !ok {
+		es, err := s.list(namespace)
+		if err != nil {
+			return err
+		}
+
+		if len(es) > 0 {
+			last = es[len(es)-1].Sequence
+		}
+	}
+
+	if e.Sequence <= last {
+		return driver.ErrAuditEventExists
+	}
+
+	if _, err := s.f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	s.sequences[namespace] = e.Sequence
+
+	return s.f.Sync()
+}
+
+func (s *FileSink) Close() error {
+	return s.f.Close()
+}
+
+// BackendSink stores each event as an object in the storage backend
+type BackendSink struct {
+	driver driver.Audit
+}
+
+var _ Sink = (*BackendSink)(nil)
+
+func NewBackendSink(d driver.Audit) *BackendSink {
+	return &BackendSink{
+		driver: d,
+	}
+}
+
+func (s *BackendSink) List(ctx context.Context, namespace string) ([]*Event, error) {
+	return s.driver.ListAuditEvents(ctx, namespace)
+}
+
+func (s *BackendSink) Write(ctx context.Context, e *Event) error {
+	return s.driver.SaveAuditEvent(ctx, e)
+}
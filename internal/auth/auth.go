@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrMissingCredentials = errors.New("missing credentials")
+)
+
+type Method string
+
+const (
+	MethodAPIToken          Method = "api-token"
+	MethodClientCertificate Method = "client-certificate"
+	MethodLogin             Method = "login"
+	MethodOIDC              Method = "oidc"
+	MethodStaticToken       Method = "static-token"
+)
+
+// The subjects are prefixed by the authentication method, so that the subject authenticated by one method
+// cannot be claimed by another, e.g. the OIDC `sub` claim as the name of the static token.
+const (
+	SubjectPrefixCertificate = "cert:"
+	SubjectPrefixLogin       = "login:"
+	SubjectPrefixOIDC        = "oidc:"
+	SubjectPrefixToken       = "token:"
+)
+
+// Identity represents the authenticated caller of the registry
+type Identity struct {
+	Subject string
+	Method  Method
+
+	// Namespaces restricts the namespaces that the identity can act on, which are compared literally without any wildcard.
+	// Empty means no restriction.
+	Namespaces []string
+
+	// ExpiredAt is the expiry of the credential, which bounds the API tokens issued to the identity.
+	// Zero means the credential does not expire.
+	ExpiredAt time.Time
+}
+
+// Authenticator resolves the identity of the request.
+// It returns nil identity without error if the request does not carry any
+// credential that the authenticator understands.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Identity, error)
+}
+
+// Chain tries each authenticator in order and returns the first identity found
+type Chain []Authenticator
+
+var _ Authenticator = (Chain)(nil)
+
+func (c Chain) Authenticate(r *http.Request) (*Identity, error) {
+	for _, a := range c {
+		id, err := a.Authenticate(r)
+		if err != nil {
+			return nil, err
+		}
+
+		if id != nil {
+			return id, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// TokenSubject returns the subject of the token issued to the name, which is left as it is if already prefixed
+func TokenSubject(name string) string {
+	for _, prefix := range []string{SubjectPrefixCertificate, SubjectPrefixLogin, SubjectPrefixOIDC, SubjectPrefixToken} {
+		if strings.HasPrefix(name, prefix) {
+			return name
+		}
+	}
+
+	return SubjectPrefixToken + name
+}
+
+// BearerToken returns the token of the `Authorization: Bearer` header
+func BearerToken(r *http.Request) (string, bool) {
+	h := r.Header.Get("Authorization")
+	scheme, token, ok := strings.Cut(h, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,26 @@
+package auth
+
+import "net/http"
+
+// ClientCertificate authenticates the verified client certificate of mutual TLS.
+// The subject of the certificate is used as the subject of the identity, prefixed by `cert:`.
+type ClientCertificate struct{}
+
+var _ Authenticator = (*ClientCertificate)(nil)
+
+func (c *ClientCertificate) Authenticate(r *http.Request) (*Identity, error) {
+	// The bearer token takes precedence over the client certificate
+	if _, ok := BearerToken(r); ok {
+		return nil, nil
+	}
+
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, nil
+	}
+
+	cert := r.TLS.VerifiedChains[0][0]
+	return &Identity{
+		Subject: SubjectPrefixCertificate + cert.Subject.String(),
+		Method:  MethodClientCertificate,
+	}, nil
+}
@@ -0,0 +1,18 @@
+package auth
+
+import "context"
+
+type contextKey struct{}
+
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromCtx returns the identity of the caller, or nil if the caller is anonymous
+func FromCtx(ctx context.Context) *Identity {
+	id, ok := ctx.Value(contextKey{}).(*Identity)
+	if !ok {
+		return nil
+	}
+	return id
+}
@@ -0,0 +1,259 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrKeyNotFound = errors.New("signing key not found")
+	ErrNoUsableKey = errors.New("no usable signing key in jwks")
+)
+
+const (
+	// jwksRefreshInterval is the interval to refresh the keys fetched from URL
+	jwksRefreshInterval = time.Hour
+
+	// jwksMinRefreshInterval throttles the refresh triggered by the unknown key ID
+	jwksMinRefreshInterval = time.Minute
+
+	// jwksFetchTimeout is the timeout to fetch the keys from URL by the default client, as the refresh blocks the authentication
+	jwksFetchTimeout = 10 * time.Second
+)
+
+// JWK is the JSON Web Key defined in RFC 7517
+type JWK struct {
+	KeyID string `json:"kid"`
+	Kty   string `json:"kty"`
+	Alg   string `json:"alg"`
+	Use   string `json:"use"`
+
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// KeySet holds the public keys of the issuer fetched from URL or loaded from file
+type KeySet struct {
+	client   *http.Client
+	filepath string
+	logger   *zap.Logger
+	url      string
+
+	mu        sync.RWMutex
+	keys      map[string]*key
+	fetchedAt time.Time
+}
+
+// key is the public key of the JWK with the algorithm it is restricted to, if any
+type key struct {
+	publicKey crypto.PublicKey
+	alg       string
+}
+
+// NewRemoteKeySet returns the key set fetched from URL by the client, which defaults to the client with jwksFetchTimeout
+func NewRemoteKeySet(url string, client *http.Client, logger *zap.Logger) *KeySet {
+	if client == nil {
+		client = &http.Client{
+			Timeout: jwksFetchTimeout,
+		}
+	}
+
+	return &KeySet{
+		client: client,
+		logger: logger,
+		url:    url,
+	}
+}
+
+func NewFileKeySet(filepath string, logger *zap.Logger) *KeySet {
+	return &KeySet{
+		filepath: filepath,
+		logger:   logger,
+	}
+}
+
+// Key returns the public key of the key ID, and the algorithm of the JWK which is empty if not restricted.
+// The key set is refreshed if the key ID is unknown or the keys are stale.
+func (s *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, string, error) {
+	s.mu.RLock()
+	k, ok := s.keys[kid]
+	loaded := s.keys != nil
+	stale := time.Since(s.fetchedAt) > jwksRefreshInterval
+	throttled := time.Since(s.fetchedAt) < jwksMinRefreshInterval
+	s.mu.RUnlock()
+
+	if ok && !stale {
+		return k.publicKey, k.alg, nil
+	}
+
+	if !loaded || !throttled {
+		if err := s.refresh(ctx); err != nil {
+			if ok {
+				// Keep using the stale key on the temporary failure
+				return k.publicKey, k.alg, nil
+			}
+			return nil, "", err
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	k, ok = s.keys[kid]
+	if !ok {
+		return nil, "", fmt.Errorf("%w: %q", ErrKeyNotFound, kid)
+	}
+
+	return k.publicKey, k.alg, nil
+}
+
+// refresh loads the keys, skipping the ones not supported (e.g. the new key type added by the issuer),
+// so that the supported keys keep working. It fails only if no key is usable.
+func (s *KeySet) refresh(ctx context.Context) error {
+	b, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	var jwks JWKS
+	if err := json.Unmarshal(b, &jwks); err != nil {
+		return fmt.Errorf("failed to decode jwks: %w", err)
+	}
+
+	keys := map[string]*key{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		publicKey, err := jwk.PublicKey()
+		if err != nil {
+			s.logger.Warn("skip invalid jwk", zap.String("kid", jwk.KeyID), zap.String("kty", jwk.Kty), zap.Error(err))
+			continue
+		}
+		keys[jwk.KeyID] = &key{
+			publicKey: publicKey,
+			alg:       jwk.Alg,
+		}
+	}
+
+	if len(keys) == 0 {
+		return ErrNoUsableKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+	s.fetchedAt = time.Now()
+	return nil
+}
+
+func (s *KeySet) load(ctx context.Context) ([]byte, error) {
+	if s.filepath != "" {
+		return os.ReadFile(s.filepath)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch jwks, got: %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// PublicKey converts the JWK to the public key
+func (k *JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{
+			N: n,
+			E: int(e.Int64()),
+		}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("curve %q not supported", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+
+		return &ecdsa.PublicKey{
+			Curve: curve,
+			X:     x,
+			Y:     y,
+		}, nil
+	default:
+		return nil, fmt.Errorf("key type %q not supported", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
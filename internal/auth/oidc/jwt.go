@@ -0,0 +1,213 @@
+package oidc
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid jwt")
+)
+
+const (
+	// leeway tolerates the clock skew between the issuer and the registry
+	leeway = time.Minute
+)
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Claims is the payload of the JWT
+type Claims map[string]interface{}
+
+// String returns the claim as string
+func (c Claims) String(name string) (string, bool) {
+	v, ok := c[name].(string)
+	return v, ok && v != ""
+}
+
+func (c Claims) time(name string) (time.Time, bool) {
+	n, ok := c[name].(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(int64(f), 0), true
+}
+
+func (c Claims) hasAudience(aud string) bool {
+	switch v := c["aud"].(type) {
+	case string:
+		return v == aud
+	case []interface{}:
+		for _, a := range v {
+			if s, ok := a.(string); ok && s == aud {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Verifier verifies the signature and the registered claims of the JWT
+type Verifier struct {
+	audience string
+	issuer   string
+	keySet   *KeySet
+}
+
+func NewVerifier(issuer, audience string, keySet *KeySet) *Verifier {
+	return &Verifier{
+		audience: audience,
+		issuer:   issuer,
+		keySet:   keySet,
+	}
+}
+
+func (v *Verifier) Verify(ctx context.Context, raw string) (Claims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, fmt.Errorf("%w: malformed header: %s", ErrInvalidToken, err)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature: %s", ErrInvalidToken, err)
+	}
+
+	key, alg, err := v.keySet.Key(ctx, h.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	// The key restricted to the algorithm must not verify the token signed by another one
+	if alg != "" && alg != h.Alg {
+		return nil, fmt.Errorf("%w: algorithm %q does not match %q of the key", ErrInvalidToken, h.Alg, alg)
+	}
+
+	if err := verifySignature(h.Alg, key, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed payload: %s", ErrInvalidToken, err)
+	}
+
+	if iss, _ := claims.String("iss"); iss != v.issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, iss)
+	}
+
+	if !claims.hasAudience(v.audience) {
+		return nil, fmt.Errorf("%w: audience %q not found", ErrInvalidToken, v.audience)
+	}
+
+	now := time.Now()
+	exp, ok := claims.time("exp")
+	if !ok {
+		return nil, fmt.Errorf("%w: exp claim is required", ErrInvalidToken)
+	}
+
+	if now.After(exp.Add(leeway)) {
+		return nil, fmt.Errorf("%w: token expired", ErrInvalidToken)
+	}
+
+	if nbf, ok := claims.time("nbf"); ok && now.Add(leeway).Before(nbf) {
+		return nil, fmt.Errorf("%w: token not valid yet", ErrInvalidToken)
+	}
+
+	return claims, nil
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed, sig []byte) error {
+	if len(alg) != 5 {
+		return fmt.Errorf("algorithm %q not supported", alg)
+	}
+
+	// The curve of each ES algorithm is fixed by RFC 7518
+	var h crypto.Hash
+	var curve string
+	switch alg[len(alg)-3:] {
+	case "256":
+		h = crypto.SHA256
+		curve = "P-256"
+	case "384":
+		h = crypto.SHA384
+		curve = "P-384"
+	case "512":
+		h = crypto.SHA512
+		curve = "P-521"
+	default:
+		return fmt.Errorf("algorithm %q not supported", alg)
+	}
+
+	hasher := h.New()
+	hasher.Write(signed)
+	digest := hasher.Sum(nil)
+
+	switch alg[:2] {
+	case "RS", "PS":
+		k, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key is not for %s", alg)
+		}
+
+		if alg[:2] == "PS" {
+			return rsa.VerifyPSS(k, h, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+		return rsa.VerifyPKCS1v15(k, h, digest, sig)
+	case "ES":
+		k, ok := key.(*ecdsa.PublicKey)
+		if !ok || k.Curve.Params().Name != curve {
+			return fmt.Errorf("key is not for %s", alg)
+		}
+
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return errors.New("invalid signature length")
+		}
+
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return errors.New("signature mismatch")
+		}
+		return nil
+	default:
+		return fmt.Errorf("algorithm %q not supported", alg)
+	}
+}
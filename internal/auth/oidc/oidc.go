@@ -0,0 +1,83 @@
+package oidc
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/kerraform/kegistry/internal/auth"
+)
+
+const (
+	DefaultSubjectClaim = "sub"
+)
+
+// Authenticator authenticates the OIDC ID token (e.g. issued by CI systems) as bearer token
+type Authenticator struct {
+	namespaceClaim string
+	subjectClaim   string
+	verifier       *Verifier
+}
+
+type Config struct {
+	// Audience is required, as the ID tokens issued by the same issuer to the other services must not be accepted
+	Audience string
+	Issuer   string
+	KeySet   *KeySet
+
+	// NamespaceClaim restricts the identity to the namespace of the claim value if set
+	NamespaceClaim string
+
+	// SubjectClaim is used as the subject of the identity prefixed by `oidc:`, defaults to `sub`
+	SubjectClaim string
+}
+
+var _ auth.Authenticator = (*Authenticator)(nil)
+
+func New(cfg *Config) *Authenticator {
+	subjectClaim := cfg.SubjectClaim
+	if subjectClaim == "" {
+		subjectClaim = DefaultSubjectClaim
+	}
+
+	return &Authenticator{
+		namespaceClaim: cfg.NamespaceClaim,
+		subjectClaim:   subjectClaim,
+		verifier:       NewVerifier(cfg.Issuer, cfg.Audience, cfg.KeySet),
+	}
+}
+
+func (a *Authenticator) Authenticate(r *http.Request) (*auth.Identity, error) {
+	token, ok := auth.BearerToken(r)
+	if !ok || strings.Count(token, ".") != 2 {
+		return nil, nil
+	}
+
+	claims, err := a.verifier.Verify(r.Context(), token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", auth.ErrInvalidCredentials, err)
+	}
+
+	subject, ok := claims.String(a.subjectClaim)
+	if !ok {
+		return nil, fmt.Errorf("%w: claim %q not found", auth.ErrInvalidCredentials, a.subjectClaim)
+	}
+
+	// The exp claim is required by the verifier
+	exp, _ := claims.time("exp")
+	id := &auth.Identity{
+		Subject:   auth.SubjectPrefixOIDC + subject,
+		Method:    auth.MethodOIDC,
+		ExpiredAt: exp,
+	}
+
+	if a.namespaceClaim != "" {
+		namespace, ok := claims.String(a.namespaceClaim)
+		if !ok {
+			return nil, fmt.Errorf("%w: claim %q not found", auth.ErrInvalidCredentials, a.namespaceClaim)
+		}
+		id.Namespaces = []string{namespace}
+	}
+
+	return id, nil
+}
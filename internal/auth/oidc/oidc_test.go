@@ -0,0 +1,385 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kerraform/kegistry/internal/auth"
+	"github.com/kerraform/kegistry/internal/policy"
+	"go.uber.org/zap"
+)
+
+const (
+	testAudience = "kegistry"
+	testIssuer   = "https://token.actions.githubusercontent.com"
+	testKeyID    = "key-1"
+)
+
+// issuer signs the ID tokens by the key served as JWKS
+type issuer struct {
+	key    *rsa.PrivateKey
+	keySet *KeySet
+}
+
+func newIssuer(t *testing.T) *issuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := &JWKS{
+		Keys: []JWK{
+			{
+				KeyID: testKeyID,
+				Kty:   "RSA",
+				Alg:   "RS256",
+				Use:   "sig",
+				N:     base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:     base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			},
+		},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jwks)
+	}))
+	t.Cleanup(srv.Close)
+
+	return &issuer{
+		key:    key,
+		keySet: NewRemoteKeySet(srv.URL, srv.Client(), zap.NewNop()),
+	}
+}
+
+func (i *issuer) claims(overrides map[string]interface{}) map[string]interface{} {
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss":              testIssuer,
+		"aud":              testAudience,
+		"sub":              "repo:acme/provider:ref:refs/heads/main",
+		"repository_owner": "acme",
+		"iat":              now.Unix(),
+		"exp":              now.Add(5 * time.Minute).Unix(),
+	}
+
+	for k, v := range overrides {
+		if v == nil {
+			delete(claims, k)
+			continue
+		}
+		claims[k] = v
+	}
+
+	return claims
+}
+
+// sign returns the JWT of the claims signed by the algorithm, where `HS256` is signed with the public key as the secret
+func (i *issuer) sign(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	h, err := json.Marshal(&header{Alg: alg, Kid: kid})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(p)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	switch alg {
+	case "RS256":
+		sig, err = rsa.SignPKCS1v15(rand.Reader, i.key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+	case "PS256":
+		sig, err = rsa.SignPSS(rand.Reader, i.key, crypto.SHA256, digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		if err != nil {
+			t.Fatal(err)
+		}
+	case "HS256":
+		mac := hmac.New(sha256.New, i.key.N.Bytes())
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestVerify(t *testing.T) {
+	iss := newIssuer(t)
+	v := NewVerifier(testIssuer, testAudience, iss.keySet)
+
+	cases := map[string]struct {
+		alg       string
+		kid       string
+		overrides map[string]interface{}
+		want      error
+	}{
+		"valid": {},
+		"audience in list": {
+			overrides: map[string]interface{}{"aud": []string{"other", testAudience}},
+		},
+		"within leeway": {
+			overrides: map[string]interface{}{"exp": time.Now().Add(-leeway / 2).Unix()},
+		},
+		"wrong issuer": {
+			overrides: map[string]interface{}{"iss": "https://example.com"},
+			want:      ErrInvalidToken,
+		},
+		"wrong audience": {
+			overrides: map[string]interface{}{"aud": "other"},
+			want:      ErrInvalidToken,
+		},
+		"no audience": {
+			overrides: map[string]interface{}{"aud": nil},
+			want:      ErrInvalidToken,
+		},
+		"expired": {
+			overrides: map[string]interface{}{"exp": time.Now().Add(-2 * leeway).Unix()},
+			want:      ErrInvalidToken,
+		},
+		"no expiry": {
+			overrides: map[string]interface{}{"exp": nil},
+			want:      ErrInvalidToken,
+		},
+		"not before": {
+			overrides: map[string]interface{}{"nbf": time.Now().Add(2 * leeway).Unix()},
+			want:      ErrInvalidToken,
+		},
+		"alg none": {
+			alg:  "none",
+			want: ErrInvalidToken,
+		},
+		"alg not of key": {
+			alg:  "PS256",
+			want: ErrInvalidToken,
+		},
+		"hs256 with rsa key": {
+			alg:  "HS256",
+			want: ErrInvalidToken,
+		},
+		"unknown kid": {
+			kid:  "key-2",
+			want: ErrKeyNotFound,
+		},
+	}
+
+	for name, tc := range cases {
+		alg, kid := tc.alg, tc.kid
+		if alg == "" {
+			alg = "RS256"
+		}
+
+		if kid == "" {
+			kid = testKeyID
+		}
+
+		_, err := v.Verify(context.Background(), iss.sign(t, alg, kid, iss.claims(tc.overrides)))
+		if tc.want == nil {
+			if err != nil {
+				t.Errorf("%s: expected valid, got %v", name, err)
+			}
+			continue
+		}
+
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: expected %v, got %v", name, tc.want, err)
+		}
+	}
+}
+
+func TestVerifySignatureCurve(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := []byte("header.payload")
+	for alg, h := range map[string]crypto.Hash{"ES256": crypto.SHA256, "ES384": crypto.SHA384} {
+		hasher := h.New()
+		hasher.Write(signed)
+		r, s, err := ecdsa.Sign(rand.Reader, key, hasher.Sum(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sig := make([]byte, 96)
+		r.FillBytes(sig[:48])
+		s.FillBytes(sig[48:])
+
+		err = verifySignature(alg, &key.PublicKey, signed, sig)
+		if alg == "ES384" && err != nil {
+			t.Errorf("%s: expected valid, got %v", alg, err)
+		}
+
+		if alg == "ES256" && err == nil {
+			t.Errorf("%s: expected the P-384 key to be refused", alg)
+		}
+	}
+}
+
+func TestKeySetUnsupportedKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unsupported := []JWK{
+		{KeyID: "okp", Kty: "OKP", Crv: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+		{KeyID: "p192", Kty: "EC", Crv: "P-192"},
+	}
+	supported := JWK{
+		KeyID: testKeyID,
+		Kty:   "RSA",
+		N:     base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:     base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+
+	cases := map[string]struct {
+		keys []JWK
+		want error
+	}{
+		"unsupported keys skipped": {
+			keys: append(unsupported, supported),
+		},
+		"no usable key": {
+			keys: unsupported,
+			want: ErrNoUsableKey,
+		},
+	}
+
+	for name, tc := range cases {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(&JWKS{Keys: tc.keys})
+		}))
+
+		_, _, err := NewRemoteKeySet(srv.URL, srv.Client(), zap.NewNop()).Key(context.Background(), testKeyID)
+		srv.Close()
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: expected %v, got %v", name, tc.want, err)
+		}
+	}
+}
+
+func TestKeySetDefaultClientTimeout(t *testing.T) {
+	if s := NewRemoteKeySet("https://example.com/jwks", nil, zap.NewNop()); s.client.Timeout == 0 {
+		t.Error("expected the default client to time out")
+	}
+}
+
+func TestVerifyTampered(t *testing.T) {
+	iss := newIssuer(t)
+	v := NewVerifier(testIssuer, testAudience, iss.keySet)
+
+	other := iss.sign(t, "RS256", testKeyID, iss.claims(map[string]interface{}{"repository_owner": "other"}))
+	valid := iss.sign(t, "RS256", testKeyID, iss.claims(nil))
+
+	// The payload of the other token with the signature of the valid one
+	tampered := other[:len(other)-len(sigSegment(valid))] + sigSegment(valid)
+	if _, err := v.Verify(context.Background(), tampered); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected %v, got %v", ErrInvalidToken, err)
+	}
+}
+
+func sigSegment(token string) string {
+	return token[strings.LastIndex(token, ".")+1:]
+}
+
+func TestAuthenticateNamespaceClaim(t *testing.T) {
+	iss := newIssuer(t)
+	a := New(&Config{
+		Audience:       testAudience,
+		Issuer:         testIssuer,
+		KeySet:         iss.keySet,
+		NamespaceClaim: "repository_owner",
+	})
+
+	cases := map[string]struct {
+		owner     string
+		namespace string
+		allowed   bool
+	}{
+		"same namespace": {
+			owner:     "acme",
+			namespace: "acme",
+			allowed:   true,
+		},
+		"other namespace": {
+			owner:     "acme",
+			namespace: "other",
+		},
+		// The claim value is not the pattern, otherwise the owner named `*` acts on every namespace
+		"wildcard claim": {
+			owner:     "*",
+			namespace: "acme",
+		},
+		"pattern claim": {
+			owner:     "ac?e",
+			namespace: "acme",
+		},
+	}
+
+	for name, tc := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer "+iss.sign(t, "RS256", testKeyID, iss.claims(map[string]interface{}{"repository_owner": tc.owner})))
+
+		id, err := a.Authenticate(r)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if id.Subject != auth.SubjectPrefixOIDC+"repo:acme/provider:ref:refs/heads/main" {
+			t.Fatalf("%s: unexpected subject %q", name, id.Subject)
+		}
+
+		var p *policy.Policy
+		err = p.Authorize(auth.WithIdentity(context.Background(), id), tc.namespace, policy.ScopePublish)
+		if allowed := err == nil; allowed != tc.allowed {
+			t.Errorf("%s: expected allowed %t, got %v", name, tc.allowed, err)
+		}
+	}
+}
+
+func TestAuthenticateMissingClaim(t *testing.T) {
+	iss := newIssuer(t)
+	a := New(&Config{
+		Audience:       testAudience,
+		Issuer:         testIssuer,
+		KeySet:         iss.keySet,
+		NamespaceClaim: "repository_owner",
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+iss.sign(t, "RS256", testKeyID, iss.claims(map[string]interface{}{"repository_owner": nil})))
+	if _, err := a.Authenticate(r); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Fatalf("expected %v, got %v", auth.ErrInvalidCredentials, err)
+	}
+
+	// The request without the JWT is left to the other authenticators
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer kgt_foo")
+	if id, err := a.Authenticate(r); id != nil || err != nil {
+		t.Fatalf("expected no identity, got %v, %v", id, err)
+	}
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// StaticTokens authenticates the bearer tokens configured on startup.
+// The subject of the token is prefixed by `token:`.
+type StaticTokens struct {
+	tokens map[string]string
+}
+
+var _ Authenticator = (*StaticTokens)(nil)
+
+// NewStaticTokens creates the authenticator from the map of subject to token
+func NewStaticTokens(tokens map[string]string) *StaticTokens {
+	return &StaticTokens{
+		tokens: tokens,
+	}
+}
+
+func (s *StaticTokens) Authenticate(r *http.Request) (*Identity, error) {
+	token, ok := BearerToken(r)
+	if !ok {
+		return nil, nil
+	}
+
+	for subject, t := range s.tokens {
+		if t == "" {
+			continue
+		}
+
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			return &Identity{
+				Subject: SubjectPrefixToken + subject,
+				Method:  MethodStaticToken,
+			}, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,96 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CAReloader serves the CA certificates to verify the client certificates, and reloads them when the file is modified
+type CAReloader struct {
+	file   string
+	logger *zap.Logger
+
+	mu        sync.RWMutex
+	pool      *x509.CertPool
+	checkedAt time.Time
+	modTime   time.Time
+}
+
+func NewCAReloader(file string, logger *zap.Logger) (*CAReloader, error) {
+	r := &CAReloader{
+		file:   file,
+		logger: logger,
+	}
+
+	fi, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.load(fi.ModTime()); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// ClientCAs returns the CA certificates, reloading them if the file is modified
+func (r *CAReloader) ClientCAs() *x509.CertPool {
+	r.mu.RLock()
+	pool := r.pool
+	shouldCheck := time.Since(r.checkedAt) > checkInterval
+	r.mu.RUnlock()
+
+	if shouldCheck {
+		r.reloadIfModified()
+
+		r.mu.RLock()
+		pool = r.pool
+		r.mu.RUnlock()
+	}
+
+	return pool
+}
+
+func (r *CAReloader) reloadIfModified() {
+	r.mu.Lock()
+	r.checkedAt = time.Now()
+	current := r.modTime
+	r.mu.Unlock()
+
+	fi, err := os.Stat(r.file)
+	if err != nil {
+		r.logger.Error("failed to check the client ca file", zap.Error(err))
+		return
+	}
+
+	if !fi.ModTime().After(current) {
+		return
+	}
+
+	if err := r.load(fi.ModTime()); err != nil {
+		// Keep verifying by the current certificates, e.g. while the file is being written
+		r.logger.Error("failed to reload the client ca", zap.Error(err))
+		return
+	}
+
+	r.logger.Info("reloaded the client ca", zap.String("file", r.file))
+}
+
+func (r *CAReloader) load(modTime time.Time) error {
+	pool, err := loadCertPool(r.file)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pool = pool
+	r.modTime = modTime
+	r.checkedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,90 @@
+package certificate
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.uber.org/zap"
+)
+
+type ClientAuthType string
+
+const (
+	ClientAuthTypeNone    ClientAuthType = "none"
+	ClientAuthTypeRequest ClientAuthType = "request"
+	ClientAuthTypeRequire ClientAuthType = "require"
+)
+
+type Config struct {
+	CertFile string
+	KeyFile  string
+	Logger   *zap.Logger
+
+	// ClientAuth configures the verification of the client certificates against ClientCAFile
+	ClientAuth   ClientAuthType
+	ClientCAFile string
+}
+
+// NewTLSConfig creates the TLS configuration of the server
+func NewTLSConfig(cfg *Config) (*tls.Config, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("both certificate and key files are required")
+	}
+
+	reloader, err := NewReloader(cfg.CertFile, cfg.KeyFile, cfg.Logger)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		GetCertificate: reloader.GetCertificate,
+		MinVersion:     tls.VersionTLS12,
+	}
+
+	switch cfg.ClientAuth {
+	case ClientAuthTypeNone, "":
+		return tlsConfig, nil
+	case ClientAuthTypeRequest:
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	case ClientAuthTypeRequire:
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("client auth type %s not supported", cfg.ClientAuth)
+	}
+
+	if cfg.ClientCAFile == "" {
+		return nil, errors.New("client ca file is required to verify the client certificates")
+	}
+
+	caReloader, err := NewCAReloader(cfg.ClientCAFile, cfg.Logger)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.ClientCAs = caReloader.ClientCAs()
+
+	// The client certificates are verified against the CA certificates reloaded on change
+	tlsConfig.GetConfigForClient = func(_ *tls.ClientHelloInfo) (*tls.Config, error) {
+		c := tlsConfig.Clone()
+		c.ClientCAs = caReloader.ClientCAs()
+		return c, nil
+	}
+
+	return tlsConfig, nil
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no certificate found in %s", file)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,119 @@
+package certificate
+
+import (
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// checkInterval throttles the check of the modification of the files
+	checkInterval = 10 * time.Second
+)
+
+// Reloader serves the certificate and reloads it when the files are modified
+type Reloader struct {
+	certFile string
+	keyFile  string
+	logger   *zap.Logger
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	checkedAt time.Time
+	modTime   time.Time
+}
+
+func NewReloader(certFile, keyFile string, logger *zap.Logger) (*Reloader, error) {
+	r := &Reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		logger:   logger,
+	}
+
+	modTime, err := r.modTimeOfFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.load(modTime); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+func (r *Reloader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	cert := r.cert
+	shouldCheck := time.Since(r.checkedAt) > checkInterval
+	r.mu.RUnlock()
+
+	if shouldCheck {
+		r.reloadIfModified()
+
+		r.mu.RLock()
+		cert = r.cert
+		r.mu.RUnlock()
+	}
+
+	return cert, nil
+}
+
+func (r *Reloader) reloadIfModified() {
+	r.mu.Lock()
+	r.checkedAt = time.Now()
+	current := r.modTime
+	r.mu.Unlock()
+
+	modTime, err := r.modTimeOfFiles()
+	if err != nil {
+		r.logger.Error("failed to check the certificate files", zap.Error(err))
+		return
+	}
+
+	if !modTime.After(current) {
+		return
+	}
+
+	if err := r.load(modTime); err != nil {
+		// Keep serving the current certificate, e.g. while only one of the files is replaced
+		r.logger.Error("failed to reload the certificate", zap.Error(err))
+		return
+	}
+
+	r.logger.Info("reloaded the certificate", zap.String("certFile", r.certFile))
+}
+
+func (r *Reloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.modTime = modTime
+	r.checkedAt = time.Now()
+	return nil
+}
+
+func (r *Reloader) modTimeOfFiles() (time.Time, error) {
+	var latest time.Time
+	for _, f := range []string{r.certFile, r.keyFile} {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if fi.ModTime().After(latest) {
+			latest = fi.ModTime()
+		}
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,43 @@
+package gpgkey
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type deleteOpts struct {
+	force bool
+}
+
+func newDeleteCmd() *cobra.Command {
+	opts := &deleteOpts{}
+
+	cmd := &cobra.Command{
+		Use:   "delete <namespace> <key-id>",
+		Short: "Delete GPG key",
+		Args:  cobra.ExactArgs(2),
+		RunE:  runDeleteCmd(opts),
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("url", "u", "http://localhost:8888", "Specify the endpoint of the registry (defaults to localhost:8888)")
+	flags.BoolVar(&opts.force, "force", false, "Delete the key even if it is used by provider versions")
+	viper.BindEnv("url", "URL")
+	viper.BindPFlag("url", flags.Lookup("url"))
+
+	return cmd
+}
+
+func runDeleteCmd(opts *deleteOpts) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		gc, err := newGPGKeyClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		return gc.Delete(ctx, args[0], args[1], opts.force)
+	}
+}
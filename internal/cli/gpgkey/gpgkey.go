@@ -1,7 +1,12 @@
 package gpgkey
 
 import (
+	"context"
+	"net/url"
+
+	"github.com/kerraform/kegistry/internal/client"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 func NewCmd() *cobra.Command {
@@ -10,9 +15,29 @@ func NewCmd() *cobra.Command {
 		Short: "GPG key related operations",
 		Aliases: []string{
 			"gk",
+			"gpgkey",
 		},
 	}
 
+	cmd.AddCommand(newDeleteCmd())
+	cmd.AddCommand(newListCmd())
+	cmd.AddCommand(newRevokeCmd())
 	cmd.AddCommand(newSaveCmd())
+	cmd.AddCommand(newShowCmd())
 	return cmd
 }
+
+func newGPGKeyClient(ctx context.Context) (*client.GPGKeyService, error) {
+	u, err := url.Parse(viper.GetString("url"))
+	if err != nil {
+		return nil, err
+	}
+
+	c := client.New(u, client.WithToken(viper.GetString("token")))
+	svc, err := c.ServiceDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.NewGPGKeyClient(svc.GPGKeysV1, c)
+}
@@ -0,0 +1,61 @@
+package gpgkey
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func newListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list <namespace>",
+		Short: "List GPG keys of the namespace",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runListCmd(),
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("url", "u", "http://localhost:8888", "Specify the endpoint of the registry (defaults to localhost:8888)")
+	viper.BindEnv("url", "URL")
+	viper.BindPFlag("url", flags.Lookup("url"))
+
+	return cmd
+}
+
+func runListCmd() func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		gc, err := newGPGKeyClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		ks, err := gc.List(ctx, args[0])
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "KEY ID\tSOURCE\tCREATED AT\tREVOKED AT")
+		for _, k := range ks {
+			revokedAt := "-"
+			if k.Attributes.RevokedAt != nil {
+				revokedAt = k.Attributes.RevokedAt.Format(time.RFC3339)
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+				k.Attributes.KeyID,
+				k.Attributes.Source,
+				k.Attributes.CreatedAt.Format(time.RFC3339),
+				revokedAt,
+			)
+		}
+
+		return w.Flush()
+	}
+}
@@ -0,0 +1,37 @@
+package gpgkey
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func newRevokeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "revoke <namespace> <key-id>",
+		Short: "Revoke GPG key, so that it cannot be used for new provider versions",
+		Args:  cobra.ExactArgs(2),
+		RunE:  runRevokeCmd(),
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("url", "u", "http://localhost:8888", "Specify the endpoint of the registry (defaults to localhost:8888)")
+	viper.BindEnv("url", "URL")
+	viper.BindPFlag("url", flags.Lookup("url"))
+
+	return cmd
+}
+
+func runRevokeCmd() func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		gc, err := newGPGKeyClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = gc.Revoke(ctx, args[0], args[1])
+		return err
+	}
+}
@@ -46,7 +46,7 @@ func runSaveCmd(opts *saveOpts) func(cmd *cobra.Command, args []string) error {
 			return err
 		}
 
-		c := client.New(u)
+		c := client.New(u, client.WithToken(viper.GetString("token")))
 		svc, err := c.ServiceDiscovery(ctx)
 		if err != nil {
 			return err
@@ -0,0 +1,53 @@
+package gpgkey
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func newShowCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show <namespace> <key-id>",
+		Short: "Show GPG key",
+		Args:  cobra.ExactArgs(2),
+		RunE:  runShowCmd(),
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("url", "u", "http://localhost:8888", "Specify the endpoint of the registry (defaults to localhost:8888)")
+	viper.BindEnv("url", "URL")
+	viper.BindPFlag("url", flags.Lookup("url"))
+
+	return cmd
+}
+
+func runShowCmd() func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		gc, err := newGPGKeyClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		k, err := gc.Get(ctx, args[0], args[1])
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("key-id: %s\n", k.Attributes.KeyID)
+		fmt.Printf("namespace: %s\n", k.Attributes.Namespace)
+		fmt.Printf("source: %s\n", k.Attributes.Source)
+		fmt.Printf("source-url: %s\n", k.Attributes.SourceURL)
+		fmt.Printf("created-at: %s\n", k.Attributes.CreatedAt.Format(time.RFC3339))
+		if k.Attributes.RevokedAt != nil {
+			fmt.Printf("revoked-at: %s\n", k.Attributes.RevokedAt.Format(time.RFC3339))
+		}
+		fmt.Println()
+		fmt.Print(k.Attributes.ASCIIArmor)
+		return nil
+	}
+}
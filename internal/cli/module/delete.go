@@ -0,0 +1,59 @@
+package module
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/kerraform/kegistry/internal/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type deleteOpts struct {
+	namespace string
+	name      string
+	provider  string
+}
+
+func newDeleteCmd() *cobra.Command {
+	opts := &deleteOpts{}
+
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete Terraform module with all of its versions",
+		RunE:  runDeleteCmd(opts),
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("url", "u", "http://localhost:8888", "Specify the endpoint of the registry (defaults to localhost:8888)")
+	flags.StringVarP(&opts.namespace, "namespace", "n", "", "Namespace (a.k.a organization) of the module")
+	flags.StringVar(&opts.name, "name", "", "Name of the module")
+	flags.StringVarP(&opts.provider, "provider", "p", "", "Target provider")
+	viper.BindEnv("url", "URL")
+	viper.BindPFlag("url", flags.Lookup("url"))
+
+	return cmd
+}
+
+func runDeleteCmd(opts *deleteOpts) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		u, err := url.Parse(viper.GetString("url"))
+		if err != nil {
+			return err
+		}
+
+		c := client.New(u, client.WithToken(viper.GetString("token")))
+		svc, err := c.ServiceDiscovery(ctx)
+		if err != nil {
+			return err
+		}
+
+		mc, err := client.NewModuleClient(svc.ModulesV1, c)
+		if err != nil {
+			return err
+		}
+
+		return mc.DeleteModule(ctx, opts.namespace, opts.name, opts.provider)
+	}
+}
@@ -14,6 +14,7 @@ func NewCmd() *cobra.Command {
 		},
 	}
 
+	cmd.AddCommand(newDeleteCmd())
 	cmd.AddCommand(version.NewCmd())
 	return cmd
 }
@@ -47,7 +47,7 @@ func runSaveCmd(opts *saveOpts) func(cmd *cobra.Command, args []string) error {
 			return err
 		}
 
-		c := client.New(u)
+		c := client.New(u, client.WithToken(viper.GetString("token")))
 		svc, err := c.ServiceDiscovery(ctx)
 		if err != nil {
 			return err
@@ -59,7 +59,7 @@ func runSaveCmd(opts *saveOpts) func(cmd *cobra.Command, args []string) error {
 		}
 
 		version := args[0]
-		uploadURL, err := mc.CreateVersion(ctx, opts.namespace, opts.name, opts.provider, version)
+		uploadURL, headers, err := mc.CreateVersion(ctx, opts.namespace, opts.name, opts.provider, version)
 		if err != nil {
 			return err
 		}
@@ -70,7 +70,7 @@ func runSaveCmd(opts *saveOpts) func(cmd *cobra.Command, args []string) error {
 				return err
 			}
 
-			return mc.UploadModuleVersion(ctx, uploadURL, f)
+			return mc.UploadModuleVersion(ctx, uploadURL, headers, f)
 		}
 
 		return nil
@@ -0,0 +1,47 @@
+package version
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type undeprecateOpts struct {
+	namespace string
+	name      string
+	provider  string
+}
+
+func newUndeprecateCmd() *cobra.Command {
+	opts := &undeprecateOpts{}
+
+	cmd := &cobra.Command{
+		Use:   "undeprecate <version>",
+		Short: "Undeprecate Terraform module version",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runUndeprecateCmd(opts),
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("url", "u", "http://localhost:8888", "Specify the endpoint of the registry (defaults to localhost:8888)")
+	flags.StringVarP(&opts.namespace, "namespace", "n", "", "Namespace (a.k.a organization) of the module")
+	flags.StringVar(&opts.name, "name", "", "Name of the module")
+	flags.StringVarP(&opts.provider, "provider", "p", "", "Target provider")
+	viper.BindEnv("url", "URL")
+	viper.BindPFlag("url", flags.Lookup("url"))
+
+	return cmd
+}
+
+func runUndeprecateCmd(opts *undeprecateOpts) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		mc, err := newModuleClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		return mc.UndeprecateVersion(ctx, opts.namespace, opts.name, opts.provider, args[0])
+	}
+}
@@ -0,0 +1,47 @@
+package version
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type unyankOpts struct {
+	namespace string
+	name      string
+	provider  string
+}
+
+func newUnyankCmd() *cobra.Command {
+	opts := &unyankOpts{}
+
+	cmd := &cobra.Command{
+		Use:   "unyank <version>",
+		Short: "Unyank Terraform module version, so that it is listed again",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runUnyankCmd(opts),
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("url", "u", "http://localhost:8888", "Specify the endpoint of the registry (defaults to localhost:8888)")
+	flags.StringVarP(&opts.namespace, "namespace", "n", "", "Namespace (a.k.a organization) of the module")
+	flags.StringVar(&opts.name, "name", "", "Name of the module")
+	flags.StringVarP(&opts.provider, "provider", "p", "", "Target provider")
+	viper.BindEnv("url", "URL")
+	viper.BindPFlag("url", flags.Lookup("url"))
+
+	return cmd
+}
+
+func runUnyankCmd(opts *unyankOpts) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		mc, err := newModuleClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		return mc.UnyankVersion(ctx, opts.namespace, opts.name, opts.provider, args[0])
+	}
+}
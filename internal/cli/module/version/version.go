@@ -1,7 +1,12 @@
 package version
 
 import (
+	"context"
+	"net/url"
+
+	"github.com/kerraform/kegistry/internal/client"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 func NewCmd() *cobra.Command {
@@ -13,6 +18,26 @@ func NewCmd() *cobra.Command {
 		},
 	}
 
+	cmd.AddCommand(newDeleteCmd())
+	cmd.AddCommand(newDeprecateCmd())
 	cmd.AddCommand(newSaveCmd())
+	cmd.AddCommand(newUndeprecateCmd())
+	cmd.AddCommand(newUnyankCmd())
+	cmd.AddCommand(newYankCmd())
 	return cmd
 }
+
+func newModuleClient(ctx context.Context) (*client.ModuleService, error) {
+	u, err := url.Parse(viper.GetString("url"))
+	if err != nil {
+		return nil, err
+	}
+
+	c := client.New(u, client.WithToken(viper.GetString("token")))
+	svc, err := c.ServiceDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.NewModuleClient(svc.ModulesV1, c)
+}
@@ -0,0 +1,49 @@
+package version
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type yankOpts struct {
+	namespace string
+	name      string
+	provider  string
+	reason    string
+}
+
+func newYankCmd() *cobra.Command {
+	opts := &yankOpts{}
+
+	cmd := &cobra.Command{
+		Use:   "yank <version>",
+		Short: "Yank Terraform module version, so that it is no longer listed but still can be downloaded",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runYankCmd(opts),
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("url", "u", "http://localhost:8888", "Specify the endpoint of the registry (defaults to localhost:8888)")
+	flags.StringVarP(&opts.namespace, "namespace", "n", "", "Namespace (a.k.a organization) of the module")
+	flags.StringVar(&opts.name, "name", "", "Name of the module")
+	flags.StringVarP(&opts.provider, "provider", "p", "", "Target provider")
+	flags.StringVar(&opts.reason, "reason", "", "Reason of yanking this version")
+	viper.BindEnv("url", "URL")
+	viper.BindPFlag("url", flags.Lookup("url"))
+
+	return cmd
+}
+
+func runYankCmd(opts *yankOpts) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		mc, err := newModuleClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		return mc.YankVersion(ctx, opts.namespace, opts.name, opts.provider, args[0], opts.reason)
+	}
+}
@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/kerraform/kegistry/internal/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type fsckOpts struct {
+	namespace string
+	registry  string
+}
+
+func newFsckCmd() *cobra.Command {
+	opts := &fsckOpts{}
+
+	cmd := &cobra.Command{
+		Use:   "fsck",
+		Short: "Check the digests of all the Terraform provider binaries, and backfill the missing ones",
+		RunE:  runFsckCmd(opts),
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("url", "u", "http://localhost:8888", "Specify the endpoint of the registry (defaults to localhost:8888)")
+	flags.StringVarP(&opts.namespace, "namespace", "n", "", "Namespace (a.k.a organization) of the provider")
+	flags.StringVarP(&opts.registry, "registry", "r", "", "Registry name of the provider")
+	viper.BindEnv("url", "URL")
+	viper.BindPFlag("url", flags.Lookup("url"))
+
+	return cmd
+}
+
+func runFsckCmd(opts *fsckOpts) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		u, err := url.Parse(viper.GetString("url"))
+		if err != nil {
+			return err
+		}
+
+		c := client.New(u, client.WithToken(viper.GetString("token")))
+		svc, err := c.ServiceDiscovery(ctx)
+		if err != nil {
+			return err
+		}
+
+		pc, err := client.NewProviderClient(svc.ProvidersV1, c)
+		if err != nil {
+			return err
+		}
+
+		platforms, err := pc.Fsck(ctx, opts.namespace, opts.registry)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range platforms {
+			fmt.Printf("%s\t%s_%s\t%s\t%s\n", p.Attributes.Version, p.Attributes.OS, p.Attributes.Arch, p.Attributes.Status, p.Attributes.SHA256)
+		}
+		return nil
+	}
+}
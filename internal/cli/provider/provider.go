@@ -14,6 +14,7 @@ func NewCmd() *cobra.Command {
 		},
 	}
 
+	cmd.AddCommand(newFsckCmd())
 	cmd.AddCommand(version.NewCmd())
 	return cmd
 }
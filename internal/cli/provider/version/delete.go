@@ -0,0 +1,46 @@
+package version
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type deleteOpts struct {
+	namespace string
+	registry  string
+	version   string
+}
+
+func newDeleteCmd() *cobra.Command {
+	opts := &deleteOpts{}
+
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete Terraform provider version with all of its platforms",
+		RunE:  runDeleteCmd(opts),
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("url", "u", "http://localhost:8888", "Specify the endpoint of the registry (defaults to localhost:8888)")
+	flags.StringVarP(&opts.namespace, "namespace", "n", "", "Namespace (a.k.a organization) of the provider")
+	flags.StringVarP(&opts.registry, "registry", "r", "", "Registry name of the provider")
+	flags.StringVar(&opts.version, "version", "", "Version of the provider")
+	viper.BindEnv("url", "URL")
+	viper.BindPFlag("url", flags.Lookup("url"))
+
+	return cmd
+}
+
+func runDeleteCmd(opts *deleteOpts) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		pc, err := newProviderClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		return pc.DeleteVersion(ctx, opts.namespace, opts.registry, opts.version)
+	}
+}
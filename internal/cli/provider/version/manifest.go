@@ -0,0 +1,55 @@
+package version
+
+import (
+	"context"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type uploadManifestOpts struct {
+	namespace    string
+	manifestPath string
+	registry     string
+	version      string
+}
+
+func newUploadManifestCmd() *cobra.Command {
+	opts := &uploadManifestOpts{}
+
+	cmd := &cobra.Command{
+		Use:   "upload-manifest",
+		Short: "Upload terraform-registry-manifest.json of Terraform provider version to declare its protocols",
+		RunE:  runUploadManifestCmd(opts),
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("url", "u", "http://localhost:8888", "Specify the endpoint of the registry (defaults to localhost:8888)")
+	flags.StringVar(&opts.manifestPath, "path", "terraform-registry-manifest.json", "Path to terraform-registry-manifest.json")
+	flags.StringVarP(&opts.namespace, "namespace", "n", "", "Namespace (a.k.a organization) of the provider")
+	flags.StringVarP(&opts.registry, "registry", "r", "", "Registry name of the provider")
+	flags.StringVar(&opts.version, "version", "", "Version of the provider")
+	viper.BindEnv("url", "URL")
+	viper.BindPFlag("url", flags.Lookup("url"))
+
+	return cmd
+}
+
+func runUploadManifestCmd(opts *uploadManifestOpts) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		pc, err := newProviderClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(opts.manifestPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		return pc.UploadManifest(ctx, opts.namespace, opts.registry, opts.version, f)
+	}
+}
@@ -0,0 +1,63 @@
+package platform
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/kerraform/kegistry/internal/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type deleteOpts struct {
+	arch      string
+	namespace string
+	registry  string
+	os        string
+	version   string
+}
+
+func newDeleteCmd() *cobra.Command {
+	opts := &deleteOpts{}
+
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete Terraform provider version platform",
+		RunE:  runDeleteCmd(opts),
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("url", "u", "http://localhost:8888", "Specify the endpoint of the registry (defaults to localhost:8888)")
+	flags.StringVar(&opts.arch, "arch", "", "Architecture of the platform")
+	flags.StringVarP(&opts.namespace, "namespace", "n", "", "Namespace (a.k.a organization) of the provider")
+	flags.StringVarP(&opts.registry, "registry", "r", "", "Registry name of the provider")
+	flags.StringVar(&opts.os, "os", "", "OS of the platform")
+	flags.StringVar(&opts.version, "version", "", "Version of the provider")
+	viper.BindEnv("url", "URL")
+	viper.BindPFlag("url", flags.Lookup("url"))
+
+	return cmd
+}
+
+func runDeleteCmd(opts *deleteOpts) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		u, err := url.Parse(viper.GetString("url"))
+		if err != nil {
+			return err
+		}
+
+		c := client.New(u, client.WithToken(viper.GetString("token")))
+		svc, err := c.ServiceDiscovery(ctx)
+		if err != nil {
+			return err
+		}
+
+		pc, err := client.NewProviderClient(svc.ProvidersV1, c)
+		if err != nil {
+			return err
+		}
+
+		return pc.DeleteVersionPlatform(ctx, opts.namespace, opts.registry, opts.version, opts.os, opts.arch)
+	}
+}
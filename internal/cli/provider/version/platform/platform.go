@@ -11,6 +11,7 @@ func NewCmd() *cobra.Command {
 		},
 	}
 
+	cmd.AddCommand(newDeleteCmd())
 	cmd.AddCommand(newSaveCmd())
 	return cmd
 }
@@ -50,7 +50,7 @@ func runCreateCmd(opts *saveOpts) func(cmd *cobra.Command, args []string) error
 			return err
 		}
 
-		c := client.New(u)
+		c := client.New(u, client.WithToken(viper.GetString("token")))
 		svc, err := c.ServiceDiscovery(ctx)
 		if err != nil {
 			return err
@@ -61,7 +61,7 @@ func runCreateCmd(opts *saveOpts) func(cmd *cobra.Command, args []string) error
 			return err
 		}
 
-		uploadURL, err := pc.CreateVersionPlatform(ctx, opts.namespace, opts.registry, opts.version, opts.os, opts.arch)
+		uploadURL, headers, err := pc.CreateVersionPlatform(ctx, opts.namespace, opts.registry, opts.version, opts.os, opts.arch)
 		if err != nil {
 			return err
 		}
@@ -72,7 +72,7 @@ func runCreateCmd(opts *saveOpts) func(cmd *cobra.Command, args []string) error
 				return err
 			}
 
-			return pc.UploadProviderBinary(ctx, uploadURL, f)
+			return pc.UploadProviderBinary(ctx, uploadURL, headers, f)
 		}
 
 		return nil
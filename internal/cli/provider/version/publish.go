@@ -0,0 +1,58 @@
+package version
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type publishOpts struct {
+	namespace string
+	registry  string
+	version   string
+}
+
+func newPublishCmd() *cobra.Command {
+	opts := &publishOpts{}
+
+	cmd := &cobra.Command{
+		Use:   "publish",
+		Short: "Publish Terraform provider version after all of its artifacts are uploaded",
+		RunE:  runPublishCmd(opts),
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("url", "u", "http://localhost:8888", "Specify the endpoint of the registry (defaults to localhost:8888)")
+	flags.StringVarP(&opts.namespace, "namespace", "n", "", "Namespace (a.k.a organization) of the provider")
+	flags.StringVarP(&opts.registry, "registry", "r", "", "Registry name of the provider")
+	flags.StringVar(&opts.version, "version", "", "Version of the provider")
+	viper.BindEnv("url", "URL")
+	viper.BindPFlag("url", flags.Lookup("url"))
+
+	return cmd
+}
+
+func runPublishCmd(opts *publishOpts) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		pc, err := newProviderClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		v, err := pc.PublishVersion(ctx, opts.namespace, opts.registry, opts.version)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("version: %s\n", v.Attributes.Version)
+		fmt.Printf("state: %s\n", v.Attributes.State)
+		if v.Attributes.PublishedAt != nil {
+			fmt.Printf("published-at: %s\n", v.Attributes.PublishedAt.Format(time.RFC3339))
+		}
+		return nil
+	}
+}
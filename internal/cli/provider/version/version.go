@@ -1,8 +1,13 @@
 package version
 
 import (
+	"context"
+	"net/url"
+
 	"github.com/kerraform/kegistry/internal/cli/provider/version/platform"
+	"github.com/kerraform/kegistry/internal/client"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 func NewCmd() *cobra.Command {
@@ -14,6 +19,26 @@ func NewCmd() *cobra.Command {
 		},
 	}
 
+	cmd.AddCommand(newDeleteCmd())
+	cmd.AddCommand(newPublishCmd())
+	cmd.AddCommand(newUnyankCmd())
+	cmd.AddCommand(newUploadManifestCmd())
+	cmd.AddCommand(newYankCmd())
 	cmd.AddCommand(platform.NewCmd())
 	return cmd
 }
+
+func newProviderClient(ctx context.Context) (*client.ProviderService, error) {
+	u, err := url.Parse(viper.GetString("url"))
+	if err != nil {
+		return nil, err
+	}
+
+	c := client.New(u, client.WithToken(viper.GetString("token")))
+	svc, err := c.ServiceDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.NewProviderClient(svc.ProvidersV1, c)
+}
@@ -0,0 +1,48 @@
+package version
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type yankOpts struct {
+	namespace string
+	reason    string
+	registry  string
+	version   string
+}
+
+func newYankCmd() *cobra.Command {
+	opts := &yankOpts{}
+
+	cmd := &cobra.Command{
+		Use:   "yank",
+		Short: "Yank Terraform provider version, so that it is no longer listed but still can be downloaded",
+		RunE:  runYankCmd(opts),
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("url", "u", "http://localhost:8888", "Specify the endpoint of the registry (defaults to localhost:8888)")
+	flags.StringVarP(&opts.namespace, "namespace", "n", "", "Namespace (a.k.a organization) of the provider")
+	flags.StringVar(&opts.reason, "reason", "", "Reason of yanking this version")
+	flags.StringVarP(&opts.registry, "registry", "r", "", "Registry name of the provider")
+	flags.StringVar(&opts.version, "version", "", "Version of the provider")
+	viper.BindEnv("url", "URL")
+	viper.BindPFlag("url", flags.Lookup("url"))
+
+	return cmd
+}
+
+func runYankCmd(opts *yankOpts) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		pc, err := newProviderClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		return pc.YankVersion(ctx, opts.namespace, opts.registry, opts.version, opts.reason)
+	}
+}
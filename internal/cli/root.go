@@ -6,6 +6,7 @@ import (
 	"github.com/kerraform/kegistry/internal/cli/gpgkey"
 	"github.com/kerraform/kegistry/internal/cli/module"
 	"github.com/kerraform/kegistry/internal/cli/provider"
+	"github.com/kerraform/kegistry/internal/cli/token"
 	"github.com/kerraform/kegistry/internal/version"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -16,6 +17,13 @@ var (
 		Use:     "kegistry-cli",
 		Short:   "CLI for Kegistry, Terraform provider",
 		Version: version.Version,
+		// Every command has its own url flag, bind the one of the executed command
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if f := cmd.Flags().Lookup("url"); f != nil {
+				return viper.BindPFlag("url", f)
+			}
+			return nil
+		},
 	}
 )
 
@@ -28,6 +36,11 @@ func Execute() error {
 	rootCmd.AddCommand(gpgkey.NewCmd())
 	rootCmd.AddCommand(module.NewCmd())
 	rootCmd.AddCommand(provider.NewCmd())
+	rootCmd.AddCommand(token.NewCmd())
+
+	flags := rootCmd.PersistentFlags()
+	flags.String("token", "", "API token of the registry")
+	viper.BindPFlag("token", flags.Lookup("token"))
 
 	viper.SetEnvPrefix(envPrefix)
 	viper.AutomaticEnv()
@@ -0,0 +1,69 @@
+package token
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kerraform/kegistry/internal/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type createOpts struct {
+	description string
+	namespace   string
+	subject     string
+	ttl         time.Duration
+}
+
+func newCreateCmd() *cobra.Command {
+	opts := &createOpts{}
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create API token",
+		RunE:  runCreateCmd(opts),
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("url", "u", "http://localhost:8888", "Specify the endpoint of the registry (defaults to localhost:8888)")
+	flags.StringVarP(&opts.description, "description", "d", "", "Description of the token")
+	flags.StringVarP(&opts.namespace, "namespace", "n", "*", "Namespace (a.k.a organization) which the token can act on")
+	flags.StringVar(&opts.subject, "subject", "", "Subject of the token (defaults to the caller itself)")
+	flags.DurationVar(&opts.ttl, "ttl", 0, "Lifetime of the token (defaults to the server configuration)")
+	viper.BindEnv("url", "URL")
+	viper.BindPFlag("url", flags.Lookup("url"))
+
+	return cmd
+}
+
+func runCreateCmd(opts *createOpts) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		tc, err := newTokenClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		var expiredAt time.Time
+		if opts.ttl > 0 {
+			expiredAt = time.Now().Add(opts.ttl)
+		}
+
+		t, err := tc.Create(ctx, &client.CreateTokenOpts{
+			Description: opts.description,
+			Namespace:   opts.namespace,
+			Subject:     opts.subject,
+			ExpiredAt:   expiredAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("id: %s\n", t.ID)
+		fmt.Printf("expired-at: %s\n", t.Attributes.ExpiredAt.Format(time.RFC3339))
+		fmt.Printf("token: %s\n", t.Attributes.Token)
+		return nil
+	}
+}
@@ -0,0 +1,62 @@
+package token
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func newListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List API tokens",
+		RunE:  runListCmd(),
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("url", "u", "http://localhost:8888", "Specify the endpoint of the registry (defaults to localhost:8888)")
+	viper.BindEnv("url", "URL")
+	viper.BindPFlag("url", flags.Lookup("url"))
+
+	return cmd
+}
+
+func runListCmd() func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		tc, err := newTokenClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		ts, err := tc.List(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tSUBJECT\tNAMESPACE\tEXPIRED AT\tLAST USED AT\tDESCRIPTION")
+		for _, t := range ts {
+			lastUsedAt := "-"
+			if t.Attributes.LastUsedAt != nil {
+				lastUsedAt = t.Attributes.LastUsedAt.Format(time.RFC3339)
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				t.ID,
+				t.Attributes.Subject,
+				t.Attributes.Namespace,
+				t.Attributes.ExpiredAt.Format(time.RFC3339),
+				lastUsedAt,
+				t.Attributes.Description,
+			)
+		}
+
+		return w.Flush()
+	}
+}
@@ -0,0 +1,40 @@
+package token
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/kerraform/kegistry/internal/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "token",
+		Short: "API token related operations",
+		Aliases: []string{
+			"t",
+		},
+	}
+
+	cmd.AddCommand(newCreateCmd())
+	cmd.AddCommand(newListCmd())
+	cmd.AddCommand(newRevokeCmd())
+	return cmd
+}
+
+func newTokenClient(ctx context.Context) (*client.TokenService, error) {
+	u, err := url.Parse(viper.GetString("url"))
+	if err != nil {
+		return nil, err
+	}
+
+	c := client.New(u, client.WithToken(viper.GetString("token")))
+	svc, err := c.ServiceDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.NewTokenClient(svc.TokensV1, c)
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 
 	"github.com/kerraform/kegistry/internal/model"
 )
@@ -15,12 +16,14 @@ import (
 type Client struct {
 	baseURL   *url.URL
 	client    *http.Client
+	token     string
 	userAgent string
 }
 
 type ClientOpts struct {
 	BaseURL    *url.URL
 	HTTPClient *http.Client
+	Token      string
 	UserAgent  string
 }
 type ClientOpt func(o *ClientOpts)
@@ -31,6 +34,13 @@ func WithHTTPClient(client *http.Client) ClientOpt {
 	}
 }
 
+// WithToken sets the bearer token sent to the registry
+func WithToken(token string) ClientOpt {
+	return func(o *ClientOpts) {
+		o.Token = token
+	}
+}
+
 func WithUserAgent(ua string) ClientOpt {
 	return func(o *ClientOpts) {
 		o.UserAgent = ua
@@ -50,6 +60,7 @@ func New(baseURL *url.URL, opts ...ClientOpt) *Client {
 	c := &Client{
 		baseURL: baseURL,
 		client:  o.HTTPClient,
+		token:   o.Token,
 	}
 
 	return c
@@ -79,6 +90,7 @@ func (c *Client) Do(ctx context.Context, req *http.Request) (*http.Response, err
 }
 
 type RequestOpts struct {
+	headers  map[string]string
 	isBinary bool
 	url      *url.URL
 }
@@ -91,6 +103,13 @@ func WithBinary(isBinary bool) RequestOpt {
 	}
 }
 
+// WithHeaders sets the headers, e.g. the ones required by the presigned URL
+func WithHeaders(headers map[string]string) RequestOpt {
+	return func(ro *RequestOpts) {
+		ro.headers = headers
+	}
+}
+
 func WithURL(url *url.URL) RequestOpt {
 	return func(ro *RequestOpts) {
 		ro.url = url
@@ -162,10 +181,33 @@ func (c *Client) NewRequest(method, urlStr string, body interface{}, opts ...Req
 		return nil, err
 	}
 
+	// The object storages refuse the chunked upload by the presigned URL
+	if f, ok := buf.(*os.File); ok {
+		fi, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		req.ContentLength = fi.Size()
+	}
+
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
+	// Do not send the credential to the external URL (e.g. presigned URL)
+	if c.token != "" && o.url == nil {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	// Azure Blob Storage requires the blob type on the upload by the SAS URL, which is ignored by the others
+	if method == http.MethodPut && o.url != nil {
+		req.Header.Set("x-ms-blob-type", "BlockBlob")
+	}
+
+	for k, v := range o.headers {
+		req.Header.Set(k, v)
+	}
+
 	if c.userAgent != "" {
 		req.Header.Set("User-Agent", c.userAgent)
 	}
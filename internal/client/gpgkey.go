@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	v1 "github.com/kerraform/kegistry/internal/v1"
+	"github.com/kerraform/kegistry/internal/v1/request"
+)
+
+type GPGKeyService struct {
+	client *Client
+	url    *url.URL
+}
+
+func NewGPGKeyClient(urlStr string, c *Client) (*GPGKeyService, error) {
+	if urlStr == "" {
+		return nil, errors.New("gpg key api is not supported by the registry")
+	}
+
+	url, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GPGKeyService{
+		client: c,
+		url:    url,
+	}, nil
+}
+
+func (s *GPGKeyService) List(ctx context.Context, namespace string) ([]*v1.GPGKeyData, error) {
+	req, err := s.client.NewGetRequest(fmt.Sprintf("%s/%s", s.url, url.PathEscape(namespace)))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("invalid status code, got: %d", resp.StatusCode)
+	}
+
+	r := &v1.ListGPGKeysResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(r); err != nil {
+		return nil, err
+	}
+
+	return r.Data, nil
+}
+
+func (s *GPGKeyService) Get(ctx context.Context, namespace, keyID string) (*v1.GPGKeyData, error) {
+	req, err := s.client.NewGetRequest(s.keyURL(namespace, keyID))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("invalid status code, got: %d", resp.StatusCode)
+	}
+
+	r := &v1.GPGKeyResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(r); err != nil {
+		return nil, err
+	}
+
+	return r.Data, nil
+}
+
+func (s *GPGKeyService) Revoke(ctx context.Context, namespace, keyID string) (*v1.GPGKeyData, error) {
+	revoked := true
+	b := &v1.UpdateGPGKeyRequest{
+		Data: &request.Data[v1.UpdateGPGKeyRequestAttributes, v1.DataType]{
+			Type: v1.DataTypeAddGPGKey,
+			Attributes: &v1.UpdateGPGKeyRequestAttributes{
+				Revoked: &revoked,
+			},
+		},
+	}
+
+	req, err := s.client.NewPatchRequest(s.keyURL(namespace, keyID), b)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("invalid status code, got: %d", resp.StatusCode)
+	}
+
+	r := &v1.GPGKeyResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(r); err != nil {
+		return nil, err
+	}
+
+	return r.Data, nil
+}
+
+func (s *GPGKeyService) Delete(ctx context.Context, namespace, keyID string, force bool) error {
+	u := s.keyURL(namespace, keyID)
+	if force {
+		u += "?force=true"
+	}
+
+	req, err := s.client.NewDeleteRequest(u)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil
+	case http.StatusConflict:
+		return errors.New("gpg key is used by provider versions, use --force to delete it anyway")
+	default:
+		return fmt.Errorf("invalid status code, got: %d", resp.StatusCode)
+	}
+}
+
+func (s *GPGKeyService) keyURL(namespace, keyID string) string {
+	return fmt.Sprintf("%s/%s/%s", s.url, url.PathEscape(namespace), url.PathEscape(keyID))
+}
@@ -30,7 +30,7 @@ func NewModuleClient(urlStr string, c *Client) (*ModuleService, error) {
 	}, nil
 }
 
-func (s *ModuleService) CreateVersion(ctx context.Context, namespace, name, provider, version string) (*url.URL, error) {
+func (s *ModuleService) CreateVersion(ctx context.Context, namespace, name, provider, version string) (*url.URL, map[string]string, error) {
 	b := &module.CreateModuleVersionRequest{
 		Data: &module.CreateModuleVersionRequestData{
 			Attributes: &module.CreateModuleVersionDataAttributes{
@@ -39,47 +39,48 @@ func (s *ModuleService) CreateVersion(ctx context.Context, namespace, name, prov
 		},
 	}
 
-	req, err := s.client.NewPostRequest(fmt.Sprintf("%s/%s/%s/%s/versions", s.url, namespace, name, provider), b)
+	req, err := s.client.NewPostRequest(fmt.Sprintf("%s%s/%s/%s/versions", s.url, namespace, name, provider), b)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	resp, err := s.client.Do(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("invalid status code, got: %d", resp.StatusCode)
+		return nil, nil, fmt.Errorf("invalid status code, got: %d", resp.StatusCode)
 	}
 
 	r := &module.CreateModuleVersionResponse{}
 	if err := json.NewDecoder(resp.Body).Decode(r); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if r.Data.Links.Upload == "" {
-		return nil, errors.New("invalid response")
+		return nil, nil, errors.New("invalid response")
 	}
 
 	url, err := url.Parse(r.Data.Links.Upload)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return url, nil
+	return url, r.Data.Links.UploadHeaders, nil
 }
 
-func (s *ModuleService) UploadModuleVersion(ctx context.Context, u *url.URL, pkg io.ReadWriter) error {
+func (s *ModuleService) UploadModuleVersion(ctx context.Context, u *url.URL, headers map[string]string, pkg io.ReadWriter) error {
 	opts := []RequestOpt{
 		WithBinary(true),
+		WithHeaders(headers),
 	}
 
 	if strings.HasPrefix(u.String(), "http") || strings.HasPrefix(u.String(), "https") {
 		opts = append(opts, WithURL(u))
 	}
 
-	req, err := s.client.NewPutRequest(u.String(), nil, opts...)
+	req, err := s.client.NewPutRequest(u.String(), pkg, opts...)
 	if err != nil {
 		return err
 	}
@@ -89,7 +90,94 @@ func (s *ModuleService) UploadModuleVersion(ctx context.Context, u *url.URL, pkg
 		return err
 	}
 
-	if resp.StatusCode != http.StatusOK {
+	// Azure Blob Storage responds to the upload by the SAS URL with 201 Created
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("invalid status code, got: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (s *ModuleService) DeleteModule(ctx context.Context, namespace, name, provider string) error {
+	req, err := s.client.NewDeleteRequest(fmt.Sprintf("%s%s/%s/%s", s.url, namespace, name, provider))
+	if err != nil {
+		return err
+	}
+
+	return s.doNoContent(ctx, req)
+}
+
+func (s *ModuleService) DeleteVersion(ctx context.Context, namespace, name, provider, version string) error {
+	req, err := s.client.NewDeleteRequest(fmt.Sprintf("%s%s/%s/%s/versions/%s", s.url, namespace, name, provider, version))
+	if err != nil {
+		return err
+	}
+
+	return s.doNoContent(ctx, req)
+}
+
+func (s *ModuleService) DeprecateVersion(ctx context.Context, namespace, name, provider, version, reason string) error {
+	b := &module.DeprecateModuleVersionRequest{
+		Data: &module.DeprecateModuleVersionRequestData{
+			Type: module.DataTypeRegistryModuleVersion,
+			Attributes: &module.DeprecateModuleVersionDataAttributes{
+				Reason: reason,
+			},
+		},
+	}
+
+	req, err := s.client.NewPostRequest(fmt.Sprintf("%s%s/%s/%s/versions/%s/deprecate", s.url, namespace, name, provider, version), b)
+	if err != nil {
+		return err
+	}
+
+	return s.doNoContent(ctx, req)
+}
+
+func (s *ModuleService) UndeprecateVersion(ctx context.Context, namespace, name, provider, version string) error {
+	req, err := s.client.NewDeleteRequest(fmt.Sprintf("%s%s/%s/%s/versions/%s/deprecate", s.url, namespace, name, provider, version))
+	if err != nil {
+		return err
+	}
+
+	return s.doNoContent(ctx, req)
+}
+
+func (s *ModuleService) YankVersion(ctx context.Context, namespace, name, provider, version, reason string) error {
+	b := &module.YankModuleVersionRequest{
+		Data: &module.YankModuleVersionRequestData{
+			Type: module.DataTypeRegistryModuleVersion,
+			Attributes: &module.YankModuleVersionDataAttributes{
+				Reason: reason,
+			},
+		},
+	}
+
+	req, err := s.client.NewPostRequest(fmt.Sprintf("%s%s/%s/%s/versions/%s/yank", s.url, namespace, name, provider, version), b)
+	if err != nil {
+		return err
+	}
+
+	return s.doNoContent(ctx, req)
+}
+
+func (s *ModuleService) UnyankVersion(ctx context.Context, namespace, name, provider, version string) error {
+	req, err := s.client.NewDeleteRequest(fmt.Sprintf("%s%s/%s/%s/versions/%s/yank", s.url, namespace, name, provider, version))
+	if err != nil {
+		return err
+	}
+
+	return s.doNoContent(ctx, req)
+}
+
+func (s *ModuleService) doNoContent(ctx context.Context, req *http.Request) error {
+	resp, err := s.client.Do(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
 		return fmt.Errorf("invalid status code, got: %d", resp.StatusCode)
 	}
 
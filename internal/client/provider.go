@@ -31,7 +31,7 @@ func NewProviderClient(urlStr string, c *Client) (*ProviderService, error) {
 	}, nil
 }
 
-func (s *ProviderService) CreateVersionPlatform(ctx context.Context, namespace, name, version, pos, arch string) (*url.URL, error) {
+func (s *ProviderService) CreateVersionPlatform(ctx context.Context, namespace, name, version, pos, arch string) (*url.URL, map[string]string, error) {
 	b := &provider.CreateProviderPlatformRequest{
 		Data: &request.Data[provider.CreateProviderPlatformRequestDataAttributes, provider.DataType]{
 			Attributes: &provider.CreateProviderPlatformRequestDataAttributes{
@@ -43,33 +43,33 @@ func (s *ProviderService) CreateVersionPlatform(ctx context.Context, namespace,
 
 	req, err := s.client.NewPostRequest(fmt.Sprintf("%s%s/%s/versions/%s/platforms", s.url, namespace, name, version), b)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	resp, err := s.client.Do(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("invalid status code, got: %d", resp.StatusCode)
+		return nil, nil, fmt.Errorf("invalid status code, got: %d", resp.StatusCode)
 	}
 
 	r := &provider.CreateProviderPlatformResponse{}
 	if err := json.NewDecoder(resp.Body).Decode(r); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if r.Data.Links.ProviderBinaryUploads == "" {
-		return nil, errors.New("invalid response")
+		return nil, nil, errors.New("invalid response")
 	}
 
 	url, err := url.Parse(r.Data.Links.ProviderBinaryUploads)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return url, nil
+	return url, r.Data.Links.ProviderBinaryUploadHeaders, nil
 }
 
 func (s *ProviderService) SaveGPGKey(ctx context.Context, key io.ReadWriter) error {
@@ -94,9 +94,10 @@ func (s *ProviderService) SaveGPGKey(ctx context.Context, key io.ReadWriter) err
 	return nil
 }
 
-func (s *ProviderService) UploadProviderBinary(ctx context.Context, u *url.URL, b io.ReadWriter) error {
+func (s *ProviderService) UploadProviderBinary(ctx context.Context, u *url.URL, headers map[string]string, b io.ReadWriter) error {
 	opts := []RequestOpt{
 		WithBinary(true),
+		WithHeaders(headers),
 	}
 
 	if strings.HasPrefix(u.String(), "http") || strings.HasPrefix(u.String(), "https") {
@@ -113,7 +114,144 @@ func (s *ProviderService) UploadProviderBinary(ctx context.Context, u *url.URL,
 		return err
 	}
 
+	// Azure Blob Storage responds to the upload by the SAS URL with 201 Created
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("invalid status code, got: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (s *ProviderService) DeleteVersion(ctx context.Context, namespace, name, version string) error {
+	req, err := s.client.NewDeleteRequest(fmt.Sprintf("%s%s/%s/versions/%s", s.url, namespace, name, version))
+	if err != nil {
+		return err
+	}
+
+	return s.doNoContent(ctx, req)
+}
+
+func (s *ProviderService) DeleteVersionPlatform(ctx context.Context, namespace, name, version, pos, arch string) error {
+	req, err := s.client.NewDeleteRequest(fmt.Sprintf("%s%s/%s/versions/%s/platforms/%s/%s", s.url, namespace, name, version, pos, arch))
+	if err != nil {
+		return err
+	}
+
+	return s.doNoContent(ctx, req)
+}
+
+func (s *ProviderService) PublishVersion(ctx context.Context, namespace, name, version string) (*provider.PublishProviderVersionResponseData, error) {
+	req, err := s.client.NewPostRequest(fmt.Sprintf("%s%s/%s/versions/%s/publish", s.url, namespace, name, version), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// The reason why the version cannot be published is in the detail
+	if resp.StatusCode == http.StatusConflict {
+		e := &struct {
+			Detail string `json:"detail"`
+		}{}
+		if err := json.NewDecoder(resp.Body).Decode(e); err == nil && e.Detail != "" {
+			return nil, errors.New(e.Detail)
+		}
+	}
+
 	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("invalid status code, got: %d", resp.StatusCode)
+	}
+
+	r := &provider.PublishProviderVersionResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(r); err != nil {
+		return nil, err
+	}
+
+	return r.Data, nil
+}
+
+func (s *ProviderService) Fsck(ctx context.Context, namespace, name string) ([]*provider.FsckProviderResponseData, error) {
+	req, err := s.client.NewPostRequest(fmt.Sprintf("%s%s/%s/fsck", s.url, namespace, name), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("invalid status code, got: %d", resp.StatusCode)
+	}
+
+	r := &provider.FsckProviderResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(r); err != nil {
+		return nil, err
+	}
+
+	return r.Data, nil
+}
+
+func (s *ProviderService) UploadManifest(ctx context.Context, namespace, name, version string, b io.ReadWriter) error {
+	req, err := s.client.NewPutRequest(fmt.Sprintf("%s%s/%s/versions/%s/manifest", s.url, namespace, name, version), b, WithBinary(true))
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("invalid status code, got: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (s *ProviderService) YankVersion(ctx context.Context, namespace, name, version, reason string) error {
+	b := &provider.YankProviderVersionRequest{
+		Data: &request.Data[provider.YankProviderVersionRequestDataAttributes, provider.DataType]{
+			Type: provider.DataTypeRegistryProviderVersions,
+			Attributes: &provider.YankProviderVersionRequestDataAttributes{
+				Reason: reason,
+			},
+		},
+	}
+
+	req, err := s.client.NewPostRequest(fmt.Sprintf("%s%s/%s/versions/%s/yank", s.url, namespace, name, version), b)
+	if err != nil {
+		return err
+	}
+
+	return s.doNoContent(ctx, req)
+}
+
+func (s *ProviderService) UnyankVersion(ctx context.Context, namespace, name, version string) error {
+	req, err := s.client.NewDeleteRequest(fmt.Sprintf("%s%s/%s/versions/%s/yank", s.url, namespace, name, version))
+	if err != nil {
+		return err
+	}
+
+	return s.doNoContent(ctx, req)
+}
+
+func (s *ProviderService) doNoContent(ctx context.Context, req *http.Request) error {
+	resp, err := s.client.Do(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
 		return fmt.Errorf("invalid status code, got: %d", resp.StatusCode)
 	}
 
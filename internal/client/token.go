@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	v1 "github.com/kerraform/kegistry/internal/v1"
+	"github.com/kerraform/kegistry/internal/v1/request"
+)
+
+type TokenService struct {
+	client *Client
+	url    *url.URL
+}
+
+func NewTokenClient(urlStr string, c *Client) (*TokenService, error) {
+	url, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenService{
+		client: c,
+		url:    url,
+	}, nil
+}
+
+type CreateTokenOpts struct {
+	Description string
+	Namespace   string
+	Subject     string
+	ExpiredAt   time.Time
+}
+
+func (s *TokenService) Create(ctx context.Context, opts *CreateTokenOpts) (*v1.TokenData, error) {
+	b := &v1.CreateTokenRequest{
+		Data: &request.Data[v1.CreateTokenRequestAttributes, v1.DataType]{
+			Type: v1.DataTypeAuthenticationTokens,
+			Attributes: &v1.CreateTokenRequestAttributes{
+				Description: opts.Description,
+				Namespace:   opts.Namespace,
+				Subject:     opts.Subject,
+				ExpiredAt:   opts.ExpiredAt,
+			},
+		},
+	}
+
+	req, err := s.client.NewPostRequest(s.url.String(), b)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("invalid status code, got: %d", resp.StatusCode)
+	}
+
+	r := &v1.TokenResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(r); err != nil {
+		return nil, err
+	}
+
+	return r.Data, nil
+}
+
+func (s *TokenService) List(ctx context.Context) ([]*v1.TokenData, error) {
+	req, err := s.client.NewGetRequest(s.url.String())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("invalid status code, got: %d", resp.StatusCode)
+	}
+
+	r := &v1.ListTokensResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(r); err != nil {
+		return nil, err
+	}
+
+	return r.Data, nil
+}
+
+func (s *TokenService) Revoke(ctx context.Context, id string) error {
+	req, err := s.client.NewDeleteRequest(fmt.Sprintf("%s/%s", s.url, url.PathEscape(id)))
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("invalid status code, got: %d", resp.StatusCode)
+	}
+
+	return nil
+}
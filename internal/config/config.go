@@ -3,15 +3,44 @@ package config
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/sethvargo/go-envconfig"
 	"go.uber.org/zap/zapcore"
 )
 
+type Audit struct {
+	Enable  bool   `env:"ENABLE,default=false"`
+	File    string `env:"FILE"`
+	KeyFile string `env:"KEY_FILE"`
+	Sink    string `env:"SINK,default=backend"`
+}
+
+type Auth struct {
+	AnonymousRead bool              `env:"ANONYMOUS_READ,default=true"`
+	Enable        bool              `env:"ENABLE,default=false"`
+	OIDC          *AuthOIDC         `env:",prefix=OIDC_"`
+	Tokens        map[string]string `env:"TOKENS"`
+	TokenMaxTTL   time.Duration     `env:"TOKEN_MAX_TTL,default=8760h"`
+	TokenTTL      time.Duration     `env:"TOKEN_TTL,default=720h"`
+}
+
+type AuthOIDC struct {
+	Audience       string `env:"AUDIENCE"`
+	Enable         bool   `env:"ENABLE,default=false"`
+	Issuer         string `env:"ISSUER"`
+	JWKSFile       string `env:"JWKS_FILE"`
+	JWKSURL        string `env:"JWKS_URL"`
+	NamespaceClaim string `env:"NAMESPACE_CLAIM"`
+	SubjectClaim   string `env:"SUBJECT_CLAIM,default=sub"`
+}
+
 type Backend struct {
-	S3       *BackendS3 `env:",prefix=S3_"`
-	Type     string     `env:"TYPE,required"`
-	RootPath string     `env:"ROOT_PATH,default=/tmp"`
+	Azure    *BackendAzure `env:",prefix=AZURE_"`
+	GCS      *BackendGCS   `env:",prefix=GCS_"`
+	S3       *BackendS3    `env:",prefix=S3_"`
+	Type     string        `env:"TYPE,required"`
+	RootPath string        `env:"ROOT_PATH,default=/tmp"`
 }
 
 func (b *Backend) MarshalLogObject(enc zapcore.ObjectEncoder) error {
@@ -19,6 +48,20 @@ func (b *Backend) MarshalLogObject(enc zapcore.ObjectEncoder) error {
 	return nil
 }
 
+type BackendAzure struct {
+	AccountKey       string `env:"ACCOUNT_KEY"`
+	AccountName      string `env:"ACCOUNT_NAME"`
+	ConnectionString string `env:"CONNECTION_STRING"`
+	Container        string `env:"CONTAINER"`
+	Endpoint         string `env:"ENDPOINT"`
+}
+
+type BackendGCS struct {
+	Bucket          string `env:"BUCKET"`
+	CredentialsFile string `env:"CREDENTIALS_FILE"`
+	Endpoint        string `env:"ENDPOINT"`
+}
+
 type BackendS3 struct {
 	AccessKey    string `env:"ACCESS_KEY"`
 	Bucket       string `env:"BUCKET"`
@@ -28,13 +71,89 @@ type BackendS3 struct {
 }
 
 type Config struct {
-	Backend        *Backend `env:",prefix=BACKEND_"`
-	EnableModule   bool     `env:"ENABLE_MODULE_REGISTRY,default=false"`
-	EnableProvider bool     `env:"ENABLE_PROVIDER_REGISTRY,default=false"`
-	Log            *Log     `env:",prefix=LOG_"`
-	Name           string   `env:"NAME,default=kegistry"`
-	Port           int      `env:"PORT,default=5000"`
-	Trace          *Trace   `env:",prefix=TRACE_"`
+	Audit          *Audit     `env:",prefix=AUDIT_"`
+	Auth           *Auth      `env:",prefix=AUTH_"`
+	Backend        *Backend   `env:",prefix=BACKEND_"`
+	EnableModule   bool       `env:"ENABLE_MODULE_REGISTRY,default=false"`
+	EnableProvider bool       `env:"ENABLE_PROVIDER_REGISTRY,default=false"`
+	Log            *Log       `env:",prefix=LOG_"`
+	Login          *Login     `env:",prefix=LOGIN_"`
+	Name           string     `env:"NAME,default=kegistry"`
+	Policy         *Policy    `env:",prefix=POLICY_"`
+	Port           int        `env:"PORT,default=5000"`
+	Proxy          *Proxy     `env:",prefix=PROXY_"`
+	RateLimit      *RateLimit `env:",prefix=RATELIMIT_"`
+	Signing        *Signing   `env:",prefix=SIGNING_"`
+	TLS            *TLS       `env:",prefix=TLS_"`
+	Trace          *Trace     `env:",prefix=TRACE_"`
+	Upload         *Upload    `env:",prefix=UPLOAD_"`
+}
+
+type Login struct {
+	ClientID string            `env:"CLIENT_ID,default=terraform-cli"`
+	Enable   bool              `env:"ENABLE,default=false"`
+	Users    map[string]string `env:"USERS"`
+}
+
+type Policy struct {
+	File string `env:"FILE"`
+}
+
+// Proxy is the upstream provider registries to fetch the missing providers from
+type Proxy struct {
+	File         string        `env:"FILE"`
+	ListCacheTTL time.Duration `env:"LIST_CACHE_TTL,default=1m"`
+	ListTimeout  time.Duration `env:"LIST_TIMEOUT,default=10s"`
+	Timeout      time.Duration `env:"TIMEOUT,default=10m"`
+}
+
+type RateLimit struct {
+	Enable       bool            `env:"ENABLE,default=false"`
+	Login        *RateLimitLogin `env:",prefix=LOGIN_"`
+	ModuleRead   *RateLimitGroup `env:",prefix=MODULE_READ_"`
+	ProviderRead *RateLimitGroup `env:",prefix=PROVIDER_READ_"`
+	Upload       *RateLimitGroup `env:",prefix=UPLOAD_"`
+}
+
+// RateLimitGroup is the token bucket of each client.
+// Rate is the number of requests per second, and zero means unlimited.
+type RateLimitGroup struct {
+	Burst int     `env:"BURST,default=20"`
+	Rate  float64 `env:"RATE,default=10"`
+}
+
+// RateLimitLogin is the token bucket of each client to log in, which is strict against guessing the passwords
+type RateLimitLogin struct {
+	Burst int     `env:"BURST,default=5"`
+	Rate  float64 `env:"RATE,default=0.1"`
+}
+
+// Signing is the key of the registry to sign SHA256SUMS of the provider versions.
+// The key is generated per namespace and stored in the backend if no key file is given,
+// which is encrypted by the passphrase if configured.
+type Signing struct {
+	Enable        bool   `env:"ENABLE,default=false"`
+	KeyFile       string `env:"KEY_FILE"`
+	KeyPassphrase string `env:"KEY_PASSPHRASE"`
+}
+
+type TLS struct {
+	CertFile     string `env:"CERT_FILE"`
+	ClientAuth   string `env:"CLIENT_AUTH,default=none"`
+	ClientCAFile string `env:"CLIENT_CA_FILE"`
+	KeyFile      string `env:"KEY_FILE"`
+}
+
+// Enabled reports whether the server serves HTTPS
+func (t *TLS) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
+
+// Upload is the maximum size of each artifact in bytes, zero means unlimited
+type Upload struct {
+	ModuleMaxSize         int64 `env:"MODULE_MAX_SIZE,default=104857600"`
+	ProviderBinaryMaxSize int64 `env:"PROVIDER_BINARY_MAX_SIZE,default=536870912"`
+	SHASumsMaxSize        int64 `env:"SHASUMS_MAX_SIZE,default=1048576"`
 }
 
 type Trace struct {
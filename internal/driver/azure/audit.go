@@ -0,0 +1,85 @@
+package azure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/kerraform/kegistry/internal/driver"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type audit struct {
+	container *container.Client
+	logger    *zap.Logger
+	tracer    trace.Tracer
+}
+
+var _ driver.Audit = (*audit)(nil)
+
+func (d *audit) ListAuditEvents(ctx context.Context, namespace string) ([]*driver.AuditEvent, error) {
+	ctx, span := d.tracer.Start(ctx, "ListAuditEvents")
+	defer span.End()
+	prefix := fmt.Sprintf("%s/%s/", driver.AuditRootPath, namespace)
+
+	names, err := listObjects(ctx, d.container, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	for _, name := range names {
+		if filepath.Ext(name) != ".json" {
+			continue
+		}
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+
+	es := make([]*driver.AuditEvent, 0, len(keys))
+	for _, key := range keys {
+		rc, err := getObject(ctx, d.container, key, fs.ErrNotExist)
+		if err != nil {
+			return nil, err
+		}
+
+		var e driver.AuditEvent
+		err = json.NewDecoder(rc).Decode(&e)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		es = append(es, &e)
+	}
+
+	d.logger.Debug("list audit events", zap.String("namespace", namespace), zap.Int("count", len(es)))
+	return es, nil
+}
+
+func (d *audit) SaveAuditEvent(ctx context.Context, event *driver.AuditEvent) error {
+	ctx, span := d.tracer.Start(ctx, "SaveAuditEvent")
+	defer span.End()
+	eventPath := fmt.Sprintf("%s/%s/%s", driver.AuditRootPath, event.Resource.Namespace, driver.AuditEventFilename(event))
+
+	b := new(bytes.Buffer)
+	if err := json.NewEncoder(b).Encode(event); err != nil {
+		return err
+	}
+
+	if err := createObject(ctx, d.container, d.logger, eventPath, b); err != nil {
+		if errors.Is(err, errObjectExists) {
+			return driver.ErrAuditEventExists
+		}
+
+		return err
+	}
+
+	return nil
+}
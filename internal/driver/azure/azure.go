@@ -0,0 +1,311 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
+	"github.com/kerraform/kegistry/internal/driver"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+var (
+	errObjectExists = errors.New("object already exists")
+)
+
+const (
+	// sasURLExpiry is the same as the default expiry of the presigned URLs of Amazon S3
+	sasURLExpiry = 15 * time.Minute
+)
+
+type DriverOpts struct {
+	AccountName string
+	AccountKey  string
+
+	// ConnectionString is used instead of the account name and key if set (e.g. the one of Azurite)
+	ConnectionString string
+
+	Container string
+
+	// Endpoint of the blob service. Defaults to https://<account name>.blob.core.windows.net/
+	Endpoint string
+
+	Tracer trace.Tracer
+}
+
+func NewDriver(logger *zap.Logger, opts *DriverOpts) (*driver.Driver, error) {
+	if opts == nil {
+		return nil, fmt.Errorf("invalid azure credentials")
+	}
+
+	c, err := newContainerClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := &audit{
+		container: c,
+		logger:    logger,
+		tracer:    opts.Tracer,
+	}
+
+	module := &module{
+		container: c,
+		logger:    logger,
+		tracer:    opts.Tracer,
+	}
+
+	provider := &provider{
+		container: c,
+		logger:    logger,
+		tracer:    opts.Tracer,
+	}
+
+	token := &token{
+		container: c,
+		logger:    logger,
+		tracer:    opts.Tracer,
+	}
+
+	return &driver.Driver{
+		Audit:    audit,
+		Module:   module,
+		Provider: provider,
+		Token:    token,
+	}, nil
+}
+
+// newContainerClient creates the client of the container authorized by the shared key, which also signs the SAS URLs
+func newContainerClient(opts *DriverOpts) (*container.Client, error) {
+	if opts.ConnectionString != "" {
+		return container.NewClientFromConnectionString(opts.ConnectionString, opts.Container, nil)
+	}
+
+	if opts.AccountName == "" || opts.AccountKey == "" {
+		return nil, fmt.Errorf("invalid azure credentials")
+	}
+
+	cred, err := container.NewSharedKeyCredential(opts.AccountName, opts.AccountKey)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net/", opts.AccountName)
+	}
+
+	return container.NewClientWithSharedKeyCredential(strings.TrimSuffix(endpoint, "/")+"/"+opts.Container, cred, nil)
+}
+
+// sasURL returns the SAS URL of the blob with the permissions
+func sasURL(c *container.Client, key string, permissions sas.BlobPermissions) (string, error) {
+	return c.NewBlobClient(key).GetSASURL(permissions, time.Now().Add(sasURLExpiry), nil)
+}
+
+// uploadSASURL returns the SAS URL to upload the blob with the headers to be sent. Unless overwrite, the SAS only permits
+// to create the blob, which Azure refuses over the existing one, and the upload is conditioned by `If-None-Match: *` as well.
+// The size cannot be limited by the SAS, so the uploaded blob is validated by the registry before it is served.
+func uploadSASURL(c *container.Client, key string, overwrite bool) (string, map[string]string, error) {
+	headers := map[string]string{"x-ms-blob-type": "BlockBlob"}
+	permissions := sas.BlobPermissions{Create: true, Write: true}
+	if !overwrite {
+		headers["If-None-Match"] = "*"
+		permissions.Write = false
+	}
+
+	u, err := sasURL(c, key, permissions)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return u, headers, nil
+}
+
+// createDir creates the placeholder blob of the directory, as there are no directories on Azure Blob Storage
+func createDir(ctx context.Context, c *container.Client, logger *zap.Logger, prefix string) error {
+	return putObject(ctx, c, logger, prefix+"/", strings.NewReader(""))
+}
+
+// deleteObject deletes the blob, which succeeds even if the blob does not exist
+func deleteObject(ctx context.Context, c *container.Client, key string) error {
+	if _, err := c.NewBlobClient(key).Delete(ctx, nil); err != nil && !bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+// deletePrefix deletes all the blobs under the prefix, or returns notExistErr if there is no blob
+func deletePrefix(ctx context.Context, c *container.Client, logger *zap.Logger, prefix string, notExistErr error) error {
+	keys, err := listObjects(ctx, c, prefix)
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		return notExistErr
+	}
+
+	for _, key := range keys {
+		if err := deleteObject(ctx, c, key); err != nil {
+			return err
+		}
+	}
+
+	logger.Debug("deleted blobs from azure blob storage", zap.String("prefix", prefix), zap.Int("count", len(keys)))
+	return nil
+}
+
+// getObject returns the body of the blob, or notExistErr if the blob does not exist
+func getObject(ctx context.Context, c *container.Client, key string, notExistErr error) (io.ReadCloser, error) {
+	resp, err := c.NewBlobClient(key).DownloadStream(ctx, nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return nil, notExistErr
+		}
+
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// isObjectCreated returns notExistErr if the blob does not exist
+func isObjectCreated(ctx context.Context, c *container.Client, key string, notExistErr error) error {
+	if _, err := c.NewBlobClient(key).GetProperties(ctx, nil); err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return notExistErr
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// isPrefixCreated returns notExistErr if there is no blob under the prefix
+func isPrefixCreated(ctx context.Context, c *container.Client, prefix string, notExistErr error) error {
+	var max int32 = 1
+	p := c.NewListBlobsFlatPager(&container.ListBlobsFlatOptions{
+		Prefix:     &prefix,
+		MaxResults: &max,
+	})
+	resp, err := p.NextPage(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(resp.Segment.BlobItems) == 0 {
+		return notExistErr
+	}
+
+	return nil
+}
+
+// listDirs returns the names of the directories right under the prefix
+func listDirs(ctx context.Context, c *container.Client, prefix string) ([]string, error) {
+	dirs := []string{}
+	p := c.NewListBlobsHierarchyPager("/", &container.ListBlobsHierarchyOptions{
+		Prefix: &prefix,
+	})
+	for p.More() {
+		resp, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, bp := range resp.Segment.BlobPrefixes {
+			dirs = append(dirs, strings.TrimSuffix(strings.TrimPrefix(*bp.Name, prefix), "/"))
+		}
+	}
+
+	return dirs, nil
+}
+
+// listObjects returns the names of all the blobs under the prefix
+func listObjects(ctx context.Context, c *container.Client, prefix string) ([]string, error) {
+	keys := []string{}
+	p := c.NewListBlobsFlatPager(&container.ListBlobsFlatOptions{
+		Prefix: &prefix,
+	})
+	for p.More() {
+		resp, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range resp.Segment.BlobItems {
+			keys = append(keys, *item.Name)
+		}
+	}
+
+	return keys, nil
+}
+
+func putObject(ctx context.Context, c *container.Client, logger *zap.Logger, key string, body io.Reader) error {
+	if _, err := c.NewBlockBlobClient(key).UploadStream(ctx, body, nil); err != nil {
+		return err
+	}
+
+	logger.Debug("saved blob to azure blob storage", zap.String("key", key))
+	return nil
+}
+
+// putObjectIfNotExist saves the blob only if the blob does not exist, which succeeds even if the blob exists
+func putObjectIfNotExist(ctx context.Context, c *container.Client, logger *zap.Logger, key string, body io.Reader) error {
+	if err := createObject(ctx, c, logger, key, body); err != nil && !errors.Is(err, errObjectExists) {
+		return err
+	}
+
+	return nil
+}
+
+// createObject saves the blob only if the blob does not exist, otherwise returns errObjectExists
+func createObject(ctx context.Context, c *container.Client, logger *zap.Logger, key string, body io.Reader) error {
+	if _, err := c.NewBlockBlobClient(key).UploadStream(ctx, body, &blockblob.UploadStreamOptions{
+		AccessConditions: &blob.AccessConditions{
+			ModifiedAccessConditions: &blob.ModifiedAccessConditions{
+				IfNoneMatch: to.Ptr(azcore.ETagAny),
+			},
+		},
+	}); err != nil {
+		if bloberror.HasCode(err, bloberror.BlobAlreadyExists, bloberror.ConditionNotMet) {
+			logger.Debug("blob already exists in azure blob storage", zap.String("key", key))
+			return errObjectExists
+		}
+
+		return err
+	}
+
+	logger.Debug("saved blob to azure blob storage", zap.String("key", key))
+	return nil
+}
+
+// saveArtifact saves the artifact, which is only created unless overwrite, otherwise returns driver.ErrArtifactExists
+func saveArtifact(ctx context.Context, c *container.Client, logger *zap.Logger, key string, body io.Reader, overwrite bool) error {
+	if overwrite {
+		return putObject(ctx, c, logger, key, body)
+	}
+
+	if err := createObject(ctx, c, logger, key, body); err != nil {
+		if errors.Is(err, errObjectExists) {
+			return driver.ErrArtifactExists
+		}
+
+		return err
+	}
+
+	return nil
+}
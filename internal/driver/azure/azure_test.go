@@ -0,0 +1,93 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/kerraform/kegistry/internal/driver/drivertest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	// azuriteAccountName and azuriteAccountKey are the well-known account of Azurite
+	// https://learn.microsoft.com/en-us/azure/storage/common/storage-use-azurite#well-known-storage-account-and-key
+	azuriteAccountName = "devstoreaccount1"
+	azuriteAccountKey  = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+)
+
+// TestDriver runs against Azurite at BACKEND_AZURE_ENDPOINT (e.g. http://127.0.0.1:10000/devstoreaccount1)
+// with the well-known account, and is skipped if unset. The container is created if not exist.
+func TestDriver(t *testing.T) {
+	endpoint := os.Getenv("BACKEND_AZURE_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("BACKEND_AZURE_ENDPOINT is not set")
+	}
+
+	d, err := NewDriver(zap.NewNop(), &DriverOpts{
+		ConnectionString: fmt.Sprintf("DefaultEndpointsProtocol=http;AccountName=%s;AccountKey=%s;BlobEndpoint=%s;", azuriteAccountName, azuriteAccountKey, endpoint),
+		Container:        getenv("BACKEND_AZURE_CONTAINER", "kegistry"),
+		Tracer:           trace.NewNoopTracerProvider().Tracer(""),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := d.Provider.(*provider).container.Create(context.Background(), nil); err != nil && !bloberror.HasCode(err, bloberror.ContainerAlreadyExists) {
+		t.Fatal(err)
+	}
+
+	drivertest.TestDriver(t, d)
+}
+
+func TestUploadSASURL(t *testing.T) {
+	c, err := newContainerClient(&DriverOpts{
+		AccountName: azuriteAccountName,
+		AccountKey:  azuriteAccountKey,
+		Container:   "kegistry",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[bool]struct {
+		permissions string
+		ifNoneMatch string
+	}{
+		false: {permissions: "c", ifNoneMatch: "*"},
+		true:  {permissions: "cw"},
+	}
+
+	for overwrite, tc := range cases {
+		u, headers, err := uploadSASURL(c, "foo", overwrite)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		signed, err := url.Parse(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Without the write permission, the SAS cannot overwrite even if the header is omitted
+		if sp := signed.Query().Get("sp"); sp != tc.permissions {
+			t.Fatalf("overwrite %t: expected the permissions %q, got %q", overwrite, tc.permissions, sp)
+		}
+
+		if headers["If-None-Match"] != tc.ifNoneMatch || headers["x-ms-blob-type"] != "BlockBlob" {
+			t.Fatalf("overwrite %t: unexpected headers %v", overwrite, headers)
+		}
+	}
+}
+
+func getenv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+
+	return fallback
+}
@@ -3,88 +3,372 @@ package driver
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"regexp"
+	"strings"
+	"time"
 
 	"github.com/kerraform/kegistry/internal/model/provider"
 )
 
 var (
+	// Artifact
+	ErrArtifactExists = errors.New("artifact already exists")
+
+	// Path
+	ErrInvalidPathSegment = errors.New("invalid path segment")
+
+	// Audit
+	ErrAuditEventExists = errors.New("audit event already exists")
+
 	// Module
-	ErrModuleNotExist = errors.New("module not exist")
+	ErrModuleNotExist        = errors.New("module not exist")
+	ErrModulePackageNotExist = errors.New("module package not exist")
+	ErrModuleVersionNotExist = errors.New("module version not exist")
 
 	// Provider
-	ErrProviderBinaryNotExist        = errors.New("provider binary not exist")
-	ErrProviderGPGKeyNotExist        = errors.New("provider gpg key not exist")
-	ErrProviderSHA256SUMSNotExist    = errors.New("sha256 sum key not exist")
-	ErrProviderSHA256SUMSSigNotExist = errors.New("sha256 sum sig key not exist")
-	ErrProviderNotExist              = errors.New("provider not exist")
-	ErrProviderVersionNotExist       = errors.New("provider version not exist")
+	ErrProviderBinaryNotExist           = errors.New("provider binary not exist")
+	ErrProviderGPGKeyNotExist           = errors.New("provider gpg key not exist")
+	ErrProviderSHA256SUMSNotExist       = errors.New("sha256 sum key not exist")
+	ErrProviderSHA256SUMSSigNotExist    = errors.New("sha256 sum sig key not exist")
+	ErrProviderNotExist                 = errors.New("provider not exist")
+	ErrProviderPlatformNotExist         = errors.New("provider platform not exist")
+	ErrProviderPlatformMetadataNotExist = errors.New("provider platform metadata not exist")
+	ErrProviderVersionNotExist          = errors.New("provider version not exist")
+	ErrSigningKeyNotExist               = errors.New("signing key not exist")
+
+	// Token
+	ErrTokenNotExist = errors.New("token not exist")
 
 	PlatformBinaryRegex = regexp.MustCompile(`terraform-provider-(\w+)_([0-9]+.[0-9]+.[0-9]+)_(\w+)_(\w+).zip`)
 )
 
 const (
-	KeyDirname              = "keys"
-	ModuleRootPath          = "modules"
-	ProviderRootPath        = "providers"
-	VersionMetadataFilename = "metadata.json"
+	AuditRootPath            = "audit"
+	KeyDirname               = "keys"
+	KeyMetadataExt           = ".json"
+	ModuleRootPath           = "modules"
+	PlatformMetadataFilename = "platform.json"
+	ProviderRootPath         = "providers"
+	SigningKeyFilename       = "private-key.asc"
+	SigningRootPath          = "signing"
+	TokenLastUsedExt         = ".last-used"
+	TokenRootPath            = "tokens"
+	VersionMetadataFilename  = "metadata.json"
+)
+
+const (
+	// MetadataConcurrency is the number of the version metadata read at once by the callers iterating the versions
+	MetadataConcurrency = 16
 )
 
 type DriverType string
 
 const (
-	DriverTypeLocal DriverType = "local"
-	DriverTypeS3    DriverType = "s3"
+	DriverTypeAzure  DriverType = "azure"
+	DriverTypeGCS    DriverType = "gcs"
+	DriverTypeLocal  DriverType = "local"
+	DriverTypeMemory DriverType = "memory"
+	DriverTypeS3     DriverType = "s3"
 )
 
+// Module stores the modules.
+// The package saved by SavePackage or presigned to upload by CreateVersion is only created, unless overwrite is set.
+// SavePackage returns ErrArtifactExists if the package exists, and GetModule returns ErrModulePackageNotExist if it does not.
+// CreateModule and CreateVersion return ErrInvalidPathSegment if any of the names is not the single element of the path.
 type Module interface {
 	CreateModule(ctx context.Context, namespace, provider, name string) error
-	CreateVersion(ctx context.Context, namespace, provider, name, version string) (*CreateModuleVersionResult, error)
+	CreateVersion(ctx context.Context, namespace, provider, name, version string, overwrite bool) (*CreateModuleVersionResult, error)
+	DeleteModule(ctx context.Context, namespace, provider, name string) error
+	DeleteVersion(ctx context.Context, namespace, provider, name, version string) error
 	GetDownloadURL(ctx context.Context, namespace, provider, name, version string) (string, error)
 	GetModule(ctx context.Context, namespace, provider, name, version string) (*os.File, error)
+	GetVersionMetadata(ctx context.Context, namespace, provider, name, version string) (*ModuleVersionMetadata, error)
+	IsPackageUploaded(ctx context.Context, namespace, provider, name, version string) error
 	ListAvailableVersions(ctx context.Context, namespace, provider, name string) ([]string, error)
-	SavePackage(ctx context.Context, namespace, provider, name, version string, body io.Reader) error
+	SavePackage(ctx context.Context, namespace, provider, name, version string, body io.Reader, overwrite bool) error
+	SaveVersionMetadata(ctx context.Context, namespace, provider, name, version string, metadata *ModuleVersionMetadata) error
 }
 
+// Provider stores the providers.
+// The artifacts saved by SavePlatformBinary, SaveSHASUMs and SaveSHASUMsSig or presigned to upload by CreateProviderPlatform
+// and CreateProviderVersion are only created, unless overwrite is set. The Save methods return ErrArtifactExists if the artifact exists.
+// The Create methods and SaveGPGKey return ErrInvalidPathSegment if any of the names is not the single element of the path.
 type Provider interface {
 	CreateProvider(ctx context.Context, namespace, registryName string) error
-	CreateProviderPlatform(ctx context.Context, namespace, registryName, version, os, arch string) (*CreateProviderPlatformResult, error)
-	CreateProviderVersion(ctx context.Context, namespace, registryName, version string) (*CreateProviderVersionResult, error)
+	CreateProviderPlatform(ctx context.Context, namespace, registryName, version, os, arch string, overwrite bool) (*CreateProviderPlatformResult, error)
+	CreateProviderVersion(ctx context.Context, namespace, registryName, version string, overwrite bool) (*CreateProviderVersionResult, error)
+	DeleteGPGKey(ctx context.Context, namespace, keyID string) error
+	DeleteProviderPlatform(ctx context.Context, namespace, registryName, version, os, arch string) error
+	DeleteProviderVersion(ctx context.Context, namespace, registryName, version string) error
 	FindPackage(ctx context.Context, namespace, registryName, version, os, arch string) (*provider.Package, error)
 	GetPlatformBinary(ctx context.Context, namespace, registryName, version, os, arch string) (io.ReadCloser, error)
+	GetPlatformMetadata(ctx context.Context, namespace, registryName, version, os, arch string) (*ProviderPlatformMetadata, error)
 	GetSHASums(ctx context.Context, namespace, registryName, version string) (io.ReadCloser, error)
+	GetGPGKey(ctx context.Context, namespace, keyID string) (*GPGKey, error)
 	GetSHASumsSig(ctx context.Context, namespace, registryName, version string) (io.ReadCloser, error)
+	GetSigningKey(ctx context.Context, namespace string) ([]byte, error)
+	GetVersionMetadata(ctx context.Context, namespace, registryName, version string) (*ProviderVersionMetadata, error)
 	ListAvailableVersions(ctx context.Context, namespace, registryName string) ([]provider.AvailableVersion, error)
+	ListGPGKeys(ctx context.Context, namespace string) ([]*GPGKey, error)
+	ListProviders(ctx context.Context, namespace string) ([]string, error)
 	IsGPGKeyCreated(ctx context.Context, namespace, registryName string) error
 	IsProviderCreated(ctx context.Context, namespace, registryName string) error
 	IsProviderVersionCreated(ctx context.Context, namespace, registryName, version string) error
-	SaveGPGKey(ctx context.Context, namespace, keyID string, key []byte) error
-	SavePlatformBinary(ctx context.Context, namespace, registryName, version, os, arch string, body io.Reader) error
-	SaveSHASUMs(ctx context.Context, namespace, registryName, version string, body io.Reader) error
-	SaveSHASUMsSig(ctx context.Context, namespace, registryName, version string, body io.Reader) error
-	SaveVersionMetadata(ctx context.Context, namespace, registryName, version, keyID string) error
+	SaveGPGKey(ctx context.Context, namespace string, key *GPGKey) error
+	SavePlatformBinary(ctx context.Context, namespace, registryName, version, os, arch string, body io.Reader, overwrite bool) error
+	SavePlatformMetadata(ctx context.Context, namespace, registryName, version, os, arch string, metadata *ProviderPlatformMetadata) error
+	SaveSHASUMs(ctx context.Context, namespace, registryName, version string, body io.Reader, overwrite bool) error
+	SaveSHASUMsSig(ctx context.Context, namespace, registryName, version string, body io.Reader, overwrite bool) error
+	SaveSigningKey(ctx context.Context, namespace string, key []byte) error
+	SaveVersionMetadata(ctx context.Context, namespace, registryName, version string, metadata *ProviderVersionMetadata) error
+}
+
+type Audit interface {
+	ListAuditEvents(ctx context.Context, namespace string) ([]*AuditEvent, error)
+
+	// SaveAuditEvent saves the event only if no event of the sequence exists, otherwise returns ErrAuditEventExists
+	SaveAuditEvent(ctx context.Context, event *AuditEvent) error
+}
+
+// Token stores the API tokens.
+// The last used time is stored apart from the token, so that recording it never writes back the token deleted meanwhile.
+type Token interface {
+	DeleteToken(ctx context.Context, id string) error
+	GetToken(ctx context.Context, id string) (*APIToken, error)
+	ListTokens(ctx context.Context) ([]*APIToken, error)
+	SaveToken(ctx context.Context, token *APIToken) error
+
+	// SaveTokenLastUsed saves the last used time of the token, or returns ErrTokenNotExist if the token does not exist
+	SaveTokenLastUsed(ctx context.Context, id string, lastUsedAt time.Time) error
 }
 
 type Driver struct {
+	Audit    Audit
 	Module   Module
 	Provider Provider
+	Token    Token
 }
 
 type CreateModuleVersionResult struct {
 	Upload string
+
+	// UploadHeaders are the headers to be sent with the presigned upload, which make the backend refuse the upload
+	// over the existing object unless presigned to overwrite
+	UploadHeaders map[string]string
+
+	// Presigned is true if the upload goes directly to the backend, bypassing the registry
+	Presigned bool
+}
+
+type ModuleVersionMetadata struct {
+	// Deprecated version is still listed as available, but with the warning
+	DeprecatedAt      *time.Time `json:"deprecated-at,omitempty"`
+	DeprecationReason string     `json:"deprecation-reason,omitempty"`
+
+	// Yanked version is not listed as available, but still can be downloaded
+	YankedAt   *time.Time `json:"yanked-at,omitempty"`
+	YankReason string     `json:"yank-reason,omitempty"`
+
+	// Presigned is the package presigned to upload, bypassing the registry.
+	// It is validated on the first download after the upload, and cleared once valid.
+	Presigned *PresignedPackage `json:"presigned,omitempty"`
 }
 
+// PresignedPackage is the module package presigned to upload, with the sha256 sum of the package replaced by the upload if any,
+// which tells whether the package is uploaded yet.
+type PresignedPackage struct {
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+type VerificationState string
+
+const (
+	VerificationStateFailed   VerificationState = "failed"
+	VerificationStatePending  VerificationState = "pending"
+	VerificationStateVerified VerificationState = "verified"
+)
+
+type VersionState string
+
+const (
+	VersionStateDraft     VersionState = "draft"
+	VersionStatePublished VersionState = "published"
+)
+
 type ProviderVersionMetadata struct {
 	KeyID string `json:"key-id"`
+
+	// State is draft until the version is explicitly published.
+	// It is empty for the versions created before the publishing is introduced, which are treated as published.
+	State       VersionState `json:"state,omitempty"`
+	PublishedAt *time.Time   `json:"published-at,omitempty"`
+
+	// Platforms are the platforms declared for the version, all of which must be uploaded to publish it
+	Platforms []provider.AvailableVersionPlatform `json:"platforms,omitempty"`
+
+	// Presigned are the platforms whose binaries are presigned to upload, bypassing the registry.
+	// Their digests are saved again by the verification once the binaries are uploaded.
+	Presigned []PresignedPlatform `json:"presigned,omitempty"`
+
+	// Protocols are the plugin protocol versions supported by the version (e.g. 5.0)
+	Protocols []string `json:"protocols,omitempty"`
+
+	// Upstream is the hostname of the upstream registry which the version is fetched from by the proxy
+	Upstream string `json:"upstream,omitempty"`
+
+	// RegistrySigned is true if SHA256SUMS and its signature are generated by the registry
+	RegistrySigned bool `json:"registry-signed,omitempty"`
+
+	// Verification is the result of the verification of the SHA256SUMS signature and the binary digests.
+	// It is empty for the versions created before the verification is introduced.
+	Verification      VerificationState `json:"verification,omitempty"`
+	VerificationError string            `json:"verification-error,omitempty"`
+
+	// Yanked version is not listed as available, but still can be downloaded for the existing lock files
+	YankedAt   *time.Time `json:"yanked-at,omitempty"`
+	YankReason string     `json:"yank-reason,omitempty"`
+}
+
+// Draft reports whether the version is not published yet
+func (m *ProviderVersionMetadata) Draft() bool {
+	return m.State == VersionStateDraft
+}
+
+// Visible reports whether the version is listed as available
+func (m *ProviderVersionMetadata) Visible() bool {
+	if m.YankedAt != nil || m.Draft() {
+		return false
+	}
+
+	return m.Verification == "" || m.Verification == VerificationStateVerified
+}
+
+// PresignedPlatform is the platform presigned to upload, with the sha256 sum of the binary replaced by the upload if any,
+// which tells whether the binary is uploaded yet.
+type PresignedPlatform struct {
+	OS     string `json:"os"`
+	Arch   string `json:"arch"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// ProviderPlatformMetadata is the digests of the platform binary computed at the upload, so that the binary is not read to find the package.
+// It is missing for the binaries uploaded before the metadata is introduced, or uploaded by the presigned URLs.
+type ProviderPlatformMetadata struct {
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+
+	// HashV1 is the "h1:" hash of the binary for the network mirror
+	HashV1 string `json:"h1,omitempty"`
+}
+
+// GPGKey is the GPG public key of the namespace.
+// The armored key and the metadata are stored separately, so the keys saved before the metadata is introduced have no metadata.
+type GPGKey struct {
+	KeyID          string     `json:"key-id"`
+	ASCIIArmor     string     `json:"-"`
+	Source         string     `json:"source,omitempty"`
+	SourceURL      string     `json:"source-url,omitempty"`
+	TrustSignature string     `json:"trust-signature,omitempty"`
+	CreatedAt      time.Time  `json:"created-at"`
+	RevokedAt      *time.Time `json:"revoked-at,omitempty"`
+}
+
+// Revoked reports whether the key is revoked, which is not allowed for the new versions
+func (k *GPGKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// PublicKey returns the key as the signing key of the provider package
+func (k *GPGKey) PublicKey() provider.GPGPublicKey {
+	return provider.GPGPublicKey{
+		KeyID:          k.KeyID,
+		ASCIIArmor:     k.ASCIIArmor,
+		TrustSignature: k.TrustSignature,
+		Source:         k.Source,
+		SourceURL:      k.SourceURL,
+	}
+}
+
+// APIToken is the stored record of the registry API token.
+// The token itself is never stored, only its hash.
+type APIToken struct {
+	ID          string     `json:"id"`
+	Description string     `json:"description"`
+	Hash        string     `json:"hash"`
+	Namespace   string     `json:"namespace"`
+	Subject     string     `json:"subject"`
+	CreatedAt   time.Time  `json:"created-at"`
+	ExpiredAt   time.Time  `json:"expired-at"`
+	LastUsedAt  *time.Time `json:"last-used-at,omitempty"`
+}
+
+// AuditEvent is the stored record of the mutating operation on the registry.
+// Events are chained per namespace by the hash of the previous event, and the sequence is the position in the chain starting from 1.
+type AuditEvent struct {
+	ID         string            `json:"id"`
+	Sequence   uint64            `json:"sequence"`
+	Time       time.Time         `json:"time"`
+	Actor      string            `json:"actor"`
+	AuthMethod string            `json:"auth-method,omitempty"`
+	Action     string            `json:"action"`
+	Resource   *AuditResource    `json:"resource"`
+	Digests    map[string]string `json:"digests,omitempty"`
+	PrevHash   string            `json:"prev-hash"`
+	Hash       string            `json:"hash"`
+}
+
+type AuditResource struct {
+	Type      string `json:"type"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name,omitempty"`
+	Provider  string `json:"provider,omitempty"`
+	Version   string `json:"version,omitempty"`
+	OS        string `json:"os,omitempty"`
+	Arch      string `json:"arch,omitempty"`
+	KeyID     string `json:"key-id,omitempty"`
+	Subject   string `json:"subject,omitempty"`
+}
+
+// ValidatePathSegments returns ErrInvalidPathSegment if any of the segments is not the single element of the path,
+// so that the names given by the request never resolve to the path out of its directory
+func ValidatePathSegments(segments ...string) error {
+	for _, segment := range segments {
+		if segment == "" || segment == "." || segment == ".." || strings.ContainsAny(segment, "/\\\x00") {
+			return fmt.Errorf("%w: %q", ErrInvalidPathSegment, segment)
+		}
+	}
+
+	return nil
+}
+
+// AuditEventFilename returns the filename of the event, which sorts in the order of the sequence.
+// As the event is saved only if the file does not exist, only one of the events racing for the sequence is saved.
+func AuditEventFilename(event *AuditEvent) string {
+	return fmt.Sprintf("%020d.json", event.Sequence)
 }
 
 type CreateProviderVersionResult struct {
 	SHASumsUpload    string
 	SHASumsSigUpload string
+
+	// UploadHeaders are the headers to be sent with both of the presigned uploads, which make the backend refuse the upload
+	// over the existing object unless presigned to overwrite
+	UploadHeaders map[string]string
+
+	// Presigned is true if the uploads go directly to the backend, bypassing the registry
+	Presigned bool
 }
 
 type CreateProviderPlatformResult struct {
 	ProviderBinaryUploads string
+
+	// UploadHeaders are the headers to be sent with the presigned upload, which make the backend refuse the upload
+	// over the existing object unless presigned to overwrite
+	UploadHeaders map[string]string
+
+	// Presigned is true if the upload goes directly to the backend, bypassing the registry
+	Presigned bool
 }
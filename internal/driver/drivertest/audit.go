@@ -0,0 +1,68 @@
+package drivertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kerraform/kegistry/internal/driver"
+)
+
+func testAudit(ctx context.Context, s *suite) error {
+	a := s.driver.Audit
+	events, err := a.ListAuditEvents(ctx, s.namespace)
+	if err != nil {
+		return fmt.Errorf("ListAuditEvents: %w", err)
+	}
+
+	if len(events) != 0 {
+		return fmt.Errorf("ListAuditEvents: expected no event, got %d events", len(events))
+	}
+
+	// The events are saved in the reverse order of the sequence, but listed in the order of the sequence
+	now := time.Now().UTC()
+	want := []*driver.AuditEvent{
+		{ID: "first", Sequence: 1, Time: now, Hash: "hash-1"},
+		{ID: "second", Sequence: 2, Time: now.Add(time.Second), PrevHash: "hash-1", Hash: "hash-2"},
+	}
+	for i := len(want) - 1; i >= 0; i-- {
+		e := want[i]
+		e.Actor = "conformance"
+		e.Action = "create"
+		e.Resource = &driver.AuditResource{
+			Type:      "provider",
+			Namespace: s.namespace,
+			Name:      providerName,
+		}
+
+		if err := a.SaveAuditEvent(ctx, e); err != nil {
+			return fmt.Errorf("SaveAuditEvent: %w", err)
+		}
+	}
+
+	// The event of the sequence taken by another replica must not overwrite the existing one
+	conflict := *want[1]
+	conflict.ID = "conflict"
+	if err := a.SaveAuditEvent(ctx, &conflict); !errors.Is(err, driver.ErrAuditEventExists) {
+		return fmt.Errorf("SaveAuditEvent: expected %v for the existing sequence, got %v", driver.ErrAuditEventExists, err)
+	}
+
+	events, err = a.ListAuditEvents(ctx, s.namespace)
+	if err != nil {
+		return fmt.Errorf("ListAuditEvents: %w", err)
+	}
+
+	if len(events) != len(want) {
+		return fmt.Errorf("ListAuditEvents: expected %d events, got %d events", len(want), len(events))
+	}
+
+	for i, e := range events {
+		w := want[i]
+		if e.ID != w.ID || e.Sequence != w.Sequence || !e.Time.Equal(w.Time) || e.PrevHash != w.PrevHash || e.Hash != w.Hash || e.Resource == nil || e.Resource.Namespace != s.namespace {
+			return fmt.Errorf("ListAuditEvents: expected %+v at %d, got %+v", w, i, e)
+		}
+	}
+
+	return nil
+}
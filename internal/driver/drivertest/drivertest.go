@@ -0,0 +1,143 @@
+package drivertest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/kerraform/kegistry/internal/driver"
+)
+
+// scenario is the set of the operations which every driver must behave the same
+type scenario struct {
+	name string
+	run  func(ctx context.Context, s *suite) error
+}
+
+var scenarios = []scenario{
+	{name: "provider/create", run: testProviderCreate},
+	{name: "provider/upload", run: testProviderUpload},
+	{name: "provider/find-package", run: testProviderFindPackage},
+	{name: "provider/not-found", run: testProviderNotFound},
+	{name: "provider/overwrite", run: testProviderOverwrite},
+	{name: "provider/delete", run: testProviderDelete},
+	{name: "module/create", run: testModuleCreate},
+	{name: "module/upload", run: testModuleUpload},
+	{name: "module/not-found", run: testModuleNotFound},
+	{name: "module/overwrite", run: testModuleOverwrite},
+	{name: "module/delete", run: testModuleDelete},
+	{name: "path/invalid-segment", run: testPathInvalidSegment},
+	{name: "token", run: testToken},
+	{name: "audit", run: testAudit},
+}
+
+type suite struct {
+	driver *driver.Driver
+
+	// namespace is unique to the scenario and the run, so that the suite can run against the storage which is not empty
+	namespace string
+}
+
+// TestDriver runs the conformance scenarios against the driver, each as the subtest named after the scenario.
+// Every scenario works in its own namespace unique to the run, which is left in the storage except the deleted resources.
+//
+// It is meant to be called from the tests of each driver, e.g.
+//
+//	func TestDriver(t *testing.T) {
+//		drivertest.TestDriver(t, local.NewDriver(cfg))
+//	}
+func TestDriver(t *testing.T, d *driver.Driver) {
+	t.Helper()
+	id, err := randomID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for _, sc := range scenarios {
+		sc := sc
+		t.Run(sc.name, func(t *testing.T) {
+			s := &suite{
+				driver:    d,
+				namespace: fmt.Sprintf("conformance-%s-%s", id, strings.ReplaceAll(sc.name, "/", "-")),
+			}
+
+			if err := sc.run(ctx, s); err != nil {
+				t.Fatalf("namespace %s: %v", s.namespace, err)
+			}
+		})
+	}
+}
+
+func randomID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// expectErr returns the error unless err is target
+func expectErr(op string, err, target error) error {
+	if !errors.Is(err, target) {
+		return fmt.Errorf("%s: expected %q, got %v", op, target, err)
+	}
+
+	return nil
+}
+
+// expectBody returns the error unless the body is read without any error and is the same as want
+func expectBody(op string, rc io.ReadCloser, err error, want string) error {
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if string(b) != want {
+		return fmt.Errorf("%s: expected body %q, got %q", op, want, string(b))
+	}
+
+	return nil
+}
+
+// expectStrings returns the error unless got has the same elements as want, in any order
+func expectStrings(op string, got, want []string) error {
+	counts := map[string]int{}
+	for _, v := range got {
+		counts[v]++
+	}
+
+	for _, v := range want {
+		counts[v]--
+	}
+
+	for _, c := range counts {
+		if c != 0 {
+			return fmt.Errorf("%s: expected %v, got %v", op, want, got)
+		}
+	}
+
+	return nil
+}
+
+// contains reports whether the elements have v
+func contains(vs []string, v string) bool {
+	for _, e := range vs {
+		if e == v {
+			return true
+		}
+	}
+
+	return false
+}
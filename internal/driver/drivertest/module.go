@@ -0,0 +1,239 @@
+package drivertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kerraform/kegistry/internal/driver"
+)
+
+const (
+	moduleProvider = "aws"
+	moduleName     = "vpc"
+	moduleVersion  = "1.0.0"
+)
+
+// createModuleVersion creates the module and the versions
+func createModuleVersion(ctx context.Context, s *suite, versions ...string) error {
+	if err := s.driver.Module.CreateModule(ctx, s.namespace, moduleProvider, moduleName); err != nil {
+		return fmt.Errorf("CreateModule: %w", err)
+	}
+
+	for _, version := range versions {
+		if _, err := s.driver.Module.CreateVersion(ctx, s.namespace, moduleProvider, moduleName, version, false); err != nil {
+			return fmt.Errorf("CreateVersion %s: %w", version, err)
+		}
+	}
+
+	return nil
+}
+
+// expectModule returns the error unless the package of the version is the same as want
+func expectModule(ctx context.Context, s *suite, version, want string) error {
+	f, err := s.driver.Module.GetModule(ctx, s.namespace, moduleProvider, moduleName, version)
+	if err != nil {
+		return fmt.Errorf("GetModule: %w", err)
+	}
+
+	return expectBody("GetModule", f, nil, want)
+}
+
+// expectModuleVersions returns the error unless the listed versions are the same as want
+func expectModuleVersions(ctx context.Context, s *suite, want ...string) error {
+	vs, err := s.driver.Module.ListAvailableVersions(ctx, s.namespace, moduleProvider, moduleName)
+	if err != nil {
+		return fmt.Errorf("ListAvailableVersions: %w", err)
+	}
+
+	return expectStrings("ListAvailableVersions", vs, want)
+}
+
+func testModuleCreate(ctx context.Context, s *suite) error {
+	m := s.driver.Module
+	if _, err := m.ListAvailableVersions(ctx, s.namespace, moduleProvider, moduleName); !errors.Is(err, driver.ErrModuleNotExist) {
+		return expectErr("ListAvailableVersions", err, driver.ErrModuleNotExist)
+	}
+
+	if err := m.CreateModule(ctx, s.namespace, moduleProvider, moduleName); err != nil {
+		return fmt.Errorf("CreateModule: %w", err)
+	}
+
+	if err := expectModuleVersions(ctx, s); err != nil {
+		return err
+	}
+
+	result, err := m.CreateVersion(ctx, s.namespace, moduleProvider, moduleName, moduleVersion, false)
+	if err != nil {
+		return fmt.Errorf("CreateVersion: %w", err)
+	}
+
+	if result.Upload == "" {
+		return fmt.Errorf("CreateVersion: expected upload URL, got %+v", result)
+	}
+
+	if err := expectModuleVersions(ctx, s, moduleVersion); err != nil {
+		return err
+	}
+
+	metadata, err := m.GetVersionMetadata(ctx, s.namespace, moduleProvider, moduleName, moduleVersion)
+	if err != nil {
+		return fmt.Errorf("GetVersionMetadata: %w", err)
+	}
+
+	if metadata.DeprecatedAt != nil || metadata.YankedAt != nil {
+		return fmt.Errorf("GetVersionMetadata: expected empty metadata, got %+v", metadata)
+	}
+
+	return expectErr("IsPackageUploaded", m.IsPackageUploaded(ctx, s.namespace, moduleProvider, moduleName, moduleVersion), driver.ErrModulePackageNotExist)
+}
+
+func testModuleUpload(ctx context.Context, s *suite) error {
+	m := s.driver.Module
+	if err := createModuleVersion(ctx, s, moduleVersion); err != nil {
+		return err
+	}
+
+	if err := m.SavePackage(ctx, s.namespace, moduleProvider, moduleName, moduleVersion, strings.NewReader("package"), false); err != nil {
+		return fmt.Errorf("SavePackage: %w", err)
+	}
+
+	if err := m.IsPackageUploaded(ctx, s.namespace, moduleProvider, moduleName, moduleVersion); err != nil {
+		return fmt.Errorf("IsPackageUploaded: %w", err)
+	}
+
+	if err := expectModule(ctx, s, moduleVersion, "package"); err != nil {
+		return err
+	}
+
+	url, err := m.GetDownloadURL(ctx, s.namespace, moduleProvider, moduleName, moduleVersion)
+	if err != nil {
+		return fmt.Errorf("GetDownloadURL: %w", err)
+	}
+
+	if url == "" {
+		return fmt.Errorf("GetDownloadURL: expected download URL")
+	}
+
+	now := time.Now().UTC()
+	if err := m.SaveVersionMetadata(ctx, s.namespace, moduleProvider, moduleName, moduleVersion, &driver.ModuleVersionMetadata{
+		DeprecatedAt:      &now,
+		DeprecationReason: "conformance",
+	}); err != nil {
+		return fmt.Errorf("SaveVersionMetadata: %w", err)
+	}
+
+	metadata, err := m.GetVersionMetadata(ctx, s.namespace, moduleProvider, moduleName, moduleVersion)
+	if err != nil {
+		return fmt.Errorf("GetVersionMetadata: %w", err)
+	}
+
+	if metadata.DeprecatedAt == nil || !metadata.DeprecatedAt.Equal(now) || metadata.DeprecationReason != "conformance" {
+		return fmt.Errorf("GetVersionMetadata: unexpected metadata %+v", metadata)
+	}
+
+	// The metadata is not listed as the version
+	return expectModuleVersions(ctx, s, moduleVersion)
+}
+
+func testModuleNotFound(ctx context.Context, s *suite) error {
+	m := s.driver.Module
+
+	if _, err := m.GetModule(ctx, s.namespace, moduleProvider, moduleName, moduleVersion); !errors.Is(err, driver.ErrModulePackageNotExist) {
+		return expectErr("GetModule", err, driver.ErrModulePackageNotExist)
+	}
+
+	if _, err := m.GetVersionMetadata(ctx, s.namespace, moduleProvider, moduleName, moduleVersion); !errors.Is(err, driver.ErrModuleVersionNotExist) {
+		return expectErr("GetVersionMetadata", err, driver.ErrModuleVersionNotExist)
+	}
+
+	if err := expectErr("SaveVersionMetadata", m.SaveVersionMetadata(ctx, s.namespace, moduleProvider, moduleName, moduleVersion, &driver.ModuleVersionMetadata{}), driver.ErrModuleVersionNotExist); err != nil {
+		return err
+	}
+
+	if err := expectErr("DeleteVersion", m.DeleteVersion(ctx, s.namespace, moduleProvider, moduleName, moduleVersion), driver.ErrModuleVersionNotExist); err != nil {
+		return err
+	}
+
+	if err := expectErr("DeleteModule", m.DeleteModule(ctx, s.namespace, moduleProvider, moduleName), driver.ErrModuleNotExist); err != nil {
+		return err
+	}
+
+	// Nothing is uploaded to the version yet
+	if err := createModuleVersion(ctx, s, moduleVersion); err != nil {
+		return err
+	}
+
+	if _, err := m.GetModule(ctx, s.namespace, moduleProvider, moduleName, moduleVersion); !errors.Is(err, driver.ErrModulePackageNotExist) {
+		return expectErr("GetModule", err, driver.ErrModulePackageNotExist)
+	}
+
+	return expectErr("IsPackageUploaded", m.IsPackageUploaded(ctx, s.namespace, moduleProvider, moduleName, moduleVersion), driver.ErrModulePackageNotExist)
+}
+
+func testModuleOverwrite(ctx context.Context, s *suite) error {
+	m := s.driver.Module
+	if err := createModuleVersion(ctx, s, moduleVersion); err != nil {
+		return err
+	}
+
+	if err := m.SavePackage(ctx, s.namespace, moduleProvider, moduleName, moduleVersion, strings.NewReader("package"), false); err != nil {
+		return fmt.Errorf("SavePackage: %w", err)
+	}
+
+	// The package is only created without overwrite
+	if err := expectErr("SavePackage", m.SavePackage(ctx, s.namespace, moduleProvider, moduleName, moduleVersion, strings.NewReader("package-2"), false), driver.ErrArtifactExists); err != nil {
+		return err
+	}
+
+	if err := expectModule(ctx, s, moduleVersion, "package"); err != nil {
+		return err
+	}
+
+	if err := m.SavePackage(ctx, s.namespace, moduleProvider, moduleName, moduleVersion, strings.NewReader("package-2"), true); err != nil {
+		return fmt.Errorf("SavePackage: %w", err)
+	}
+
+	return expectModule(ctx, s, moduleVersion, "package-2")
+}
+
+func testModuleDelete(ctx context.Context, s *suite) error {
+	m := s.driver.Module
+	if err := createModuleVersion(ctx, s, "1.0.0", "1.1.0"); err != nil {
+		return err
+	}
+
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		if err := m.SavePackage(ctx, s.namespace, moduleProvider, moduleName, version, strings.NewReader("package-"+version), false); err != nil {
+			return fmt.Errorf("SavePackage %s: %w", version, err)
+		}
+	}
+
+	if err := m.DeleteVersion(ctx, s.namespace, moduleProvider, moduleName, "1.0.0"); err != nil {
+		return fmt.Errorf("DeleteVersion: %w", err)
+	}
+
+	if _, err := m.GetModule(ctx, s.namespace, moduleProvider, moduleName, "1.0.0"); !errors.Is(err, driver.ErrModulePackageNotExist) {
+		return expectErr("GetModule", err, driver.ErrModulePackageNotExist)
+	}
+
+	if err := expectModuleVersions(ctx, s, "1.1.0"); err != nil {
+		return err
+	}
+
+	if err := expectModule(ctx, s, "1.1.0", "package-1.1.0"); err != nil {
+		return err
+	}
+
+	if err := m.DeleteModule(ctx, s.namespace, moduleProvider, moduleName); err != nil {
+		return fmt.Errorf("DeleteModule: %w", err)
+	}
+
+	if _, err := m.ListAvailableVersions(ctx, s.namespace, moduleProvider, moduleName); !errors.Is(err, driver.ErrModuleNotExist) {
+		return expectErr("ListAvailableVersions", err, driver.ErrModuleNotExist)
+	}
+
+	return nil
+}
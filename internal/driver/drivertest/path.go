@@ -0,0 +1,51 @@
+package drivertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kerraform/kegistry/internal/driver"
+)
+
+// testPathInvalidSegment checks that the names which are not the single element of the path are refused before anything is created
+func testPathInvalidSegment(ctx context.Context, s *suite) error {
+	p, m := s.driver.Provider, s.driver.Module
+	for _, segment := range []string{"", ".", "..", "../other", `..\other`} {
+		if err := expectErr(fmt.Sprintf("CreateProvider %q", segment), p.CreateProvider(ctx, s.namespace, segment), driver.ErrInvalidPathSegment); err != nil {
+			return err
+		}
+
+		if _, err := p.CreateProviderVersion(ctx, s.namespace, providerName, segment, false); !errors.Is(err, driver.ErrInvalidPathSegment) {
+			return expectErr(fmt.Sprintf("CreateProviderVersion %q", segment), err, driver.ErrInvalidPathSegment)
+		}
+
+		if _, err := p.CreateProviderPlatform(ctx, s.namespace, providerName, providerVersion, "linux", segment, false); !errors.Is(err, driver.ErrInvalidPathSegment) {
+			return expectErr(fmt.Sprintf("CreateProviderPlatform %q", segment), err, driver.ErrInvalidPathSegment)
+		}
+
+		if err := expectErr(fmt.Sprintf("SaveGPGKey %q", segment), p.SaveGPGKey(ctx, segment, &driver.GPGKey{KeyID: gpgKeyID}), driver.ErrInvalidPathSegment); err != nil {
+			return err
+		}
+
+		if err := expectErr(fmt.Sprintf("CreateModule %q", segment), m.CreateModule(ctx, s.namespace, moduleProvider, segment), driver.ErrInvalidPathSegment); err != nil {
+			return err
+		}
+
+		if _, err := m.CreateVersion(ctx, s.namespace, moduleProvider, moduleName, segment, false); !errors.Is(err, driver.ErrInvalidPathSegment) {
+			return expectErr(fmt.Sprintf("CreateVersion %q", segment), err, driver.ErrInvalidPathSegment)
+		}
+	}
+
+	// Nothing is created in the namespace
+	providers, err := p.ListProviders(ctx, s.namespace)
+	if err != nil {
+		return fmt.Errorf("ListProviders: %w", err)
+	}
+
+	if len(providers) != 0 {
+		return fmt.Errorf("ListProviders: expected no provider, got %v", providers)
+	}
+
+	return nil
+}
@@ -0,0 +1,553 @@
+package drivertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kerraform/kegistry/internal/driver"
+	model "github.com/kerraform/kegistry/internal/model/provider"
+)
+
+const (
+	providerName    = "foo"
+	providerVersion = "1.0.0"
+	gpgKeyID        = "0123456789ABCDEF"
+)
+
+// createProviderVersion creates the provider, the version and the platforms in the form of <os>-<arch>
+func createProviderVersion(ctx context.Context, s *suite, platforms ...string) error {
+	if err := s.driver.Provider.CreateProvider(ctx, s.namespace, providerName); err != nil {
+		return fmt.Errorf("CreateProvider: %w", err)
+	}
+
+	if _, err := s.driver.Provider.CreateProviderVersion(ctx, s.namespace, providerName, providerVersion, false); err != nil {
+		return fmt.Errorf("CreateProviderVersion: %w", err)
+	}
+
+	for _, platform := range platforms {
+		e := strings.SplitN(platform, "-", 2)
+		if _, err := s.driver.Provider.CreateProviderPlatform(ctx, s.namespace, providerName, providerVersion, e[0], e[1], false); err != nil {
+			return fmt.Errorf("CreateProviderPlatform %s: %w", platform, err)
+		}
+	}
+
+	return nil
+}
+
+// uploadProviderVersion creates the version and saves everything of it, same as the registry does on the upload
+func uploadProviderVersion(ctx context.Context, s *suite, platforms ...string) error {
+	if err := createProviderVersion(ctx, s, platforms...); err != nil {
+		return err
+	}
+
+	if err := s.driver.Provider.SaveGPGKey(ctx, s.namespace, &driver.GPGKey{
+		KeyID:      gpgKeyID,
+		ASCIIArmor: "gpg-key",
+		Source:     "conformance",
+		CreatedAt:  time.Now().UTC(),
+	}); err != nil {
+		return fmt.Errorf("SaveGPGKey: %w", err)
+	}
+
+	if err := s.driver.Provider.SaveVersionMetadata(ctx, s.namespace, providerName, providerVersion, &driver.ProviderVersionMetadata{
+		KeyID:     gpgKeyID,
+		State:     driver.VersionStateDraft,
+		Protocols: []string{"5.0"},
+	}); err != nil {
+		return fmt.Errorf("SaveVersionMetadata: %w", err)
+	}
+
+	if err := s.driver.Provider.SaveSHASUMs(ctx, s.namespace, providerName, providerVersion, strings.NewReader("shasums"), false); err != nil {
+		return fmt.Errorf("SaveSHASUMs: %w", err)
+	}
+
+	if err := s.driver.Provider.SaveSHASUMsSig(ctx, s.namespace, providerName, providerVersion, strings.NewReader("shasums-sig"), false); err != nil {
+		return fmt.Errorf("SaveSHASUMsSig: %w", err)
+	}
+
+	for _, platform := range platforms {
+		e := strings.SplitN(platform, "-", 2)
+		if err := s.driver.Provider.SavePlatformBinary(ctx, s.namespace, providerName, providerVersion, e[0], e[1], strings.NewReader("binary-"+platform), false); err != nil {
+			return fmt.Errorf("SavePlatformBinary %s: %w", platform, err)
+		}
+
+		if err := s.driver.Provider.SavePlatformMetadata(ctx, s.namespace, providerName, providerVersion, e[0], e[1], &driver.ProviderPlatformMetadata{
+			Size:   int64(len("binary-" + platform)),
+			SHA256: "sha256-" + platform,
+		}); err != nil {
+			return fmt.Errorf("SavePlatformMetadata %s: %w", platform, err)
+		}
+	}
+
+	return nil
+}
+
+// expectVersions returns the error unless the listed versions and their platforms in the form of <os>-<arch> are the same as want
+func expectVersions(ctx context.Context, s *suite, want map[string][]string) error {
+	vs, err := s.driver.Provider.ListAvailableVersions(ctx, s.namespace, providerName)
+	if err != nil {
+		return fmt.Errorf("ListAvailableVersions: %w", err)
+	}
+
+	got := map[string][]string{}
+	for _, v := range vs {
+		got[v.Version] = platformNames(v.Platforms)
+	}
+
+	if len(got) != len(want) {
+		return fmt.Errorf("ListAvailableVersions: expected %v, got %v", want, got)
+	}
+
+	for version, platforms := range want {
+		pfs, ok := got[version]
+		if !ok {
+			return fmt.Errorf("ListAvailableVersions: expected %v, got %v", want, got)
+		}
+
+		if err := expectStrings(fmt.Sprintf("ListAvailableVersions %s", version), pfs, platforms); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func platformNames(pfs []model.AvailableVersionPlatform) []string {
+	names := make([]string, len(pfs))
+	for i, pf := range pfs {
+		names[i] = fmt.Sprintf("%s-%s", pf.OS, pf.Arch)
+	}
+
+	return names
+}
+
+func testProviderCreate(ctx context.Context, s *suite) error {
+	p := s.driver.Provider
+	if err := expectErr("IsProviderCreated", p.IsProviderCreated(ctx, s.namespace, providerName), driver.ErrProviderNotExist); err != nil {
+		return err
+	}
+
+	if _, err := p.ListAvailableVersions(ctx, s.namespace, providerName); !errors.Is(err, driver.ErrProviderNotExist) {
+		return expectErr("ListAvailableVersions", err, driver.ErrProviderNotExist)
+	}
+
+	if err := p.CreateProvider(ctx, s.namespace, providerName); err != nil {
+		return fmt.Errorf("CreateProvider: %w", err)
+	}
+
+	if err := p.IsProviderCreated(ctx, s.namespace, providerName); err != nil {
+		return fmt.Errorf("IsProviderCreated: %w", err)
+	}
+
+	providers, err := p.ListProviders(ctx, s.namespace)
+	if err != nil {
+		return fmt.Errorf("ListProviders: %w", err)
+	}
+
+	if err := expectStrings("ListProviders", providers, []string{providerName}); err != nil {
+		return err
+	}
+
+	if err := expectVersions(ctx, s, map[string][]string{}); err != nil {
+		return err
+	}
+
+	if err := expectErr("IsProviderVersionCreated", p.IsProviderVersionCreated(ctx, s.namespace, providerName, providerVersion), driver.ErrProviderVersionNotExist); err != nil {
+		return err
+	}
+
+	version, err := p.CreateProviderVersion(ctx, s.namespace, providerName, providerVersion, false)
+	if err != nil {
+		return fmt.Errorf("CreateProviderVersion: %w", err)
+	}
+
+	if version.SHASumsUpload == "" || version.SHASumsSigUpload == "" {
+		return fmt.Errorf("CreateProviderVersion: expected upload URLs, got %+v", version)
+	}
+
+	if err := p.IsProviderVersionCreated(ctx, s.namespace, providerName, providerVersion); err != nil {
+		return fmt.Errorf("IsProviderVersionCreated: %w", err)
+	}
+
+	if err := expectVersions(ctx, s, map[string][]string{providerVersion: {}}); err != nil {
+		return err
+	}
+
+	for _, platform := range [][]string{{"linux", "amd64"}, {"darwin", "arm64"}} {
+		result, err := p.CreateProviderPlatform(ctx, s.namespace, providerName, providerVersion, platform[0], platform[1], false)
+		if err != nil {
+			return fmt.Errorf("CreateProviderPlatform: %w", err)
+		}
+
+		if result.ProviderBinaryUploads == "" {
+			return fmt.Errorf("CreateProviderPlatform: expected upload URL, got %+v", result)
+		}
+	}
+
+	// The platforms are listed once created, even before the binaries are uploaded
+	return expectVersions(ctx, s, map[string][]string{providerVersion: {"linux-amd64", "darwin-arm64"}})
+}
+
+func testProviderUpload(ctx context.Context, s *suite) error {
+	p := s.driver.Provider
+	if err := uploadProviderVersion(ctx, s, "linux-amd64"); err != nil {
+		return err
+	}
+
+	if err := p.IsGPGKeyCreated(ctx, s.namespace, providerName); err != nil {
+		return fmt.Errorf("IsGPGKeyCreated: %w", err)
+	}
+
+	key, err := p.GetGPGKey(ctx, s.namespace, gpgKeyID)
+	if err != nil {
+		return fmt.Errorf("GetGPGKey: %w", err)
+	}
+
+	if key.KeyID != gpgKeyID || key.ASCIIArmor != "gpg-key" || key.Source != "conformance" {
+		return fmt.Errorf("GetGPGKey: unexpected key %+v", key)
+	}
+
+	keys, err := p.ListGPGKeys(ctx, s.namespace)
+	if err != nil {
+		return fmt.Errorf("ListGPGKeys: %w", err)
+	}
+
+	if len(keys) != 1 || keys[0].KeyID != gpgKeyID || keys[0].ASCIIArmor != "gpg-key" {
+		return fmt.Errorf("ListGPGKeys: expected the key %s, got %+v", gpgKeyID, keys)
+	}
+
+	metadata, err := p.GetVersionMetadata(ctx, s.namespace, providerName, providerVersion)
+	if err != nil {
+		return fmt.Errorf("GetVersionMetadata: %w", err)
+	}
+
+	if metadata.KeyID != gpgKeyID || !metadata.Draft() || len(metadata.Protocols) != 1 || metadata.Protocols[0] != "5.0" {
+		return fmt.Errorf("GetVersionMetadata: unexpected metadata %+v", metadata)
+	}
+
+	rc, err := p.GetSHASums(ctx, s.namespace, providerName, providerVersion)
+	if err := expectBody("GetSHASums", rc, err, "shasums"); err != nil {
+		return err
+	}
+
+	rc, err = p.GetSHASumsSig(ctx, s.namespace, providerName, providerVersion)
+	if err := expectBody("GetSHASumsSig", rc, err, "shasums-sig"); err != nil {
+		return err
+	}
+
+	rc, err = p.GetPlatformBinary(ctx, s.namespace, providerName, providerVersion, "linux", "amd64")
+	if err := expectBody("GetPlatformBinary", rc, err, "binary-linux-amd64"); err != nil {
+		return err
+	}
+
+	platformMetadata, err := p.GetPlatformMetadata(ctx, s.namespace, providerName, providerVersion, "linux", "amd64")
+	if err != nil {
+		return fmt.Errorf("GetPlatformMetadata: %w", err)
+	}
+
+	if platformMetadata.Size != int64(len("binary-linux-amd64")) || platformMetadata.SHA256 != "sha256-linux-amd64" {
+		return fmt.Errorf("GetPlatformMetadata: unexpected metadata %+v", platformMetadata)
+	}
+
+	return expectVersions(ctx, s, map[string][]string{providerVersion: {"linux-amd64"}})
+}
+
+func testProviderFindPackage(ctx context.Context, s *suite) error {
+	p := s.driver.Provider
+	if err := uploadProviderVersion(ctx, s, "linux-amd64"); err != nil {
+		return err
+	}
+
+	pkg, err := p.FindPackage(ctx, s.namespace, providerName, providerVersion, "linux", "amd64")
+	if err != nil {
+		return fmt.Errorf("FindPackage: %w", err)
+	}
+
+	filename := fmt.Sprintf("terraform-provider-%s_%s_linux_amd64.zip", providerName, providerVersion)
+	if pkg.OS != "linux" || pkg.Arch != "amd64" || pkg.Filename != filename || pkg.SHASum != "sha256-linux-amd64" {
+		return fmt.Errorf("FindPackage: unexpected package %+v", pkg)
+	}
+
+	if pkg.DownloadURL == "" || pkg.SHASumsURL == "" || pkg.SHASumsSigURL == "" {
+		return fmt.Errorf("FindPackage: expected download URLs, got %+v", pkg)
+	}
+
+	if pkg.SigningKeys == nil || len(pkg.SigningKeys.GPGPublicKeys) != 1 {
+		return fmt.Errorf("FindPackage: expected the signing key, got %+v", pkg.SigningKeys)
+	}
+
+	if key := pkg.SigningKeys.GPGPublicKeys[0]; key.KeyID != gpgKeyID || key.ASCIIArmor != "gpg-key" {
+		return fmt.Errorf("FindPackage: unexpected signing key %+v", key)
+	}
+
+	_, err = p.FindPackage(ctx, s.namespace, providerName, providerVersion, "windows", "amd64")
+	return expectErr("FindPackage of the platform not created", err, driver.ErrProviderBinaryNotExist)
+}
+
+func testProviderNotFound(ctx context.Context, s *suite) error {
+	p := s.driver.Provider
+
+	// Nothing is created in the namespace yet
+	if err := expectErr("IsGPGKeyCreated", p.IsGPGKeyCreated(ctx, s.namespace, providerName), driver.ErrProviderGPGKeyNotExist); err != nil {
+		return err
+	}
+
+	keys, err := p.ListGPGKeys(ctx, s.namespace)
+	if err != nil {
+		return fmt.Errorf("ListGPGKeys: %w", err)
+	}
+
+	if len(keys) != 0 {
+		return fmt.Errorf("ListGPGKeys: expected no key, got %+v", keys)
+	}
+
+	providers, err := p.ListProviders(ctx, s.namespace)
+	if err != nil {
+		return fmt.Errorf("ListProviders: %w", err)
+	}
+
+	if len(providers) != 0 {
+		return fmt.Errorf("ListProviders: expected no provider, got %v", providers)
+	}
+
+	if _, err := p.GetGPGKey(ctx, s.namespace, gpgKeyID); !errors.Is(err, driver.ErrProviderGPGKeyNotExist) {
+		return expectErr("GetGPGKey", err, driver.ErrProviderGPGKeyNotExist)
+	}
+
+	if err := expectErr("DeleteGPGKey", p.DeleteGPGKey(ctx, s.namespace, gpgKeyID), driver.ErrProviderGPGKeyNotExist); err != nil {
+		return err
+	}
+
+	if _, err := p.GetSigningKey(ctx, s.namespace); !errors.Is(err, driver.ErrSigningKeyNotExist) {
+		return expectErr("GetSigningKey", err, driver.ErrSigningKeyNotExist)
+	}
+
+	if _, err := p.GetVersionMetadata(ctx, s.namespace, providerName, providerVersion); !errors.Is(err, driver.ErrProviderVersionNotExist) {
+		return expectErr("GetVersionMetadata", err, driver.ErrProviderVersionNotExist)
+	}
+
+	// Nothing is saved to the version not created
+	if err := expectErr("SaveVersionMetadata", p.SaveVersionMetadata(ctx, s.namespace, providerName, providerVersion, &driver.ProviderVersionMetadata{}), driver.ErrProviderVersionNotExist); err != nil {
+		return err
+	}
+
+	if err := expectErr("SaveSHASUMs", p.SaveSHASUMs(ctx, s.namespace, providerName, providerVersion, strings.NewReader("shasums"), false), driver.ErrProviderVersionNotExist); err != nil {
+		return err
+	}
+
+	if err := expectErr("SaveSHASUMsSig", p.SaveSHASUMsSig(ctx, s.namespace, providerName, providerVersion, strings.NewReader("shasums-sig"), false), driver.ErrProviderVersionNotExist); err != nil {
+		return err
+	}
+
+	if err := expectErr("SavePlatformBinary", p.SavePlatformBinary(ctx, s.namespace, providerName, providerVersion, "linux", "amd64", strings.NewReader("binary"), false), driver.ErrProviderVersionNotExist); err != nil {
+		return err
+	}
+
+	if err := expectErr("DeleteProviderVersion", p.DeleteProviderVersion(ctx, s.namespace, providerName, providerVersion), driver.ErrProviderVersionNotExist); err != nil {
+		return err
+	}
+
+	// Nothing is uploaded to the version yet
+	if err := createProviderVersion(ctx, s); err != nil {
+		return err
+	}
+
+	if _, err := p.GetSHASums(ctx, s.namespace, providerName, providerVersion); !errors.Is(err, driver.ErrProviderSHA256SUMSNotExist) {
+		return expectErr("GetSHASums", err, driver.ErrProviderSHA256SUMSNotExist)
+	}
+
+	if _, err := p.GetSHASumsSig(ctx, s.namespace, providerName, providerVersion); !errors.Is(err, driver.ErrProviderSHA256SUMSSigNotExist) {
+		return expectErr("GetSHASumsSig", err, driver.ErrProviderSHA256SUMSSigNotExist)
+	}
+
+	if _, err := p.GetPlatformBinary(ctx, s.namespace, providerName, providerVersion, "linux", "amd64"); !errors.Is(err, driver.ErrProviderBinaryNotExist) {
+		return expectErr("GetPlatformBinary", err, driver.ErrProviderBinaryNotExist)
+	}
+
+	if _, err := p.GetPlatformMetadata(ctx, s.namespace, providerName, providerVersion, "linux", "amd64"); !errors.Is(err, driver.ErrProviderPlatformMetadataNotExist) {
+		return expectErr("GetPlatformMetadata", err, driver.ErrProviderPlatformMetadataNotExist)
+	}
+
+	if _, err := p.FindPackage(ctx, s.namespace, providerName, providerVersion, "linux", "amd64"); !errors.Is(err, driver.ErrProviderBinaryNotExist) {
+		return expectErr("FindPackage", err, driver.ErrProviderBinaryNotExist)
+	}
+
+	if err := expectErr("SavePlatformMetadata", p.SavePlatformMetadata(ctx, s.namespace, providerName, providerVersion, "linux", "amd64", &driver.ProviderPlatformMetadata{}), driver.ErrProviderPlatformNotExist); err != nil {
+		return err
+	}
+
+	return expectErr("DeleteProviderPlatform", p.DeleteProviderPlatform(ctx, s.namespace, providerName, providerVersion, "linux", "amd64"), driver.ErrProviderPlatformNotExist)
+}
+
+func testProviderOverwrite(ctx context.Context, s *suite) error {
+	p := s.driver.Provider
+	if err := uploadProviderVersion(ctx, s, "linux-amd64"); err != nil {
+		return err
+	}
+
+	// The artifacts are only created without overwrite, and the metadata of the existing binary is kept
+	if err := expectErr("SavePlatformBinary", p.SavePlatformBinary(ctx, s.namespace, providerName, providerVersion, "linux", "amd64", strings.NewReader("binary-2"), false), driver.ErrArtifactExists); err != nil {
+		return err
+	}
+
+	rc, err := p.GetPlatformBinary(ctx, s.namespace, providerName, providerVersion, "linux", "amd64")
+	if err := expectBody("GetPlatformBinary", rc, err, "binary-linux-amd64"); err != nil {
+		return err
+	}
+
+	if _, err := p.GetPlatformMetadata(ctx, s.namespace, providerName, providerVersion, "linux", "amd64"); err != nil {
+		return fmt.Errorf("GetPlatformMetadata: %w", err)
+	}
+
+	if err := expectErr("SaveSHASUMs", p.SaveSHASUMs(ctx, s.namespace, providerName, providerVersion, strings.NewReader("shasums-2"), false), driver.ErrArtifactExists); err != nil {
+		return err
+	}
+
+	if err := expectErr("SaveSHASUMsSig", p.SaveSHASUMsSig(ctx, s.namespace, providerName, providerVersion, strings.NewReader("shasums-sig-2"), false), driver.ErrArtifactExists); err != nil {
+		return err
+	}
+
+	rc, err = p.GetSHASums(ctx, s.namespace, providerName, providerVersion)
+	if err := expectBody("GetSHASums", rc, err, "shasums"); err != nil {
+		return err
+	}
+
+	rc, err = p.GetSHASumsSig(ctx, s.namespace, providerName, providerVersion)
+	if err := expectBody("GetSHASumsSig", rc, err, "shasums-sig"); err != nil {
+		return err
+	}
+
+	if err := p.SavePlatformBinary(ctx, s.namespace, providerName, providerVersion, "linux", "amd64", strings.NewReader("binary-2"), true); err != nil {
+		return fmt.Errorf("SavePlatformBinary: %w", err)
+	}
+
+	rc, err = p.GetPlatformBinary(ctx, s.namespace, providerName, providerVersion, "linux", "amd64")
+	if err := expectBody("GetPlatformBinary", rc, err, "binary-2"); err != nil {
+		return err
+	}
+
+	// The metadata of the previous binary is stale
+	if _, err := p.GetPlatformMetadata(ctx, s.namespace, providerName, providerVersion, "linux", "amd64"); !errors.Is(err, driver.ErrProviderPlatformMetadataNotExist) {
+		return expectErr("GetPlatformMetadata", err, driver.ErrProviderPlatformMetadataNotExist)
+	}
+
+	if _, err := p.FindPackage(ctx, s.namespace, providerName, providerVersion, "linux", "amd64"); !errors.Is(err, driver.ErrProviderPlatformMetadataNotExist) {
+		return expectErr("FindPackage", err, driver.ErrProviderPlatformMetadataNotExist)
+	}
+
+	if err := p.SaveSHASUMs(ctx, s.namespace, providerName, providerVersion, strings.NewReader("shasums-2"), true); err != nil {
+		return fmt.Errorf("SaveSHASUMs: %w", err)
+	}
+
+	rc, err = p.GetSHASums(ctx, s.namespace, providerName, providerVersion)
+	if err := expectBody("GetSHASums", rc, err, "shasums-2"); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	if err := p.SaveVersionMetadata(ctx, s.namespace, providerName, providerVersion, &driver.ProviderVersionMetadata{
+		KeyID:       gpgKeyID,
+		State:       driver.VersionStatePublished,
+		PublishedAt: &now,
+	}); err != nil {
+		return fmt.Errorf("SaveVersionMetadata: %w", err)
+	}
+
+	metadata, err := p.GetVersionMetadata(ctx, s.namespace, providerName, providerVersion)
+	if err != nil {
+		return fmt.Errorf("GetVersionMetadata: %w", err)
+	}
+
+	if metadata.Draft() || metadata.PublishedAt == nil || !metadata.PublishedAt.Equal(now) {
+		return fmt.Errorf("GetVersionMetadata: unexpected metadata %+v", metadata)
+	}
+
+	if err := p.SaveGPGKey(ctx, s.namespace, &driver.GPGKey{
+		KeyID:      gpgKeyID,
+		ASCIIArmor: "gpg-key",
+		CreatedAt:  now,
+		RevokedAt:  &now,
+	}); err != nil {
+		return fmt.Errorf("SaveGPGKey: %w", err)
+	}
+
+	key, err := p.GetGPGKey(ctx, s.namespace, gpgKeyID)
+	if err != nil {
+		return fmt.Errorf("GetGPGKey: %w", err)
+	}
+
+	if !key.Revoked() {
+		return fmt.Errorf("GetGPGKey: expected the revoked key, got %+v", key)
+	}
+
+	// The signing key saved first is used by all the replicas
+	for _, signingKey := range []string{"signing-key", "signing-key-2"} {
+		if err := p.SaveSigningKey(ctx, s.namespace, []byte(signingKey)); err != nil {
+			return fmt.Errorf("SaveSigningKey: %w", err)
+		}
+	}
+
+	b, err := p.GetSigningKey(ctx, s.namespace)
+	if err != nil {
+		return fmt.Errorf("GetSigningKey: %w", err)
+	}
+
+	if string(b) != "signing-key" {
+		return fmt.Errorf("GetSigningKey: expected the key saved first, got %q", string(b))
+	}
+
+	return nil
+}
+
+func testProviderDelete(ctx context.Context, s *suite) error {
+	p := s.driver.Provider
+	if err := uploadProviderVersion(ctx, s, "linux-amd64", "darwin-arm64"); err != nil {
+		return err
+	}
+
+	if err := p.DeleteProviderPlatform(ctx, s.namespace, providerName, providerVersion, "linux", "amd64"); err != nil {
+		return fmt.Errorf("DeleteProviderPlatform: %w", err)
+	}
+
+	if _, err := p.GetPlatformBinary(ctx, s.namespace, providerName, providerVersion, "linux", "amd64"); !errors.Is(err, driver.ErrProviderBinaryNotExist) {
+		return expectErr("GetPlatformBinary", err, driver.ErrProviderBinaryNotExist)
+	}
+
+	if err := expectVersions(ctx, s, map[string][]string{providerVersion: {"darwin-arm64"}}); err != nil {
+		return err
+	}
+
+	if err := p.DeleteProviderVersion(ctx, s.namespace, providerName, providerVersion); err != nil {
+		return fmt.Errorf("DeleteProviderVersion: %w", err)
+	}
+
+	if err := expectErr("IsProviderVersionCreated", p.IsProviderVersionCreated(ctx, s.namespace, providerName, providerVersion), driver.ErrProviderVersionNotExist); err != nil {
+		return err
+	}
+
+	if _, err := p.GetSHASums(ctx, s.namespace, providerName, providerVersion); !errors.Is(err, driver.ErrProviderSHA256SUMSNotExist) {
+		return expectErr("GetSHASums", err, driver.ErrProviderSHA256SUMSNotExist)
+	}
+
+	// The provider is still there without any version
+	if err := p.IsProviderCreated(ctx, s.namespace, providerName); err != nil {
+		return fmt.Errorf("IsProviderCreated: %w", err)
+	}
+
+	if err := expectVersions(ctx, s, map[string][]string{}); err != nil {
+		return err
+	}
+
+	if err := p.DeleteGPGKey(ctx, s.namespace, gpgKeyID); err != nil {
+		return fmt.Errorf("DeleteGPGKey: %w", err)
+	}
+
+	if _, err := p.GetGPGKey(ctx, s.namespace, gpgKeyID); !errors.Is(err, driver.ErrProviderGPGKeyNotExist) {
+		return expectErr("GetGPGKey", err, driver.ErrProviderGPGKeyNotExist)
+	}
+
+	return expectErr("IsGPGKeyCreated", p.IsGPGKeyCreated(ctx, s.namespace, providerName), driver.ErrProviderGPGKeyNotExist)
+}
@@ -0,0 +1,113 @@
+package drivertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kerraform/kegistry/internal/driver"
+)
+
+// tokenIDs returns the IDs of the tokens
+func tokenIDs(ctx context.Context, s *suite) ([]string, error) {
+	tokens, err := s.driver.Token.ListTokens(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ListTokens: %w", err)
+	}
+
+	ids := make([]string, len(tokens))
+	for i, t := range tokens {
+		ids[i] = t.ID
+	}
+
+	return ids, nil
+}
+
+func testToken(ctx context.Context, s *suite) error {
+	tk := s.driver.Token
+
+	// The tokens are not in the namespace, so the namespace is used as the ID unique to the run
+	id := s.namespace
+	if _, err := tk.GetToken(ctx, id); !errors.Is(err, driver.ErrTokenNotExist) {
+		return expectErr("GetToken", err, driver.ErrTokenNotExist)
+	}
+
+	if err := expectErr("DeleteToken", tk.DeleteToken(ctx, id), driver.ErrTokenNotExist); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	t := &driver.APIToken{
+		ID:          id,
+		Description: "conformance",
+		Hash:        "hash",
+		Namespace:   s.namespace,
+		Subject:     "conformance",
+		CreatedAt:   now,
+		ExpiredAt:   now.Add(time.Hour),
+	}
+	if err := tk.SaveToken(ctx, t); err != nil {
+		return fmt.Errorf("SaveToken: %w", err)
+	}
+
+	got, err := tk.GetToken(ctx, id)
+	if err != nil {
+		return fmt.Errorf("GetToken: %w", err)
+	}
+
+	if got.ID != id || got.Hash != t.Hash || got.Namespace != t.Namespace || got.Subject != t.Subject || !got.ExpiredAt.Equal(t.ExpiredAt) || got.LastUsedAt != nil {
+		return fmt.Errorf("GetToken: expected %+v, got %+v", t, got)
+	}
+
+	ids, err := tokenIDs(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	if !contains(ids, id) {
+		return fmt.Errorf("ListTokens: expected the token %s, got %v", id, ids)
+	}
+
+	// The last used time is saved apart from the token
+	if err := tk.SaveTokenLastUsed(ctx, id, now); err != nil {
+		return fmt.Errorf("SaveTokenLastUsed: %w", err)
+	}
+
+	got, err = tk.GetToken(ctx, id)
+	if err != nil {
+		return fmt.Errorf("GetToken: %w", err)
+	}
+
+	if got.LastUsedAt == nil || !got.LastUsedAt.Equal(now) {
+		return fmt.Errorf("GetToken: expected the last used time %s, got %v", now, got.LastUsedAt)
+	}
+
+	if err := tk.DeleteToken(ctx, id); err != nil {
+		return fmt.Errorf("DeleteToken: %w", err)
+	}
+
+	if _, err := tk.GetToken(ctx, id); !errors.Is(err, driver.ErrTokenNotExist) {
+		return expectErr("GetToken", err, driver.ErrTokenNotExist)
+	}
+
+	// The last used time of the deleted token does not restore the token
+	if err := expectErr("SaveTokenLastUsed", tk.SaveTokenLastUsed(ctx, id, now), driver.ErrTokenNotExist); err != nil {
+		return err
+	}
+
+	if _, err := tk.GetToken(ctx, id); !errors.Is(err, driver.ErrTokenNotExist) {
+		return expectErr("GetToken", err, driver.ErrTokenNotExist)
+	}
+
+	ids, err = tokenIDs(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	if contains(ids, id) {
+		return fmt.Errorf("ListTokens: expected the token %s deleted, got %v", id, ids)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package gcs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"cloud.google.com/go/storage"
+	"github.com/kerraform/kegistry/internal/driver"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/api/iterator"
+)
+
+type audit struct {
+	bucket *storage.BucketHandle
+	logger *zap.Logger
+	tracer trace.Tracer
+}
+
+var _ driver.Audit = (*audit)(nil)
+
+func (d *audit) ListAuditEvents(ctx context.Context, namespace string) ([]*driver.AuditEvent, error) {
+	ctx, span := d.tracer.Start(ctx, "ListAuditEvents")
+	defer span.End()
+	prefix := fmt.Sprintf("%s/%s/", driver.AuditRootPath, namespace)
+
+	keys := []string{}
+	it := d.bucket.Objects(ctx, &storage.Query{
+		Prefix: prefix,
+	})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if filepath.Ext(attrs.Name) != ".json" {
+			continue
+		}
+		keys = append(keys, attrs.Name)
+	}
+	sort.Strings(keys)
+
+	es := make([]*driver.AuditEvent, 0, len(keys))
+	for _, key := range keys {
+		rc, err := getObject(ctx, d.bucket, key, storage.ErrObjectNotExist)
+		if err != nil {
+			return nil, err
+		}
+
+		var e driver.AuditEvent
+		err = json.NewDecoder(rc).Decode(&e)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		es = append(es, &e)
+	}
+
+	d.logger.Debug("list audit events", zap.String("namespace", namespace), zap.Int("count", len(es)))
+	return es, nil
+}
+
+func (d *audit) SaveAuditEvent(ctx context.Context, event *driver.AuditEvent) error {
+	ctx, span := d.tracer.Start(ctx, "SaveAuditEvent")
+	defer span.End()
+	eventPath := fmt.Sprintf("%s/%s/%s", driver.AuditRootPath, event.Resource.Namespace, driver.AuditEventFilename(event))
+
+	b := new(bytes.Buffer)
+	if err := json.NewEncoder(b).Encode(event); err != nil {
+		return err
+	}
+
+	if err := createObject(ctx, d.bucket, d.logger, eventPath, b); err != nil {
+		if errors.Is(err, errObjectExists) {
+			return driver.ErrAuditEventExists
+		}
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,382 @@
+package gcs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/kerraform/kegistry/internal/artifact"
+	"github.com/kerraform/kegistry/internal/driver"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+var (
+	errObjectExists = errors.New("object already exists")
+)
+
+const (
+	// signedURLExpiry is the same as the default expiry of the presigned URLs of Amazon S3
+	signedURLExpiry = 15 * time.Minute
+)
+
+type DriverOpts struct {
+	Bucket string
+
+	// CredentialsFile is the service account key file, which is also used to sign the URLs.
+	// The application default credentials are used if empty.
+	CredentialsFile string
+
+	// Endpoint of the emulator (e.g. fake-gcs-server), which the signed URLs are rewritten to
+	Endpoint string
+
+	// Limits are the maximum sizes of the uploads by the signed URLs, which are unlimited if nil
+	Limits *artifact.Limits
+
+	Tracer trace.Tracer
+}
+
+// signer signs the URLs to upload and download the objects directly, bypassing the registry
+type signer struct {
+	accessID   string
+	privateKey []byte
+	endpoint   *url.URL
+}
+
+type serviceAccountKey struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+}
+
+func NewDriver(logger *zap.Logger, opts *DriverOpts) (*driver.Driver, error) {
+	if opts == nil {
+		return nil, fmt.Errorf("invalid gcs credentials")
+	}
+
+	s := &signer{}
+	clientOpts := []option.ClientOption{}
+	if opts.CredentialsFile != "" {
+		b, err := os.ReadFile(opts.CredentialsFile)
+		if err != nil {
+			return nil, err
+		}
+
+		var key serviceAccountKey
+		if err := json.Unmarshal(b, &key); err != nil {
+			return nil, fmt.Errorf("invalid gcs credentials file: %w", err)
+		}
+
+		// The emulator does not authenticate the requests, but the key is still needed to sign the URLs
+		s.accessID = key.ClientEmail
+		s.privateKey = []byte(key.PrivateKey)
+		if opts.Endpoint == "" {
+			clientOpts = append(clientOpts, option.WithCredentialsJSON(b))
+		}
+	}
+
+	if opts.Endpoint != "" {
+		u, err := url.Parse(opts.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+
+		s.endpoint = u
+		clientOpts = append(clientOpts,
+			option.WithEndpoint(u.JoinPath("storage", "v1").String()+"/"),
+			option.WithoutAuthentication(),
+		)
+	}
+
+	client, err := storage.NewClient(context.Background(), clientOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket := client.Bucket(opts.Bucket)
+	limits := opts.Limits
+	if limits == nil {
+		limits = &artifact.Limits{}
+	}
+
+	audit := &audit{
+		bucket: bucket,
+		logger: logger,
+		tracer: opts.Tracer,
+	}
+
+	module := &module{
+		bucket: bucket,
+		limits: limits,
+		logger: logger,
+		signer: s,
+		tracer: opts.Tracer,
+	}
+
+	provider := &provider{
+		bucket: bucket,
+		limits: limits,
+		logger: logger,
+		signer: s,
+		tracer: opts.Tracer,
+	}
+
+	token := &token{
+		bucket: bucket,
+		logger: logger,
+		tracer: opts.Tracer,
+	}
+
+	return &driver.Driver{
+		Audit:    audit,
+		Module:   module,
+		Provider: provider,
+		Token:    token,
+	}, nil
+}
+
+// signedUploadURL returns the V4 signed URL to upload the object. Unless overwrite, the upload is conditioned on
+// no object existing by the signed `x-goog-if-generation-match: 0` header, so that the uploader must send the returned headers.
+// The size of the upload is also conditioned on maxSize by the signed `x-goog-content-length-range` header, unless zero.
+func (s *signer) signedUploadURL(b *storage.BucketHandle, key string, overwrite bool, maxSize int64) (string, map[string]string, error) {
+	headers := map[string]string{}
+	if !overwrite {
+		headers["x-goog-if-generation-match"] = "0"
+	}
+
+	if maxSize > 0 {
+		headers["x-goog-content-length-range"] = fmt.Sprintf("0,%d", maxSize)
+	}
+
+	if len(headers) == 0 {
+		headers = nil
+	}
+
+	u, err := s.signedURL(b, key, http.MethodPut, headers)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return u, headers, nil
+}
+
+// signedURL returns the V4 signed URL of the object, which requires the headers to be sent.
+// The URL is rewritten to the endpoint of the emulator if configured, which does not verify the signature.
+func (s *signer) signedURL(b *storage.BucketHandle, key, method string, headers map[string]string) (string, error) {
+	signedHeaders := make([]string, 0, len(headers))
+	for k, v := range headers {
+		signedHeaders = append(signedHeaders, k+":"+v)
+	}
+
+	u, err := b.SignedURL(key, &storage.SignedURLOptions{
+		GoogleAccessID: s.accessID,
+		PrivateKey:     s.privateKey,
+		Method:         method,
+		Headers:        signedHeaders,
+		Expires:        time.Now().Add(signedURLExpiry),
+		Scheme:         storage.SigningSchemeV4,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if s.endpoint == nil {
+		return u, nil
+	}
+
+	signed, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+
+	signed.Scheme = s.endpoint.Scheme
+	signed.Host = s.endpoint.Host
+	return signed.String(), nil
+}
+
+// createDir creates the placeholder object of the directory, as there are no directories on Google Cloud Storage
+func createDir(ctx context.Context, b *storage.BucketHandle, logger *zap.Logger, prefix string) error {
+	return putObject(ctx, b, logger, prefix+"/", strings.NewReader(""))
+}
+
+// deleteObject deletes the object, which succeeds even if the object does not exist
+func deleteObject(ctx context.Context, b *storage.BucketHandle, key string) error {
+	if err := b.Object(key).Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// deletePrefix deletes all the objects under the prefix, or returns notExistErr if there is no object
+func deletePrefix(ctx context.Context, b *storage.BucketHandle, logger *zap.Logger, prefix string, notExistErr error) error {
+	deleted := 0
+	it := b.Objects(ctx, &storage.Query{
+		Prefix: prefix,
+	})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := deleteObject(ctx, b, attrs.Name); err != nil {
+			return err
+		}
+		deleted++
+	}
+
+	if deleted == 0 {
+		return notExistErr
+	}
+
+	logger.Debug("deleted objects from google cloud storage", zap.String("prefix", prefix), zap.Int("count", deleted))
+	return nil
+}
+
+// getObject returns the body of the object, or notExistErr if the object does not exist
+func getObject(ctx context.Context, b *storage.BucketHandle, key string, notExistErr error) (io.ReadCloser, error) {
+	r, err := b.Object(key).NewReader(ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return nil, notExistErr
+		}
+
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// isObjectCreated returns notExistErr if the object does not exist
+func isObjectCreated(ctx context.Context, b *storage.BucketHandle, key string, notExistErr error) error {
+	if _, err := b.Object(key).Attrs(ctx); err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return notExistErr
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// isPrefixCreated returns notExistErr if there is no object under the prefix
+func isPrefixCreated(ctx context.Context, b *storage.BucketHandle, prefix string, notExistErr error) error {
+	it := b.Objects(ctx, &storage.Query{
+		Prefix: prefix,
+	})
+	if _, err := it.Next(); err != nil {
+		if errors.Is(err, iterator.Done) {
+			return notExistErr
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// listDirs returns the names of the directories right under the prefix
+func listDirs(ctx context.Context, b *storage.BucketHandle, prefix string) ([]string, error) {
+	dirs := []string{}
+	it := b.Objects(ctx, &storage.Query{
+		Prefix:    prefix,
+		Delimiter: "/",
+	})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if attrs.Prefix == "" {
+			continue
+		}
+
+		dirs = append(dirs, strings.TrimSuffix(strings.TrimPrefix(attrs.Prefix, prefix), "/"))
+	}
+
+	return dirs, nil
+}
+
+func putObject(ctx context.Context, b *storage.BucketHandle, logger *zap.Logger, key string, body io.Reader) error {
+	w := b.Object(key).NewWriter(ctx)
+	if _, err := io.Copy(w, body); err != nil {
+		w.Close()
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	logger.Debug("saved object to google cloud storage", zap.String("key", key))
+	return nil
+}
+
+// putObjectIfNotExist saves the object only if the object does not exist, which succeeds even if the object exists
+func putObjectIfNotExist(ctx context.Context, b *storage.BucketHandle, logger *zap.Logger, key string, body io.Reader) error {
+	if err := createObject(ctx, b, logger, key, body); err != nil && !errors.Is(err, errObjectExists) {
+		return err
+	}
+
+	return nil
+}
+
+// createObject saves the object only if the object does not exist, otherwise returns errObjectExists
+func createObject(ctx context.Context, b *storage.BucketHandle, logger *zap.Logger, key string, body io.Reader) error {
+	w := b.Object(key).If(storage.Conditions{DoesNotExist: true}).NewWriter(ctx)
+	if _, err := io.Copy(w, body); err != nil {
+		w.Close()
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		var gerr *googleapi.Error
+		if errors.As(err, &gerr) && gerr.Code == http.StatusPreconditionFailed {
+			logger.Debug("object already exists in google cloud storage", zap.String("key", key))
+			return errObjectExists
+		}
+
+		return err
+	}
+
+	logger.Debug("saved object to google cloud storage", zap.String("key", key))
+	return nil
+}
+
+// saveArtifact saves the artifact, which is only created unless overwrite, otherwise returns driver.ErrArtifactExists
+func saveArtifact(ctx context.Context, b *storage.BucketHandle, logger *zap.Logger, key string, body io.Reader, overwrite bool) error {
+	if overwrite {
+		return putObject(ctx, b, logger, key, body)
+	}
+
+	if err := createObject(ctx, b, logger, key, body); err != nil {
+		if errors.Is(err, errObjectExists) {
+			return driver.ErrArtifactExists
+		}
+
+		return err
+	}
+
+	return nil
+}
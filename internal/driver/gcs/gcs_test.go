@@ -0,0 +1,138 @@
+package gcs
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kerraform/kegistry/internal/driver/drivertest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/api/googleapi"
+)
+
+// TestDriver runs against the emulator (e.g. fake-gcs-server) at BACKEND_GCS_ENDPOINT, and is skipped if unset.
+// The bucket is created if not exist, and the URLs are signed by the generated key unless BACKEND_GCS_CREDENTIALS_FILE is set.
+func TestDriver(t *testing.T) {
+	endpoint := os.Getenv("BACKEND_GCS_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("BACKEND_GCS_ENDPOINT is not set")
+	}
+
+	credentialsFile := os.Getenv("BACKEND_GCS_CREDENTIALS_FILE")
+	if credentialsFile == "" {
+		credentialsFile = writeServiceAccountKey(t)
+	}
+
+	d, err := NewDriver(zap.NewNop(), &DriverOpts{
+		Bucket:          getenv("BACKEND_GCS_BUCKET", "kegistry"),
+		CredentialsFile: credentialsFile,
+		Endpoint:        endpoint,
+		Tracer:          trace.NewNoopTracerProvider().Tracer(""),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Provider.(*provider).bucket.Create(context.Background(), "kegistry", nil); err != nil {
+		var gerr *googleapi.Error
+		if !errors.As(err, &gerr) || gerr.Code != http.StatusConflict {
+			t.Fatal(err)
+		}
+	}
+
+	drivertest.TestDriver(t, d)
+}
+
+func TestSignedUploadURL(t *testing.T) {
+	d, err := NewDriver(zap.NewNop(), &DriverOpts{
+		Bucket:          "kegistry",
+		CredentialsFile: writeServiceAccountKey(t),
+		Endpoint:        "http://localhost:4443",
+		Tracer:          trace.NewNoopTracerProvider().Tracer(""),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := d.Provider.(*provider)
+	for _, overwrite := range []bool{false, true} {
+		u, headers, err := p.signer.signedUploadURL(p.bucket, "foo", overwrite, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		signed, err := url.Parse(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The header must be signed, otherwise the upload can omit it
+		conditioned := strings.Contains(signed.Query().Get("X-Goog-SignedHeaders"), "x-goog-if-generation-match")
+		if conditioned == overwrite || (headers["x-goog-if-generation-match"] == "0") == overwrite {
+			t.Fatalf("overwrite %t: unexpected condition of %s with the headers %v", overwrite, u, headers)
+		}
+	}
+
+	u, headers, err := p.signer.signedUploadURL(p.bucket, "foo", true, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := url.Parse(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The size must be limited by the signed header as well
+	if !strings.Contains(signed.Query().Get("X-Goog-SignedHeaders"), "x-goog-content-length-range") || headers["x-goog-content-length-range"] != "0,1024" {
+		t.Fatalf("unexpected size limit of %s with the headers %v", u, headers)
+	}
+}
+
+// writeServiceAccountKey writes the service account key of the generated private key, which the emulator accepts the URLs signed by
+func writeServiceAccountKey(t *testing.T) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(&serviceAccountKey{
+		ClientEmail: "kegistry@example.iam.gserviceaccount.com",
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := filepath.Join(t.TempDir(), "credentials.json")
+	if err := os.WriteFile(p, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+func getenv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+
+	return fallback
+}
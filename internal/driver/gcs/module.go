@@ -0,0 +1,204 @@
+package gcs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"cloud.google.com/go/storage"
+	"github.com/kerraform/kegistry/internal/artifact"
+	"github.com/kerraform/kegistry/internal/driver"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type module struct {
+	bucket *storage.BucketHandle
+	limits *artifact.Limits
+	logger *zap.Logger
+	signer *signer
+	tracer trace.Tracer
+}
+
+var _ driver.Module = (*module)(nil)
+
+func (d *module) CreateModule(ctx context.Context, namespace, provider, name string) error {
+	ctx, span := d.tracer.Start(ctx, "CreateModule")
+	defer span.End()
+	if err := driver.ValidatePathSegments(namespace, provider, name); err != nil {
+		return err
+	}
+
+	moduleRootPath := fmt.Sprintf("%s/%s/%s/%s", driver.ModuleRootPath, namespace, provider, name)
+	return createDir(ctx, d.bucket, d.logger, moduleRootPath)
+}
+
+func (d *module) CreateVersion(ctx context.Context, namespace, provider, name, version string, overwrite bool) (*driver.CreateModuleVersionResult, error) {
+	ctx, span := d.tracer.Start(ctx, "CreateVersion")
+	defer span.End()
+	if err := driver.ValidatePathSegments(namespace, provider, name, version); err != nil {
+		return nil, err
+	}
+
+	versionRootPath := fmt.Sprintf("%s/%s/%s/%s/versions/%s", driver.ModuleRootPath, namespace, provider, name, version)
+	if err := createDir(ctx, d.bucket, d.logger, versionRootPath); err != nil {
+		return nil, err
+	}
+
+	uploadURL, headers, err := d.signer.signedUploadURL(d.bucket, fmt.Sprintf("%s/terraform-%s-%s-%s.tar.gz", versionRootPath, provider, name, version), overwrite, d.limits.Module)
+	if err != nil {
+		return nil, err
+	}
+
+	return &driver.CreateModuleVersionResult{
+		Upload:        uploadURL,
+		UploadHeaders: headers,
+		Presigned:     true,
+	}, nil
+}
+
+func (d *module) DeleteModule(ctx context.Context, namespace, provider, name string) error {
+	ctx, span := d.tracer.Start(ctx, "DeleteModule")
+	defer span.End()
+	prefix := fmt.Sprintf("%s/%s/%s/%s/", driver.ModuleRootPath, namespace, provider, name)
+	return deletePrefix(ctx, d.bucket, d.logger, prefix, driver.ErrModuleNotExist)
+}
+
+func (d *module) DeleteVersion(ctx context.Context, namespace, provider, name, version string) error {
+	ctx, span := d.tracer.Start(ctx, "DeleteVersion")
+	defer span.End()
+	prefix := fmt.Sprintf("%s/%s/%s/%s/versions/%s/", driver.ModuleRootPath, namespace, provider, name, version)
+	return deletePrefix(ctx, d.bucket, d.logger, prefix, driver.ErrModuleVersionNotExist)
+}
+
+func (d *module) GetDownloadURL(ctx context.Context, namespace, provider, name, version string) (string, error) {
+	_, span := d.tracer.Start(ctx, "GetDownloadURL")
+	defer span.End()
+	packagePath := fmt.Sprintf("%s/%s/%s/%s/versions/%s/terraform-%s-%s-%s.tar.gz", driver.ModuleRootPath, namespace, provider, name, version, provider, name, version)
+	return d.signer.signedURL(d.bucket, packagePath, http.MethodGet, nil)
+}
+
+// GetModule downloads the package to the temporary file, which is removed once closed.
+// It returns driver.ErrModulePackageNotExist if the package is not uploaded.
+func (d *module) GetModule(ctx context.Context, namespace, provider, name, version string) (*os.File, error) {
+	ctx, span := d.tracer.Start(ctx, "GetModule")
+	defer span.End()
+	packagePath := fmt.Sprintf("%s/%s/%s/%s/versions/%s/terraform-%s-%s-%s.tar.gz", driver.ModuleRootPath, namespace, provider, name, version, provider, name, version)
+	rc, err := getObject(ctx, d.bucket, packagePath, driver.ErrModulePackageNotExist)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	f, err := os.CreateTemp("", "kegistry-module-*.tar.gz")
+	if err != nil {
+		return nil, err
+	}
+
+	// The file is still readable until closed
+	if err := os.Remove(f.Name()); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if _, err := io.Copy(f, rc); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// GetVersionMetadata returns the metadata of the version, which is empty if nothing is saved for the version
+func (d *module) GetVersionMetadata(ctx context.Context, namespace, provider, name, version string) (*driver.ModuleVersionMetadata, error) {
+	ctx, span := d.tracer.Start(ctx, "GetVersionMetadata")
+	defer span.End()
+	if err := d.isVersionCreated(ctx, namespace, provider, name, version); err != nil {
+		return nil, err
+	}
+
+	metadataPath := fmt.Sprintf("%s/%s/%s/%s/versions/%s/%s", driver.ModuleRootPath, namespace, provider, name, version, driver.VersionMetadataFilename)
+	rc, err := getObject(ctx, d.bucket, metadataPath, driver.ErrModuleVersionNotExist)
+	if err != nil {
+		if errors.Is(err, driver.ErrModuleVersionNotExist) {
+			return &driver.ModuleVersionMetadata{}, nil
+		}
+
+		return nil, err
+	}
+	defer rc.Close()
+
+	var metadata driver.ModuleVersionMetadata
+	if err := json.NewDecoder(rc).Decode(&metadata); err != nil {
+		return nil, err
+	}
+
+	return &metadata, nil
+}
+
+func (d *module) IsPackageUploaded(ctx context.Context, namespace, provider, name, version string) error {
+	ctx, span := d.tracer.Start(ctx, "IsPackageUploaded")
+	defer span.End()
+	packagePath := fmt.Sprintf("%s/%s/%s/%s/versions/%s/terraform-%s-%s-%s.tar.gz", driver.ModuleRootPath, namespace, provider, name, version, provider, name, version)
+	return isObjectCreated(ctx, d.bucket, packagePath, driver.ErrModulePackageNotExist)
+}
+
+func (d *module) ListAvailableVersions(ctx context.Context, namespace, provider, name string) ([]string, error) {
+	ctx, span := d.tracer.Start(ctx, "ListAvailableVersions")
+	defer span.End()
+	moduleRootPath := fmt.Sprintf("%s/%s/%s/%s/", driver.ModuleRootPath, namespace, provider, name)
+	if err := isPrefixCreated(ctx, d.bucket, moduleRootPath, driver.ErrModuleNotExist); err != nil {
+		return nil, err
+	}
+
+	prefix := moduleRootPath + "versions/"
+	vs, err := listDirs(ctx, d.bucket, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	d.logger.Debug("found versions",
+		zap.Int("count", len(vs)),
+		zap.String("prefix", prefix),
+	)
+	return vs, nil
+}
+
+func (d *module) SavePackage(ctx context.Context, namespace, provider, name, version string, body io.Reader, overwrite bool) error {
+	ctx, span := d.tracer.Start(ctx, "SavePackage")
+	defer span.End()
+	packagePath := fmt.Sprintf("%s/%s/%s/%s/versions/%s/terraform-%s-%s-%s.tar.gz", driver.ModuleRootPath, namespace, provider, name, version, provider, name, version)
+	return saveArtifact(ctx, d.bucket, d.logger, packagePath, body, overwrite)
+}
+
+func (d *module) SaveVersionMetadata(ctx context.Context, namespace, provider, name, version string, metadata *driver.ModuleVersionMetadata) error {
+	ctx, span := d.tracer.Start(ctx, "SaveVersionMetadata")
+	defer span.End()
+	if err := d.isVersionCreated(ctx, namespace, provider, name, version); err != nil {
+		return err
+	}
+
+	b := new(bytes.Buffer)
+	if err := json.NewEncoder(b).Encode(metadata); err != nil {
+		return err
+	}
+
+	metadataPath := fmt.Sprintf("%s/%s/%s/%s/versions/%s/%s", driver.ModuleRootPath, namespace, provider, name, version, driver.VersionMetadataFilename)
+	return putObject(ctx, d.bucket, d.logger, metadataPath, b)
+}
+
+// isVersionCreated returns driver.ErrModuleVersionNotExist if there is no object of the version
+func (d *module) isVersionCreated(ctx context.Context, namespace, provider, name, version string) error {
+	prefix := fmt.Sprintf("%s/%s/%s/%s/versions/%s/", driver.ModuleRootPath, namespace, provider, name, version)
+	return isPrefixCreated(ctx, d.bucket, prefix, driver.ErrModuleVersionNotExist)
+}
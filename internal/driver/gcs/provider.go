@@ -0,0 +1,546 @@
+package gcs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/kerraform/kegistry/internal/artifact"
+	"github.com/kerraform/kegistry/internal/driver"
+	model "github.com/kerraform/kegistry/internal/model/provider"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/api/iterator"
+)
+
+type provider struct {
+	bucket *storage.BucketHandle
+	limits *artifact.Limits
+	logger *zap.Logger
+	signer *signer
+	tracer trace.Tracer
+}
+
+var _ driver.Provider = (*provider)(nil)
+
+func (d *provider) CreateProvider(ctx context.Context, namespace, registryName string) error {
+	ctx, span := d.tracer.Start(ctx, "CreateProvider")
+	defer span.End()
+	if err := driver.ValidatePathSegments(namespace, registryName); err != nil {
+		return err
+	}
+
+	registryRootPath := fmt.Sprintf("%s/%s/%s", driver.ProviderRootPath, namespace, registryName)
+	return createDir(ctx, d.bucket, d.logger, registryRootPath)
+}
+
+func (d *provider) CreateProviderPlatform(ctx context.Context, namespace, registryName, version, pos, arch string, overwrite bool) (*driver.CreateProviderPlatformResult, error) {
+	ctx, span := d.tracer.Start(ctx, "CreateProviderPlatform")
+	defer span.End()
+	if err := driver.ValidatePathSegments(namespace, registryName, version, pos, arch); err != nil {
+		return nil, err
+	}
+
+	platformPath := fmt.Sprintf("%s/%s/%s/versions/%s/%s-%s", driver.ProviderRootPath, namespace, registryName, version, pos, arch)
+	if err := createDir(ctx, d.bucket, d.logger, platformPath); err != nil {
+		return nil, err
+	}
+
+	binaryUploadURL, headers, err := d.signer.signedUploadURL(d.bucket, fmt.Sprintf("%s/terraform-provider-%s_%s_%s_%s.zip", platformPath, registryName, version, pos, arch), overwrite, d.limits.ProviderBinary)
+	if err != nil {
+		return nil, err
+	}
+
+	return &driver.CreateProviderPlatformResult{
+		ProviderBinaryUploads: binaryUploadURL,
+		UploadHeaders:         headers,
+		Presigned:             true,
+	}, nil
+}
+
+func (d *provider) CreateProviderVersion(ctx context.Context, namespace, registryName, version string, overwrite bool) (*driver.CreateProviderVersionResult, error) {
+	ctx, span := d.tracer.Start(ctx, "CreateProviderVersion")
+	defer span.End()
+	if err := driver.ValidatePathSegments(namespace, registryName, version); err != nil {
+		return nil, err
+	}
+
+	versionRootPath := fmt.Sprintf("%s/%s/%s/versions/%s", driver.ProviderRootPath, namespace, registryName, version)
+	if err := createDir(ctx, d.bucket, d.logger, versionRootPath); err != nil {
+		return nil, err
+	}
+
+	sha256SumKeyUploadURL, headers, err := d.signer.signedUploadURL(d.bucket, fmt.Sprintf("%s/terraform-provider-%s_%s_SHA256SUMS", versionRootPath, registryName, version), overwrite, d.limits.SHASums)
+	if err != nil {
+		return nil, err
+	}
+
+	sha256SumSigKeyUploadURL, _, err := d.signer.signedUploadURL(d.bucket, fmt.Sprintf("%s/terraform-provider-%s_%s_SHA256SUMS.sig", versionRootPath, registryName, version), overwrite, d.limits.SHASums)
+	if err != nil {
+		return nil, err
+	}
+
+	d.logger.Debug("created provider version path", zap.String("path", versionRootPath))
+
+	return &driver.CreateProviderVersionResult{
+		SHASumsUpload:    sha256SumKeyUploadURL,
+		SHASumsSigUpload: sha256SumSigKeyUploadURL,
+		UploadHeaders:    headers,
+		Presigned:        true,
+	}, nil
+}
+
+func (d *provider) DeleteGPGKey(ctx context.Context, namespace, keyID string) error {
+	ctx, span := d.tracer.Start(ctx, "DeleteGPGKey")
+	defer span.End()
+	keyPath := fmt.Sprintf("%s/%s/%s/%s", driver.ProviderRootPath, namespace, driver.KeyDirname, keyID)
+	if err := isObjectCreated(ctx, d.bucket, keyPath, driver.ErrProviderGPGKeyNotExist); err != nil {
+		return err
+	}
+
+	for _, key := range []string{keyPath, keyPath + driver.KeyMetadataExt} {
+		if err := deleteObject(ctx, d.bucket, key); err != nil {
+			return err
+		}
+	}
+
+	d.logger.Debug("deleted gpg key from google cloud storage", zap.String("key", keyPath))
+	return nil
+}
+
+func (d *provider) DeleteProviderPlatform(ctx context.Context, namespace, registryName, version, pos, arch string) error {
+	ctx, span := d.tracer.Start(ctx, "DeleteProviderPlatform")
+	defer span.End()
+	platformPath := fmt.Sprintf("%s/%s/%s/versions/%s/%s-%s/", driver.ProviderRootPath, namespace, registryName, version, pos, arch)
+	return deletePrefix(ctx, d.bucket, d.logger, platformPath, driver.ErrProviderPlatformNotExist)
+}
+
+func (d *provider) DeleteProviderVersion(ctx context.Context, namespace, registryName, version string) error {
+	ctx, span := d.tracer.Start(ctx, "DeleteProviderVersion")
+	defer span.End()
+	versionRootPath := fmt.Sprintf("%s/%s/%s/versions/%s/", driver.ProviderRootPath, namespace, registryName, version)
+	return deletePrefix(ctx, d.bucket, d.logger, versionRootPath, driver.ErrProviderVersionNotExist)
+}
+
+func (d *provider) FindPackage(ctx context.Context, namespace, registryName, version, pos, arch string) (*model.Package, error) {
+	ctx, span := d.tracer.Start(ctx, "FindPackage")
+	defer span.End()
+	versionRootPath := fmt.Sprintf("%s/%s/%s/versions/%s", driver.ProviderRootPath, namespace, registryName, version)
+	filename := fmt.Sprintf("terraform-provider-%s_%s_%s_%s.zip", registryName, version, pos, arch)
+	binaryPath := fmt.Sprintf("%s/%s-%s/%s", versionRootPath, pos, arch, filename)
+
+	if err := isObjectCreated(ctx, d.bucket, binaryPath, driver.ErrProviderBinaryNotExist); err != nil {
+		return nil, err
+	}
+
+	platformMetadata, err := d.GetPlatformMetadata(ctx, namespace, registryName, version, pos, arch)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := d.GetVersionMetadata(ctx, namespace, registryName, version)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := d.GetGPGKey(ctx, namespace, metadata.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	d.logger.Debug("found signing key", zap.String("keyID", key.KeyID))
+
+	platformBinaryDownload, err := d.signer.signedURL(d.bucket, binaryPath, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sha256SumKeyDownload, err := d.signer.signedURL(d.bucket, fmt.Sprintf("%s/terraform-provider-%s_%s_SHA256SUMS", versionRootPath, registryName, version), http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sha256SumSigKeyDownload, err := d.signer.signedURL(d.bucket, fmt.Sprintf("%s/terraform-provider-%s_%s_SHA256SUMS.sig", versionRootPath, registryName, version), http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &model.Package{
+		OS:            pos,
+		Arch:          arch,
+		Filename:      filename,
+		DownloadURL:   platformBinaryDownload,
+		SHASumsURL:    sha256SumKeyDownload,
+		SHASumsSigURL: sha256SumSigKeyDownload,
+		SHASum:        platformMetadata.SHA256,
+		SigningKeys: &model.SigningKeys{
+			GPGPublicKeys: []model.GPGPublicKey{key.PublicKey()},
+		},
+	}
+
+	return pkg, nil
+}
+
+func (d *provider) GetPlatformBinary(ctx context.Context, namespace, registryName, version, pos, arch string) (io.ReadCloser, error) {
+	ctx, span := d.tracer.Start(ctx, "GetPlatformBinary")
+	defer span.End()
+	binaryPath := fmt.Sprintf("%s/%s/%s/versions/%s/%s-%s/terraform-provider-%s_%s_%s_%s.zip", driver.ProviderRootPath, namespace, registryName, version, pos, arch, registryName, version, pos, arch)
+	return getObject(ctx, d.bucket, binaryPath, driver.ErrProviderBinaryNotExist)
+}
+
+func (d *provider) GetPlatformMetadata(ctx context.Context, namespace, registryName, version, pos, arch string) (*driver.ProviderPlatformMetadata, error) {
+	ctx, span := d.tracer.Start(ctx, "GetPlatformMetadata")
+	defer span.End()
+	metadataPath := fmt.Sprintf("%s/%s/%s/versions/%s/%s-%s/%s", driver.ProviderRootPath, namespace, registryName, version, pos, arch, driver.PlatformMetadataFilename)
+	rc, err := getObject(ctx, d.bucket, metadataPath, driver.ErrProviderPlatformMetadataNotExist)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var metadata driver.ProviderPlatformMetadata
+	if err := json.NewDecoder(rc).Decode(&metadata); err != nil {
+		return nil, err
+	}
+
+	return &metadata, nil
+}
+
+func (d *provider) GetGPGKey(ctx context.Context, namespace, keyID string) (*driver.GPGKey, error) {
+	ctx, span := d.tracer.Start(ctx, "GetGPGKey")
+	defer span.End()
+	keyPath := fmt.Sprintf("%s/%s/%s/%s", driver.ProviderRootPath, namespace, driver.KeyDirname, keyID)
+	rc, err := getObject(ctx, d.bucket, keyPath, driver.ErrProviderGPGKeyNotExist)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &driver.GPGKey{
+		KeyID: keyID,
+	}
+
+	metadata, err := getObject(ctx, d.bucket, keyPath+driver.KeyMetadataExt, driver.ErrProviderGPGKeyNotExist)
+	if err != nil && !errors.Is(err, driver.ErrProviderGPGKeyNotExist) {
+		return nil, err
+	}
+
+	if err == nil {
+		defer metadata.Close()
+		if err := json.NewDecoder(metadata).Decode(key); err != nil {
+			return nil, err
+		}
+	}
+
+	key.ASCIIArmor = string(b)
+	return key, nil
+}
+
+func (d *provider) GetSHASums(ctx context.Context, namespace, registryName, version string) (io.ReadCloser, error) {
+	ctx, span := d.tracer.Start(ctx, "GetSHASums")
+	defer span.End()
+	sumsPath := fmt.Sprintf("%s/%s/%s/versions/%s/terraform-provider-%s_%s_SHA256SUMS", driver.ProviderRootPath, namespace, registryName, version, registryName, version)
+	return getObject(ctx, d.bucket, sumsPath, driver.ErrProviderSHA256SUMSNotExist)
+}
+
+func (d *provider) GetSHASumsSig(ctx context.Context, namespace, registryName, version string) (io.ReadCloser, error) {
+	ctx, span := d.tracer.Start(ctx, "GetSHASumsSig")
+	defer span.End()
+	sigPath := fmt.Sprintf("%s/%s/%s/versions/%s/terraform-provider-%s_%s_SHA256SUMS.sig", driver.ProviderRootPath, namespace, registryName, version, registryName, version)
+	return getObject(ctx, d.bucket, sigPath, driver.ErrProviderSHA256SUMSSigNotExist)
+}
+
+func (d *provider) GetSigningKey(ctx context.Context, namespace string) ([]byte, error) {
+	ctx, span := d.tracer.Start(ctx, "GetSigningKey")
+	defer span.End()
+	keyPath := fmt.Sprintf("%s/%s/%s", driver.SigningRootPath, namespace, driver.SigningKeyFilename)
+	rc, err := getObject(ctx, d.bucket, keyPath, driver.ErrSigningKeyNotExist)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+func (d *provider) GetVersionMetadata(ctx context.Context, namespace, registryName, version string) (*driver.ProviderVersionMetadata, error) {
+	ctx, span := d.tracer.Start(ctx, "GetVersionMetadata")
+	defer span.End()
+	metadataPath := fmt.Sprintf("%s/%s/%s/versions/%s/%s", driver.ProviderRootPath, namespace, registryName, version, driver.VersionMetadataFilename)
+	rc, err := getObject(ctx, d.bucket, metadataPath, driver.ErrProviderVersionNotExist)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var metadata driver.ProviderVersionMetadata
+	if err := json.NewDecoder(rc).Decode(&metadata); err != nil {
+		return nil, err
+	}
+
+	return &metadata, nil
+}
+
+func (d *provider) IsGPGKeyCreated(ctx context.Context, namespace, registryName string) error {
+	ctx, span := d.tracer.Start(ctx, "IsGPGKeyCreated")
+	defer span.End()
+	keyRootPath := fmt.Sprintf("%s/%s/%s/", driver.ProviderRootPath, namespace, driver.KeyDirname)
+	return isPrefixCreated(ctx, d.bucket, keyRootPath, driver.ErrProviderGPGKeyNotExist)
+}
+
+func (d *provider) IsProviderCreated(ctx context.Context, namespace, registryName string) error {
+	ctx, span := d.tracer.Start(ctx, "IsProviderCreated")
+	defer span.End()
+	registryRootPath := fmt.Sprintf("%s/%s/%s/", driver.ProviderRootPath, namespace, registryName)
+	return isPrefixCreated(ctx, d.bucket, registryRootPath, driver.ErrProviderNotExist)
+}
+
+func (d *provider) IsProviderVersionCreated(ctx context.Context, namespace, registryName, version string) error {
+	ctx, span := d.tracer.Start(ctx, "IsProviderVersionCreated")
+	defer span.End()
+	versionRootPath := fmt.Sprintf("%s/%s/%s/versions/%s/", driver.ProviderRootPath, namespace, registryName, version)
+	return isPrefixCreated(ctx, d.bucket, versionRootPath, driver.ErrProviderVersionNotExist)
+}
+
+// ListAvailableVersions lists the versions with the platforms created in them, reading the object names only once
+func (d *provider) ListAvailableVersions(ctx context.Context, namespace, registryName string) ([]model.AvailableVersion, error) {
+	ctx, span := d.tracer.Start(ctx, "ListAvailableVersions")
+	defer span.End()
+	if err := d.IsProviderCreated(ctx, namespace, registryName); err != nil {
+		return nil, err
+	}
+
+	prefix := fmt.Sprintf("%s/%s/%s/versions/", driver.ProviderRootPath, namespace, registryName)
+	platforms := map[string]map[string]bool{}
+	it := d.bucket.Objects(ctx, &storage.Query{
+		Prefix: prefix,
+	})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		// <version>/<os>-<arch>/<file>
+		e := strings.SplitN(strings.TrimPrefix(attrs.Name, prefix), "/", 3)
+		if _, ok := platforms[e[0]]; !ok {
+			platforms[e[0]] = map[string]bool{}
+		}
+
+		if len(e) == 3 {
+			platforms[e[0]][e[1]] = true
+		}
+	}
+
+	versions := make([]string, 0, len(platforms))
+	for v := range platforms {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+
+	d.logger.Debug("found versions", zap.String("prefix", prefix), zap.Int("count", len(versions)))
+
+	vs := make([]model.AvailableVersion, 0, len(versions))
+	for _, v := range versions {
+		names := make([]string, 0, len(platforms[v]))
+		for name := range platforms[v] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		pfs := []model.AvailableVersionPlatform{}
+		for _, name := range names {
+			e := strings.SplitN(name, "-", 2)
+			if len(e) != 2 {
+				continue
+			}
+
+			pfs = append(pfs, model.AvailableVersionPlatform{
+				OS:   e[0],
+				Arch: e[1],
+			})
+		}
+
+		vs = append(vs, model.AvailableVersion{
+			Version:   v,
+			Platforms: pfs,
+		})
+	}
+
+	return vs, nil
+}
+
+func (d *provider) ListGPGKeys(ctx context.Context, namespace string) ([]*driver.GPGKey, error) {
+	ctx, span := d.tracer.Start(ctx, "ListGPGKeys")
+	defer span.End()
+	prefix := fmt.Sprintf("%s/%s/%s/", driver.ProviderRootPath, namespace, driver.KeyDirname)
+
+	keys := []*driver.GPGKey{}
+	it := d.bucket.Objects(ctx, &storage.Query{
+		Prefix: prefix,
+	})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.HasSuffix(attrs.Name, driver.KeyMetadataExt) {
+			continue
+		}
+
+		key, err := d.GetGPGKey(ctx, namespace, strings.TrimPrefix(attrs.Name, prefix))
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	d.logger.Debug("found gpg keys", zap.String("prefix", prefix), zap.Int("count", len(keys)))
+	return keys, nil
+}
+
+func (d *provider) ListProviders(ctx context.Context, namespace string) ([]string, error) {
+	ctx, span := d.tracer.Start(ctx, "ListProviders")
+	defer span.End()
+	prefix := fmt.Sprintf("%s/%s/", driver.ProviderRootPath, namespace)
+	dirs, err := listDirs(ctx, d.bucket, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	providers := []string{}
+	for _, name := range dirs {
+		if name == driver.KeyDirname {
+			continue
+		}
+
+		providers = append(providers, name)
+	}
+
+	return providers, nil
+}
+
+func (d *provider) SaveGPGKey(ctx context.Context, namespace string, key *driver.GPGKey) error {
+	ctx, span := d.tracer.Start(ctx, "SaveGPGKey")
+	defer span.End()
+	if err := driver.ValidatePathSegments(namespace, key.KeyID); err != nil {
+		return err
+	}
+
+	keyPath := fmt.Sprintf("%s/%s/%s/%s", driver.ProviderRootPath, namespace, driver.KeyDirname, key.KeyID)
+	if err := putObject(ctx, d.bucket, d.logger, keyPath, bytes.NewBufferString(key.ASCIIArmor)); err != nil {
+		return err
+	}
+
+	b := new(bytes.Buffer)
+	if err := json.NewEncoder(b).Encode(key); err != nil {
+		return err
+	}
+
+	return putObject(ctx, d.bucket, d.logger, keyPath+driver.KeyMetadataExt, b)
+}
+
+func (d *provider) SavePlatformBinary(ctx context.Context, namespace, registryName, version, pos, arch string, body io.Reader, overwrite bool) error {
+	ctx, span := d.tracer.Start(ctx, "SavePlatformBinary")
+	defer span.End()
+	if err := d.IsProviderVersionCreated(ctx, namespace, registryName, version); err != nil {
+		return err
+	}
+
+	platformPath := fmt.Sprintf("%s/%s/%s/versions/%s/%s-%s", driver.ProviderRootPath, namespace, registryName, version, pos, arch)
+
+	// The metadata of the previous binary is stale, which is saved again with the digests of this binary.
+	// Without overwrite, the metadata is left to the existing binary.
+	if overwrite {
+		if err := deleteObject(ctx, d.bucket, fmt.Sprintf("%s/%s", platformPath, driver.PlatformMetadataFilename)); err != nil {
+			return err
+		}
+	}
+
+	binaryPath := fmt.Sprintf("%s/terraform-provider-%s_%s_%s_%s.zip", platformPath, registryName, version, pos, arch)
+	return saveArtifact(ctx, d.bucket, d.logger, binaryPath, body, overwrite)
+}
+
+func (d *provider) SavePlatformMetadata(ctx context.Context, namespace, registryName, version, pos, arch string, metadata *driver.ProviderPlatformMetadata) error {
+	ctx, span := d.tracer.Start(ctx, "SavePlatformMetadata")
+	defer span.End()
+	platformPath := fmt.Sprintf("%s/%s/%s/versions/%s/%s-%s", driver.ProviderRootPath, namespace, registryName, version, pos, arch)
+	if err := isPrefixCreated(ctx, d.bucket, platformPath+"/", driver.ErrProviderPlatformNotExist); err != nil {
+		return err
+	}
+
+	metadataPath := fmt.Sprintf("%s/%s", platformPath, driver.PlatformMetadataFilename)
+	b := new(bytes.Buffer)
+	if err := json.NewEncoder(b).Encode(metadata); err != nil {
+		return err
+	}
+
+	return putObject(ctx, d.bucket, d.logger, metadataPath, b)
+}
+
+func (d *provider) SaveSHASUMs(ctx context.Context, namespace, registryName, version string, body io.Reader, overwrite bool) error {
+	ctx, span := d.tracer.Start(ctx, "SaveSHASUMs")
+	defer span.End()
+	if err := d.IsProviderVersionCreated(ctx, namespace, registryName, version); err != nil {
+		return err
+	}
+
+	sumsPath := fmt.Sprintf("%s/%s/%s/versions/%s/terraform-provider-%s_%s_SHA256SUMS", driver.ProviderRootPath, namespace, registryName, version, registryName, version)
+	return saveArtifact(ctx, d.bucket, d.logger, sumsPath, body, overwrite)
+}
+
+func (d *provider) SaveSHASUMsSig(ctx context.Context, namespace, registryName, version string, body io.Reader, overwrite bool) error {
+	ctx, span := d.tracer.Start(ctx, "SaveSHASUMsSig")
+	defer span.End()
+	if err := d.IsProviderVersionCreated(ctx, namespace, registryName, version); err != nil {
+		return err
+	}
+
+	sigPath := fmt.Sprintf("%s/%s/%s/versions/%s/terraform-provider-%s_%s_SHA256SUMS.sig", driver.ProviderRootPath, namespace, registryName, version, registryName, version)
+	return saveArtifact(ctx, d.bucket, d.logger, sigPath, body, overwrite)
+}
+
+// SaveSigningKey saves the signing key only if not exist, so that the key saved first is used
+func (d *provider) SaveSigningKey(ctx context.Context, namespace string, key []byte) error {
+	ctx, span := d.tracer.Start(ctx, "SaveSigningKey")
+	defer span.End()
+	keyPath := fmt.Sprintf("%s/%s/%s", driver.SigningRootPath, namespace, driver.SigningKeyFilename)
+	return putObjectIfNotExist(ctx, d.bucket, d.logger, keyPath, bytes.NewReader(key))
+}
+
+func (d *provider) SaveVersionMetadata(ctx context.Context, namespace, registryName, version string, metadata *driver.ProviderVersionMetadata) error {
+	ctx, span := d.tracer.Start(ctx, "SaveVersionMetadata")
+	defer span.End()
+	metadataPath := fmt.Sprintf("%s/%s/%s/versions/%s/%s", driver.ProviderRootPath, namespace, registryName, version, driver.VersionMetadataFilename)
+	if err := d.IsProviderVersionCreated(ctx, namespace, registryName, version); err != nil {
+		return err
+	}
+
+	b := new(bytes.Buffer)
+	if err := json.NewEncoder(b).Encode(metadata); err != nil {
+		return err
+	}
+
+	return putObject(ctx, d.bucket, d.logger, metadataPath, b)
+}
@@ -0,0 +1,151 @@
+package gcs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/kerraform/kegistry/internal/driver"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/api/iterator"
+)
+
+type token struct {
+	bucket *storage.BucketHandle
+	logger *zap.Logger
+	tracer trace.Tracer
+}
+
+var _ driver.Token = (*token)(nil)
+
+// errTokenLastUsedNotExist is returned for the token which has never been used
+var errTokenLastUsedNotExist = errors.New("token last used time not exist")
+
+func (d *token) DeleteToken(ctx context.Context, id string) error {
+	ctx, span := d.tracer.Start(ctx, "DeleteToken")
+	defer span.End()
+	tokenPath := fmt.Sprintf("%s/%s.json", driver.TokenRootPath, id)
+	if err := isObjectCreated(ctx, d.bucket, tokenPath, driver.ErrTokenNotExist); err != nil {
+		return err
+	}
+
+	if err := deleteObject(ctx, d.bucket, tokenPath); err != nil {
+		return err
+	}
+
+	lastUsedPath := fmt.Sprintf("%s/%s%s", driver.TokenRootPath, id, driver.TokenLastUsedExt)
+	if err := deleteObject(ctx, d.bucket, lastUsedPath); err != nil {
+		return err
+	}
+
+	d.logger.Debug("deleted token from google cloud storage", zap.String("key", tokenPath))
+	return nil
+}
+
+func (d *token) GetToken(ctx context.Context, id string) (*driver.APIToken, error) {
+	ctx, span := d.tracer.Start(ctx, "GetToken")
+	defer span.End()
+	tokenPath := fmt.Sprintf("%s/%s.json", driver.TokenRootPath, id)
+	return d.getToken(ctx, tokenPath)
+}
+
+func (d *token) ListTokens(ctx context.Context) ([]*driver.APIToken, error) {
+	ctx, span := d.tracer.Start(ctx, "ListTokens")
+	defer span.End()
+
+	ts := []*driver.APIToken{}
+	it := d.bucket.Objects(ctx, &storage.Query{
+		Prefix: driver.TokenRootPath + "/",
+	})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if filepath.Ext(attrs.Name) != ".json" {
+			continue
+		}
+
+		t, err := d.getToken(ctx, attrs.Name)
+		if err != nil {
+			return nil, err
+		}
+		ts = append(ts, t)
+	}
+
+	d.logger.Debug("list tokens", zap.Int("count", len(ts)))
+	return ts, nil
+}
+
+func (d *token) SaveToken(ctx context.Context, t *driver.APIToken) error {
+	ctx, span := d.tracer.Start(ctx, "SaveToken")
+	defer span.End()
+	tokenPath := fmt.Sprintf("%s/%s.json", driver.TokenRootPath, t.ID)
+
+	b := new(bytes.Buffer)
+	if err := json.NewEncoder(b).Encode(t); err != nil {
+		return err
+	}
+
+	return putObject(ctx, d.bucket, d.logger, tokenPath, b)
+}
+
+func (d *token) getToken(ctx context.Context, key string) (*driver.APIToken, error) {
+	rc, err := getObject(ctx, d.bucket, key, driver.ErrTokenNotExist)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var t driver.APIToken
+	if err := json.NewDecoder(rc).Decode(&t); err != nil {
+		return nil, err
+	}
+
+	lr, err := getObject(ctx, d.bucket, strings.TrimSuffix(key, ".json")+driver.TokenLastUsedExt, errTokenLastUsedNotExist)
+	if err != nil {
+		if errors.Is(err, errTokenLastUsedNotExist) {
+			return &t, nil
+		}
+
+		return nil, err
+	}
+	defer lr.Close()
+
+	var lastUsedAt time.Time
+	if err := json.NewDecoder(lr).Decode(&lastUsedAt); err != nil {
+		return nil, err
+	}
+	t.LastUsedAt = &lastUsedAt
+
+	return &t, nil
+}
+
+func (d *token) SaveTokenLastUsed(ctx context.Context, id string, lastUsedAt time.Time) error {
+	ctx, span := d.tracer.Start(ctx, "SaveTokenLastUsed")
+	defer span.End()
+	tokenPath := fmt.Sprintf("%s/%s.json", driver.TokenRootPath, id)
+	if err := isObjectCreated(ctx, d.bucket, tokenPath, driver.ErrTokenNotExist); err != nil {
+		return err
+	}
+
+	b := new(bytes.Buffer)
+	if err := json.NewEncoder(b).Encode(lastUsedAt); err != nil {
+		return err
+	}
+
+	lastUsedPath := fmt.Sprintf("%s/%s%s", driver.TokenRootPath, id, driver.TokenLastUsedExt)
+	return putObject(ctx, d.bucket, d.logger, lastUsedPath, b)
+}
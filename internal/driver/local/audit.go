@@ -0,0 +1,96 @@
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/kerraform/kegistry/internal/driver"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type audit struct {
+	logger   *zap.Logger
+	rootPath string
+	tracer   trace.Tracer
+}
+
+var _ driver.Audit = (*audit)(nil)
+
+func (d *audit) ListAuditEvents(ctx context.Context, namespace string) ([]*driver.AuditEvent, error) {
+	_, span := d.tracer.Start(ctx, "ListAuditEvents")
+	defer span.End()
+	auditPath := fmt.Sprintf("%s/%s/%s", d.rootPath, driver.AuditRootPath, namespace)
+	fs, err := ioutil.ReadDir(auditPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*driver.AuditEvent{}, nil
+		}
+
+		return nil, err
+	}
+
+	names := []string{}
+	for _, f := range fs {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+		names = append(names, f.Name())
+	}
+	sort.Strings(names)
+
+	es := make([]*driver.AuditEvent, 0, len(names))
+	for _, name := range names {
+		b, err := ioutil.ReadFile(fmt.Sprintf("%s/%s", auditPath, name))
+		if err != nil {
+			return nil, err
+		}
+
+		var e driver.AuditEvent
+		if err := json.Unmarshal(b, &e); err != nil {
+			return nil, err
+		}
+		es = append(es, &e)
+	}
+
+	d.logger.Debug("list audit events", zap.String("namespace", namespace), zap.Int("count", len(es)))
+	return es, nil
+}
+
+func (d *audit) SaveAuditEvent(ctx context.Context, event *driver.AuditEvent) error {
+	_, span := d.tracer.Start(ctx, "SaveAuditEvent")
+	defer span.End()
+	auditPath := fmt.Sprintf("%s/%s/%s", d.rootPath, driver.AuditRootPath, event.Resource.Namespace)
+	if err := os.MkdirAll(auditPath, 0700); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	eventPath := fmt.Sprintf("%s/%s", auditPath, driver.AuditEventFilename(event))
+	// Never overwrite the existing event
+	f, err := os.OpenFile(eventPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return driver.ErrAuditEventExists
+		}
+
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(b); err != nil {
+		return err
+	}
+
+	d.logger.Debug("saved audit event", zap.String("path", eventPath))
+	return nil
+}
@@ -1,6 +1,9 @@
 package local
 
 import (
+	"io"
+	"os"
+
 	"github.com/kerraform/kegistry/internal/driver"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
@@ -13,6 +16,12 @@ type DriverConfig struct {
 }
 
 func NewDriver(cfg *DriverConfig) *driver.Driver {
+	audit := &audit{
+		logger:   cfg.Logger,
+		rootPath: cfg.RootPath,
+		tracer:   cfg.Tracer,
+	}
+
 	module := &module{
 		logger:   cfg.Logger,
 		rootPath: cfg.RootPath,
@@ -25,8 +34,45 @@ func NewDriver(cfg *DriverConfig) *driver.Driver {
 		tracer:   cfg.Tracer,
 	}
 
+	token := &token{
+		logger:   cfg.Logger,
+		rootPath: cfg.RootPath,
+		tracer:   cfg.Tracer,
+	}
+
 	return &driver.Driver{
+		Audit:    audit,
 		Module:   module,
 		Provider: provider,
+		Token:    token,
 	}
 }
+
+// saveArtifact saves the artifact to the file, which is only created unless overwrite, otherwise returns driver.ErrArtifactExists.
+// The file partially created is removed, so that the failed upload can be retried.
+func saveArtifact(filepath string, body io.Reader, overwrite bool) error {
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	}
+
+	f, err := os.OpenFile(filepath, flag, 0666)
+	if err != nil {
+		if os.IsExist(err) {
+			return driver.ErrArtifactExists
+		}
+
+		return err
+	}
+
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		if !overwrite {
+			os.Remove(filepath)
+		}
+
+		return err
+	}
+
+	return f.Close()
+}
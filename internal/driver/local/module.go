@@ -1,7 +1,9 @@
 package local
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -23,6 +25,10 @@ var _ driver.Module = (*module)(nil)
 func (d *module) CreateModule(ctx context.Context, namespace, provider, name string) error {
 	_, span := d.tracer.Start(ctx, "CreateModule")
 	defer span.End()
+	if err := driver.ValidatePathSegments(namespace, provider, name); err != nil {
+		return err
+	}
+
 	moduleRootPath := fmt.Sprintf("%s/modules/%s/%s/%s", d.rootPath, namespace, provider, name)
 	if err := os.MkdirAll(moduleRootPath, 0700); err != nil {
 		return err
@@ -31,9 +37,13 @@ func (d *module) CreateModule(ctx context.Context, namespace, provider, name str
 	return nil
 }
 
-func (d *module) CreateVersion(ctx context.Context, namespace, provider, name, version string) (*driver.CreateModuleVersionResult, error) {
+func (d *module) CreateVersion(ctx context.Context, namespace, provider, name, version string, overwrite bool) (*driver.CreateModuleVersionResult, error) {
 	_, span := d.tracer.Start(ctx, "CreateVersion")
 	defer span.End()
+	if err := driver.ValidatePathSegments(namespace, provider, name, version); err != nil {
+		return nil, err
+	}
+
 	versionRootPath := fmt.Sprintf("%s/modules/%s/%s/%s/versions/%s", d.rootPath, namespace, provider, name, version)
 	if err := os.MkdirAll(versionRootPath, 0700); err != nil {
 		return nil, err
@@ -45,6 +55,44 @@ func (d *module) CreateVersion(ctx context.Context, namespace, provider, name, v
 	}, nil
 }
 
+func (d *module) DeleteModule(ctx context.Context, namespace, provider, name string) error {
+	_, span := d.tracer.Start(ctx, "DeleteModule")
+	defer span.End()
+	moduleRootPath := fmt.Sprintf("%s/%s/%s/%s/%s", d.rootPath, driver.ModuleRootPath, namespace, provider, name)
+	if _, err := os.Stat(moduleRootPath); err != nil {
+		if os.IsNotExist(err) {
+			return driver.ErrModuleNotExist
+		}
+
+		return err
+	}
+
+	if err := os.RemoveAll(moduleRootPath); err != nil {
+		return err
+	}
+	d.logger.Debug("deleted module path", zap.String("path", moduleRootPath))
+	return nil
+}
+
+func (d *module) DeleteVersion(ctx context.Context, namespace, provider, name, version string) error {
+	_, span := d.tracer.Start(ctx, "DeleteVersion")
+	defer span.End()
+	versionRootPath := fmt.Sprintf("%s/%s/%s/%s/%s/versions/%s", d.rootPath, driver.ModuleRootPath, namespace, provider, name, version)
+	if _, err := os.Stat(versionRootPath); err != nil {
+		if os.IsNotExist(err) {
+			return driver.ErrModuleVersionNotExist
+		}
+
+		return err
+	}
+
+	if err := os.RemoveAll(versionRootPath); err != nil {
+		return err
+	}
+	d.logger.Debug("deleted module version path", zap.String("path", versionRootPath))
+	return nil
+}
+
 func (d *module) GetDownloadURL(ctx context.Context, namespace, provider, name, version string) (string, error) {
 	_, span := d.tracer.Start(ctx, "GetDownloadURL")
 	defer span.End()
@@ -55,16 +103,82 @@ func (d *module) GetModule(ctx context.Context, namespace, provider, name, versi
 	_, span := d.tracer.Start(ctx, "GetModule")
 	defer span.End()
 	packagePath := fmt.Sprintf("%s/modules/%s/%s/%s/versions/%s/terraform-%s-%s-%s.tar.gz", d.rootPath, namespace, provider, name, version, provider, name, version)
-	return os.Open(packagePath)
+	f, err := os.Open(packagePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, driver.ErrModulePackageNotExist
+		}
+
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// GetVersionMetadata returns the metadata of the version, which is empty if nothing is saved for the version
+func (d *module) GetVersionMetadata(ctx context.Context, namespace, provider, name, version string) (*driver.ModuleVersionMetadata, error) {
+	_, span := d.tracer.Start(ctx, "GetVersionMetadata")
+	defer span.End()
+	versionRootPath := fmt.Sprintf("%s/%s/%s/%s/%s/versions/%s", d.rootPath, driver.ModuleRootPath, namespace, provider, name, version)
+	if _, err := os.Stat(versionRootPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil, driver.ErrModuleVersionNotExist
+		}
+
+		return nil, err
+	}
+
+	metadata := &driver.ModuleVersionMetadata{}
+	b, err := ioutil.ReadFile(fmt.Sprintf("%s/%s", versionRootPath, driver.VersionMetadataFilename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return metadata, nil
+		}
+
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, metadata); err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+func (d *module) IsPackageUploaded(ctx context.Context, namespace, provider, name, version string) error {
+	_, span := d.tracer.Start(ctx, "IsPackageUploaded")
+	defer span.End()
+	packagePath := fmt.Sprintf("%s/modules/%s/%s/%s/versions/%s/terraform-%s-%s-%s.tar.gz", d.rootPath, namespace, provider, name, version, provider, name, version)
+	if _, err := os.Stat(packagePath); err != nil {
+		if os.IsNotExist(err) {
+			return driver.ErrModulePackageNotExist
+		}
+
+		return err
+	}
+
+	return nil
 }
 
 func (d *module) ListAvailableVersions(ctx context.Context, namespace, provider, name string) ([]string, error) {
 	_, span := d.tracer.Start(ctx, "ListAvailableVersions")
 	defer span.End()
-	modulePath := fmt.Sprintf("%s/%s/%s/%s/%s/versions", d.rootPath, driver.ModuleRootPath, namespace, provider, name)
+	moduleRootPath := fmt.Sprintf("%s/%s/%s/%s/%s", d.rootPath, driver.ModuleRootPath, namespace, provider, name)
+	modulePath := fmt.Sprintf("%s/versions", moduleRootPath)
 	fs, err := ioutil.ReadDir(modulePath)
 	if err != nil {
-		return nil, err
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		// The module is created without any version yet
+		if _, err := os.Stat(moduleRootPath); err != nil {
+			if os.IsNotExist(err) {
+				return nil, driver.ErrModuleNotExist
+			}
+
+			return nil, err
+		}
 	}
 
 	vs := []string{}
@@ -79,20 +193,39 @@ func (d *module) ListAvailableVersions(ctx context.Context, namespace, provider,
 	return vs, nil
 }
 
-func (d *module) SavePackage(ctx context.Context, namespace, provider, name, version string, b io.Reader) error {
+func (d *module) SavePackage(ctx context.Context, namespace, provider, name, version string, b io.Reader, overwrite bool) error {
 	_, span := d.tracer.Start(ctx, "SavePackage")
 	defer span.End()
 	pkgPath := fmt.Sprintf("%s/modules/%s/%s/%s/versions/%s/terraform-%s-%s-%s.tar.gz", d.rootPath, namespace, provider, name, version, provider, name, version)
-	f, err := os.Create(pkgPath)
-	if err != nil {
+	if err := saveArtifact(pkgPath, b, overwrite); err != nil {
 		return err
 	}
 
-	if _, err := io.Copy(f, b); err != nil {
+	d.logger.Debug("create module version path", zap.String("path", pkgPath))
+	return nil
+}
+
+func (d *module) SaveVersionMetadata(ctx context.Context, namespace, provider, name, version string, metadata *driver.ModuleVersionMetadata) error {
+	_, span := d.tracer.Start(ctx, "SaveVersionMetadata")
+	defer span.End()
+	versionRootPath := fmt.Sprintf("%s/%s/%s/%s/%s/versions/%s", d.rootPath, driver.ModuleRootPath, namespace, provider, name, version)
+	if _, err := os.Stat(versionRootPath); err != nil {
+		if os.IsNotExist(err) {
+			return driver.ErrModuleVersionNotExist
+		}
+
 		return err
 	}
-	defer f.Close()
 
-	d.logger.Debug("create module version path", zap.String("path", pkgPath))
+	b := new(bytes.Buffer)
+	if err := json.NewEncoder(b).Encode(metadata); err != nil {
+		return err
+	}
+
+	filepath := fmt.Sprintf("%s/%s", versionRootPath, driver.VersionMetadataFilename)
+	if err := ioutil.WriteFile(filepath, b.Bytes(), 0600); err != nil {
+		return err
+	}
+	d.logger.Debug("save module version metadata", zap.String("path", filepath))
 	return nil
 }
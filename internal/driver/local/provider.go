@@ -3,8 +3,6 @@ package local
 import (
 	"bytes"
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -13,9 +11,6 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/ProtonMail/go-crypto/openpgp"
-	"github.com/ProtonMail/go-crypto/openpgp/armor"
-	"github.com/ProtonMail/go-crypto/openpgp/packet"
 	"github.com/kerraform/kegistry/internal/driver"
 	model "github.com/kerraform/kegistry/internal/model/provider"
 	"go.opentelemetry.io/otel/trace"
@@ -33,6 +28,10 @@ var _ driver.Provider = (*provider)(nil)
 func (d *provider) CreateProvider(ctx context.Context, namespace, registryName string) error {
 	_, span := d.tracer.Start(ctx, "CreateProvider")
 	defer span.End()
+	if err := driver.ValidatePathSegments(namespace, registryName); err != nil {
+		return err
+	}
+
 	registryRootPath := fmt.Sprintf("%s/%s/%s/%s", d.rootPath, driver.ProviderRootPath, namespace, registryName)
 	if err := os.MkdirAll(registryRootPath, 0700); err != nil {
 		return err
@@ -41,9 +40,13 @@ func (d *provider) CreateProvider(ctx context.Context, namespace, registryName s
 	return nil
 }
 
-func (d *provider) CreateProviderPlatform(ctx context.Context, namespace, registryName, version, pos, arch string) (*driver.CreateProviderPlatformResult, error) {
+func (d *provider) CreateProviderPlatform(ctx context.Context, namespace, registryName, version, pos, arch string, overwrite bool) (*driver.CreateProviderPlatformResult, error) {
 	_, span := d.tracer.Start(ctx, "CreateProviderPlatform")
 	defer span.End()
+	if err := driver.ValidatePathSegments(namespace, registryName, version, pos, arch); err != nil {
+		return nil, err
+	}
+
 	platformRootPath := fmt.Sprintf("%s/%s/%s/%s/versions/%s/%s-%s", d.rootPath, driver.ProviderRootPath, namespace, registryName, version, pos, arch)
 	if err := os.MkdirAll(platformRootPath, 0700); err != nil {
 		return nil, err
@@ -54,9 +57,13 @@ func (d *provider) CreateProviderPlatform(ctx context.Context, namespace, regist
 	}, nil
 }
 
-func (d *provider) CreateProviderVersion(ctx context.Context, namespace, registryName, version string) (*driver.CreateProviderVersionResult, error) {
+func (d *provider) CreateProviderVersion(ctx context.Context, namespace, registryName, version string, overwrite bool) (*driver.CreateProviderVersionResult, error) {
 	_, span := d.tracer.Start(ctx, "CreateProviderVersion")
 	defer span.End()
+	if err := driver.ValidatePathSegments(namespace, registryName, version); err != nil {
+		return nil, err
+	}
+
 	versionRootPath := fmt.Sprintf("%s/%s/%s/%s/versions/%s", d.rootPath, driver.ProviderRootPath, namespace, registryName, version)
 	if err := os.MkdirAll(versionRootPath, 0700); err != nil {
 		return nil, err
@@ -68,27 +75,174 @@ func (d *provider) CreateProviderVersion(ctx context.Context, namespace, registr
 	}, nil
 }
 
+func (d *provider) DeleteGPGKey(ctx context.Context, namespace, keyID string) error {
+	_, span := d.tracer.Start(ctx, "DeleteGPGKey")
+	defer span.End()
+	keyPath := fmt.Sprintf("%s/%s/%s/%s/%s", d.rootPath, driver.ProviderRootPath, namespace, driver.KeyDirname, keyID)
+	if err := os.Remove(keyPath); err != nil {
+		if os.IsNotExist(err) {
+			return driver.ErrProviderGPGKeyNotExist
+		}
+
+		return err
+	}
+
+	if err := os.Remove(keyPath + driver.KeyMetadataExt); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	d.logger.Debug("deleted gpg key", zap.String("filepath", keyPath))
+	return nil
+}
+
+func (d *provider) DeleteProviderPlatform(ctx context.Context, namespace, registryName, version, pos, arch string) error {
+	_, span := d.tracer.Start(ctx, "DeleteProviderPlatform")
+	defer span.End()
+	platformPath := fmt.Sprintf("%s/%s/%s/%s/versions/%s/%s-%s", d.rootPath, driver.ProviderRootPath, namespace, registryName, version, pos, arch)
+	if _, err := os.Stat(platformPath); err != nil {
+		if os.IsNotExist(err) {
+			return driver.ErrProviderPlatformNotExist
+		}
+
+		return err
+	}
+
+	if err := os.RemoveAll(platformPath); err != nil {
+		return err
+	}
+	d.logger.Debug("deleted platform path", zap.String("path", platformPath))
+	return nil
+}
+
+func (d *provider) DeleteProviderVersion(ctx context.Context, namespace, registryName, version string) error {
+	_, span := d.tracer.Start(ctx, "DeleteProviderVersion")
+	defer span.End()
+	versionRootPath := fmt.Sprintf("%s/%s/%s/%s/versions/%s", d.rootPath, driver.ProviderRootPath, namespace, registryName, version)
+	if _, err := os.Stat(versionRootPath); err != nil {
+		if os.IsNotExist(err) {
+			return driver.ErrProviderVersionNotExist
+		}
+
+		return err
+	}
+
+	if err := os.RemoveAll(versionRootPath); err != nil {
+		return err
+	}
+	d.logger.Debug("deleted version path", zap.String("path", versionRootPath))
+	return nil
+}
+
 func (d *provider) GetPlatformBinary(ctx context.Context, namespace, registryName, version, pos, arch string) (io.ReadCloser, error) {
 	_, span := d.tracer.Start(ctx, "GetPlatformBinary")
 	defer span.End()
 	platformPath := fmt.Sprintf("%s/%s/%s/%s/versions/%s/%s-%s", d.rootPath, driver.ProviderRootPath, namespace, registryName, version, pos, arch)
 	filename := fmt.Sprintf("terraform-provider-%s_%s_%s_%s.zip", registryName, version, pos, arch)
 	filepath := fmt.Sprintf("%s/%s", platformPath, filename)
-	return os.Open(filepath)
+	return open(filepath, driver.ErrProviderBinaryNotExist)
+}
+
+func (d *provider) GetPlatformMetadata(ctx context.Context, namespace, registryName, version, pos, arch string) (*driver.ProviderPlatformMetadata, error) {
+	_, span := d.tracer.Start(ctx, "GetPlatformMetadata")
+	defer span.End()
+	filepath := fmt.Sprintf("%s/%s/%s/%s/versions/%s/%s-%s/%s", d.rootPath, driver.ProviderRootPath, namespace, registryName, version, pos, arch, driver.PlatformMetadataFilename)
+	b, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, driver.ErrProviderPlatformMetadataNotExist
+		}
+
+		return nil, err
+	}
+
+	var metadata driver.ProviderPlatformMetadata
+	if err := json.Unmarshal(b, &metadata); err != nil {
+		return nil, err
+	}
+
+	return &metadata, nil
+}
+
+func (d *provider) GetGPGKey(ctx context.Context, namespace, keyID string) (*driver.GPGKey, error) {
+	_, span := d.tracer.Start(ctx, "GetGPGKey")
+	defer span.End()
+	keyPath := fmt.Sprintf("%s/%s/%s/%s/%s", d.rootPath, driver.ProviderRootPath, namespace, driver.KeyDirname, keyID)
+	b, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, driver.ErrProviderGPGKeyNotExist
+		}
+
+		return nil, err
+	}
+
+	key := &driver.GPGKey{
+		KeyID: keyID,
+	}
+
+	metadata, err := ioutil.ReadFile(keyPath + driver.KeyMetadataExt)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if err == nil {
+		if err := json.Unmarshal(metadata, key); err != nil {
+			return nil, err
+		}
+	}
+
+	key.ASCIIArmor = string(b)
+	return key, nil
 }
 
 func (d *provider) GetSHASums(ctx context.Context, namespace, registryName, version string) (io.ReadCloser, error) {
 	_, span := d.tracer.Start(ctx, "GetSHASums")
 	defer span.End()
 	filepath := fmt.Sprintf("%s/%s/%s/%s/versions/%s/terraform-provider-%s_%s_SHA256SUMS", d.rootPath, driver.ProviderRootPath, namespace, registryName, version, registryName, version)
-	return os.Open(filepath)
+	return open(filepath, driver.ErrProviderSHA256SUMSNotExist)
 }
 
 func (d *provider) GetSHASumsSig(ctx context.Context, namespace, registryName, version string) (io.ReadCloser, error) {
 	_, span := d.tracer.Start(ctx, "GetSHASumsSig")
 	defer span.End()
 	filepath := fmt.Sprintf("%s/%s/%s/%s/versions/%s/terraform-provider-%s_%s_SHA256SUMS.sig", d.rootPath, driver.ProviderRootPath, namespace, registryName, version, registryName, version)
-	return os.Open(filepath)
+	return open(filepath, driver.ErrProviderSHA256SUMSSigNotExist)
+}
+
+func (d *provider) GetSigningKey(ctx context.Context, namespace string) ([]byte, error) {
+	_, span := d.tracer.Start(ctx, "GetSigningKey")
+	defer span.End()
+	keyPath := fmt.Sprintf("%s/%s/%s/%s", d.rootPath, driver.SigningRootPath, namespace, driver.SigningKeyFilename)
+	b, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, driver.ErrSigningKeyNotExist
+		}
+
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (d *provider) GetVersionMetadata(ctx context.Context, namespace, registryName, version string) (*driver.ProviderVersionMetadata, error) {
+	_, span := d.tracer.Start(ctx, "GetVersionMetadata")
+	defer span.End()
+	filepath := fmt.Sprintf("%s/%s/%s/%s/versions/%s/%s", d.rootPath, driver.ProviderRootPath, namespace, registryName, version, driver.VersionMetadataFilename)
+	b, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, driver.ErrProviderVersionNotExist
+		}
+
+		return nil, err
+	}
+
+	var metadata driver.ProviderVersionMetadata
+	if err := json.Unmarshal(b, &metadata); err != nil {
+		return nil, err
+	}
+
+	return &metadata, nil
 }
 
 func (d *provider) FindPackage(ctx context.Context, namespace, registryName, version, pos, arch string) (*model.Package, error) {
@@ -107,71 +261,24 @@ func (d *provider) FindPackage(ctx context.Context, namespace, registryName, ver
 		return nil, err
 	}
 
-	b, err := ioutil.ReadFile(filepath)
+	platformMetadata, err := d.GetPlatformMetadata(ctx, namespace, registryName, version, pos, arch)
 	if err != nil {
 		return nil, err
 	}
 
-	sha256SumHex := sha256.Sum256(b)
-	sha256Sum := hex.EncodeToString(sha256SumHex[:])
-	d.logger.Debug("generated sha256sum for file", zap.String("sha256sum", sha256Sum))
-
-	keysPath := fmt.Sprintf("%s/%s/%s/%s", d.rootPath, driver.ProviderRootPath, namespace, driver.KeyDirname)
-	keys, err := ioutil.ReadDir(keysPath)
+	metadata, err := d.GetVersionMetadata(ctx, namespace, registryName, version)
 	if err != nil {
 		return nil, err
 	}
 
-	gpgKeys := []model.GPGPublicKey{}
-	for _, key := range keys {
-		d.logger.Debug("found keys",
-			zap.Int("count", len(keys)),
-			zap.String("path", key.Name()),
-		)
-		f, err := ioutil.ReadFile(fmt.Sprintf("%s/%s", keysPath, key.Name()))
-		if err != nil {
-			d.logger.Error("failed to read key file",
-				zap.String("file", key.Name()),
-				zap.Error(err),
-			)
-
-			continue
-		}
-
-		b := bytes.NewBuffer(f)
-		block, err := armor.Decode(b)
-		if err != nil {
-			d.logger.Error("failed to armor decode", zap.Error(err))
-			continue
-		}
-
-		if block.Type != openpgp.PublicKeyType {
-			d.logger.Error("skipping non public key type")
-			continue
-		}
-
-		reader := packet.NewReader(block.Body)
-		pkt, err := reader.Next()
-		if err != nil {
-			d.logger.Error("failed to packet read", zap.Error(err))
-			continue
-		}
-
-		k, ok := pkt.(*packet.PublicKey)
-		if !ok {
-			d.logger.Error("failed to cast packet to public key type")
-			continue
-		}
-
-		gpgKey := model.GPGPublicKey{
-			KeyID:      k.KeyIdString(),
-			ASCIIArmor: string(f),
-		}
-		gpgKeys = append(gpgKeys, gpgKey)
+	key, err := d.GetGPGKey(ctx, namespace, metadata.KeyID)
+	if err != nil {
+		return nil, err
 	}
+	d.logger.Debug("found signing key", zap.String("keyID", key.KeyID))
 
 	signingKeys := &model.SigningKeys{
-		GPGPublicKeys: gpgKeys,
+		GPGPublicKeys: []model.GPGPublicKey{key.PublicKey()},
 	}
 
 	pkg := &model.Package{
@@ -181,7 +288,7 @@ func (d *provider) FindPackage(ctx context.Context, namespace, registryName, ver
 		DownloadURL:   fmt.Sprintf("/registry/v1/providers/%s/%s/versions/%s/%s/%s/binary", namespace, registryName, version, pos, arch),
 		SHASumsURL:    fmt.Sprintf("/registry/v1/providers/%s/%s/versions/%s/shasums", namespace, registryName, version),
 		SHASumsSigURL: fmt.Sprintf("/registry/v1/providers/%s/%s/versions/%s/shasums-sig", namespace, registryName, version),
-		SHASum:        sha256Sum,
+		SHASum:        platformMetadata.SHA256,
 		SigningKeys:   signingKeys,
 	}
 
@@ -232,7 +339,7 @@ func (d *provider) IsProviderVersionCreated(ctx context.Context, namespace, regi
 	d.logger.Debug("checking provider version", zap.String("path", versionRootPath))
 	if _, err := os.Stat(versionRootPath); err != nil {
 		if os.IsNotExist(err) {
-			return driver.ErrProviderNotExist
+			return driver.ErrProviderVersionNotExist
 		}
 
 		return err
@@ -242,12 +349,19 @@ func (d *provider) IsProviderVersionCreated(ctx context.Context, namespace, regi
 }
 
 func (d *provider) ListAvailableVersions(ctx context.Context, namespace, registryName string) ([]model.AvailableVersion, error) {
-	_, span := d.tracer.Start(ctx, "ListAvailableVersions")
+	ctx, span := d.tracer.Start(ctx, "ListAvailableVersions")
 	defer span.End()
 	versionsRootPath := fmt.Sprintf("%s/%s/%s/%s/versions", d.rootPath, driver.ProviderRootPath, namespace, registryName)
 	versions, err := ioutil.ReadDir(versionsRootPath)
 	if err != nil {
-		return nil, err
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		// The provider is created without any version yet
+		if err := d.IsProviderCreated(ctx, namespace, registryName); err != nil {
+			return nil, err
+		}
 	}
 
 	d.logger.Debug("found versions", zap.String("path", versionsRootPath), zap.Int("count", len(versions)))
@@ -294,29 +408,92 @@ func (d *provider) ListAvailableVersions(ctx context.Context, namespace, registr
 	return vs, nil
 }
 
-func (d *provider) SaveGPGKey(ctx context.Context, namespace, keyID string, key []byte) error {
+func (d *provider) ListGPGKeys(ctx context.Context, namespace string) ([]*driver.GPGKey, error) {
+	ctx, span := d.tracer.Start(ctx, "ListGPGKeys")
+	defer span.End()
+	keyRootPath := fmt.Sprintf("%s/%s/%s/%s", d.rootPath, driver.ProviderRootPath, namespace, driver.KeyDirname)
+	files, err := ioutil.ReadDir(keyRootPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*driver.GPGKey{}, nil
+		}
+
+		return nil, err
+	}
+
+	keys := []*driver.GPGKey{}
+	for _, f := range files {
+		if f.IsDir() || strings.HasSuffix(f.Name(), driver.KeyMetadataExt) {
+			continue
+		}
+
+		key, err := d.GetGPGKey(ctx, namespace, f.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	d.logger.Debug("found gpg keys", zap.String("path", keyRootPath), zap.Int("count", len(keys)))
+	return keys, nil
+}
+
+func (d *provider) ListProviders(ctx context.Context, namespace string) ([]string, error) {
+	_, span := d.tracer.Start(ctx, "ListProviders")
+	defer span.End()
+	namespaceRootPath := fmt.Sprintf("%s/%s/%s", d.rootPath, driver.ProviderRootPath, namespace)
+	files, err := ioutil.ReadDir(namespaceRootPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+
+		return nil, err
+	}
+
+	providers := []string{}
+	for _, f := range files {
+		if !f.IsDir() || f.Name() == driver.KeyDirname {
+			continue
+		}
+
+		providers = append(providers, f.Name())
+	}
+
+	return providers, nil
+}
+
+func (d *provider) SaveGPGKey(ctx context.Context, namespace string, key *driver.GPGKey) error {
 	_, span := d.tracer.Start(ctx, "SaveGPGKey")
 	defer span.End()
+	if err := driver.ValidatePathSegments(namespace, key.KeyID); err != nil {
+		return err
+	}
+
 	keyRootPath := fmt.Sprintf("%s/%s/%s/%s", d.rootPath, driver.ProviderRootPath, namespace, driver.KeyDirname)
 	if err := os.MkdirAll(keyRootPath, 0700); err != nil {
 		return err
 	}
 
-	keyPath := fmt.Sprintf("%s/%s", keyRootPath, keyID)
-	f, err := os.Create(keyPath)
+	keyPath := fmt.Sprintf("%s/%s", keyRootPath, key.KeyID)
+	if err := ioutil.WriteFile(keyPath, []byte(key.ASCIIArmor), 0600); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(key)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
-	if _, err := f.Write(key); err != nil {
+	if err := ioutil.WriteFile(keyPath+driver.KeyMetadataExt, b, 0600); err != nil {
 		return err
 	}
 	d.logger.Debug("saved gpg key", zap.String("filepath", keyPath))
 	return nil
 }
 
-func (d *provider) SavePlatformBinary(ctx context.Context, namespace, registryName, version, pos, arch string, body io.Reader) error {
+func (d *provider) SavePlatformBinary(ctx context.Context, namespace, registryName, version, pos, arch string, body io.Reader, overwrite bool) error {
 	_, span := d.tracer.Start(ctx, "SavePlatformBinary")
 	defer span.End()
 	platformPath := fmt.Sprintf("%s/%s/%s/%s/versions/%s/%s-%s", d.rootPath, driver.ProviderRootPath, namespace, registryName, version, pos, arch)
@@ -324,20 +501,49 @@ func (d *provider) SavePlatformBinary(ctx context.Context, namespace, registryNa
 		return err
 	}
 
+	// The metadata of the previous binary is stale, which is saved again with the digests of this binary.
+	// Without overwrite, the metadata is left to the existing binary.
+	if overwrite {
+		if err := os.Remove(fmt.Sprintf("%s/%s", platformPath, driver.PlatformMetadataFilename)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
 	filepath := fmt.Sprintf("%s/terraform-provider-%s_%s_%s_%s.zip", platformPath, registryName, version, pos, arch)
+	err := saveArtifact(filepath, body, overwrite)
+	d.logger.Debug("save platform binary",
+		zap.String("path", filepath),
+	)
+	return err
+}
+
+func (d *provider) SavePlatformMetadata(ctx context.Context, namespace, registryName, version, pos, arch string, metadata *driver.ProviderPlatformMetadata) error {
+	_, span := d.tracer.Start(ctx, "SavePlatformMetadata")
+	defer span.End()
+	filepath := fmt.Sprintf("%s/%s/%s/%s/versions/%s/%s-%s/%s", d.rootPath, driver.ProviderRootPath, namespace, registryName, version, pos, arch, driver.PlatformMetadataFilename)
 	f, err := os.Create(filepath)
 	if err != nil {
+		if os.IsNotExist(err) {
+			return driver.ErrProviderPlatformNotExist
+		}
+
 		return err
 	}
+	defer f.Close()
 
-	_, err = io.Copy(f, body)
-	d.logger.Debug("save platform binary",
+	b := new(bytes.Buffer)
+	if err := json.NewEncoder(b).Encode(metadata); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(f, b)
+	d.logger.Debug("save platform metadata",
 		zap.String("path", filepath),
 	)
 	return err
 }
 
-func (d *provider) SaveSHASUMs(ctx context.Context, namespace, registryName, version string, body io.Reader) error {
+func (d *provider) SaveSHASUMs(ctx context.Context, namespace, registryName, version string, body io.Reader, overwrite bool) error {
 	_, span := d.tracer.Start(ctx, "SaveSHASUMs")
 	defer span.End()
 	versionRootPath := fmt.Sprintf("%s/%s/%s/%s/versions/%s", d.rootPath, driver.ProviderRootPath, namespace, registryName, version)
@@ -346,19 +552,14 @@ func (d *provider) SaveSHASUMs(ctx context.Context, namespace, registryName, ver
 	}
 
 	filepath := fmt.Sprintf("%s/terraform-provider-%s_%s_SHA256SUMS", versionRootPath, registryName, version)
-	f, err := os.Create(filepath)
-	if err != nil {
-		return err
-	}
-
-	_, err = io.Copy(f, body)
+	err := saveArtifact(filepath, body, overwrite)
 	d.logger.Debug("save shasums",
 		zap.String("path", filepath),
 	)
 	return err
 }
 
-func (d *provider) SaveSHASUMsSig(ctx context.Context, namespace, registryName, version string, body io.Reader) error {
+func (d *provider) SaveSHASUMsSig(ctx context.Context, namespace, registryName, version string, body io.Reader, overwrite bool) error {
 	_, span := d.tracer.Start(ctx, "SaveSHASUMsSig")
 	defer span.End()
 	versionRootPath := fmt.Sprintf("%s/%s/%s/%s/versions/%s", d.rootPath, driver.ProviderRootPath, namespace, registryName, version)
@@ -367,32 +568,55 @@ func (d *provider) SaveSHASUMsSig(ctx context.Context, namespace, registryName,
 	}
 
 	filepath := fmt.Sprintf("%s/terraform-provider-%s_%s_SHA256SUMS.sig", versionRootPath, registryName, version)
-	f, err := os.Create(filepath)
-	if err != nil {
-		return err
-	}
-
-	_, err = io.Copy(f, body)
+	err := saveArtifact(filepath, body, overwrite)
 	d.logger.Debug("save shasums signature",
 		zap.String("path", filepath),
 	)
 	return err
 }
 
-func (d *provider) SaveVersionMetadata(ctx context.Context, namespace, registryName, version, keyID string) error {
+// SaveSigningKey saves the signing key only if not exist, so that the key saved first is used
+func (d *provider) SaveSigningKey(ctx context.Context, namespace string, key []byte) error {
+	_, span := d.tracer.Start(ctx, "SaveSigningKey")
+	defer span.End()
+	keyRootPath := fmt.Sprintf("%s/%s/%s", d.rootPath, driver.SigningRootPath, namespace)
+	if err := os.MkdirAll(keyRootPath, 0700); err != nil {
+		return err
+	}
+
+	keyPath := fmt.Sprintf("%s/%s", keyRootPath, driver.SigningKeyFilename)
+	f, err := os.OpenFile(keyPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil
+		}
+
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(key); err != nil {
+		return err
+	}
+	d.logger.Debug("saved signing key", zap.String("filepath", keyPath))
+	return nil
+}
+
+func (d *provider) SaveVersionMetadata(ctx context.Context, namespace, registryName, version string, metadata *driver.ProviderVersionMetadata) error {
 	_, span := d.tracer.Start(ctx, "SaveVersionMetadata")
 	defer span.End()
 	filepath := fmt.Sprintf("%s/%s/%s/%s/versions/%s/%s", d.rootPath, driver.ProviderRootPath, namespace, registryName, version, driver.VersionMetadataFilename)
+	if err := d.IsProviderVersionCreated(ctx, namespace, registryName, version); err != nil {
+		return err
+	}
+
 	f, err := os.Create(filepath)
 	if err != nil {
 		return err
 	}
+	defer f.Close()
 
 	b := new(bytes.Buffer)
-	metadata := &driver.ProviderVersionMetadata{
-		KeyID: keyID,
-	}
-
 	if err := json.NewEncoder(b).Encode(metadata); err != nil {
 		return err
 	}
@@ -403,3 +627,17 @@ func (d *provider) SaveVersionMetadata(ctx context.Context, namespace, registryN
 	)
 	return err
 }
+
+// open opens the file, returning notExistErr if the file does not exist
+func open(filepath string, notExistErr error) (io.ReadCloser, error) {
+	f, err := os.Open(filepath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, notExistErr
+		}
+
+		return nil, err
+	}
+
+	return f, nil
+}
@@ -0,0 +1,160 @@
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kerraform/kegistry/internal/driver"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type token struct {
+	logger   *zap.Logger
+	rootPath string
+	tracer   trace.Tracer
+}
+
+var _ driver.Token = (*token)(nil)
+
+func (d *token) DeleteToken(ctx context.Context, id string) error {
+	_, span := d.tracer.Start(ctx, "DeleteToken")
+	defer span.End()
+	tokenPath := fmt.Sprintf("%s/%s/%s.json", d.rootPath, driver.TokenRootPath, id)
+	if err := os.Remove(tokenPath); err != nil {
+		if os.IsNotExist(err) {
+			return driver.ErrTokenNotExist
+		}
+
+		return err
+	}
+
+	lastUsedPath := fmt.Sprintf("%s/%s/%s%s", d.rootPath, driver.TokenRootPath, id, driver.TokenLastUsedExt)
+	if err := os.Remove(lastUsedPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	d.logger.Debug("deleted token", zap.String("path", tokenPath))
+	return nil
+}
+
+func (d *token) GetToken(ctx context.Context, id string) (*driver.APIToken, error) {
+	_, span := d.tracer.Start(ctx, "GetToken")
+	defer span.End()
+	tokenPath := fmt.Sprintf("%s/%s/%s.json", d.rootPath, driver.TokenRootPath, id)
+	b, err := ioutil.ReadFile(tokenPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, driver.ErrTokenNotExist
+		}
+
+		return nil, err
+	}
+
+	var t driver.APIToken
+	if err := json.Unmarshal(b, &t); err != nil {
+		return nil, err
+	}
+
+	lastUsedPath := fmt.Sprintf("%s/%s/%s%s", d.rootPath, driver.TokenRootPath, id, driver.TokenLastUsedExt)
+	b, err = ioutil.ReadFile(lastUsedPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &t, nil
+		}
+
+		return nil, err
+	}
+
+	var lastUsedAt time.Time
+	if err := json.Unmarshal(b, &lastUsedAt); err != nil {
+		return nil, err
+	}
+	t.LastUsedAt = &lastUsedAt
+
+	return &t, nil
+}
+
+func (d *token) ListTokens(ctx context.Context) ([]*driver.APIToken, error) {
+	ctx, span := d.tracer.Start(ctx, "ListTokens")
+	defer span.End()
+	tokenRootPath := fmt.Sprintf("%s/%s", d.rootPath, driver.TokenRootPath)
+	fs, err := ioutil.ReadDir(tokenRootPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*driver.APIToken{}, nil
+		}
+
+		return nil, err
+	}
+
+	ts := []*driver.APIToken{}
+	for _, f := range fs {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+
+		t, err := d.GetToken(ctx, strings.TrimSuffix(f.Name(), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		ts = append(ts, t)
+	}
+
+	d.logger.Debug("list tokens", zap.Int("count", len(ts)))
+	return ts, nil
+}
+
+func (d *token) SaveToken(ctx context.Context, t *driver.APIToken) error {
+	_, span := d.tracer.Start(ctx, "SaveToken")
+	defer span.End()
+	tokenRootPath := fmt.Sprintf("%s/%s", d.rootPath, driver.TokenRootPath)
+	if err := os.MkdirAll(tokenRootPath, 0700); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	tokenPath := fmt.Sprintf("%s/%s.json", tokenRootPath, t.ID)
+	if err := ioutil.WriteFile(tokenPath, b, 0600); err != nil {
+		return err
+	}
+
+	d.logger.Debug("saved token", zap.String("path", tokenPath))
+	return nil
+}
+
+func (d *token) SaveTokenLastUsed(ctx context.Context, id string, lastUsedAt time.Time) error {
+	_, span := d.tracer.Start(ctx, "SaveTokenLastUsed")
+	defer span.End()
+	tokenPath := fmt.Sprintf("%s/%s/%s.json", d.rootPath, driver.TokenRootPath, id)
+	if _, err := os.Stat(tokenPath); err != nil {
+		if os.IsNotExist(err) {
+			return driver.ErrTokenNotExist
+		}
+
+		return err
+	}
+
+	b, err := json.Marshal(lastUsedAt)
+	if err != nil {
+		return err
+	}
+
+	lastUsedPath := fmt.Sprintf("%s/%s/%s%s", d.rootPath, driver.TokenRootPath, id, driver.TokenLastUsedExt)
+	if err := ioutil.WriteFile(lastUsedPath, b, 0600); err != nil {
+		return err
+	}
+
+	d.logger.Debug("saved last used time of token", zap.String("path", lastUsedPath))
+	return nil
+}
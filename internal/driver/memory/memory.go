@@ -0,0 +1,248 @@
+package memory
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kerraform/kegistry/internal/driver"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type DriverConfig struct {
+	Logger *zap.Logger
+	Tracer trace.Tracer
+}
+
+// NewDriver returns the driver which keeps everything in memory, laid out the same as the local driver.
+// Everything is lost once the process exits, so that it is meant for the tests and the ephemeral environments.
+func NewDriver(cfg *DriverConfig) *driver.Driver {
+	store := newStore()
+
+	audit := &audit{
+		logger: cfg.Logger,
+		store:  store,
+		tracer: cfg.Tracer,
+	}
+
+	module := &module{
+		logger: cfg.Logger,
+		store:  store,
+		tracer: cfg.Tracer,
+	}
+
+	provider := &provider{
+		logger: cfg.Logger,
+		store:  store,
+		tracer: cfg.Tracer,
+	}
+
+	token := &token{
+		logger: cfg.Logger,
+		store:  store,
+		tracer: cfg.Tracer,
+	}
+
+	return &driver.Driver{
+		Audit:    audit,
+		Module:   module,
+		Provider: provider,
+		Token:    token,
+	}
+}
+
+// store is the file system in memory, which returns the same errors as the os package so that os.IsNotExist works
+type store struct {
+	mu    sync.RWMutex
+	dirs  map[string]bool
+	files map[string][]byte
+}
+
+// entry is the file or the directory listed by readDir
+type entry struct {
+	name string
+	dir  bool
+}
+
+func (e *entry) Name() string {
+	return e.name
+}
+
+func (e *entry) IsDir() bool {
+	return e.dir
+}
+
+func newStore() *store {
+	return &store{
+		dirs: map[string]bool{
+			".": true,
+		},
+		files: map[string][]byte{},
+	}
+}
+
+func (s *store) mkdirAll(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for p := path.Clean(name); p != "."; p = path.Dir(p) {
+		if _, ok := s.files[p]; ok {
+			return &fs.PathError{Op: "mkdir", Path: p, Err: fs.ErrExist}
+		}
+		s.dirs[p] = true
+	}
+
+	return nil
+}
+
+// stat reports whether the file or the directory exists
+func (s *store) stat(name string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p := path.Clean(name)
+	if _, ok := s.files[p]; ok || s.dirs[p] {
+		return nil
+	}
+
+	return &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+// readDir returns the entries of the directory sorted by the name, same as ioutil.ReadDir
+func (s *store) readDir(name string) ([]*entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	dir := path.Clean(name)
+	if !s.dirs[dir] {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	es := []*entry{}
+	for p := range s.dirs {
+		if p != dir && path.Dir(p) == dir {
+			es = append(es, &entry{name: path.Base(p), dir: true})
+		}
+	}
+
+	for p := range s.files {
+		if path.Dir(p) == dir {
+			es = append(es, &entry{name: path.Base(p)})
+		}
+	}
+
+	sort.Slice(es, func(i, j int) bool {
+		return es[i].name < es[j].name
+	})
+	return es, nil
+}
+
+func (s *store) readFile(name string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	b, ok := s.files[path.Clean(name)]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return b, nil
+}
+
+// writeFile creates or truncates the file, which fails if the parent directory does not exist
+func (s *store) writeFile(name string, b []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.write(name, b, false)
+}
+
+// createFile creates the file only if not exist, same as os.O_EXCL
+func (s *store) createFile(name string, b []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.write(name, b, true)
+}
+
+// saveArtifact saves the artifact, which is only created unless overwrite, otherwise returns driver.ErrArtifactExists
+func (s *store) saveArtifact(name string, b []byte, overwrite bool) error {
+	if overwrite {
+		return s.writeFile(name, b)
+	}
+
+	if err := s.createFile(name, b); err != nil {
+		if os.IsExist(err) {
+			return driver.ErrArtifactExists
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *store) write(name string, b []byte, excl bool) error {
+	p := path.Clean(name)
+	if !s.dirs[path.Dir(p)] {
+		return &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	if _, ok := s.files[p]; (ok && excl) || s.dirs[p] {
+		return &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	}
+
+	// Copy the bytes as the caller may reuse them
+	s.files[p] = append([]byte{}, b...)
+	return nil
+}
+
+// remove removes the file or the empty directory
+func (s *store) remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := path.Clean(name)
+	if _, ok := s.files[p]; ok {
+		delete(s.files, p)
+		return nil
+	}
+
+	if !s.dirs[p] {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+
+	prefix := p + "/"
+	for f := range s.files {
+		if strings.HasPrefix(f, prefix) {
+			return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrExist}
+		}
+	}
+
+	for d := range s.dirs {
+		if strings.HasPrefix(d, prefix) {
+			return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrExist}
+		}
+	}
+
+	delete(s.dirs, p)
+	return nil
+}
+
+// removeAll removes the file or the directory with everything in it, which succeeds even if not exist
+func (s *store) removeAll(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := path.Clean(name)
+	prefix := p + "/"
+	for f := range s.files {
+		if f == p || strings.HasPrefix(f, prefix) {
+			delete(s.files, f)
+		}
+	}
+
+	for d := range s.dirs {
+		if d == p || strings.HasPrefix(d, prefix) {
+			delete(s.dirs, d)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,18 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/kerraform/kegistry/internal/driver/drivertest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+func TestDriver(t *testing.T) {
+	d := NewDriver(&DriverConfig{
+		Logger: zap.NewNop(),
+		Tracer: trace.NewNoopTracerProvider().Tracer(""),
+	})
+
+	drivertest.TestDriver(t, d)
+}
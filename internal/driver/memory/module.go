@@ -0,0 +1,249 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kerraform/kegistry/internal/driver"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type module struct {
+	logger *zap.Logger
+	store  *store
+	tracer trace.Tracer
+}
+
+var _ driver.Module = (*module)(nil)
+
+func (d *module) CreateModule(ctx context.Context, namespace, provider, name string) error {
+	_, span := d.tracer.Start(ctx, "CreateModule")
+	defer span.End()
+	if err := driver.ValidatePathSegments(namespace, provider, name); err != nil {
+		return err
+	}
+
+	moduleRootPath := fmt.Sprintf("%s/%s/%s/%s", driver.ModuleRootPath, namespace, provider, name)
+	if err := d.store.mkdirAll(moduleRootPath); err != nil {
+		return err
+	}
+	d.logger.Debug("create module path", zap.String("path", moduleRootPath))
+	return nil
+}
+
+func (d *module) CreateVersion(ctx context.Context, namespace, provider, name, version string, overwrite bool) (*driver.CreateModuleVersionResult, error) {
+	_, span := d.tracer.Start(ctx, "CreateVersion")
+	defer span.End()
+	if err := driver.ValidatePathSegments(namespace, provider, name, version); err != nil {
+		return nil, err
+	}
+
+	versionRootPath := fmt.Sprintf("%s/%s/%s/%s/versions/%s", driver.ModuleRootPath, namespace, provider, name, version)
+	if err := d.store.mkdirAll(versionRootPath); err != nil {
+		return nil, err
+	}
+	upload := fmt.Sprintf("/registry/v1/modules/%s/%s/%s/versions/%s", namespace, name, provider, version)
+	d.logger.Debug("create module version path", zap.String("path", versionRootPath), zap.String("uploadPath", upload))
+	return &driver.CreateModuleVersionResult{
+		Upload: upload,
+	}, nil
+}
+
+func (d *module) DeleteModule(ctx context.Context, namespace, provider, name string) error {
+	_, span := d.tracer.Start(ctx, "DeleteModule")
+	defer span.End()
+	moduleRootPath := fmt.Sprintf("%s/%s/%s/%s", driver.ModuleRootPath, namespace, provider, name)
+	if err := d.store.stat(moduleRootPath); err != nil {
+		if os.IsNotExist(err) {
+			return driver.ErrModuleNotExist
+		}
+
+		return err
+	}
+
+	if err := d.store.removeAll(moduleRootPath); err != nil {
+		return err
+	}
+	d.logger.Debug("deleted module path", zap.String("path", moduleRootPath))
+	return nil
+}
+
+func (d *module) DeleteVersion(ctx context.Context, namespace, provider, name, version string) error {
+	_, span := d.tracer.Start(ctx, "DeleteVersion")
+	defer span.End()
+	versionRootPath := fmt.Sprintf("%s/%s/%s/%s/versions/%s", driver.ModuleRootPath, namespace, provider, name, version)
+	if err := d.store.stat(versionRootPath); err != nil {
+		if os.IsNotExist(err) {
+			return driver.ErrModuleVersionNotExist
+		}
+
+		return err
+	}
+
+	if err := d.store.removeAll(versionRootPath); err != nil {
+		return err
+	}
+	d.logger.Debug("deleted module version path", zap.String("path", versionRootPath))
+	return nil
+}
+
+func (d *module) GetDownloadURL(ctx context.Context, namespace, provider, name, version string) (string, error) {
+	_, span := d.tracer.Start(ctx, "GetDownloadURL")
+	defer span.End()
+	return fmt.Sprintf("/registry/v1/modules/%s/%s/%s/%s/terraform-%s-%s-%v.tar.gz", namespace, provider, name, version, provider, name, version), nil
+}
+
+// GetModule returns the read end of the pipe streaming the package, so that nothing is written to the disk
+func (d *module) GetModule(ctx context.Context, namespace, provider, name, version string) (*os.File, error) {
+	_, span := d.tracer.Start(ctx, "GetModule")
+	defer span.End()
+	packagePath := fmt.Sprintf("%s/%s/%s/%s/versions/%s/terraform-%s-%s-%s.tar.gz", driver.ModuleRootPath, namespace, provider, name, version, provider, name, version)
+	b, err := d.store.readFile(packagePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, driver.ErrModulePackageNotExist
+		}
+
+		return nil, err
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	// The write fails once the reader is closed without reading everything
+	go func() {
+		defer w.Close()
+		if _, err := w.Write(b); err != nil {
+			d.logger.Debug("stopped streaming module package", zap.String("path", packagePath), zap.Error(err))
+		}
+	}()
+
+	return r, nil
+}
+
+// GetVersionMetadata returns the metadata of the version, which is empty if nothing is saved for the version
+func (d *module) GetVersionMetadata(ctx context.Context, namespace, provider, name, version string) (*driver.ModuleVersionMetadata, error) {
+	_, span := d.tracer.Start(ctx, "GetVersionMetadata")
+	defer span.End()
+	versionRootPath := fmt.Sprintf("%s/%s/%s/%s/versions/%s", driver.ModuleRootPath, namespace, provider, name, version)
+	if err := d.store.stat(versionRootPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil, driver.ErrModuleVersionNotExist
+		}
+
+		return nil, err
+	}
+
+	metadata := &driver.ModuleVersionMetadata{}
+	b, err := d.store.readFile(fmt.Sprintf("%s/%s", versionRootPath, driver.VersionMetadataFilename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return metadata, nil
+		}
+
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, metadata); err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+func (d *module) IsPackageUploaded(ctx context.Context, namespace, provider, name, version string) error {
+	_, span := d.tracer.Start(ctx, "IsPackageUploaded")
+	defer span.End()
+	packagePath := fmt.Sprintf("%s/%s/%s/%s/versions/%s/terraform-%s-%s-%s.tar.gz", driver.ModuleRootPath, namespace, provider, name, version, provider, name, version)
+	if err := d.store.stat(packagePath); err != nil {
+		if os.IsNotExist(err) {
+			return driver.ErrModulePackageNotExist
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (d *module) ListAvailableVersions(ctx context.Context, namespace, provider, name string) ([]string, error) {
+	_, span := d.tracer.Start(ctx, "ListAvailableVersions")
+	defer span.End()
+	moduleRootPath := fmt.Sprintf("%s/%s/%s/%s", driver.ModuleRootPath, namespace, provider, name)
+	modulePath := fmt.Sprintf("%s/versions", moduleRootPath)
+	fs, err := d.store.readDir(modulePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		// The module is created without any version yet
+		if err := d.store.stat(moduleRootPath); err != nil {
+			if os.IsNotExist(err) {
+				return nil, driver.ErrModuleNotExist
+			}
+
+			return nil, err
+		}
+	}
+
+	vs := []string{}
+	for _, f := range fs {
+		vs = append(vs, f.Name())
+	}
+
+	d.logger.Debug("list available versions",
+		zap.Int("count", len(vs)),
+		zap.String("path", modulePath),
+	)
+	return vs, nil
+}
+
+func (d *module) SavePackage(ctx context.Context, namespace, provider, name, version string, body io.Reader, overwrite bool) error {
+	_, span := d.tracer.Start(ctx, "SavePackage")
+	defer span.End()
+	pkgPath := fmt.Sprintf("%s/%s/%s/%s/versions/%s/terraform-%s-%s-%s.tar.gz", driver.ModuleRootPath, namespace, provider, name, version, provider, name, version)
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	if err := d.store.saveArtifact(pkgPath, b, overwrite); err != nil {
+		return err
+	}
+
+	d.logger.Debug("create module version path", zap.String("path", pkgPath))
+	return nil
+}
+
+func (d *module) SaveVersionMetadata(ctx context.Context, namespace, provider, name, version string, metadata *driver.ModuleVersionMetadata) error {
+	_, span := d.tracer.Start(ctx, "SaveVersionMetadata")
+	defer span.End()
+	versionRootPath := fmt.Sprintf("%s/%s/%s/%s/versions/%s", driver.ModuleRootPath, namespace, provider, name, version)
+	if err := d.store.stat(versionRootPath); err != nil {
+		if os.IsNotExist(err) {
+			return driver.ErrModuleVersionNotExist
+		}
+
+		return err
+	}
+
+	b := new(bytes.Buffer)
+	if err := json.NewEncoder(b).Encode(metadata); err != nil {
+		return err
+	}
+
+	metadataPath := fmt.Sprintf("%s/%s", versionRootPath, driver.VersionMetadataFilename)
+	if err := d.store.writeFile(metadataPath, b.Bytes()); err != nil {
+		return err
+	}
+	d.logger.Debug("save module version metadata", zap.String("path", metadataPath))
+	return nil
+}
@@ -0,0 +1,648 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kerraform/kegistry/internal/driver"
+	model "github.com/kerraform/kegistry/internal/model/provider"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type provider struct {
+	logger *zap.Logger
+	store  *store
+	tracer trace.Tracer
+}
+
+var _ driver.Provider = (*provider)(nil)
+
+func (d *provider) CreateProvider(ctx context.Context, namespace, registryName string) error {
+	_, span := d.tracer.Start(ctx, "CreateProvider")
+	defer span.End()
+	if err := driver.ValidatePathSegments(namespace, registryName); err != nil {
+		return err
+	}
+
+	registryRootPath := fmt.Sprintf("%s/%s/%s", driver.ProviderRootPath, namespace, registryName)
+	if err := d.store.mkdirAll(registryRootPath); err != nil {
+		return err
+	}
+	d.logger.Debug("created registry path", zap.String("path", registryRootPath))
+	return nil
+}
+
+func (d *provider) CreateProviderPlatform(ctx context.Context, namespace, registryName, version, pos, arch string, overwrite bool) (*driver.CreateProviderPlatformResult, error) {
+	_, span := d.tracer.Start(ctx, "CreateProviderPlatform")
+	defer span.End()
+	if err := driver.ValidatePathSegments(namespace, registryName, version, pos, arch); err != nil {
+		return nil, err
+	}
+
+	platformRootPath := fmt.Sprintf("%s/%s/%s/versions/%s/%s-%s", driver.ProviderRootPath, namespace, registryName, version, pos, arch)
+	if err := d.store.mkdirAll(platformRootPath); err != nil {
+		return nil, err
+	}
+	d.logger.Debug("created platform path", zap.String("path", platformRootPath))
+	return &driver.CreateProviderPlatformResult{
+		ProviderBinaryUploads: fmt.Sprintf("/registry/v1/providers/%s/%s/versions/%s/%s/%s/binary", namespace, registryName, version, pos, arch),
+	}, nil
+}
+
+func (d *provider) CreateProviderVersion(ctx context.Context, namespace, registryName, version string, overwrite bool) (*driver.CreateProviderVersionResult, error) {
+	_, span := d.tracer.Start(ctx, "CreateProviderVersion")
+	defer span.End()
+	if err := driver.ValidatePathSegments(namespace, registryName, version); err != nil {
+		return nil, err
+	}
+
+	versionRootPath := fmt.Sprintf("%s/%s/%s/versions/%s", driver.ProviderRootPath, namespace, registryName, version)
+	if err := d.store.mkdirAll(versionRootPath); err != nil {
+		return nil, err
+	}
+	d.logger.Debug("created version path", zap.String("path", versionRootPath))
+	return &driver.CreateProviderVersionResult{
+		SHASumsUpload:    fmt.Sprintf("/registry/v1/providers/%s/%s/versions/%s/shasums", namespace, registryName, version),
+		SHASumsSigUpload: fmt.Sprintf("/registry/v1/providers/%s/%s/versions/%s/shasums-sig", namespace, registryName, version),
+	}, nil
+}
+
+func (d *provider) DeleteGPGKey(ctx context.Context, namespace, keyID string) error {
+	_, span := d.tracer.Start(ctx, "DeleteGPGKey")
+	defer span.End()
+	keyPath := fmt.Sprintf("%s/%s/%s/%s", driver.ProviderRootPath, namespace, driver.KeyDirname, keyID)
+	if err := d.store.remove(keyPath); err != nil {
+		if os.IsNotExist(err) {
+			return driver.ErrProviderGPGKeyNotExist
+		}
+
+		return err
+	}
+
+	if err := d.store.remove(keyPath + driver.KeyMetadataExt); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	d.logger.Debug("deleted gpg key", zap.String("filepath", keyPath))
+	return nil
+}
+
+func (d *provider) DeleteProviderPlatform(ctx context.Context, namespace, registryName, version, pos, arch string) error {
+	_, span := d.tracer.Start(ctx, "DeleteProviderPlatform")
+	defer span.End()
+	platformPath := fmt.Sprintf("%s/%s/%s/versions/%s/%s-%s", driver.ProviderRootPath, namespace, registryName, version, pos, arch)
+	if err := d.store.stat(platformPath); err != nil {
+		if os.IsNotExist(err) {
+			return driver.ErrProviderPlatformNotExist
+		}
+
+		return err
+	}
+
+	if err := d.store.removeAll(platformPath); err != nil {
+		return err
+	}
+	d.logger.Debug("deleted platform path", zap.String("path", platformPath))
+	return nil
+}
+
+func (d *provider) DeleteProviderVersion(ctx context.Context, namespace, registryName, version string) error {
+	_, span := d.tracer.Start(ctx, "DeleteProviderVersion")
+	defer span.End()
+	versionRootPath := fmt.Sprintf("%s/%s/%s/versions/%s", driver.ProviderRootPath, namespace, registryName, version)
+	if err := d.store.stat(versionRootPath); err != nil {
+		if os.IsNotExist(err) {
+			return driver.ErrProviderVersionNotExist
+		}
+
+		return err
+	}
+
+	if err := d.store.removeAll(versionRootPath); err != nil {
+		return err
+	}
+	d.logger.Debug("deleted version path", zap.String("path", versionRootPath))
+	return nil
+}
+
+func (d *provider) GetPlatformBinary(ctx context.Context, namespace, registryName, version, pos, arch string) (io.ReadCloser, error) {
+	_, span := d.tracer.Start(ctx, "GetPlatformBinary")
+	defer span.End()
+	platformPath := fmt.Sprintf("%s/%s/%s/versions/%s/%s-%s", driver.ProviderRootPath, namespace, registryName, version, pos, arch)
+	filename := fmt.Sprintf("terraform-provider-%s_%s_%s_%s.zip", registryName, version, pos, arch)
+	filepath := fmt.Sprintf("%s/%s", platformPath, filename)
+	return d.open(filepath, driver.ErrProviderBinaryNotExist)
+}
+
+func (d *provider) GetPlatformMetadata(ctx context.Context, namespace, registryName, version, pos, arch string) (*driver.ProviderPlatformMetadata, error) {
+	_, span := d.tracer.Start(ctx, "GetPlatformMetadata")
+	defer span.End()
+	filepath := fmt.Sprintf("%s/%s/%s/versions/%s/%s-%s/%s", driver.ProviderRootPath, namespace, registryName, version, pos, arch, driver.PlatformMetadataFilename)
+	b, err := d.store.readFile(filepath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, driver.ErrProviderPlatformMetadataNotExist
+		}
+
+		return nil, err
+	}
+
+	var metadata driver.ProviderPlatformMetadata
+	if err := json.Unmarshal(b, &metadata); err != nil {
+		return nil, err
+	}
+
+	return &metadata, nil
+}
+
+func (d *provider) GetGPGKey(ctx context.Context, namespace, keyID string) (*driver.GPGKey, error) {
+	_, span := d.tracer.Start(ctx, "GetGPGKey")
+	defer span.End()
+	keyPath := fmt.Sprintf("%s/%s/%s/%s", driver.ProviderRootPath, namespace, driver.KeyDirname, keyID)
+	b, err := d.store.readFile(keyPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, driver.ErrProviderGPGKeyNotExist
+		}
+
+		return nil, err
+	}
+
+	key := &driver.GPGKey{
+		KeyID: keyID,
+	}
+
+	metadata, err := d.store.readFile(keyPath + driver.KeyMetadataExt)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if err == nil {
+		if err := json.Unmarshal(metadata, key); err != nil {
+			return nil, err
+		}
+	}
+
+	key.ASCIIArmor = string(b)
+	return key, nil
+}
+
+func (d *provider) GetSHASums(ctx context.Context, namespace, registryName, version string) (io.ReadCloser, error) {
+	_, span := d.tracer.Start(ctx, "GetSHASums")
+	defer span.End()
+	filepath := fmt.Sprintf("%s/%s/%s/versions/%s/terraform-provider-%s_%s_SHA256SUMS", driver.ProviderRootPath, namespace, registryName, version, registryName, version)
+	return d.open(filepath, driver.ErrProviderSHA256SUMSNotExist)
+}
+
+func (d *provider) GetSHASumsSig(ctx context.Context, namespace, registryName, version string) (io.ReadCloser, error) {
+	_, span := d.tracer.Start(ctx, "GetSHASumsSig")
+	defer span.End()
+	filepath := fmt.Sprintf("%s/%s/%s/versions/%s/terraform-provider-%s_%s_SHA256SUMS.sig", driver.ProviderRootPath, namespace, registryName, version, registryName, version)
+	return d.open(filepath, driver.ErrProviderSHA256SUMSSigNotExist)
+}
+
+func (d *provider) GetSigningKey(ctx context.Context, namespace string) ([]byte, error) {
+	_, span := d.tracer.Start(ctx, "GetSigningKey")
+	defer span.End()
+	keyPath := fmt.Sprintf("%s/%s/%s", driver.SigningRootPath, namespace, driver.SigningKeyFilename)
+	b, err := d.store.readFile(keyPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, driver.ErrSigningKeyNotExist
+		}
+
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (d *provider) GetVersionMetadata(ctx context.Context, namespace, registryName, version string) (*driver.ProviderVersionMetadata, error) {
+	_, span := d.tracer.Start(ctx, "GetVersionMetadata")
+	defer span.End()
+	filepath := fmt.Sprintf("%s/%s/%s/versions/%s/%s", driver.ProviderRootPath, namespace, registryName, version, driver.VersionMetadataFilename)
+	b, err := d.store.readFile(filepath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, driver.ErrProviderVersionNotExist
+		}
+
+		return nil, err
+	}
+
+	var metadata driver.ProviderVersionMetadata
+	if err := json.Unmarshal(b, &metadata); err != nil {
+		return nil, err
+	}
+
+	return &metadata, nil
+}
+
+func (d *provider) FindPackage(ctx context.Context, namespace, registryName, version, pos, arch string) (*model.Package, error) {
+	_, span := d.tracer.Start(ctx, "FindPackage")
+	defer span.End()
+	platformPath := fmt.Sprintf("%s/%s/%s/versions/%s/%s-%s", driver.ProviderRootPath, namespace, registryName, version, pos, arch)
+	filename := fmt.Sprintf("terraform-provider-%s_%s_%s_%s.zip", registryName, version, pos, arch)
+	filepath := fmt.Sprintf("%s/%s", platformPath, filename)
+
+	if err := d.store.stat(filepath); err != nil {
+		if os.IsNotExist(err) {
+			d.logger.Error("file not exist", zap.String("filepath", filepath))
+			return nil, driver.ErrProviderBinaryNotExist
+		}
+
+		return nil, err
+	}
+
+	platformMetadata, err := d.GetPlatformMetadata(ctx, namespace, registryName, version, pos, arch)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := d.GetVersionMetadata(ctx, namespace, registryName, version)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := d.GetGPGKey(ctx, namespace, metadata.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	d.logger.Debug("found signing key", zap.String("keyID", key.KeyID))
+
+	signingKeys := &model.SigningKeys{
+		GPGPublicKeys: []model.GPGPublicKey{key.PublicKey()},
+	}
+
+	pkg := &model.Package{
+		OS:            pos,
+		Arch:          arch,
+		Filename:      filename,
+		DownloadURL:   fmt.Sprintf("/registry/v1/providers/%s/%s/versions/%s/%s/%s/binary", namespace, registryName, version, pos, arch),
+		SHASumsURL:    fmt.Sprintf("/registry/v1/providers/%s/%s/versions/%s/shasums", namespace, registryName, version),
+		SHASumsSigURL: fmt.Sprintf("/registry/v1/providers/%s/%s/versions/%s/shasums-sig", namespace, registryName, version),
+		SHASum:        platformMetadata.SHA256,
+		SigningKeys:   signingKeys,
+	}
+
+	return pkg, nil
+}
+
+func (d *provider) IsGPGKeyCreated(ctx context.Context, namespace, registryName string) error {
+	_, span := d.tracer.Start(ctx, "IsGPGKeyCreated")
+	defer span.End()
+	keyRootPath := fmt.Sprintf("%s/%s/%s", driver.ProviderRootPath, namespace, driver.KeyDirname)
+	keys, err := d.store.readDir(keyRootPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return driver.ErrProviderGPGKeyNotExist
+		}
+
+		return err
+	}
+
+	if len(keys) == 0 {
+		return driver.ErrProviderGPGKeyNotExist
+	}
+
+	d.logger.Debug("found gpg keys", zap.Int("count", len(keys)))
+	return nil
+}
+
+func (d *provider) IsProviderCreated(ctx context.Context, namespace, registryName string) error {
+	_, span := d.tracer.Start(ctx, "IsProviderCreated")
+	defer span.End()
+	registryRootPath := fmt.Sprintf("%s/%s/%s", driver.ProviderRootPath, namespace, registryName)
+	d.logger.Debug("checking provider", zap.String("path", registryRootPath))
+	if err := d.store.stat(registryRootPath); err != nil {
+		if os.IsNotExist(err) {
+			return driver.ErrProviderNotExist
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (d *provider) IsProviderVersionCreated(ctx context.Context, namespace, registryName, version string) error {
+	_, span := d.tracer.Start(ctx, "IsProviderVersionCreated")
+	defer span.End()
+	versionRootPath := fmt.Sprintf("%s/%s/%s/versions/%s", driver.ProviderRootPath, namespace, registryName, version)
+	d.logger.Debug("checking provider version", zap.String("path", versionRootPath))
+	if err := d.store.stat(versionRootPath); err != nil {
+		if os.IsNotExist(err) {
+			return driver.ErrProviderVersionNotExist
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (d *provider) ListAvailableVersions(ctx context.Context, namespace, registryName string) ([]model.AvailableVersion, error) {
+	ctx, span := d.tracer.Start(ctx, "ListAvailableVersions")
+	defer span.End()
+	versionsRootPath := fmt.Sprintf("%s/%s/%s/versions", driver.ProviderRootPath, namespace, registryName)
+	versions, err := d.store.readDir(versionsRootPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		// The provider is created without any version yet
+		if err := d.IsProviderCreated(ctx, namespace, registryName); err != nil {
+			return nil, err
+		}
+	}
+
+	d.logger.Debug("found versions", zap.String("path", versionsRootPath), zap.Int("count", len(versions)))
+
+	vs := make([]model.AvailableVersion, len(versions))
+	for i, version := range versions {
+		if !version.IsDir() {
+			d.logger.Debug("skip file in version directory", zap.String("version", version.Name()))
+			continue
+		}
+		//
+		platforms, err := d.store.readDir(fmt.Sprintf("%s/%s", versionsRootPath, version.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		d.logger.Debug("found platforms for this version",
+			zap.Int("count", len(platforms)),
+			zap.String("version", version.Name()),
+		)
+
+		pfs := []model.AvailableVersionPlatform{}
+
+		for _, platform := range platforms {
+			if !platform.IsDir() {
+				d.logger.Debug("skip file in platform directory", zap.String("platform", platform.Name()))
+				continue
+			}
+
+			e := strings.Split(platform.Name(), "-")
+
+			pfs = append(pfs, model.AvailableVersionPlatform{
+				OS:   e[0],
+				Arch: e[1],
+			})
+		}
+
+		vs[i] = model.AvailableVersion{
+			Version:   version.Name(),
+			Platforms: pfs,
+		}
+	}
+
+	return vs, nil
+}
+
+func (d *provider) ListGPGKeys(ctx context.Context, namespace string) ([]*driver.GPGKey, error) {
+	ctx, span := d.tracer.Start(ctx, "ListGPGKeys")
+	defer span.End()
+	keyRootPath := fmt.Sprintf("%s/%s/%s", driver.ProviderRootPath, namespace, driver.KeyDirname)
+	files, err := d.store.readDir(keyRootPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*driver.GPGKey{}, nil
+		}
+
+		return nil, err
+	}
+
+	keys := []*driver.GPGKey{}
+	for _, f := range files {
+		if f.IsDir() || strings.HasSuffix(f.Name(), driver.KeyMetadataExt) {
+			continue
+		}
+
+		key, err := d.GetGPGKey(ctx, namespace, f.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	d.logger.Debug("found gpg keys", zap.String("path", keyRootPath), zap.Int("count", len(keys)))
+	return keys, nil
+}
+
+func (d *provider) ListProviders(ctx context.Context, namespace string) ([]string, error) {
+	_, span := d.tracer.Start(ctx, "ListProviders")
+	defer span.End()
+	namespaceRootPath := fmt.Sprintf("%s/%s", driver.ProviderRootPath, namespace)
+	files, err := d.store.readDir(namespaceRootPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+
+		return nil, err
+	}
+
+	providers := []string{}
+	for _, f := range files {
+		if !f.IsDir() || f.Name() == driver.KeyDirname {
+			continue
+		}
+
+		providers = append(providers, f.Name())
+	}
+
+	return providers, nil
+}
+
+func (d *provider) SaveGPGKey(ctx context.Context, namespace string, key *driver.GPGKey) error {
+	_, span := d.tracer.Start(ctx, "SaveGPGKey")
+	defer span.End()
+	if err := driver.ValidatePathSegments(namespace, key.KeyID); err != nil {
+		return err
+	}
+
+	keyRootPath := fmt.Sprintf("%s/%s/%s", driver.ProviderRootPath, namespace, driver.KeyDirname)
+	if err := d.store.mkdirAll(keyRootPath); err != nil {
+		return err
+	}
+
+	keyPath := fmt.Sprintf("%s/%s", keyRootPath, key.KeyID)
+	if err := d.store.writeFile(keyPath, []byte(key.ASCIIArmor)); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+
+	if err := d.store.writeFile(keyPath+driver.KeyMetadataExt, b); err != nil {
+		return err
+	}
+	d.logger.Debug("saved gpg key", zap.String("filepath", keyPath))
+	return nil
+}
+
+func (d *provider) SavePlatformBinary(ctx context.Context, namespace, registryName, version, pos, arch string, body io.Reader, overwrite bool) error {
+	_, span := d.tracer.Start(ctx, "SavePlatformBinary")
+	defer span.End()
+	platformPath := fmt.Sprintf("%s/%s/%s/versions/%s/%s-%s", driver.ProviderRootPath, namespace, registryName, version, pos, arch)
+	if err := d.IsProviderVersionCreated(ctx, namespace, registryName, version); err != nil {
+		return err
+	}
+
+	// The metadata of the previous binary is stale, which is saved again with the digests of this binary.
+	// Without overwrite, the metadata is left to the existing binary.
+	if overwrite {
+		if err := d.store.remove(fmt.Sprintf("%s/%s", platformPath, driver.PlatformMetadataFilename)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	filepath := fmt.Sprintf("%s/terraform-provider-%s_%s_%s_%s.zip", platformPath, registryName, version, pos, arch)
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	if err := d.store.saveArtifact(filepath, b, overwrite); err != nil {
+		return err
+	}
+	d.logger.Debug("save platform binary",
+		zap.String("path", filepath),
+	)
+	return nil
+}
+
+func (d *provider) SavePlatformMetadata(ctx context.Context, namespace, registryName, version, pos, arch string, metadata *driver.ProviderPlatformMetadata) error {
+	_, span := d.tracer.Start(ctx, "SavePlatformMetadata")
+	defer span.End()
+	filepath := fmt.Sprintf("%s/%s/%s/versions/%s/%s-%s/%s", driver.ProviderRootPath, namespace, registryName, version, pos, arch, driver.PlatformMetadataFilename)
+	b := new(bytes.Buffer)
+	if err := json.NewEncoder(b).Encode(metadata); err != nil {
+		return err
+	}
+
+	if err := d.store.writeFile(filepath, b.Bytes()); err != nil {
+		if os.IsNotExist(err) {
+			return driver.ErrProviderPlatformNotExist
+		}
+
+		return err
+	}
+	d.logger.Debug("save platform metadata",
+		zap.String("path", filepath),
+	)
+	return nil
+}
+
+func (d *provider) SaveSHASUMs(ctx context.Context, namespace, registryName, version string, body io.Reader, overwrite bool) error {
+	_, span := d.tracer.Start(ctx, "SaveSHASUMs")
+	defer span.End()
+	versionRootPath := fmt.Sprintf("%s/%s/%s/versions/%s", driver.ProviderRootPath, namespace, registryName, version)
+	if err := d.IsProviderVersionCreated(ctx, namespace, registryName, version); err != nil {
+		return err
+	}
+
+	filepath := fmt.Sprintf("%s/terraform-provider-%s_%s_SHA256SUMS", versionRootPath, registryName, version)
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	if err := d.store.saveArtifact(filepath, b, overwrite); err != nil {
+		return err
+	}
+	d.logger.Debug("save shasums",
+		zap.String("path", filepath),
+	)
+	return nil
+}
+
+func (d *provider) SaveSHASUMsSig(ctx context.Context, namespace, registryName, version string, body io.Reader, overwrite bool) error {
+	_, span := d.tracer.Start(ctx, "SaveSHASUMsSig")
+	defer span.End()
+	versionRootPath := fmt.Sprintf("%s/%s/%s/versions/%s", driver.ProviderRootPath, namespace, registryName, version)
+	if err := d.IsProviderVersionCreated(ctx, namespace, registryName, version); err != nil {
+		return err
+	}
+
+	filepath := fmt.Sprintf("%s/terraform-provider-%s_%s_SHA256SUMS.sig", versionRootPath, registryName, version)
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	if err := d.store.saveArtifact(filepath, b, overwrite); err != nil {
+		return err
+	}
+	d.logger.Debug("save shasums signature",
+		zap.String("path", filepath),
+	)
+	return nil
+}
+
+// SaveSigningKey saves the signing key only if not exist, so that the key saved first is used
+func (d *provider) SaveSigningKey(ctx context.Context, namespace string, key []byte) error {
+	_, span := d.tracer.Start(ctx, "SaveSigningKey")
+	defer span.End()
+	keyRootPath := fmt.Sprintf("%s/%s", driver.SigningRootPath, namespace)
+	if err := d.store.mkdirAll(keyRootPath); err != nil {
+		return err
+	}
+
+	keyPath := fmt.Sprintf("%s/%s", keyRootPath, driver.SigningKeyFilename)
+	if err := d.store.createFile(keyPath, key); err != nil {
+		if os.IsExist(err) {
+			return nil
+		}
+
+		return err
+	}
+	d.logger.Debug("saved signing key", zap.String("filepath", keyPath))
+	return nil
+}
+
+func (d *provider) SaveVersionMetadata(ctx context.Context, namespace, registryName, version string, metadata *driver.ProviderVersionMetadata) error {
+	_, span := d.tracer.Start(ctx, "SaveVersionMetadata")
+	defer span.End()
+	filepath := fmt.Sprintf("%s/%s/%s/versions/%s/%s", driver.ProviderRootPath, namespace, registryName, version, driver.VersionMetadataFilename)
+	if err := d.IsProviderVersionCreated(ctx, namespace, registryName, version); err != nil {
+		return err
+	}
+
+	b := new(bytes.Buffer)
+	if err := json.NewEncoder(b).Encode(metadata); err != nil {
+		return err
+	}
+
+	if err := d.store.writeFile(filepath, b.Bytes()); err != nil {
+		return err
+	}
+	d.logger.Debug("save version metadata",
+		zap.String("path", filepath),
+	)
+	return nil
+}
+
+// open returns the reader of the file, or notExistErr if the file does not exist
+func (d *provider) open(filepath string, notExistErr error) (io.ReadCloser, error) {
+	b, err := d.store.readFile(filepath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, notExistErr
+		}
+
+		return nil, err
+	}
+
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
@@ -0,0 +1,157 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kerraform/kegistry/internal/driver"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type token struct {
+	logger *zap.Logger
+	store  *store
+	tracer trace.Tracer
+}
+
+var _ driver.Token = (*token)(nil)
+
+func (d *token) DeleteToken(ctx context.Context, id string) error {
+	_, span := d.tracer.Start(ctx, "DeleteToken")
+	defer span.End()
+	tokenPath := fmt.Sprintf("%s/%s.json", driver.TokenRootPath, id)
+	if err := d.store.remove(tokenPath); err != nil {
+		if os.IsNotExist(err) {
+			return driver.ErrTokenNotExist
+		}
+
+		return err
+	}
+
+	lastUsedPath := fmt.Sprintf("%s/%s%s", driver.TokenRootPath, id, driver.TokenLastUsedExt)
+	if err := d.store.remove(lastUsedPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	d.logger.Debug("deleted token", zap.String("path", tokenPath))
+	return nil
+}
+
+func (d *token) GetToken(ctx context.Context, id string) (*driver.APIToken, error) {
+	_, span := d.tracer.Start(ctx, "GetToken")
+	defer span.End()
+	tokenPath := fmt.Sprintf("%s/%s.json", driver.TokenRootPath, id)
+	b, err := d.store.readFile(tokenPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, driver.ErrTokenNotExist
+		}
+
+		return nil, err
+	}
+
+	var t driver.APIToken
+	if err := json.Unmarshal(b, &t); err != nil {
+		return nil, err
+	}
+
+	lastUsedPath := fmt.Sprintf("%s/%s%s", driver.TokenRootPath, id, driver.TokenLastUsedExt)
+	b, err = d.store.readFile(lastUsedPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &t, nil
+		}
+
+		return nil, err
+	}
+
+	var lastUsedAt time.Time
+	if err := json.Unmarshal(b, &lastUsedAt); err != nil {
+		return nil, err
+	}
+	t.LastUsedAt = &lastUsedAt
+
+	return &t, nil
+}
+
+func (d *token) ListTokens(ctx context.Context) ([]*driver.APIToken, error) {
+	ctx, span := d.tracer.Start(ctx, "ListTokens")
+	defer span.End()
+	fs, err := d.store.readDir(driver.TokenRootPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*driver.APIToken{}, nil
+		}
+
+		return nil, err
+	}
+
+	ts := []*driver.APIToken{}
+	for _, f := range fs {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+
+		t, err := d.GetToken(ctx, strings.TrimSuffix(f.Name(), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		ts = append(ts, t)
+	}
+
+	d.logger.Debug("list tokens", zap.Int("count", len(ts)))
+	return ts, nil
+}
+
+func (d *token) SaveToken(ctx context.Context, t *driver.APIToken) error {
+	_, span := d.tracer.Start(ctx, "SaveToken")
+	defer span.End()
+	if err := d.store.mkdirAll(driver.TokenRootPath); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	tokenPath := fmt.Sprintf("%s/%s.json", driver.TokenRootPath, t.ID)
+	if err := d.store.writeFile(tokenPath, b); err != nil {
+		return err
+	}
+
+	d.logger.Debug("saved token", zap.String("path", tokenPath))
+	return nil
+}
+
+func (d *token) SaveTokenLastUsed(ctx context.Context, id string, lastUsedAt time.Time) error {
+	_, span := d.tracer.Start(ctx, "SaveTokenLastUsed")
+	defer span.End()
+	tokenPath := fmt.Sprintf("%s/%s.json", driver.TokenRootPath, id)
+	if err := d.store.stat(tokenPath); err != nil {
+		if os.IsNotExist(err) {
+			return driver.ErrTokenNotExist
+		}
+
+		return err
+	}
+
+	b, err := json.Marshal(lastUsedAt)
+	if err != nil {
+		return err
+	}
+
+	lastUsedPath := fmt.Sprintf("%s/%s%s", driver.TokenRootPath, id, driver.TokenLastUsedExt)
+	if err := d.store.writeFile(lastUsedPath, b); err != nil {
+		return err
+	}
+
+	d.logger.Debug("saved last used time of token", zap.String("path", lastUsedPath))
+	return nil
+}
@@ -0,0 +1,95 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/kerraform/kegistry/internal/driver"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type audit struct {
+	bucket string
+	logger *zap.Logger
+	s3     *s3.Client
+	tracer trace.Tracer
+}
+
+var _ driver.Audit = (*audit)(nil)
+
+func (d *audit) ListAuditEvents(ctx context.Context, namespace string) ([]*driver.AuditEvent, error) {
+	ctx, span := d.tracer.Start(ctx, "ListAuditEvents")
+	defer span.End()
+	prefix := fmt.Sprintf("%s/%s/", driver.AuditRootPath, namespace)
+
+	keys := []string{}
+	p := s3.NewListObjectsV2Paginator(d.s3, &s3.ListObjectsV2Input{
+		Bucket: aws.String(d.bucket),
+		Prefix: aws.String(prefix),
+	})
+	for p.HasMorePages() {
+		resp, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, obj := range resp.Contents {
+			if filepath.Ext(*obj.Key) != ".json" {
+				continue
+			}
+			keys = append(keys, *obj.Key)
+		}
+	}
+	sort.Strings(keys)
+
+	es := make([]*driver.AuditEvent, 0, len(keys))
+	for _, key := range keys {
+		resp, err := d.s3.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(d.bucket),
+			Key:    aws.String(key),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var e driver.AuditEvent
+		err = json.NewDecoder(resp.Body).Decode(&e)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		es = append(es, &e)
+	}
+
+	d.logger.Debug("list audit events", zap.String("namespace", namespace), zap.Int("count", len(es)))
+	return es, nil
+}
+
+func (d *audit) SaveAuditEvent(ctx context.Context, event *driver.AuditEvent) error {
+	ctx, span := d.tracer.Start(ctx, "SaveAuditEvent")
+	defer span.End()
+	eventPath := fmt.Sprintf("%s/%s/%s", driver.AuditRootPath, event.Resource.Namespace, driver.AuditEventFilename(event))
+
+	b := new(bytes.Buffer)
+	if err := json.NewEncoder(b).Encode(event); err != nil {
+		return err
+	}
+
+	if err := createObject(ctx, d.s3, d.bucket, d.logger, eventPath, bytes.NewReader(b.Bytes())); err != nil {
+		if errors.Is(err, errObjectExists) {
+			return driver.ErrAuditEventExists
+		}
+
+		return err
+	}
+
+	return nil
+}
@@ -3,22 +3,16 @@ package s3
 import (
 	"bytes"
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"path/filepath"
+	"sort"
+	"strings"
 
-	"github.com/ProtonMail/go-crypto/openpgp"
-	"github.com/ProtonMail/go-crypto/openpgp/armor"
-	"github.com/ProtonMail/go-crypto/openpgp/packet"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
-	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
-	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/aws/smithy-go"
 	"github.com/kerraform/kegistry/internal/driver"
 	model "github.com/kerraform/kegistry/internal/model/provider"
@@ -45,44 +39,59 @@ func (r *endpointResolver) ResolveEndpoint(service, region string, options ...in
 }
 
 func (d *provider) CreateProvider(ctx context.Context, namespace, registryName string) error {
-	return nil
+	ctx, span := d.tracer.Start(ctx, "CreateProvider")
+	defer span.End()
+	if err := driver.ValidatePathSegments(namespace, registryName); err != nil {
+		return err
+	}
+
+	registryRootPath := fmt.Sprintf("%s/%s/%s", driver.ProviderRootPath, namespace, registryName)
+	return createDir(ctx, d.s3, d.bucket, d.logger, registryRootPath)
 }
 
-func (d *provider) CreateProviderPlatform(ctx context.Context, namespace, registryName, version, pos, arch string) (*driver.CreateProviderPlatformResult, error) {
+func (d *provider) CreateProviderPlatform(ctx context.Context, namespace, registryName, version, pos, arch string, overwrite bool) (*driver.CreateProviderPlatformResult, error) {
 	ctx, span := d.tracer.Start(ctx, "CreateProviderPlatform")
 	defer span.End()
-	binaryPath := fmt.Sprintf("%s/%s/%s/versions/%s/%s-%s/terraform-provider-%s_%s_%s_%s.zip", driver.ProviderRootPath, namespace, registryName, version, pos, arch, registryName, version, pos, arch)
-	psc := s3.NewPresignClient(d.s3)
-	binaryUploadURL, err := psc.PresignPutObject(ctx, &s3.PutObjectInput{
-		Bucket: aws.String(d.bucket),
-		Key:    aws.String(binaryPath),
-	})
+	if err := driver.ValidatePathSegments(namespace, registryName, version, pos, arch); err != nil {
+		return nil, err
+	}
+
+	platformPath := fmt.Sprintf("%s/%s/%s/versions/%s/%s-%s", driver.ProviderRootPath, namespace, registryName, version, pos, arch)
+	if err := createDir(ctx, d.s3, d.bucket, d.logger, platformPath); err != nil {
+		return nil, err
+	}
+
+	binaryPath := fmt.Sprintf("%s/terraform-provider-%s_%s_%s_%s.zip", platformPath, registryName, version, pos, arch)
+	binaryUploadURL, headers, err := presignPutObject(ctx, d.s3, d.bucket, binaryPath, overwrite)
 	if err != nil {
 		return nil, err
 	}
 
 	return &driver.CreateProviderPlatformResult{
-		ProviderBinaryUploads: binaryUploadURL.URL,
+		ProviderBinaryUploads: binaryUploadURL,
+		UploadHeaders:         headers,
+		Presigned:             true,
 	}, nil
 }
 
-func (d *provider) CreateProviderVersion(ctx context.Context, namespace, registryName, version string) (*driver.CreateProviderVersionResult, error) {
+func (d *provider) CreateProviderVersion(ctx context.Context, namespace, registryName, version string, overwrite bool) (*driver.CreateProviderVersionResult, error) {
 	ctx, span := d.tracer.Start(ctx, "CreateProviderVersion")
 	defer span.End()
+	if err := driver.ValidatePathSegments(namespace, registryName, version); err != nil {
+		return nil, err
+	}
+
 	versionRootPath := fmt.Sprintf("%s/%s/%s/versions/%s", driver.ProviderRootPath, namespace, registryName, version)
-	psc := s3.NewPresignClient(d.s3)
-	sha256SumKeyUploadURL, err := psc.PresignPutObject(ctx, &s3.PutObjectInput{
-		Bucket: aws.String(d.bucket),
-		Key:    aws.String(fmt.Sprintf("%s/terraform-provider-%s_%s_SHA256SUMS", versionRootPath, registryName, version)),
-	})
+	if err := createDir(ctx, d.s3, d.bucket, d.logger, versionRootPath); err != nil {
+		return nil, err
+	}
+
+	sha256SumKeyUploadURL, headers, err := presignPutObject(ctx, d.s3, d.bucket, fmt.Sprintf("%s/terraform-provider-%s_%s_SHA256SUMS", versionRootPath, registryName, version), overwrite)
 	if err != nil {
 		return nil, err
 	}
 
-	sha256SumSigKeyUploadURL, err := psc.PresignPutObject(ctx, &s3.PutObjectInput{
-		Bucket: aws.String(d.bucket),
-		Key:    aws.String(fmt.Sprintf("%s/terraform-provider-%s_%s_SHA256SUMS.sig", versionRootPath, registryName, version)),
-	})
+	sha256SumSigKeyUploadURL, _, err := presignPutObject(ctx, d.s3, d.bucket, fmt.Sprintf("%s/terraform-provider-%s_%s_SHA256SUMS.sig", versionRootPath, registryName, version), overwrite)
 	if err != nil {
 		return nil, err
 	}
@@ -90,21 +99,151 @@ func (d *provider) CreateProviderVersion(ctx context.Context, namespace, registr
 	d.logger.Debug("created provider version path", zap.String("path", versionRootPath))
 
 	return &driver.CreateProviderVersionResult{
-		SHASumsUpload:    sha256SumKeyUploadURL.URL,
-		SHASumsSigUpload: sha256SumSigKeyUploadURL.URL,
+		SHASumsUpload:    sha256SumKeyUploadURL,
+		SHASumsSigUpload: sha256SumSigKeyUploadURL,
+		UploadHeaders:    headers,
+		Presigned:        true,
 	}, nil
 }
 
+func (d *provider) DeleteGPGKey(ctx context.Context, namespace, keyID string) error {
+	ctx, span := d.tracer.Start(ctx, "DeleteGPGKey")
+	defer span.End()
+	keyPath := fmt.Sprintf("%s/%s/%s/%s", driver.ProviderRootPath, namespace, driver.KeyDirname, keyID)
+	if err := isObjectCreated(ctx, d.s3, d.bucket, keyPath, driver.ErrProviderGPGKeyNotExist); err != nil {
+		return err
+	}
+
+	for _, key := range []string{keyPath, keyPath + driver.KeyMetadataExt} {
+		if _, err := d.s3.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(d.bucket),
+			Key:    aws.String(key),
+		}); err != nil {
+			return err
+		}
+	}
+
+	d.logger.Debug("deleted gpg key from amazon s3", zap.String("key", keyPath))
+	return nil
+}
+
+func (d *provider) DeleteProviderPlatform(ctx context.Context, namespace, registryName, version, pos, arch string) error {
+	ctx, span := d.tracer.Start(ctx, "DeleteProviderPlatform")
+	defer span.End()
+	platformPath := fmt.Sprintf("%s/%s/%s/versions/%s/%s-%s/", driver.ProviderRootPath, namespace, registryName, version, pos, arch)
+	return deletePrefix(ctx, d.s3, d.bucket, d.logger, platformPath, driver.ErrProviderPlatformNotExist)
+}
+
+func (d *provider) DeleteProviderVersion(ctx context.Context, namespace, registryName, version string) error {
+	ctx, span := d.tracer.Start(ctx, "DeleteProviderVersion")
+	defer span.End()
+	versionRootPath := fmt.Sprintf("%s/%s/%s/versions/%s/", driver.ProviderRootPath, namespace, registryName, version)
+	return deletePrefix(ctx, d.s3, d.bucket, d.logger, versionRootPath, driver.ErrProviderVersionNotExist)
+}
+
 func (d *provider) GetPlatformBinary(ctx context.Context, namespace, registryName, version, pos, arch string) (io.ReadCloser, error) {
-	return nil, ErrS3NotAllowed
+	ctx, span := d.tracer.Start(ctx, "GetPlatformBinary")
+	defer span.End()
+	binaryPath := fmt.Sprintf("%s/%s/%s/versions/%s/%s-%s/terraform-provider-%s_%s_%s_%s.zip", driver.ProviderRootPath, namespace, registryName, version, pos, arch, registryName, version, pos, arch)
+	return getObject(ctx, d.s3, d.bucket, binaryPath, driver.ErrProviderBinaryNotExist)
+}
+
+func (d *provider) GetPlatformMetadata(ctx context.Context, namespace, registryName, version, pos, arch string) (*driver.ProviderPlatformMetadata, error) {
+	ctx, span := d.tracer.Start(ctx, "GetPlatformMetadata")
+	defer span.End()
+	metadataPath := fmt.Sprintf("%s/%s/%s/versions/%s/%s-%s/%s", driver.ProviderRootPath, namespace, registryName, version, pos, arch, driver.PlatformMetadataFilename)
+	rc, err := getObject(ctx, d.s3, d.bucket, metadataPath, driver.ErrProviderPlatformMetadataNotExist)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var metadata driver.ProviderPlatformMetadata
+	if err := json.NewDecoder(rc).Decode(&metadata); err != nil {
+		return nil, err
+	}
+
+	return &metadata, nil
+}
+
+func (d *provider) GetGPGKey(ctx context.Context, namespace, keyID string) (*driver.GPGKey, error) {
+	ctx, span := d.tracer.Start(ctx, "GetGPGKey")
+	defer span.End()
+	keyPath := fmt.Sprintf("%s/%s/%s/%s", driver.ProviderRootPath, namespace, driver.KeyDirname, keyID)
+	rc, err := getObject(ctx, d.s3, d.bucket, keyPath, driver.ErrProviderGPGKeyNotExist)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &driver.GPGKey{
+		KeyID: keyID,
+	}
+
+	metadata, err := getObject(ctx, d.s3, d.bucket, keyPath+driver.KeyMetadataExt, driver.ErrProviderGPGKeyNotExist)
+	if err != nil && !errors.Is(err, driver.ErrProviderGPGKeyNotExist) {
+		return nil, err
+	}
+
+	if err == nil {
+		defer metadata.Close()
+		if err := json.NewDecoder(metadata).Decode(key); err != nil {
+			return nil, err
+		}
+	}
+
+	key.ASCIIArmor = string(b)
+	return key, nil
 }
 
 func (d *provider) GetSHASums(ctx context.Context, namespace, registryName, version string) (io.ReadCloser, error) {
-	return nil, ErrS3NotAllowed
+	ctx, span := d.tracer.Start(ctx, "GetSHASums")
+	defer span.End()
+	sumsPath := fmt.Sprintf("%s/%s/%s/versions/%s/terraform-provider-%s_%s_SHA256SUMS", driver.ProviderRootPath, namespace, registryName, version, registryName, version)
+	return getObject(ctx, d.s3, d.bucket, sumsPath, driver.ErrProviderSHA256SUMSNotExist)
 }
 
 func (d *provider) GetSHASumsSig(ctx context.Context, namespace, registryName, version string) (io.ReadCloser, error) {
-	return nil, ErrS3NotAllowed
+	ctx, span := d.tracer.Start(ctx, "GetSHASumsSig")
+	defer span.End()
+	sigPath := fmt.Sprintf("%s/%s/%s/versions/%s/terraform-provider-%s_%s_SHA256SUMS.sig", driver.ProviderRootPath, namespace, registryName, version, registryName, version)
+	return getObject(ctx, d.s3, d.bucket, sigPath, driver.ErrProviderSHA256SUMSSigNotExist)
+}
+
+func (d *provider) GetSigningKey(ctx context.Context, namespace string) ([]byte, error) {
+	ctx, span := d.tracer.Start(ctx, "GetSigningKey")
+	defer span.End()
+	keyPath := fmt.Sprintf("%s/%s/%s", driver.SigningRootPath, namespace, driver.SigningKeyFilename)
+	rc, err := getObject(ctx, d.s3, d.bucket, keyPath, driver.ErrSigningKeyNotExist)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+func (d *provider) GetVersionMetadata(ctx context.Context, namespace, registryName, version string) (*driver.ProviderVersionMetadata, error) {
+	ctx, span := d.tracer.Start(ctx, "GetVersionMetadata")
+	defer span.End()
+	metadataPath := fmt.Sprintf("%s/%s/%s/versions/%s/%s", driver.ProviderRootPath, namespace, registryName, version, driver.VersionMetadataFilename)
+	rc, err := getObject(ctx, d.s3, d.bucket, metadataPath, driver.ErrProviderVersionNotExist)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var metadata driver.ProviderVersionMetadata
+	if err := json.NewDecoder(rc).Decode(&metadata); err != nil {
+		return nil, err
+	}
+
+	return &metadata, nil
 }
 
 func (d *provider) FindPackage(ctx context.Context, namespace, registryName, version, pos, arch string) (*model.Package, error) {
@@ -115,99 +254,47 @@ func (d *provider) FindPackage(ctx context.Context, namespace, registryName, ver
 	filename := fmt.Sprintf("terraform-provider-%s_%s_%s_%s.zip", registryName, version, pos, arch)
 	filepath := fmt.Sprintf("%s/%s", platformPath, filename)
 	versionRootPath := fmt.Sprintf("%s/%s/%s/versions/%s", driver.ProviderRootPath, namespace, registryName, version)
-	keysRootPath := fmt.Sprintf("%s/%s/%s", driver.ProviderRootPath, namespace, driver.KeyDirname)
 
-	downloader := manager.NewDownloader(d.s3)
+	// The presigned URL is signed even if the object does not exist
+	if err := isObjectCreated(ctx, d.s3, d.bucket, filepath, driver.ErrProviderBinaryNotExist); err != nil {
+		return nil, err
+	}
+
 	wg, ctx := errgroup.WithContext(ctx)
 	psc := s3.NewPresignClient(d.s3)
 	var sha256Sum string
 	var platformBinaryDownload *v4.PresignedHTTPRequest
 	var sha256SumKeyDownload *v4.PresignedHTTPRequest
 	var sha256SumSigKeyDownload *v4.PresignedHTTPRequest
-	gpgKeys := []model.GPGPublicKey{}
+	var gpgKey model.GPGPublicKey
 
 	wg.Go(func() error {
-		newCtx, span := d.tracer.Start(ctx, "gpgKeys")
+		newCtx, span := d.tracer.Start(ctx, "gpgKey")
 		defer span.End()
-		input := &s3.ListObjectsV2Input{
-			Bucket: aws.String(d.bucket),
-			Prefix: aws.String(keysRootPath),
-		}
-
-		resp, err := d.s3.ListObjectsV2(newCtx, input)
+		metadata, err := d.GetVersionMetadata(newCtx, namespace, registryName, version)
 		if err != nil {
 			return err
 		}
 
-		d.logger.Debug("found keys",
-			zap.Int("count", len(resp.Contents)),
-			zap.String("path", keysRootPath),
-		)
-
-		for _, obj := range resp.Contents {
-			newCtx2, span2 := d.tracer.Start(newCtx, "gpgKey")
-			b := manager.NewWriteAtBuffer([]byte{})
-			_, err := downloader.Download(newCtx2, b, &s3.GetObjectInput{
-				Bucket: aws.String(d.bucket),
-				Key:    obj.Key,
-			})
-
-			if err != nil {
-				return err
-			}
-
-			bs := bytes.NewBuffer(b.Bytes())
-			block, err := armor.Decode(bs)
-			if err != nil {
-				return err
-			}
-
-			if block.Type != openpgp.PublicKeyType {
-				return fmt.Errorf("not public key type")
-			}
-
-			reader := packet.NewReader(block.Body)
-			pkt, err := reader.Next()
-			if err != nil {
-				return err
-			}
-
-			k, ok := pkt.(*packet.PublicKey)
-			if !ok {
-				return fmt.Errorf("not public key type")
-			}
-
-			gpgKey := model.GPGPublicKey{
-				KeyID:      k.KeyIdString(),
-				ASCIIArmor: string(b.Bytes()),
-			}
-			gpgKeys = append(gpgKeys, gpgKey)
-			span2.End()
+		key, err := d.GetGPGKey(newCtx, namespace, metadata.KeyID)
+		if err != nil {
+			return err
 		}
 
+		d.logger.Debug("found signing key", zap.String("keyID", key.KeyID))
+		gpgKey = key.PublicKey()
 		return nil
 	})
 
 	wg.Go(func() error {
 		newCtx, span := d.tracer.Start(ctx, "sha256sum")
 		defer span.End()
-		b := manager.NewWriteAtBuffer([]byte{})
-		_, err := downloader.Download(newCtx, b, &s3.GetObjectInput{
-			Bucket: aws.String(d.bucket),
-			Key:    aws.String(filepath),
-		})
-
+		metadata, err := d.GetPlatformMetadata(newCtx, namespace, registryName, version, pos, arch)
 		if err != nil {
-			var ae smithy.APIError
-			if errors.As(err, &ae) {
-				return driver.ErrProviderBinaryNotExist
-			}
-
 			return err
 		}
 
-		sha256SumHex := sha256.Sum256(b.Bytes())
-		sha256Sum = hex.EncodeToString(sha256SumHex[:])
+		sha256Sum = metadata.SHA256
 		return nil
 	})
 
@@ -287,7 +374,7 @@ func (d *provider) FindPackage(ctx context.Context, namespace, registryName, ver
 		SHASumsSigURL: sha256SumSigKeyDownload.URL,
 		SHASum:        sha256Sum,
 		SigningKeys: &model.SigningKeys{
-			GPGPublicKeys: gpgKeys,
+			GPGPublicKeys: []model.GPGPublicKey{gpgKey},
 		},
 	}
 
@@ -297,151 +384,250 @@ func (d *provider) FindPackage(ctx context.Context, namespace, registryName, ver
 func (d *provider) IsGPGKeyCreated(ctx context.Context, namespace, registryName string) error {
 	ctx, span := d.tracer.Start(ctx, "IsGPGKeyCreated")
 	defer span.End()
-	keyPath := fmt.Sprintf("%s/%s/%s", driver.ProviderRootPath, namespace, driver.KeyDirname)
-	objs, err := d.s3.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
-		Bucket:    aws.String(d.bucket),
-		Delimiter: aws.String(keyPath),
-	})
-
-	if err != nil {
-		var bne *types.NotFound
-		if errors.As(err, &bne) {
-			return driver.ErrProviderGPGKeyNotExist
-		}
-
-		return err
-	}
-
-	if len(objs.Contents) == 0 {
-		return driver.ErrProviderGPGKeyNotExist
-	}
-
-	return nil
+	keyRootPath := fmt.Sprintf("%s/%s/%s/", driver.ProviderRootPath, namespace, driver.KeyDirname)
+	return isPrefixCreated(ctx, d.s3, d.bucket, keyRootPath, driver.ErrProviderGPGKeyNotExist)
 }
 
 func (d *provider) IsProviderCreated(ctx context.Context, namespace, registryName string) error {
-	return nil
+	ctx, span := d.tracer.Start(ctx, "IsProviderCreated")
+	defer span.End()
+	registryRootPath := fmt.Sprintf("%s/%s/%s/", driver.ProviderRootPath, namespace, registryName)
+	return isPrefixCreated(ctx, d.s3, d.bucket, registryRootPath, driver.ErrProviderNotExist)
 }
 
 func (d *provider) IsProviderVersionCreated(ctx context.Context, namespace, registryName, version string) error {
-	return nil
+	ctx, span := d.tracer.Start(ctx, "IsProviderVersionCreated")
+	defer span.End()
+	versionRootPath := fmt.Sprintf("%s/%s/%s/versions/%s/", driver.ProviderRootPath, namespace, registryName, version)
+	return isPrefixCreated(ctx, d.s3, d.bucket, versionRootPath, driver.ErrProviderVersionNotExist)
 }
 
+// ListAvailableVersions lists the versions with the platforms created in them, reading the object keys only once
 func (d *provider) ListAvailableVersions(ctx context.Context, namespace, registryName string) ([]model.AvailableVersion, error) {
 	ctx, span := d.tracer.Start(ctx, "ListAvailableVersions")
 	defer span.End()
-	prefix := fmt.Sprintf("%s/%s/%s/versions", driver.ProviderRootPath, namespace, registryName)
-	input := &s3.ListObjectsV2Input{
+	if err := d.IsProviderCreated(ctx, namespace, registryName); err != nil {
+		return nil, err
+	}
+
+	prefix := fmt.Sprintf("%s/%s/%s/versions/", driver.ProviderRootPath, namespace, registryName)
+	platforms := map[string]map[string]bool{}
+	p := s3.NewListObjectsV2Paginator(d.s3, &s3.ListObjectsV2Input{
 		Bucket: aws.String(d.bucket),
 		Prefix: aws.String(prefix),
-	}
+	})
+	for p.HasMorePages() {
+		resp, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
 
-	resp, err := d.s3.ListObjectsV2(ctx, input)
-	if err != nil {
-		return nil, err
+		for _, obj := range resp.Contents {
+			// <version>/<os>-<arch>/<file>
+			e := strings.SplitN(strings.TrimPrefix(*obj.Key, prefix), "/", 3)
+			if _, ok := platforms[e[0]]; !ok {
+				platforms[e[0]] = map[string]bool{}
+			}
+
+			if len(e) == 3 {
+				platforms[e[0]][e[1]] = true
+			}
+		}
 	}
 
-	d.logger.Debug("found versions",
-		zap.Int("count", len(resp.Contents)),
-	)
+	versions := make([]string, 0, len(platforms))
+	for v := range platforms {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
 
-	platforms := map[string][]model.AvailableVersionPlatform{}
-	for _, obj := range resp.Contents {
-		ext := filepath.Ext(*obj.Key)
-		if ext != ".zip" {
-			continue
-		}
-		result := driver.PlatformBinaryRegex.FindStringSubmatch(*obj.Key)
+	d.logger.Debug("found versions", zap.String("prefix", prefix), zap.Int("count", len(versions)))
 
-		if len(result) < 3 {
-			continue
+	vs := make([]model.AvailableVersion, 0, len(versions))
+	for _, v := range versions {
+		names := make([]string, 0, len(platforms[v]))
+		for name := range platforms[v] {
+			names = append(names, name)
 		}
+		sort.Strings(names)
 
-		version := result[2]
-		pos := result[3]
-		arch := result[4]
+		pfs := []model.AvailableVersionPlatform{}
+		for _, name := range names {
+			e := strings.SplitN(name, "-", 2)
+			if len(e) != 2 {
+				continue
+			}
 
-		platforms[version] = append(platforms[version], model.AvailableVersionPlatform{
-			OS:   pos,
-			Arch: arch,
-		})
-	}
+			pfs = append(pfs, model.AvailableVersionPlatform{
+				OS:   e[0],
+				Arch: e[1],
+			})
+		}
 
-	vs := []model.AvailableVersion{}
-	for k, versions := range platforms {
 		vs = append(vs, model.AvailableVersion{
-			Version:   k,
-			Platforms: versions,
+			Version:   v,
+			Platforms: pfs,
 		})
 	}
 
 	return vs, nil
 }
 
-func (d *provider) SaveGPGKey(ctx context.Context, namespace, keyID string, key []byte) error {
-	ctx, span := d.tracer.Start(ctx, "SaveGPGKey")
+func (d *provider) ListGPGKeys(ctx context.Context, namespace string) ([]*driver.GPGKey, error) {
+	ctx, span := d.tracer.Start(ctx, "ListGPGKeys")
 	defer span.End()
-	keyPath := fmt.Sprintf("%s/%s/%s/%s", driver.ProviderRootPath, namespace, driver.KeyDirname, keyID)
-	uploader := manager.NewUploader(d.s3)
+	prefix := fmt.Sprintf("%s/%s/%s/", driver.ProviderRootPath, namespace, driver.KeyDirname)
 
-	b := bytes.NewBuffer(key)
-	res, err := uploader.Upload(ctx, &s3.PutObjectInput{
+	keys := []*driver.GPGKey{}
+	p := s3.NewListObjectsV2Paginator(d.s3, &s3.ListObjectsV2Input{
 		Bucket: aws.String(d.bucket),
-		Key:    aws.String(keyPath),
-		Body:   b,
+		Prefix: aws.String(prefix),
 	})
+	for p.HasMorePages() {
+		resp, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, obj := range resp.Contents {
+			if strings.HasSuffix(*obj.Key, driver.KeyMetadataExt) {
+				continue
+			}
+
+			key, err := d.GetGPGKey(ctx, namespace, strings.TrimPrefix(*obj.Key, prefix))
+			if err != nil {
+				return nil, err
+			}
+
+			keys = append(keys, key)
+		}
+	}
+
+	d.logger.Debug("found gpg keys", zap.String("prefix", prefix), zap.Int("count", len(keys)))
+	return keys, nil
+}
+
+func (d *provider) ListProviders(ctx context.Context, namespace string) ([]string, error) {
+	ctx, span := d.tracer.Start(ctx, "ListProviders")
+	defer span.End()
+	prefix := fmt.Sprintf("%s/%s/", driver.ProviderRootPath, namespace)
+	dirs, err := listDirs(ctx, d.s3, d.bucket, prefix)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	d.logger.Debug("saved gpg key to amazon s3", zap.String("location", res.Location))
-	return nil
+	providers := []string{}
+	for _, name := range dirs {
+		if name == driver.KeyDirname {
+			continue
+		}
+
+		providers = append(providers, name)
+	}
+
+	return providers, nil
 }
 
-func (d *provider) SavePlatformBinary(ctx context.Context, namespace, registryName, version, pos, arch string, body io.Reader) error {
-	// Note: There are no "directory" system on Amazon S3
-	return ErrS3NotAllowed
+func (d *provider) SaveGPGKey(ctx context.Context, namespace string, key *driver.GPGKey) error {
+	ctx, span := d.tracer.Start(ctx, "SaveGPGKey")
+	defer span.End()
+	if err := driver.ValidatePathSegments(namespace, key.KeyID); err != nil {
+		return err
+	}
+
+	keyPath := fmt.Sprintf("%s/%s/%s/%s", driver.ProviderRootPath, namespace, driver.KeyDirname, key.KeyID)
+	if err := putObject(ctx, d.s3, d.bucket, d.logger, keyPath, bytes.NewBufferString(key.ASCIIArmor)); err != nil {
+		return err
+	}
+
+	b := new(bytes.Buffer)
+	if err := json.NewEncoder(b).Encode(key); err != nil {
+		return err
+	}
+
+	return putObject(ctx, d.s3, d.bucket, d.logger, keyPath+driver.KeyMetadataExt, b)
 }
 
-func (d *provider) SaveSHASUMs(ctx context.Context, namespace, registryName, version string, body io.Reader) error {
-	// Note: There are no "directory" system on Amazon S3
-	return ErrS3NotAllowed
+func (d *provider) SavePlatformBinary(ctx context.Context, namespace, registryName, version, pos, arch string, body io.Reader, overwrite bool) error {
+	ctx, span := d.tracer.Start(ctx, "SavePlatformBinary")
+	defer span.End()
+	if err := d.IsProviderVersionCreated(ctx, namespace, registryName, version); err != nil {
+		return err
+	}
+
+	binaryPath := fmt.Sprintf("%s/%s/%s/versions/%s/%s-%s/terraform-provider-%s_%s_%s_%s.zip", driver.ProviderRootPath, namespace, registryName, version, pos, arch, registryName, version, pos, arch)
+
+	// The metadata of the previous binary is stale, which is saved again with the digests of this binary.
+	// Without overwrite, the metadata is left to the existing binary.
+	if overwrite {
+		metadataPath := fmt.Sprintf("%s/%s/%s/versions/%s/%s-%s/%s", driver.ProviderRootPath, namespace, registryName, version, pos, arch, driver.PlatformMetadataFilename)
+		if err := deleteObject(ctx, d.s3, d.bucket, metadataPath); err != nil {
+			return err
+		}
+	}
+
+	return saveArtifact(ctx, d.s3, d.bucket, d.logger, binaryPath, body, overwrite)
 }
 
-func (d *provider) SaveSHASUMsSig(ctx context.Context, namespace, registryName, version string, body io.Reader) error {
-	// Note: There are no "directory" system on Amazon S3
-	return ErrS3NotAllowed
+func (d *provider) SavePlatformMetadata(ctx context.Context, namespace, registryName, version, pos, arch string, metadata *driver.ProviderPlatformMetadata) error {
+	ctx, span := d.tracer.Start(ctx, "SavePlatformMetadata")
+	defer span.End()
+	platformPath := fmt.Sprintf("%s/%s/%s/versions/%s/%s-%s", driver.ProviderRootPath, namespace, registryName, version, pos, arch)
+	if err := isPrefixCreated(ctx, d.s3, d.bucket, platformPath+"/", driver.ErrProviderPlatformNotExist); err != nil {
+		return err
+	}
+
+	metadataPath := fmt.Sprintf("%s/%s", platformPath, driver.PlatformMetadataFilename)
+	b := new(bytes.Buffer)
+	if err := json.NewEncoder(b).Encode(metadata); err != nil {
+		return err
+	}
+
+	return putObject(ctx, d.s3, d.bucket, d.logger, metadataPath, b)
 }
 
-func (d *provider) SaveVersionMetadata(ctx context.Context, namespace, registryName, version, keyID string) error {
-	ctx, span := d.tracer.Start(ctx, "SaveVersionMetadata")
+func (d *provider) SaveSHASUMs(ctx context.Context, namespace, registryName, version string, body io.Reader, overwrite bool) error {
+	ctx, span := d.tracer.Start(ctx, "SaveSHASUMs")
 	defer span.End()
-	filepath := fmt.Sprintf("%s/%s/%s/versions/%s/%s", driver.ProviderRootPath, namespace, registryName, version, driver.VersionMetadataFilename)
 	if err := d.IsProviderVersionCreated(ctx, namespace, registryName, version); err != nil {
 		return err
 	}
 
-	b := new(bytes.Buffer)
-	metadata := &driver.ProviderVersionMetadata{
-		KeyID: keyID,
+	sumsPath := fmt.Sprintf("%s/%s/%s/versions/%s/terraform-provider-%s_%s_SHA256SUMS", driver.ProviderRootPath, namespace, registryName, version, registryName, version)
+	return saveArtifact(ctx, d.s3, d.bucket, d.logger, sumsPath, body, overwrite)
+}
+
+func (d *provider) SaveSHASUMsSig(ctx context.Context, namespace, registryName, version string, body io.Reader, overwrite bool) error {
+	ctx, span := d.tracer.Start(ctx, "SaveSHASUMsSig")
+	defer span.End()
+	if err := d.IsProviderVersionCreated(ctx, namespace, registryName, version); err != nil {
+		return err
 	}
 
-	if err := json.NewEncoder(b).Encode(metadata); err != nil {
+	sigPath := fmt.Sprintf("%s/%s/%s/versions/%s/terraform-provider-%s_%s_SHA256SUMS.sig", driver.ProviderRootPath, namespace, registryName, version, registryName, version)
+	return saveArtifact(ctx, d.s3, d.bucket, d.logger, sigPath, body, overwrite)
+}
+
+// SaveSigningKey saves the signing key only if not exist, so that the key saved first is used
+func (d *provider) SaveSigningKey(ctx context.Context, namespace string, key []byte) error {
+	ctx, span := d.tracer.Start(ctx, "SaveSigningKey")
+	defer span.End()
+	keyPath := fmt.Sprintf("%s/%s/%s", driver.SigningRootPath, namespace, driver.SigningKeyFilename)
+	return putObjectIfNotExist(ctx, d.s3, d.bucket, d.logger, keyPath, bytes.NewReader(key))
+}
+
+func (d *provider) SaveVersionMetadata(ctx context.Context, namespace, registryName, version string, metadata *driver.ProviderVersionMetadata) error {
+	ctx, span := d.tracer.Start(ctx, "SaveVersionMetadata")
+	defer span.End()
+	filepath := fmt.Sprintf("%s/%s/%s/versions/%s/%s", driver.ProviderRootPath, namespace, registryName, version, driver.VersionMetadataFilename)
+	if err := d.IsProviderVersionCreated(ctx, namespace, registryName, version); err != nil {
 		return err
 	}
 
-	uploader := manager.NewUploader(d.s3)
-	res, err := uploader.Upload(ctx, &s3.PutObjectInput{
-		Bucket: aws.String(d.bucket),
-		Key:    aws.String(filepath),
-		Body:   b,
-	})
-	if err != nil {
+	b := new(bytes.Buffer)
+	if err := json.NewEncoder(b).Encode(metadata); err != nil {
 		return err
 	}
 
-	d.logger.Debug("save version metadata to amazon s3",
-		zap.String("location", res.Location),
-	)
-	return nil
+	return putObject(ctx, d.s3, d.bucket, d.logger, filepath, b)
 }
@@ -4,18 +4,26 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/kerraform/kegistry/internal/driver"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 var (
-	ErrS3NotAllowed = errors.New("uploads to s3 are done by presigned url")
+	errObjectExists = errors.New("object already exists")
 )
 
 type DriverOpts struct {
@@ -53,6 +61,13 @@ func NewDriver(logger *zap.Logger, opts *DriverOpts) (*driver.Driver, error) {
 		o.UsePathStyle = opts.UsePathStyle
 	})
 
+	audit := &audit{
+		bucket: opts.Bucket,
+		logger: logger,
+		tracer: opts.Tracer,
+		s3:     s3Client,
+	}
+
 	module := &module{
 		bucket: opts.Bucket,
 		logger: logger,
@@ -67,8 +82,289 @@ func NewDriver(logger *zap.Logger, opts *DriverOpts) (*driver.Driver, error) {
 		s3:     s3Client,
 	}
 
+	token := &token{
+		bucket: opts.Bucket,
+		logger: logger,
+		tracer: opts.Tracer,
+		s3:     s3Client,
+	}
+
 	return &driver.Driver{
+		Audit:    audit,
 		Module:   module,
 		Provider: provider,
+		Token:    token,
 	}, nil
 }
+
+// isNotFound reports whether the error is caused by the missing object
+func isNotFound(err error) bool {
+	var nsk *types.NoSuchKey
+	var nf *types.NotFound
+	if errors.As(err, &nsk) || errors.As(err, &nf) {
+		return true
+	}
+
+	var ae smithy.APIError
+	if errors.As(err, &ae) {
+		switch ae.ErrorCode() {
+		case "NoSuchKey", "NotFound":
+			return true
+		}
+	}
+
+	return false
+}
+
+// createDir creates the placeholder object of the directory, as there are no directories on Amazon S3
+func createDir(ctx context.Context, c *s3.Client, bucket string, logger *zap.Logger, prefix string) error {
+	return putObject(ctx, c, bucket, logger, prefix+"/", strings.NewReader(""))
+}
+
+// deletePrefix deletes all the objects under the prefix, or returns notExistErr if there is no object
+func deletePrefix(ctx context.Context, c *s3.Client, bucket string, logger *zap.Logger, prefix string, notExistErr error) error {
+	deleted := 0
+	p := s3.NewListObjectsV2Paginator(c, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+	for p.HasMorePages() {
+		resp, err := p.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(resp.Contents) == 0 {
+			continue
+		}
+
+		objs := make([]types.ObjectIdentifier, len(resp.Contents))
+		for i, obj := range resp.Contents {
+			objs[i] = types.ObjectIdentifier{
+				Key: obj.Key,
+			}
+		}
+
+		out, err := c.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(bucket),
+			Delete: &types.Delete{
+				Objects: objs,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(out.Errors) > 0 {
+			return fmt.Errorf("failed to delete %s: %s", aws.ToString(out.Errors[0].Key), aws.ToString(out.Errors[0].Message))
+		}
+		deleted += len(objs)
+	}
+
+	if deleted == 0 {
+		return notExistErr
+	}
+
+	logger.Debug("deleted objects from amazon s3", zap.String("prefix", prefix), zap.Int("count", deleted))
+	return nil
+}
+
+// deleteObject deletes the object, which succeeds even if the object does not exist
+func deleteObject(ctx context.Context, c *s3.Client, bucket, key string) error {
+	_, err := c.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+// getObject returns the body of the object, or notExistErr if the object does not exist
+func getObject(ctx context.Context, c *s3.Client, bucket, key string, notExistErr error) (io.ReadCloser, error) {
+	resp, err := c.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, notExistErr
+		}
+
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// isObjectCreated returns notExistErr if the object does not exist
+func isObjectCreated(ctx context.Context, c *s3.Client, bucket, key string, notExistErr error) error {
+	if _, err := c.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}); err != nil {
+		if isNotFound(err) {
+			return notExistErr
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// isPrefixCreated returns notExistErr if there is no object under the prefix
+func isPrefixCreated(ctx context.Context, c *s3.Client, bucket, prefix string, notExistErr error) error {
+	resp, err := c.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket:  aws.String(bucket),
+		Prefix:  aws.String(prefix),
+		MaxKeys: 1,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(resp.Contents) == 0 {
+		return notExistErr
+	}
+
+	return nil
+}
+
+// listDirs returns the names of the directories right under the prefix
+func listDirs(ctx context.Context, c *s3.Client, bucket, prefix string) ([]string, error) {
+	dirs := []string{}
+	seen := map[string]bool{}
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			dirs = append(dirs, name)
+		}
+	}
+
+	p := s3.NewListObjectsV2Paginator(c, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(bucket),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+	})
+	for p.HasMorePages() {
+		resp, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, cp := range resp.CommonPrefixes {
+			add(strings.TrimSuffix(strings.TrimPrefix(*cp.Prefix, prefix), "/"))
+		}
+
+		// Some S3-compatible storages list the placeholder of the directory as the object
+		for _, obj := range resp.Contents {
+			name := strings.TrimPrefix(*obj.Key, prefix)
+			if name == "" || !strings.HasSuffix(name, "/") {
+				continue
+			}
+
+			add(strings.TrimSuffix(name, "/"))
+		}
+	}
+
+	return dirs, nil
+}
+
+func putObject(ctx context.Context, c *s3.Client, bucket string, logger *zap.Logger, key string, body io.Reader) error {
+	uploader := manager.NewUploader(c)
+	res, err := uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   body,
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Debug("saved object to amazon s3", zap.String("location", res.Location))
+	return nil
+}
+
+// putObjectIfNotExist saves the object only if the object does not exist, which succeeds even if the object exists
+func putObjectIfNotExist(ctx context.Context, c *s3.Client, bucket string, logger *zap.Logger, key string, body io.Reader) error {
+	if err := createObject(ctx, c, bucket, logger, key, body); err != nil && !errors.Is(err, errObjectExists) {
+		return err
+	}
+
+	return nil
+}
+
+// createObject saves the object only if the object does not exist, otherwise returns errObjectExists.
+// The body is streamed by the uploader, which sends it by the multipart upload if large.
+func createObject(ctx context.Context, c *s3.Client, bucket string, logger *zap.Logger, key string, body io.Reader) error {
+	uploader := manager.NewUploader(c, manager.WithUploaderRequestOptions(s3.WithAPIOptions(addIfNoneMatch)))
+	if _, err := uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   body,
+	}); err != nil {
+		var ae smithy.APIError
+		if errors.As(err, &ae) && ae.ErrorCode() == "PreconditionFailed" {
+			logger.Debug("object already exists on amazon s3", zap.String("key", key))
+			return errObjectExists
+		}
+
+		return err
+	}
+
+	logger.Debug("saved object to amazon s3", zap.String("key", key))
+	return nil
+}
+
+// addIfNoneMatch conditions the single and the multipart upload on no object existing by `If-None-Match: *`.
+// The header is only sent with the requests which create the object, as the parts are not conditioned.
+func addIfNoneMatch(stack *middleware.Stack) error {
+	return stack.Build.Add(middleware.BuildMiddlewareFunc("IfNoneMatch", func(ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler) (middleware.BuildOutput, middleware.Metadata, error) {
+		switch awsmiddleware.GetOperationName(ctx) {
+		case "PutObject", "CompleteMultipartUpload":
+			if req, ok := in.Request.(*smithyhttp.Request); ok {
+				req.Header.Set("If-None-Match", "*")
+			}
+		}
+
+		return next.HandleBuild(ctx, in)
+	}), middleware.After)
+}
+
+// saveArtifact saves the artifact, which is only created unless overwrite, otherwise returns driver.ErrArtifactExists
+func saveArtifact(ctx context.Context, c *s3.Client, bucket string, logger *zap.Logger, key string, body io.Reader, overwrite bool) error {
+	if overwrite {
+		return putObject(ctx, c, bucket, logger, key, body)
+	}
+
+	if err := createObject(ctx, c, bucket, logger, key, body); err != nil {
+		if errors.Is(err, errObjectExists) {
+			return driver.ErrArtifactExists
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// presignPutObject presigns the upload of the object. Unless overwrite, the upload is conditioned on no object existing
+// by the signed `If-None-Match: *` header, so that the uploader must send the returned headers.
+// The size cannot be limited by the presigned PUT, so the uploaded object is validated by the registry before it is served.
+func presignPutObject(ctx context.Context, c *s3.Client, bucket, key string, overwrite bool) (string, map[string]string, error) {
+	var headers map[string]string
+	var opts []func(*s3.PresignOptions)
+	if !overwrite {
+		headers = map[string]string{"If-None-Match": "*"}
+		opts = append(opts, s3.WithPresignClientFromClientOptions(s3.WithAPIOptions(smithyhttp.AddHeaderValue("If-None-Match", "*"))))
+	}
+
+	req, err := s3.NewPresignClient(c).PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, opts...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return req.URL, headers, nil
+}
@@ -0,0 +1,93 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	"github.com/kerraform/kegistry/internal/driver/drivertest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// TestDriver runs against the S3 compatible storage (e.g. MinIO) at BACKEND_S3_ENDPOINT, and is skipped if unset.
+// The bucket is created if not exist.
+func TestDriver(t *testing.T) {
+	endpoint := os.Getenv("BACKEND_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("BACKEND_S3_ENDPOINT is not set")
+	}
+
+	if os.Getenv("AWS_REGION") == "" {
+		t.Setenv("AWS_REGION", "us-east-1")
+	}
+
+	bucket := getenv("BACKEND_S3_BUCKET", "kegistry")
+	d, err := NewDriver(zap.NewNop(), &DriverOpts{
+		AccessKey:    getenv("BACKEND_S3_ACCESS_KEY", "minioadmin"),
+		Bucket:       bucket,
+		Endpoint:     endpoint,
+		SecretKey:    getenv("BACKEND_S3_SECRET_KEY", "minioadmin"),
+		Tracer:       trace.NewNoopTracerProvider().Tracer(""),
+		UsePathStyle: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := d.Provider.(*provider).s3.CreateBucket(context.Background(), &s3.CreateBucketInput{
+		Bucket: aws.String(bucket),
+	}); err != nil {
+		var ae smithy.APIError
+		if !errors.As(err, &ae) || (ae.ErrorCode() != "BucketAlreadyOwnedByYou" && ae.ErrorCode() != "BucketAlreadyExists") {
+			t.Fatal(err)
+		}
+	}
+
+	drivertest.TestDriver(t, d)
+}
+
+func TestPresignPutObject(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+	d, err := NewDriver(zap.NewNop(), &DriverOpts{
+		AccessKey: "access",
+		Bucket:    "kegistry",
+		SecretKey: "secret",
+		Tracer:    trace.NewNoopTracerProvider().Tracer(""),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, overwrite := range []bool{false, true} {
+		u, headers, err := presignPutObject(context.Background(), d.Provider.(*provider).s3, "kegistry", "foo", overwrite)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		signed, err := url.Parse(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The header must be signed, otherwise the upload can omit it
+		conditioned := strings.Contains(signed.Query().Get("X-Amz-SignedHeaders"), "if-none-match")
+		if conditioned == overwrite || (headers["If-None-Match"] == "*") == overwrite {
+			t.Fatalf("overwrite %t: unexpected condition of %s with the headers %v", overwrite, u, headers)
+		}
+	}
+}
+
+func getenv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+
+	return fallback
+}
@@ -0,0 +1,179 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/kerraform/kegistry/internal/driver"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type token struct {
+	bucket string
+	logger *zap.Logger
+	s3     *s3.Client
+	tracer trace.Tracer
+}
+
+var _ driver.Token = (*token)(nil)
+
+// errTokenLastUsedNotExist is returned for the token which has never been used
+var errTokenLastUsedNotExist = errors.New("token last used time not exist")
+
+func (d *token) DeleteToken(ctx context.Context, id string) error {
+	ctx, span := d.tracer.Start(ctx, "DeleteToken")
+	defer span.End()
+	tokenPath := fmt.Sprintf("%s/%s.json", driver.TokenRootPath, id)
+	if _, err := d.s3.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(d.bucket),
+		Key:    aws.String(tokenPath),
+	}); err != nil {
+		if isNotFound(err) {
+			return driver.ErrTokenNotExist
+		}
+
+		return err
+	}
+
+	if _, err := d.s3.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(d.bucket),
+		Key:    aws.String(tokenPath),
+	}); err != nil {
+		return err
+	}
+
+	lastUsedPath := fmt.Sprintf("%s/%s%s", driver.TokenRootPath, id, driver.TokenLastUsedExt)
+	if err := deleteObject(ctx, d.s3, d.bucket, lastUsedPath); err != nil {
+		return err
+	}
+
+	d.logger.Debug("deleted token from amazon s3", zap.String("key", tokenPath))
+	return nil
+}
+
+func (d *token) GetToken(ctx context.Context, id string) (*driver.APIToken, error) {
+	ctx, span := d.tracer.Start(ctx, "GetToken")
+	defer span.End()
+	tokenPath := fmt.Sprintf("%s/%s.json", driver.TokenRootPath, id)
+	return d.getToken(ctx, tokenPath)
+}
+
+func (d *token) ListTokens(ctx context.Context) ([]*driver.APIToken, error) {
+	ctx, span := d.tracer.Start(ctx, "ListTokens")
+	defer span.End()
+	ts := []*driver.APIToken{}
+	p := s3.NewListObjectsV2Paginator(d.s3, &s3.ListObjectsV2Input{
+		Bucket: aws.String(d.bucket),
+		Prefix: aws.String(driver.TokenRootPath + "/"),
+	})
+	for p.HasMorePages() {
+		resp, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, obj := range resp.Contents {
+			if filepath.Ext(*obj.Key) != ".json" {
+				continue
+			}
+
+			t, err := d.getToken(ctx, *obj.Key)
+			if err != nil {
+				return nil, err
+			}
+			ts = append(ts, t)
+		}
+	}
+
+	d.logger.Debug("list tokens", zap.Int("count", len(ts)))
+	return ts, nil
+}
+
+func (d *token) SaveToken(ctx context.Context, t *driver.APIToken) error {
+	ctx, span := d.tracer.Start(ctx, "SaveToken")
+	defer span.End()
+	tokenPath := fmt.Sprintf("%s/%s.json", driver.TokenRootPath, t.ID)
+
+	b := new(bytes.Buffer)
+	if err := json.NewEncoder(b).Encode(t); err != nil {
+		return err
+	}
+
+	uploader := manager.NewUploader(d.s3)
+	res, err := uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(d.bucket),
+		Key:    aws.String(tokenPath),
+		Body:   b,
+	})
+	if err != nil {
+		return err
+	}
+
+	d.logger.Debug("saved token to amazon s3", zap.String("location", res.Location))
+	return nil
+}
+
+func (d *token) getToken(ctx context.Context, key string) (*driver.APIToken, error) {
+	resp, err := d.s3.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(d.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, driver.ErrTokenNotExist
+		}
+
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var t driver.APIToken
+	if err := json.NewDecoder(resp.Body).Decode(&t); err != nil {
+		return nil, err
+	}
+
+	lr, err := getObject(ctx, d.s3, d.bucket, strings.TrimSuffix(key, ".json")+driver.TokenLastUsedExt, errTokenLastUsedNotExist)
+	if err != nil {
+		if errors.Is(err, errTokenLastUsedNotExist) {
+			return &t, nil
+		}
+
+		return nil, err
+	}
+	defer lr.Close()
+
+	var lastUsedAt time.Time
+	if err := json.NewDecoder(lr).Decode(&lastUsedAt); err != nil {
+		return nil, err
+	}
+	t.LastUsedAt = &lastUsedAt
+
+	return &t, nil
+}
+
+func (d *token) SaveTokenLastUsed(ctx context.Context, id string, lastUsedAt time.Time) error {
+	ctx, span := d.tracer.Start(ctx, "SaveTokenLastUsed")
+	defer span.End()
+	tokenPath := fmt.Sprintf("%s/%s.json", driver.TokenRootPath, id)
+	if err := isObjectCreated(ctx, d.s3, d.bucket, tokenPath, driver.ErrTokenNotExist); err != nil {
+		return err
+	}
+
+	b := new(bytes.Buffer)
+	if err := json.NewEncoder(b).Encode(lastUsedAt); err != nil {
+		return err
+	}
+
+	lastUsedPath := fmt.Sprintf("%s/%s%s", driver.TokenRootPath, id, driver.TokenLastUsedExt)
+	return putObject(ctx, d.s3, d.bucket, d.logger, lastUsedPath, b)
+}
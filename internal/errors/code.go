@@ -27,3 +27,59 @@ func WithNotFound() WrapOption {
 		e.StatusCode = http.StatusNotFound
 	}
 }
+
+func WithUnauthorized() WrapOption {
+	return func(e *Error) {
+		e.Code = "UNAUTHORIZED"
+		e.Message = "authentication required"
+		e.StatusCode = http.StatusUnauthorized
+	}
+}
+
+func WithForbidden() WrapOption {
+	return func(e *Error) {
+		e.Code = "DENIED"
+		e.Message = "requested access to the resource is denied"
+		e.StatusCode = http.StatusForbidden
+	}
+}
+
+func WithConflict() WrapOption {
+	return func(e *Error) {
+		e.Code = "CONFLICT"
+		e.Message = "conflict with the current state of the resource"
+		e.StatusCode = http.StatusConflict
+	}
+}
+
+// WithBadGateway is the error of the upstream, e.g. the upstream registry of the proxy
+func WithBadGateway() WrapOption {
+	return func(e *Error) {
+		e.Code = "BADGATEWAY"
+		e.Message = "invalid response from the upstream"
+		e.StatusCode = http.StatusBadGateway
+	}
+}
+
+func WithTooManyRequests() WrapOption {
+	return func(e *Error) {
+		e.Code = "TOOMANYREQUESTS"
+		e.Message = "too many requests"
+		e.StatusCode = http.StatusTooManyRequests
+	}
+}
+
+func WithRequestEntityTooLarge() WrapOption {
+	return func(e *Error) {
+		e.Code = "SIZE_INVALID"
+		e.Message = "request entity too large"
+		e.StatusCode = http.StatusRequestEntityTooLarge
+	}
+}
+
+// WithDetail sets the detail of the error to be returned to the client
+func WithDetail(detail interface{}) WrapOption {
+	return func(e *Error) {
+		e.Detail = detail
+	}
+}
@@ -3,6 +3,7 @@ package logging
 import (
 	"context"
 	"errors"
+	"sync"
 
 	"go.uber.org/zap"
 )
@@ -11,6 +12,21 @@ const (
 	Key = "logger"
 )
 
+type annotationsKey struct{}
+
+// Annotations holds the fields resolved while handling the request
+// (e.g. the identity of the caller) to be added to the access log
+type Annotations struct {
+	mu     sync.Mutex
+	fields []zap.Field
+}
+
+func (a *Annotations) Fields() []zap.Field {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]zap.Field{}, a.fields...)
+}
+
 func FromCtx(ctx context.Context) (*zap.Logger, error) {
 	l, ok := ctx.Value(Key).(*zap.Logger)
 	if !ok {
@@ -18,3 +34,24 @@ func FromCtx(ctx context.Context) (*zap.Logger, error) {
 	}
 	return l, nil
 }
+
+// WithAnnotations returns the context which collects the fields by Annotate
+func WithAnnotations(ctx context.Context) (context.Context, *Annotations) {
+	a := &Annotations{}
+	return context.WithValue(ctx, annotationsKey{}, a), a
+}
+
+// Annotate adds the fields to the logger of the context and the annotations of the request
+func Annotate(ctx context.Context, fields ...zap.Field) context.Context {
+	if a, ok := ctx.Value(annotationsKey{}).(*Annotations); ok {
+		a.mu.Lock()
+		a.fields = append(a.fields, fields...)
+		a.mu.Unlock()
+	}
+
+	if l, err := FromCtx(ctx); err == nil {
+		ctx = context.WithValue(ctx, Key, l.With(fields...))
+	}
+
+	return ctx
+}
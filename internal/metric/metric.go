@@ -19,12 +19,14 @@ const (
 	metricNamespace = "kegistry"
 
 	// Metrics
-	metricNameHTTPRequestTotal MetricName = "registry_request_total"
+	metricNameHTTPRequestTotal        MetricName = "registry_request_total"
+	metricNameRateLimitThrottledTotal MetricName = "registry_ratelimit_throttled_total"
 
 	// Labels
 	metricLabelHTTPStatusCode MetricLabel = "code"
 	metricLabelHTTPMethod     MetricLabel = "method"
 	metricLabelHTTPPath       MetricLabel = "path"
+	metricLabelRateLimitGroup MetricLabel = "group"
 )
 
 type MetricSyncFunc func(m *RegistryMetrics)
@@ -52,14 +54,32 @@ func New(logger *zap.Logger, driver *driver.Driver) *RegistryMetrics {
 					string(metricLabelHTTPPath),
 				},
 			),
+			metricNameRateLimitThrottledTotal: prometheus.NewCounterVec(
+				prometheus.CounterOpts{
+					Namespace: metricNamespace,
+					Name:      string(metricNameRateLimitThrottledTotal),
+					Help:      "Total count of the request throttled by the rate limit",
+				},
+				[]string{
+					string(metricLabelRateLimitGroup),
+				},
+			),
 		},
 	}
 }
 
-// RegisterAllMetrics records the current number of registries
+// RegisterAllMetrics records the current number of registries.
+// The collectors registered by another server in the same process (e.g. the tests) are shared.
 func (m *RegistryMetrics) RegisterAllMetrics() {
-	for _, pm := range m.metrics {
-		prometheus.MustRegister(pm)
+	for name, pm := range m.metrics {
+		if err := prometheus.Register(pm); err != nil {
+			are, ok := err.(prometheus.AlreadyRegisteredError)
+			if !ok {
+				panic(err)
+			}
+
+			m.metrics[name] = are.ExistingCollector
+		}
 	}
 }
 
@@ -70,6 +90,13 @@ func (m *RegistryMetrics) IncrementHTTPRequestTotal(code int, method, path strin
 	}
 }
 
+// IncrementRateLimitThrottled increment
+func (m *RegistryMetrics) IncrementRateLimitThrottled(group string) {
+	if c, ok := m.metrics[metricNameRateLimitThrottledTotal].(*prometheus.CounterVec); ok {
+		c.WithLabelValues(group).Add(1)
+	}
+}
+
 // Resync recomputes the metrics
 func (m *RegistryMetrics) Resync(ctx context.Context) {
 	ticker := time.NewTicker(10 * time.Second)
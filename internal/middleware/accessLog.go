@@ -55,11 +55,12 @@ func AccessLog(logger *zap.Logger) func(http.Handler) http.Handler {
 				flattenFields(r)...,
 			)
 
-			req := r.WithContext(context.WithValue(r.Context(), logging.Key, l))
+			ctx, annotations := logging.WithAnnotations(context.WithValue(r.Context(), logging.Key, l))
+			req := r.WithContext(ctx)
 
 			defer func() {
 				l.Named("accessLog").Info("access to server",
-					zap.Int("statusCode", res.StatusCode),
+					append([]zapcore.Field{zap.Int("statusCode", res.StatusCode)}, annotations.Fields()...)...,
 				)
 			}()
 			next.ServeHTTP(rww, req)
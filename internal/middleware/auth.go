@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/kerraform/kegistry/internal/auth"
+	kerrors "github.com/kerraform/kegistry/internal/errors"
+	"github.com/kerraform/kegistry/internal/logging"
+	"go.uber.org/zap"
+)
+
+// Authenticate resolves the identity of the caller.
+// Requests other than GET and HEAD always require an identity, read requests
+// are allowed anonymously if anonymousRead is enabled.
+func Authenticate(a auth.Authenticator, anonymousRead bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, err := a.Authenticate(r)
+			if err != nil {
+				if l, lerr := logging.FromCtx(r.Context()); lerr == nil {
+					l.Warn("failed to authenticate", zap.Error(err))
+				}
+				unauthorized(w, err)
+				return
+			}
+
+			if id == nil {
+				if _, ok := auth.BearerToken(r); ok {
+					unauthorized(w, auth.ErrInvalidCredentials)
+					return
+				}
+
+				if !anonymousRead || !isReadRequest(r) {
+					unauthorized(w, auth.ErrMissingCredentials)
+					return
+				}
+
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := logging.Annotate(auth.WithIdentity(r.Context(), id),
+				zap.String("subject", id.Subject),
+				zap.String("authMethod", string(id.Method)),
+			)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func isReadRequest(r *http.Request) bool {
+	return r.Method == http.MethodGet || r.Method == http.MethodHead
+}
+
+func unauthorized(w http.ResponseWriter, err error) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="kegistry"`)
+	if err := kerrors.ServeJSON(w, kerrors.Wrap(err, kerrors.WithUnauthorized())); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+
+	"github.com/kerraform/kegistry/internal/auth"
+	kerrors "github.com/kerraform/kegistry/internal/errors"
+	"github.com/kerraform/kegistry/internal/logging"
+	"github.com/kerraform/kegistry/internal/metric"
+	"github.com/kerraform/kegistry/internal/ratelimit"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrRateLimited = errors.New("rate limit exceeded")
+)
+
+// RateLimit throttles the requests per client.
+// Read requests are counted in the group returned by readGroupOf, and the others in the upload group.
+// It is used before the authentication to throttle the requests with the invalid credentials as well,
+// so the client is identified by the IP address.
+func RateLimit(l *ratelimit.Limiter, m *metric.RegistryMetrics, readGroupOf func(r *http.Request) ratelimit.Group) func(http.Handler) http.Handler {
+	return rateLimit(l, m, requestGroup(readGroupOf), clientKey)
+}
+
+// RateLimitSubject throttles the authenticated requests per subject in the same groups as RateLimit.
+// It is used after the authentication, so that the subject using the credential from many IP addresses is limited as well.
+// The anonymous requests are only limited by RateLimit.
+func RateLimitSubject(l *ratelimit.Limiter, m *metric.RegistryMetrics, readGroupOf func(r *http.Request) ratelimit.Group) func(http.Handler) http.Handler {
+	return rateLimit(l, m, requestGroup(readGroupOf), subjectKey)
+}
+
+// RateLimitGroup throttles all the requests per client in the group regardless of the method, e.g. the login attempts
+func RateLimitGroup(l *ratelimit.Limiter, m *metric.RegistryMetrics, group ratelimit.Group) func(http.Handler) http.Handler {
+	return rateLimit(l, m, func(_ *http.Request) ratelimit.Group {
+		return group
+	}, clientKey)
+}
+
+// requestGroup returns the group of the request, which is the upload group unless the request is read
+func requestGroup(readGroupOf func(r *http.Request) ratelimit.Group) func(r *http.Request) ratelimit.Group {
+	return func(r *http.Request) ratelimit.Group {
+		if isReadRequest(r) {
+			return readGroupOf(r)
+		}
+
+		return ratelimit.GroupUpload
+	}
+}
+
+// rateLimit throttles the requests by the key returned by keyOf, which returns false not to throttle the request
+func rateLimit(l *ratelimit.Limiter, m *metric.RegistryMetrics, groupOf func(r *http.Request) ratelimit.Group, keyOf func(r *http.Request) (string, bool)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, ok := keyOf(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			group := groupOf(r)
+			ok, wait := l.Allow(group, key)
+			if ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if lg, err := logging.FromCtx(r.Context()); err == nil {
+				lg.Warn("rate limited", zap.String("group", string(group)), zap.String("client", key))
+			}
+			m.IncrementRateLimitThrottled(string(group))
+
+			w.Header().Set("Retry-After", fmt.Sprint(int(math.Ceil(wait.Seconds()))))
+			if err := kerrors.ServeJSON(w, kerrors.Wrap(ErrRateLimited, kerrors.WithTooManyRequests())); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		})
+	}
+}
+
+func clientKey(r *http.Request) (string, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host, true
+}
+
+func subjectKey(r *http.Request) (string, bool) {
+	id := auth.FromCtx(r.Context())
+	if id == nil {
+		return "", false
+	}
+
+	return "subject:" + id.Subject, true
+}
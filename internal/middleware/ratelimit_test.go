@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kerraform/kegistry/internal/auth"
+	"github.com/kerraform/kegistry/internal/metric"
+	"github.com/kerraform/kegistry/internal/ratelimit"
+	"go.uber.org/zap"
+)
+
+func newTestLimiter() *ratelimit.Limiter {
+	return ratelimit.New(&ratelimit.Config{
+		Limits: map[ratelimit.Group]ratelimit.Limit{
+			ratelimit.GroupModuleRead:   {Burst: 1, Rate: 0.001},
+			ratelimit.GroupProviderRead: {Burst: 1, Rate: 0.001},
+			ratelimit.GroupUpload:       {Burst: 1, Rate: 0.001},
+		},
+	})
+}
+
+func newTestRateLimit(readGroupOf func(r *http.Request) ratelimit.Group) func(http.Handler) http.Handler {
+	return RateLimit(newTestLimiter(), metric.New(zap.NewNop(), nil), readGroupOf)
+}
+
+func newTestRateLimitSubject(readGroupOf func(r *http.Request) ratelimit.Group) func(http.Handler) http.Handler {
+	return RateLimitSubject(newTestLimiter(), metric.New(zap.NewNop(), nil), readGroupOf)
+}
+
+func providerRead(_ *http.Request) ratelimit.Group {
+	return ratelimit.GroupProviderRead
+}
+
+func TestRateLimitBeforeAuthenticate(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	h := newTestRateLimit(providerRead)(Authenticate(auth.NewStaticTokens(map[string]string{"ci": "secret"}), false)(next))
+
+	tests := []struct {
+		token  string
+		status int
+	}{
+		{token: "wrong", status: http.StatusUnauthorized},
+		{token: "wrong", status: http.StatusTooManyRequests},
+		{token: "secret", status: http.StatusTooManyRequests},
+	}
+
+	for i, tc := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/registry/v1/providers", nil)
+		r.RemoteAddr = "192.0.2.1:1234"
+		r.Header.Set("Authorization", "Bearer "+tc.token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if w.Code != tc.status {
+			t.Fatalf("request %d: expected status %d, got %d", i, tc.status, w.Code)
+		}
+	}
+}
+
+func TestRateLimitGroups(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	h := newTestRateLimit(providerRead)(next)
+
+	tests := []struct {
+		method     string
+		remoteAddr string
+		status     int
+	}{
+		{method: http.MethodGet, remoteAddr: "192.0.2.1:1234", status: http.StatusOK},
+		{method: http.MethodPut, remoteAddr: "192.0.2.1:1234", status: http.StatusOK},
+		{method: http.MethodGet, remoteAddr: "192.0.2.1:5678", status: http.StatusTooManyRequests},
+		{method: http.MethodGet, remoteAddr: "192.0.2.2:1234", status: http.StatusOK},
+	}
+
+	for i, tc := range tests {
+		r := httptest.NewRequest(tc.method, "/registry/v1/providers", nil)
+		r.RemoteAddr = tc.remoteAddr
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if w.Code != tc.status {
+			t.Fatalf("request %d: expected status %d, got %d", i, tc.status, w.Code)
+		}
+		if tc.status == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+			t.Fatalf("request %d: expected Retry-After", i)
+		}
+	}
+}
+
+func TestRateLimitSubject(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	h := Authenticate(auth.NewStaticTokens(map[string]string{"ci": "secret", "alice": "other"}), true)(newTestRateLimitSubject(providerRead)(next))
+
+	tests := []struct {
+		token      string
+		remoteAddr string
+		status     int
+	}{
+		{token: "secret", remoteAddr: "192.0.2.1:1234", status: http.StatusOK},
+		{token: "secret", remoteAddr: "192.0.2.2:1234", status: http.StatusTooManyRequests},
+		{token: "other", remoteAddr: "192.0.2.2:1234", status: http.StatusOK},
+		{remoteAddr: "192.0.2.3:1234", status: http.StatusOK},
+		{remoteAddr: "192.0.2.3:1234", status: http.StatusOK},
+	}
+
+	for i, tc := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/registry/v1/providers", nil)
+		r.RemoteAddr = tc.remoteAddr
+		if tc.token != "" {
+			r.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if w.Code != tc.status {
+			t.Fatalf("request %d: expected status %d, got %d", i, tc.status, w.Code)
+		}
+	}
+}
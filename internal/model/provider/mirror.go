@@ -0,0 +1,19 @@
+package provider
+
+// Provider Network Mirror Protocol
+// https://developer.hashicorp.com/terraform/internals/provider-network-mirror-protocol
+type MirrorIndex struct {
+	Versions map[string]MirrorIndexVersion `json:"versions"`
+}
+
+// MirrorIndexVersion is reserved for the future use by the protocol
+type MirrorIndexVersion struct{}
+
+type MirrorVersion struct {
+	Archives map[string]MirrorArchive `json:"archives"`
+}
+
+type MirrorArchive struct {
+	URL    string   `json:"url"`
+	Hashes []string `json:"hashes,omitempty"`
+}
@@ -1,6 +1,7 @@
 package provider
 
 type Package struct {
+	Protocols     []string     `json:"protocols"`
 	OS            string       `json:"os"`
 	Arch          string       `json:"arch"`
 	Filename      string       `json:"filename"`
@@ -18,6 +19,9 @@ type SigningKeys struct {
 }
 
 type GPGPublicKey struct {
-	KeyID      string `json:"key_id"`
-	ASCIIArmor string `json:"ascii_armor"`
+	KeyID          string `json:"key_id"`
+	ASCIIArmor     string `json:"ascii_armor"`
+	TrustSignature string `json:"trust_signature"`
+	Source         string `json:"source"`
+	SourceURL      string `json:"source_url"`
 }
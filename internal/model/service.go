@@ -1,6 +1,19 @@
 package model
 
 type Service struct {
-	ModulesV1   string `json:"modules.v1"`
-	ProvidersV1 string `json:"providers.v1"`
+	GPGKeysV1   string   `json:"gpg-keys.v1,omitempty"`
+	LoginV1     *LoginV1 `json:"login.v1,omitempty"`
+	ModulesV1   string   `json:"modules.v1"`
+	ProvidersV1 string   `json:"providers.v1"`
+	TokensV1    string   `json:"tokens.v1,omitempty"`
+}
+
+// LoginV1 is the service for `terraform login`
+// See: https://developer.hashicorp.com/terraform/internals/login-protocol
+type LoginV1 struct {
+	Client     string   `json:"client"`
+	GrantTypes []string `json:"grant_types"`
+	Authz      string   `json:"authz"`
+	Token      string   `json:"token"`
+	Ports      []int    `json:"ports"`
 }
@@ -0,0 +1,56 @@
+package oauth
+
+import (
+	"sync"
+	"time"
+)
+
+// authorization is the pending authorization code grant
+type authorization struct {
+	clientID      string
+	codeChallenge string
+	expiredAt     time.Time
+	redirectURI   string
+	subject       string
+}
+
+type codeStore struct {
+	mu    sync.Mutex
+	codes map[string]*authorization
+}
+
+func newCodeStore() *codeStore {
+	return &codeStore{
+		codes: map[string]*authorization{},
+	}
+}
+
+func (s *codeStore) put(code string, a *authorization) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for c, v := range s.codes {
+		if now.After(v.expiredAt) {
+			delete(s.codes, c)
+		}
+	}
+	s.codes[code] = a
+}
+
+// take returns the authorization of the code only once
+func (s *codeStore) take(code string) (*authorization, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.codes[code]
+	if !ok {
+		return nil, false
+	}
+	delete(s.codes, code)
+
+	if time.Now().After(a.expiredAt) {
+		return nil, false
+	}
+	return a, true
+}
@@ -0,0 +1,298 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/kerraform/kegistry/internal/audit"
+	"github.com/kerraform/kegistry/internal/auth"
+	"github.com/kerraform/kegistry/internal/token"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	DefaultClientID = "terraform-cli"
+
+	// GrantTypeAuthzCode is the grant type name in the login.v1 service discovery
+	GrantTypeAuthzCode = "authz_code"
+
+	codeChallengeMethodS256 = "S256"
+	codeTTL                 = time.Minute
+)
+
+// Ports are the local ports which Terraform listens on for the redirect.
+// See: https://developer.hashicorp.com/terraform/internals/login-protocol
+var Ports = []int{10000, 10010}
+
+// Server is the OAuth 2.0 authorization server for `terraform login`.
+// It supports the authorization code grant with PKCE, authenticates the users
+// by HTTP basic authentication and issues the registry API token.
+type Server struct {
+	audit    *audit.Recorder
+	clientID string
+	codes    *codeStore
+	logger   *zap.Logger
+	token    *token.Manager
+	users    map[string]string
+}
+
+type Config struct {
+	Audit    *audit.Recorder
+	ClientID string
+	Logger   *zap.Logger
+	Token    *token.Manager
+
+	// Users is the map of user name to bcrypt hashed password
+	Users map[string]string
+}
+
+func New(cfg *Config) *Server {
+	clientID := cfg.ClientID
+	if clientID == "" {
+		clientID = DefaultClientID
+	}
+
+	return &Server{
+		audit:    cfg.Audit,
+		clientID: clientID,
+		codes:    newCodeStore(),
+		logger:   cfg.Logger,
+		token:    cfg.Token,
+		users:    cfg.Users,
+	}
+}
+
+func (s *Server) ClientID() string {
+	return s.clientID
+}
+
+// Authorization handles the authorization endpoint
+// https://www.rfc-editor.org/rfc/rfc6749#section-4.1.1
+func (s *Server) Authorization() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("client_id") != s.clientID {
+			writeText(w, http.StatusBadRequest, "unknown client_id")
+			return
+		}
+
+		redirectURI, err := parseRedirectURI(q.Get("redirect_uri"))
+		if err != nil {
+			writeText(w, http.StatusBadRequest, fmt.Sprintf("invalid redirect_uri: %s", err))
+			return
+		}
+
+		state := q.Get("state")
+		if q.Get("response_type") != "code" {
+			redirectError(w, r, redirectURI, state, "unsupported_response_type")
+			return
+		}
+
+		challenge := q.Get("code_challenge")
+		if challenge == "" || q.Get("code_challenge_method") != codeChallengeMethodS256 {
+			redirectError(w, r, redirectURI, state, "invalid_request")
+			return
+		}
+
+		subject, ok := s.authenticateUser(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Basic realm="kegistry", charset="UTF-8"`)
+			writeText(w, http.StatusUnauthorized, "login required")
+			return
+		}
+
+		code, err := randomString()
+		if err != nil {
+			writeText(w, http.StatusInternalServerError, "failed to generate authorization code")
+			return
+		}
+
+		s.codes.put(code, &authorization{
+			clientID:      s.clientID,
+			codeChallenge: challenge,
+			expiredAt:     time.Now().Add(codeTTL),
+			redirectURI:   redirectURI.String(),
+			subject:       subject,
+		})
+
+		s.logger.Info("authorized user", zap.String("subject", subject))
+		v := redirectURI.Query()
+		v.Set("code", code)
+		if state != "" {
+			v.Set("state", state)
+		}
+		redirectURI.RawQuery = v.Encode()
+		http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+	})
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+type errorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// Token handles the token endpoint
+// https://www.rfc-editor.org/rfc/rfc6749#section-4.1.3
+func (s *Server) Token() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			writeError(w, "invalid_request", err.Error())
+			return
+		}
+
+		if r.PostForm.Get("grant_type") != "authorization_code" {
+			writeError(w, "unsupported_grant_type", "")
+			return
+		}
+
+		a, ok := s.codes.take(r.PostForm.Get("code"))
+		if !ok {
+			writeError(w, "invalid_grant", "authorization code is invalid or expired")
+			return
+		}
+
+		if r.PostForm.Get("client_id") != a.clientID || r.PostForm.Get("redirect_uri") != a.redirectURI {
+			writeError(w, "invalid_grant", "client_id or redirect_uri mismatch")
+			return
+		}
+
+		// https://www.rfc-editor.org/rfc/rfc7636#section-4.6
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+		if subtle.ConstantTimeCompare([]byte(challenge), []byte(a.codeChallenge)) != 1 {
+			writeError(w, "invalid_grant", "code_verifier mismatch")
+			return
+		}
+
+		plain, t, err := s.token.Create(r.Context(), &token.CreateOpts{
+			Description: "terraform login",
+			Namespace:   token.NamespaceAll,
+			Subject:     a.subject,
+		})
+		if err != nil {
+			s.logger.Error("failed to create token", zap.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		// The token is issued to the user authenticated by the authorization request
+		ctx := auth.WithIdentity(r.Context(), &auth.Identity{Subject: a.subject, Method: auth.MethodLogin})
+		s.audit.Record(ctx, audit.ActionCreate, audit.ResourceTypeToken, audit.Resource{
+			Namespace: t.Namespace,
+			Name:      t.ID,
+			Subject:   t.Subject,
+		}, nil)
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(&tokenResponse{
+			AccessToken: plain,
+			TokenType:   "bearer",
+			ExpiresIn:   int64(time.Until(t.ExpiredAt).Seconds()),
+		}); err != nil {
+			s.logger.Error("failed to encode token response", zap.Error(err))
+		}
+	})
+}
+
+// authenticateUser returns the subject of the user, prefixed by `login:` so that the user cannot claim the static token of the same name
+func (s *Server) authenticateUser(r *http.Request) (string, bool) {
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return "", false
+	}
+
+	hash, ok := s.users[user]
+	if !ok {
+		return "", false
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return "", false
+	}
+
+	return auth.SubjectPrefixLogin + user, true
+}
+
+// parseRedirectURI only allows the loopback redirect on the ports that Terraform listens on
+func parseRedirectURI(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "http" {
+		return nil, fmt.Errorf("scheme %q not allowed", u.Scheme)
+	}
+
+	host, p, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	if host != "localhost" && !net.ParseIP(host).IsLoopback() {
+		return nil, fmt.Errorf("host %q is not loopback", host)
+	}
+
+	port, err := strconv.Atoi(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if port < Ports[0] || port > Ports[1] {
+		return nil, fmt.Errorf("port %d not allowed", port)
+	}
+
+	return u, nil
+}
+
+func redirectError(w http.ResponseWriter, r *http.Request, u *url.URL, state, code string) {
+	v := u.Query()
+	v.Set("error", code)
+	if state != "" {
+		v.Set("state", state)
+	}
+	u.RawQuery = v.Encode()
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+func writeError(w http.ResponseWriter, code, description string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(&errorResponse{
+		Error:            code,
+		ErrorDescription: description,
+	})
+}
+
+func writeText(w http.ResponseWriter, statusCode int, msg string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(statusCode)
+	fmt.Fprintln(w, msg)
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,122 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kerraform/kegistry/internal/driver/memory"
+	"github.com/kerraform/kegistry/internal/token"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	testRedirectURI = "http://localhost:10000/login"
+	testVerifier    = "verifier-of-the-code-challenge"
+)
+
+func newTestServer(t *testing.T) (*Server, *token.Manager) {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := token.New(&token.Config{
+		DefaultTTL: time.Hour,
+		Driver: memory.NewDriver(&memory.DriverConfig{
+			Logger: zap.NewNop(),
+			Tracer: trace.NewNoopTracerProvider().Tracer(""),
+		}),
+		Logger: zap.NewNop(),
+	})
+
+	return New(&Config{
+		Logger: zap.NewNop(),
+		Token:  m,
+		Users:  map[string]string{"alice": string(hash)},
+	}), m
+}
+
+func authorize(s *Server, user, password string) *httptest.ResponseRecorder {
+	sum := sha256.Sum256([]byte(testVerifier))
+	q := url.Values{
+		"client_id":             {DefaultClientID},
+		"redirect_uri":          {testRedirectURI},
+		"response_type":         {"code"},
+		"state":                 {"state"},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(sum[:])},
+		"code_challenge_method": {codeChallengeMethodS256},
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/oauth/authorization?"+q.Encode(), nil)
+	r.SetBasicAuth(user, password)
+	w := httptest.NewRecorder()
+	s.Authorization().ServeHTTP(w, r)
+	return w
+}
+
+func TestLogin(t *testing.T) {
+	s, m := newTestServer(t)
+
+	w := authorize(s, "alice", "secret")
+	if w.Code != http.StatusFound {
+		t.Fatalf("expected %d, got %d: %s", http.StatusFound, w.Code, w.Body)
+	}
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {location.Query().Get("code")},
+		"client_id":     {DefaultClientID},
+		"redirect_uri":  {testRedirectURI},
+		"code_verifier": {testVerifier},
+	}
+	r := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	s.Token().ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+
+	var resp tokenResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+resp.AccessToken)
+	id, err := m.Authenticate(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The user must not be taken as the static token of the same name
+	if id == nil || id.Subject != "login:alice" {
+		t.Fatalf("unexpected identity of the issued token: %+v", id)
+	}
+}
+
+func TestLoginWrongPassword(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	for _, user := range []string{"alice", "bob"} {
+		w := authorize(s, user, "wrong")
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected %d, got %d: %s", user, http.StatusUnauthorized, w.Code, w.Body)
+		}
+	}
+}
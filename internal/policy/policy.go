@@ -0,0 +1,216 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/kerraform/kegistry/internal/auth"
+)
+
+var (
+	ErrBadPattern   = errors.New("syntax error in pattern")
+	ErrForbidden    = errors.New("forbidden")
+	ErrInvalidScope = errors.New("invalid scope")
+)
+
+const (
+	// AnonymousSubject is the subject used for the caller without any credential
+	AnonymousSubject = "anonymous"
+)
+
+// Scope is the permission granted on a namespace.
+// Each scope implies the weaker scopes, e.g. publish allows read.
+type Scope string
+
+const (
+	ScopeRead    Scope = "read"
+	ScopePublish Scope = "publish"
+	ScopeAdmin   Scope = "admin"
+)
+
+var scopeLevels = map[Scope]int{
+	ScopeRead:    1,
+	ScopePublish: 2,
+	ScopeAdmin:   3,
+}
+
+// Includes reports whether the scope grants the other scope
+func (s Scope) Includes(other Scope) bool {
+	return scopeLevels[s] >= scopeLevels[other]
+}
+
+// Rule grants the scope to the subjects on the namespaces.
+// Both subjects and namespaces support wildcards (e.g. `team-*`), where `*` matches any characters, `?` matches
+// any single character and `\` escapes the next character. In the subjects `*` also matches `/`
+// (e.g. `oidc:repo:acme/*` matches `oidc:repo:acme/x:ref:refs/heads/main`), while in the namespaces neither `*`
+// nor `?` matches `/`, so that `team-*` never covers the path out of the namespace.
+type Rule struct {
+	Subjects   []string `json:"subjects"`
+	Namespaces []string `json:"namespaces"`
+	Scope      Scope    `json:"scope"`
+}
+
+type Policy struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Load reads the policy from the JSON file
+func Load(filepath string) (*Policy, error) {
+	b, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	var p Policy
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, err
+	}
+
+	for i, rule := range p.Rules {
+		if _, ok := scopeLevels[rule.Scope]; !ok {
+			return nil, fmt.Errorf("rule %d: %w: %q", i, ErrInvalidScope, rule.Scope)
+		}
+
+		for _, pattern := range append(rule.Subjects, rule.Namespaces...) {
+			if _, err := glob(pattern, "", noSeparator); err != nil {
+				return nil, fmt.Errorf("rule %d: %w: %q", i, err, pattern)
+			}
+		}
+	}
+
+	return &p, nil
+}
+
+// Allowed reports whether the identity has the scope on the namespace.
+// A nil identity is evaluated as the anonymous subject.
+func (p *Policy) Allowed(id *auth.Identity, namespace string, scope Scope) bool {
+	subject := AnonymousSubject
+	if id != nil {
+		subject = id.Subject
+	}
+
+	for _, rule := range p.Rules {
+		if !rule.Scope.Includes(scope) {
+			continue
+		}
+
+		if match(rule.Subjects, subject, noSeparator) && match(rule.Namespaces, namespace, '/') {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Authorize checks the caller of the context has the scope on the namespace.
+// Every request is allowed if no policy is configured, except the namespaces
+// that the identity itself is restricted to.
+func (p *Policy) Authorize(ctx context.Context, namespace string, scope Scope) error {
+	id := auth.FromCtx(ctx)
+	if id != nil && len(id.Namespaces) > 0 && !contains(id.Namespaces, namespace) {
+		return fmt.Errorf("%w: credential is not allowed on namespace %q", ErrForbidden, namespace)
+	}
+
+	if p == nil {
+		return nil
+	}
+
+	if !p.Allowed(id, namespace, scope) {
+		return fmt.Errorf("%w: %s scope is required on namespace %q", ErrForbidden, scope, namespace)
+	}
+
+	return nil
+}
+
+func match(patterns []string, s string, separator rune) bool {
+	for _, pattern := range patterns {
+		if ok, _ := glob(pattern, s, separator); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// contains reports whether the namespaces have the namespace. Unlike the patterns of the rules, the namespaces of
+// the identity are compared literally, as they come from the credential, e.g. the claim of the ID token.
+func contains(namespaces []string, namespace string) bool {
+	for _, ns := range namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+
+	return false
+}
+
+// noSeparator is the separator of the names which the wildcards match entirely
+const noSeparator rune = -1
+
+// glob reports whether s matches the pattern, where neither `*` nor `?` matches the separator.
+// Unlike path.Match, the separator is given by the caller, as the subjects (e.g. the OIDC subjects of GitHub Actions)
+// have the slashes which are not the separators of the path, while the namespaces are the segments of the path.
+func glob(pattern, name string, separator rune) (bool, error) {
+	ps, s := []rune(pattern), []rune(name)
+
+	// star and next are where to retry when the characters after the last `*` do not match
+	star, next := -1, 0
+	p, i := 0, 0
+	for i < len(s) || p < len(ps) {
+		if p < len(ps) {
+			switch c := ps[p]; c {
+			case '*':
+				star, next = p, i
+				p++
+				continue
+			case '?':
+				if i < len(s) && s[i] != separator {
+					p++
+					i++
+					continue
+				}
+			default:
+				if c == '\\' {
+					if p+1 == len(ps) {
+						return false, ErrBadPattern
+					}
+					p++
+				}
+
+				if i < len(s) && s[i] == ps[p] {
+					p++
+					i++
+					continue
+				}
+			}
+		}
+
+		// Let the last `*` consume one more character, but never the separator
+		if star >= 0 && next < len(s) && s[next] != separator {
+			next++
+			p, i = star+1, next
+			continue
+		}
+
+		return false, validate(pattern)
+	}
+
+	return true, nil
+}
+
+// validate returns ErrBadPattern if the pattern ends with the unescaped `\`
+func validate(pattern string) error {
+	for p := 0; p < len(pattern); p++ {
+		if pattern[p] == '\\' {
+			if p+1 == len(pattern) {
+				return ErrBadPattern
+			}
+			p++
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,233 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kerraform/kegistry/internal/auth"
+)
+
+func TestGlob(t *testing.T) {
+	cases := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "*", name: "", want: true},
+		{pattern: "*", name: "oidc:repo:acme/x:ref:refs/heads/main", want: true},
+		{pattern: "oidc:repo:acme/*", name: "oidc:repo:acme/x:ref:refs/heads/main", want: true},
+		{pattern: "oidc:repo:acme/*:ref:refs/heads/main", name: "oidc:repo:acme/x:ref:refs/heads/main", want: true},
+		{pattern: "oidc:repo:acme/*:ref:refs/heads/main", name: "oidc:repo:acme/x:ref:refs/heads/dev", want: false},
+		{pattern: "oidc:repo:acme/*", name: "oidc:repo:other/x", want: false},
+		{pattern: "team-*", name: "team-a", want: true},
+		{pattern: "team-*", name: "team", want: false},
+		{pattern: "team-?", name: "team-a", want: true},
+		{pattern: "team-?", name: "team-ab", want: false},
+		{pattern: "team-?", name: "team-é", want: true},
+		{pattern: "*-ci-*", name: "token:a-ci-b-ci-c", want: true},
+		{pattern: `team-\*`, name: "team-*", want: true},
+		{pattern: `team-\*`, name: "team-a", want: false},
+		{pattern: "team-a", name: "team-a", want: true},
+		{pattern: "team-a", name: "team-ab", want: false},
+		{pattern: "", name: "", want: true},
+		{pattern: "", name: "a", want: false},
+	}
+
+	for _, tc := range cases {
+		got, err := glob(tc.pattern, tc.name, noSeparator)
+		if err != nil {
+			t.Fatalf("glob(%q, %q): %v", tc.pattern, tc.name, err)
+		}
+
+		if got != tc.want {
+			t.Errorf("glob(%q, %q): expected %t, got %t", tc.pattern, tc.name, tc.want, got)
+		}
+	}
+
+	for _, pattern := range []string{`\`, `team-\`, `*\`} {
+		if _, err := glob(pattern, "", noSeparator); !errors.Is(err, ErrBadPattern) {
+			t.Errorf("glob(%q): expected %v, got %v", pattern, ErrBadPattern, err)
+		}
+	}
+}
+
+func TestGlobSeparator(t *testing.T) {
+	cases := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "team-*", name: "team-a", want: true},
+		{pattern: "team-*", name: "team-a/../team-b", want: false},
+		{pattern: "*", name: "team-a/x", want: false},
+		{pattern: "team-?x", name: "team-/x", want: false},
+		{pattern: "team-*/x", name: "team-a/x", want: true},
+		{pattern: "team-*/*", name: "team-a/b/c", want: false},
+	}
+
+	for _, tc := range cases {
+		got, err := glob(tc.pattern, tc.name, '/')
+		if err != nil {
+			t.Fatalf("glob(%q, %q): %v", tc.pattern, tc.name, err)
+		}
+
+		if got != tc.want {
+			t.Errorf("glob(%q, %q): expected %t, got %t", tc.pattern, tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	p := &Policy{
+		Rules: []Rule{
+			{Subjects: []string{"*"}, Namespaces: []string{"public"}, Scope: ScopeRead},
+			{Subjects: []string{"oidc:repo:acme/*"}, Namespaces: []string{"acme"}, Scope: ScopePublish},
+			{Subjects: []string{"token:platform-*"}, Namespaces: []string{"*"}, Scope: ScopeAdmin},
+		},
+	}
+
+	cases := map[string]struct {
+		id        *auth.Identity
+		namespace string
+		scope     Scope
+		want      bool
+	}{
+		"anonymous read": {
+			namespace: "public",
+			scope:     ScopeRead,
+			want:      true,
+		},
+		"anonymous publish": {
+			namespace: "public",
+			scope:     ScopePublish,
+		},
+		"anonymous other namespace": {
+			namespace: "acme",
+			scope:     ScopeRead,
+		},
+		"oidc subject with slashes read": {
+			id:        &auth.Identity{Subject: "oidc:repo:acme/x:ref:refs/heads/main"},
+			namespace: "public",
+			scope:     ScopeRead,
+			want:      true,
+		},
+		"oidc publish implies read": {
+			id:        &auth.Identity{Subject: "oidc:repo:acme/x:ref:refs/heads/main"},
+			namespace: "acme",
+			scope:     ScopeRead,
+			want:      true,
+		},
+		"oidc publish": {
+			id:        &auth.Identity{Subject: "oidc:repo:acme/x:ref:refs/heads/main"},
+			namespace: "acme",
+			scope:     ScopePublish,
+			want:      true,
+		},
+		"oidc admin": {
+			id:        &auth.Identity{Subject: "oidc:repo:acme/x:ref:refs/heads/main"},
+			namespace: "acme",
+			scope:     ScopeAdmin,
+		},
+		"oidc other repository": {
+			id:        &auth.Identity{Subject: "oidc:repo:other/x:ref:refs/heads/main"},
+			namespace: "acme",
+			scope:     ScopePublish,
+		},
+		"oidc other namespace": {
+			id:        &auth.Identity{Subject: "oidc:repo:acme/x:ref:refs/heads/main"},
+			namespace: "acme-2",
+			scope:     ScopePublish,
+		},
+		"token subject not claimed by oidc": {
+			id:        &auth.Identity{Subject: "oidc:platform-a"},
+			namespace: "acme",
+			scope:     ScopeAdmin,
+		},
+		"admin": {
+			id:        &auth.Identity{Subject: "token:platform-a"},
+			namespace: "acme",
+			scope:     ScopeAdmin,
+			want:      true,
+		}, "admin of the path out of the namespace": {
+			id:        &auth.Identity{Subject: "token:platform-a"},
+			namespace: "acme/../other",
+			scope:     ScopeAdmin,
+		},
+	}
+
+	for name, tc := range cases {
+		if got := p.Allowed(tc.id, tc.namespace, tc.scope); got != tc.want {
+			t.Errorf("%s: expected %t, got %t", name, tc.want, got)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	p := &Policy{
+		Rules: []Rule{
+			{Subjects: []string{"*"}, Namespaces: []string{"*"}, Scope: ScopePublish},
+		},
+	}
+
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "token:ci", Namespaces: []string{"acme"}})
+	if err := p.Authorize(ctx, "acme", ScopePublish); err != nil {
+		t.Fatalf("expected allowed, got %v", err)
+	}
+
+	if err := p.Authorize(ctx, "acme", ScopeAdmin); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected %v over the granted scope, got %v", ErrForbidden, err)
+	}
+
+	// The namespaces of the identity restrict the policy and the nil policy alike
+	for _, p := range []*Policy{p, nil} {
+		if err := p.Authorize(ctx, "other", ScopeRead); !errors.Is(err, ErrForbidden) {
+			t.Fatalf("expected %v out of the namespaces of the identity, got %v", ErrForbidden, err)
+		}
+	}
+
+	var nilPolicy *Policy
+	if err := nilPolicy.Authorize(context.Background(), "acme", ScopeAdmin); err != nil {
+		t.Fatalf("expected every request to be allowed without the policy, got %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	cases := map[string]struct {
+		body string
+		want error
+	}{
+		"valid": {
+			body: `{"rules":[{"subjects":["oidc:repo:acme/*"],"namespaces":["acme"],"scope":"publish"}]}`,
+		},
+		"invalid scope": {
+			body: `{"rules":[{"subjects":["*"],"namespaces":["*"],"scope":"write"}]}`,
+			want: ErrInvalidScope,
+		},
+		"bad pattern": {
+			body: `{"rules":[{"subjects":["token:\\"],"namespaces":["*"],"scope":"read"}]}`,
+			want: ErrBadPattern,
+		},
+	}
+
+	for name, tc := range cases {
+		p := filepath.Join(t.TempDir(), "policy.json")
+		if err := os.WriteFile(p, []byte(tc.body), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := Load(p)
+		if tc.want == nil {
+			if err != nil {
+				t.Errorf("%s: %v", name, err)
+			}
+			continue
+		}
+
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: expected %v, got %v", name, tc.want, err)
+		}
+	}
+}
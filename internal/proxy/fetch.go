@@ -0,0 +1,374 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/kerraform/kegistry/internal/artifact"
+	"github.com/kerraform/kegistry/internal/driver"
+	"github.com/kerraform/kegistry/internal/model/provider"
+	"go.uber.org/zap"
+)
+
+// listing is the versions listed in the upstream, which is served until expired.
+// The provider not found in the upstream is cached as well.
+type listing struct {
+	versions  []provider.AvailableVersion
+	err       error
+	expiresAt time.Time
+}
+
+// ListAvailableVersions lists the available versions of the provider in the upstream.
+// The listing is shared by the concurrent requests and cached for a while, and times out shortly
+// so that the unavailable upstream does not hold the requests served by the versions in the registry.
+func (p *Proxy) ListAvailableVersions(ctx context.Context, u *Upstream, namespace, name string) ([]provider.AvailableVersion, error) {
+	key := strings.Join([]string{u.Hostname, namespace, name}, "/")
+
+	p.mu.Lock()
+	l, ok := p.listings[key]
+	p.mu.Unlock()
+	if ok && p.nowFunc().Before(l.expiresAt) {
+		return l.versions, l.err
+	}
+
+	v, err := p.do(ctx, "versions/"+key, func(ctx context.Context) (interface{}, error) {
+		ctx, cancel := context.WithTimeout(ctx, p.listTimeout)
+		defer cancel()
+
+		r, err := p.registry(ctx, u)
+		if err != nil {
+			return nil, err
+		}
+
+		versions, err := r.listAvailableVersions(ctx, namespace, name)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+
+		p.cacheListing(key, &listing{
+			versions: versions,
+			err:      err,
+		})
+		return versions, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.([]provider.AvailableVersion), nil
+}
+
+// cacheListing caches the listing, and removes the expired ones at most once per the TTL
+func (p *Proxy) cacheListing(key string, l *listing) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.nowFunc()
+	l.expiresAt = now.Add(p.listCacheTTL)
+	p.listings[key] = l
+
+	if now.Sub(p.sweptAt) < p.listCacheTTL {
+		return
+	}
+
+	for k, l := range p.listings {
+		if !now.Before(l.expiresAt) {
+			delete(p.listings, k)
+		}
+	}
+	p.sweptAt = now
+}
+
+// FetchVersion fetches SHA256SUMS and its signature of the version from the upstream unless it is already fetched.
+// The package of the platform is looked up for their URLs and the signing keys.
+func (p *Proxy) FetchVersion(ctx context.Context, u *Upstream, namespace, name, version, os, arch string) error {
+	fetched, err := p.lookupVersion(ctx, namespace, name, version)
+	if err != nil || fetched {
+		return err
+	}
+
+	r, err := p.registry(ctx, u)
+	if err != nil {
+		return err
+	}
+
+	pkg, err := r.findPackage(ctx, namespace, name, version, os, arch)
+	if err != nil {
+		return err
+	}
+
+	return p.fetchVersion(ctx, u, r, namespace, name, version, pkg)
+}
+
+// FetchPackage fetches the package of the platform from the upstream unless it is already stored, and reports whether it is fetched.
+// The signature of SHA256SUMS is verified by the signing keys of the upstream, and the package by SHA256SUMS,
+// before they are stored to the driver.
+func (p *Proxy) FetchPackage(ctx context.Context, u *Upstream, namespace, name, version, os, arch string) (bool, error) {
+	rc, err := p.driver.Provider.GetPlatformBinary(ctx, namespace, name, version, os, arch)
+	if err == nil {
+		rc.Close()
+		return false, nil
+	}
+
+	if !errors.Is(err, driver.ErrProviderBinaryNotExist) {
+		return false, err
+	}
+
+	key := strings.Join([]string{u.Hostname, namespace, name, version, os, arch}, "/")
+	if _, err := p.do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		return nil, p.fetchPackage(ctx, u, namespace, name, version, os, arch)
+	}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (p *Proxy) fetchPackage(ctx context.Context, u *Upstream, namespace, name, version, os, arch string) error {
+	fetched, err := p.lookupVersion(ctx, namespace, name, version)
+	if err != nil {
+		return err
+	}
+
+	r, err := p.registry(ctx, u)
+	if err != nil {
+		return err
+	}
+
+	pkg, err := r.findPackage(ctx, namespace, name, version, os, arch)
+	if err != nil {
+		return err
+	}
+
+	if !fetched {
+		if err := p.fetchVersion(ctx, u, r, namespace, name, version, pkg); err != nil {
+			return err
+		}
+	}
+
+	sums, err := p.driver.Provider.GetSHASums(ctx, namespace, name, version)
+	if err != nil {
+		return err
+	}
+	defer sums.Close()
+
+	parsed, err := artifact.ParseSHASums(sums)
+	if err != nil {
+		return err
+	}
+
+	body, err := r.download(ctx, pkg.DownloadURL)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	f, err := artifact.Spool(body, p.limits.ProviderBinary)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sum, err := f.SHA256()
+	if err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(sum, pkg.SHASum) {
+		return fmt.Errorf("%w: %s is %s, expected %s", artifact.ErrSHASumMismatch, pkg.Filename, sum, pkg.SHASum)
+	}
+
+	if err := parsed.Verify(pkg.Filename, sum); err != nil {
+		return err
+	}
+
+	h1, err := f.HashV1()
+	if err != nil {
+		return err
+	}
+
+	if _, err := p.driver.Provider.CreateProviderPlatform(ctx, namespace, name, version, os, arch, false); err != nil {
+		return err
+	}
+
+	if err := ignoreExists(p.driver.Provider.SavePlatformBinary(ctx, namespace, name, version, os, arch, f, false)); err != nil {
+		return err
+	}
+
+	if err := p.driver.Provider.SavePlatformMetadata(ctx, namespace, name, version, os, arch, &driver.ProviderPlatformMetadata{
+		Size:   f.Size(),
+		SHA256: sum,
+		HashV1: h1,
+	}); err != nil {
+		return err
+	}
+
+	p.logger.Info("fetched provider package from upstream",
+		zap.String("upstream", u.Hostname),
+		zap.String("namespace", namespace),
+		zap.String("name", name),
+		zap.String("version", version),
+		zap.String("os", os),
+		zap.String("arch", arch),
+	)
+	return nil
+}
+
+// lookupVersion reports whether the version is already fetched from the upstream.
+// The versions published to the registry are never mixed with the packages of the upstream, and ErrPublished is returned for them.
+func (p *Proxy) lookupVersion(ctx context.Context, namespace, name, version string) (bool, error) {
+	metadata, err := p.driver.Provider.GetVersionMetadata(ctx, namespace, name, version)
+	if err == nil {
+		if metadata.Upstream == "" {
+			return false, ErrPublished
+		}
+
+		return true, nil
+	}
+
+	if !errors.Is(err, driver.ErrProviderVersionNotExist) {
+		return false, err
+	}
+
+	// The versions created before the metadata is introduced
+	if err := p.driver.Provider.IsProviderVersionCreated(ctx, namespace, name, version); err == nil {
+		return false, ErrPublished
+	}
+
+	return false, nil
+}
+
+// fetchVersion fetches SHA256SUMS and its signature of the version, and creates the version signed by the key of the upstream
+func (p *Proxy) fetchVersion(ctx context.Context, u *Upstream, r *registry, namespace, name, version string, pkg *provider.Package) error {
+	key := strings.Join([]string{u.Hostname, namespace, name, version}, "/")
+	_, err := p.do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		return nil, p.saveVersion(ctx, u, r, namespace, name, version, pkg)
+	})
+
+	return err
+}
+
+func (p *Proxy) saveVersion(ctx context.Context, u *Upstream, r *registry, namespace, name, version string, pkg *provider.Package) error {
+	sums, err := p.read(ctx, r, pkg.SHASumsURL, p.limits.SHASums)
+	if err != nil {
+		return err
+	}
+
+	sig, err := p.read(ctx, r, pkg.SHASumsSigURL, p.limits.SHASums)
+	if err != nil {
+		return err
+	}
+
+	if _, err := artifact.ParseSHASums(bytes.NewReader(sums)); err != nil {
+		return err
+	}
+
+	key, err := signingKey(pkg, sums, sig)
+	if err != nil {
+		return err
+	}
+
+	if err := p.driver.Provider.IsProviderCreated(ctx, namespace, name); err != nil {
+		if !errors.Is(err, driver.ErrProviderNotExist) {
+			return err
+		}
+
+		if err := p.driver.Provider.CreateProvider(ctx, namespace, name); err != nil {
+			return err
+		}
+	}
+
+	if _, err := p.driver.Provider.GetGPGKey(ctx, namespace, key.KeyID); err != nil {
+		if !errors.Is(err, driver.ErrProviderGPGKeyNotExist) {
+			return err
+		}
+
+		if err := p.driver.Provider.SaveGPGKey(ctx, namespace, &driver.GPGKey{
+			KeyID:          key.KeyID,
+			ASCIIArmor:     key.ASCIIArmor,
+			Source:         key.Source,
+			SourceURL:      key.SourceURL,
+			TrustSignature: key.TrustSignature,
+			CreatedAt:      time.Now(),
+		}); err != nil {
+			return err
+		}
+	}
+
+	if _, err := p.driver.Provider.CreateProviderVersion(ctx, namespace, name, version, false); err != nil {
+		return err
+	}
+
+	if err := ignoreExists(p.driver.Provider.SaveSHASUMs(ctx, namespace, name, version, bytes.NewReader(sums), false)); err != nil {
+		return err
+	}
+
+	if err := ignoreExists(p.driver.Provider.SaveSHASUMsSig(ctx, namespace, name, version, bytes.NewReader(sig), false)); err != nil {
+		return err
+	}
+
+	// The version is already published in the upstream
+	now := time.Now()
+	return p.driver.Provider.SaveVersionMetadata(ctx, namespace, name, version, &driver.ProviderVersionMetadata{
+		KeyID:        key.KeyID,
+		Protocols:    pkg.Protocols,
+		PublishedAt:  &now,
+		State:        driver.VersionStatePublished,
+		Upstream:     u.Hostname,
+		Verification: driver.VerificationStatePending,
+	})
+}
+
+// read reads the artifact from the upstream, failing with artifact.ErrTooLarge if it exceeds the limit
+func (p *Proxy) read(ctx context.Context, r *registry, urlStr string, limit int64) ([]byte, error) {
+	rc, err := r.download(ctx, urlStr)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	if limit <= 0 {
+		return io.ReadAll(rc)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(b)) > limit {
+		return nil, fmt.Errorf("%w: %s exceeds %d bytes", artifact.ErrTooLarge, urlStr, limit)
+	}
+
+	return b, nil
+}
+
+// ignoreExists ignores the artifact saved by the fetch of another replica, which is verified against the same upstream
+func ignoreExists(err error) error {
+	if errors.Is(err, driver.ErrArtifactExists) {
+		return nil
+	}
+
+	return err
+}
+
+// signingKey returns the signing key of the package which verifies the signature of SHA256SUMS
+func signingKey(pkg *provider.Package, sums, sig []byte) (*provider.GPGPublicKey, error) {
+	if pkg.SigningKeys == nil || len(pkg.SigningKeys.GPGPublicKeys) == 0 {
+		return nil, fmt.Errorf("%w: no signing key found", artifact.ErrInvalidSignature)
+	}
+
+	var err error
+	for _, key := range pkg.SigningKeys.GPGPublicKeys {
+		if err = artifact.VerifySignature([]byte(key.ASCIIArmor), sums, sig); err == nil {
+			return &key, nil
+		}
+	}
+
+	return nil, err
+}
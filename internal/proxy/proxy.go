@@ -0,0 +1,241 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/kerraform/kegistry/internal/artifact"
+	"github.com/kerraform/kegistry/internal/driver"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	DefaultTimeout = 10 * time.Minute
+
+	// DefaultListTimeout is the timeout of listing the versions in the upstream, which the requests wait for
+	DefaultListTimeout = 10 * time.Second
+
+	// DefaultListCacheTTL is the duration to serve the versions listed in the upstream without listing them again
+	DefaultListCacheTTL = time.Minute
+)
+
+// File is the configuration of the upstreams
+//
+//	{
+//	  "upstreams": [
+//	    {
+//	      "hostname": "registry.terraform.io",
+//	      "namespaces": ["hashicorp", "integrations"]
+//	    }
+//	  ]
+//	}
+type File struct {
+	Upstreams []*Upstream `json:"upstreams"`
+}
+
+// Upstream is the provider registry to fetch the providers missing in the registry
+type Upstream struct {
+	// Hostname of the upstream, which is also the hostname of the network mirror
+	Hostname string `json:"hostname"`
+
+	// URL of the upstream for the service discovery, defaults to https://<hostname>
+	URL string `json:"url,omitempty"`
+
+	// Namespaces allowed to be fetched from the upstream, which can be the patterns of path.Match (e.g. "*")
+	Namespaces []string `json:"namespaces"`
+}
+
+// Allows reports whether the namespace is allowed to be fetched from the upstream
+func (u *Upstream) Allows(namespace string) bool {
+	for _, pattern := range u.Namespaces {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Load loads the upstreams from the JSON file
+func Load(filepath string) ([]*Upstream, error) {
+	b, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	var f File
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+
+	for i, u := range f.Upstreams {
+		if u.Hostname == "" {
+			return nil, fmt.Errorf("upstream %d: hostname is required", i)
+		}
+
+		if u.URL == "" {
+			u.URL = fmt.Sprintf("https://%s", u.Hostname)
+		}
+
+		if _, err := url.Parse(u.URL); err != nil {
+			return nil, fmt.Errorf("upstream %d: %w", i, err)
+		}
+
+		for _, pattern := range u.Namespaces {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("upstream %d: %w", i, err)
+			}
+		}
+	}
+
+	return f.Upstreams, nil
+}
+
+// Proxy fetches the providers from the upstreams and stores them to the driver, so that they are served afterwards
+// without accessing the upstreams.
+type Proxy struct {
+	client       *http.Client
+	driver       *driver.Driver
+	limits       artifact.Limits
+	listCacheTTL time.Duration
+	listTimeout  time.Duration
+	logger       *zap.Logger
+	timeout      time.Duration
+	upstreams    []*Upstream
+
+	group      singleflight.Group
+	mu         sync.Mutex
+	registries map[string]*registry
+	listings   map[string]*listing
+	sweptAt    time.Time
+	nowFunc    func() time.Time
+}
+
+type Config struct {
+	Driver     *driver.Driver
+	HTTPClient *http.Client
+
+	// Limits of the artifacts fetched from the upstreams, which are same as the uploads
+	Limits *artifact.Limits
+
+	// ListCacheTTL is the duration to cache the versions listed in the upstreams, defaults to DefaultListCacheTTL
+	ListCacheTTL time.Duration
+
+	// ListTimeout is the timeout of listing the versions in the upstreams, defaults to DefaultListTimeout
+	ListTimeout time.Duration
+	Logger      *zap.Logger
+
+	// Timeout of the fetch shared by the concurrent requests, defaults to DefaultTimeout
+	Timeout   time.Duration
+	Upstreams []*Upstream
+}
+
+func New(cfg *Config) *Proxy {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{
+			Timeout: timeout,
+		}
+	}
+
+	listTimeout := cfg.ListTimeout
+	if listTimeout == 0 {
+		listTimeout = DefaultListTimeout
+	}
+
+	listCacheTTL := cfg.ListCacheTTL
+	if listCacheTTL == 0 {
+		listCacheTTL = DefaultListCacheTTL
+	}
+
+	var limits artifact.Limits
+	if cfg.Limits != nil {
+		limits = *cfg.Limits
+	}
+
+	return &Proxy{
+		client:       client,
+		driver:       cfg.Driver,
+		limits:       limits,
+		listCacheTTL: listCacheTTL,
+		listTimeout:  listTimeout,
+		logger:       cfg.Logger,
+		timeout:      timeout,
+		upstreams:    cfg.Upstreams,
+		registries:   map[string]*registry{},
+		listings:     map[string]*listing{},
+		nowFunc:      time.Now,
+	}
+}
+
+// Upstream returns the upstream of the hostname allowing the namespace, or nil if none.
+// The first upstream allowing the namespace is returned if the hostname is empty, as the registry protocol does not have the hostname.
+func (p *Proxy) Upstream(hostname, namespace string) *Upstream {
+	if p == nil {
+		return nil
+	}
+
+	for _, u := range p.upstreams {
+		if hostname != "" && u.Hostname != hostname {
+			continue
+		}
+
+		if u.Allows(namespace) {
+			return u
+		}
+	}
+
+	return nil
+}
+
+// do runs the function of the key once for the concurrent callers, and returns its result to each of them.
+// The function runs on the context detached from the caller with the timeout of the proxy, so that the caller
+// which gives up does not fail the others waiting for the same result.
+func (p *Proxy) do(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	ch := p.group.DoChan(key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(detachedContext{parent: ctx}, p.timeout)
+		defer cancel()
+		return fn(ctx)
+	})
+
+	select {
+	case res := <-ch:
+		return res.Val, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// detachedContext keeps the values of the parent context (e.g. the logger), but is never canceled with the parent
+type detachedContext struct {
+	parent context.Context
+}
+
+func (c detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (c detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (c detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
@@ -0,0 +1,479 @@
+package proxy
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/kerraform/kegistry/internal/artifact"
+	"github.com/kerraform/kegistry/internal/driver"
+	"github.com/kerraform/kegistry/internal/driver/memory"
+	"github.com/kerraform/kegistry/internal/model"
+	"github.com/kerraform/kegistry/internal/model/provider"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	testNamespace = "hashicorp"
+	testName      = "foo"
+	testVersion   = "1.0.0"
+	testOS        = "linux"
+	testArch      = "amd64"
+)
+
+// fakeUpstream serves the provider registry protocol of the single provider package signed by the generated key
+type fakeUpstream struct {
+	*httptest.Server
+
+	binary   []byte
+	filename string
+	keyID    string
+	sig      []byte
+	sums     []byte
+
+	// discoveries counts the requests of the service discovery
+	discoveries int32
+
+	// listings counts the requests to list the available versions
+	listings int32
+
+	// release blocks the service discovery and the download of the package until closed, if set
+	release chan struct{}
+
+	// shasum overrides the sha256 sum of the package in the response of the download, if set
+	shasum string
+}
+
+func newFakeUpstream(t *testing.T) *fakeUpstream {
+	t.Helper()
+	u := &fakeUpstream{
+		filename: fmt.Sprintf("terraform-provider-%s_%s_%s_%s.zip", testName, testVersion, testOS, testArch),
+	}
+
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	w, err := zw.Create(fmt.Sprintf("terraform-provider-%s_v%s", testName, testVersion))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Write([]byte("provider")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	u.binary = buf.Bytes()
+
+	sum := sha256.Sum256(u.binary)
+	u.sums = []byte(fmt.Sprintf("%s  %s\n", hex.EncodeToString(sum[:]), u.filename))
+
+	e, err := openpgp.NewEntity("upstream", "", "upstream@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.keyID = e.PrimaryKey.KeyIdString()
+
+	sig := new(bytes.Buffer)
+	if err := openpgp.DetachSign(sig, e, bytes.NewReader(u.sums), nil); err != nil {
+		t.Fatal(err)
+	}
+	u.sig = sig.Bytes()
+
+	key := new(bytes.Buffer)
+	aw, err := armor.Encode(key, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.Serialize(aw); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := aw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	providers := fmt.Sprintf("/v1/providers/%s/%s", testNamespace, testName)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/terraform.json", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&u.discoveries, 1)
+		u.wait(r)
+		writeJSON(w, &model.Service{ProvidersV1: "/v1/providers/"})
+	})
+	mux.HandleFunc(providers+"/versions", func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&u.listings, 1)
+		writeJSON(w, &listAvailableVersionsResponse{
+			Versions: []provider.AvailableVersion{
+				{
+					Version:   testVersion,
+					Protocols: []string{"5.0"},
+					Platforms: []provider.AvailableVersionPlatform{{OS: testOS, Arch: testArch}},
+				},
+			},
+		})
+	})
+	mux.HandleFunc(fmt.Sprintf("%s/%s/download/%s/%s", providers, testVersion, testOS, testArch), func(w http.ResponseWriter, _ *http.Request) {
+		shasum := u.shasum
+		if shasum == "" {
+			shasum = hex.EncodeToString(sum[:])
+		}
+
+		// The URLs are relative to the request
+		writeJSON(w, &provider.Package{
+			Protocols:     []string{"5.0"},
+			OS:            testOS,
+			Arch:          testArch,
+			Filename:      u.filename,
+			DownloadURL:   "/files/" + u.filename,
+			SHASumsURL:    "/files/SHA256SUMS",
+			SHASumsSigURL: "/files/SHA256SUMS.sig",
+			SHASum:        shasum,
+			SigningKeys: &provider.SigningKeys{
+				GPGPublicKeys: []provider.GPGPublicKey{
+					{KeyID: u.keyID, ASCIIArmor: key.String()},
+				},
+			},
+		})
+	})
+	mux.HandleFunc("/files/"+u.filename, func(w http.ResponseWriter, r *http.Request) {
+		u.wait(r)
+		w.Write(u.binary)
+	})
+	mux.HandleFunc("/files/SHA256SUMS", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(u.sums)
+	})
+	mux.HandleFunc("/files/SHA256SUMS.sig", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(u.sig)
+	})
+
+	u.Server = httptest.NewServer(mux)
+	t.Cleanup(u.Close)
+	return u
+}
+
+func (u *fakeUpstream) wait(r *http.Request) {
+	if u.release == nil {
+		return
+	}
+
+	select {
+	case <-u.release:
+	case <-r.Context().Done():
+	}
+}
+
+func (u *fakeUpstream) upstream(hostname string) *Upstream {
+	return &Upstream{
+		Hostname:   hostname,
+		URL:        u.URL,
+		Namespaces: []string{testNamespace},
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func newProxy(upstreams ...*Upstream) *Proxy {
+	return newProxyWithConfig(&Config{}, upstreams...)
+}
+
+// newProxyWithConfig returns the proxy of the config with the memory driver
+func newProxyWithConfig(cfg *Config, upstreams ...*Upstream) *Proxy {
+	cfg.Driver = memory.NewDriver(&memory.DriverConfig{
+		Logger: zap.NewNop(),
+		Tracer: trace.NewNoopTracerProvider().Tracer(""),
+	})
+	cfg.Logger = zap.NewNop()
+	cfg.Timeout = 10 * time.Second
+	cfg.Upstreams = upstreams
+	return New(cfg)
+}
+
+func TestFetchPackage(t *testing.T) {
+	fake := newFakeUpstream(t)
+	u := fake.upstream("registry.example.com")
+	p := newProxy(u)
+	ctx := context.Background()
+
+	fetched, err := p.FetchPackage(ctx, u, testNamespace, testName, testVersion, testOS, testArch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !fetched {
+		t.Fatal("expected the package to be fetched")
+	}
+
+	rc, err := p.driver.Provider.GetPlatformBinary(ctx, testNamespace, testName, testVersion, testOS, testArch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc.Close()
+
+	metadata, err := p.driver.Provider.GetVersionMetadata(ctx, testNamespace, testName, testVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if metadata.Upstream != u.Hostname || metadata.KeyID != fake.keyID || metadata.State != driver.VersionStatePublished {
+		t.Fatalf("unexpected version metadata: %+v", metadata)
+	}
+
+	platform, err := p.driver.Provider.GetPlatformMetadata(ctx, testNamespace, testName, testVersion, testOS, testArch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256(fake.binary)
+	if platform.SHA256 != hex.EncodeToString(sum[:]) || platform.Size != int64(len(fake.binary)) || !strings.HasPrefix(platform.HashV1, "h1:") {
+		t.Fatalf("unexpected platform metadata: %+v", platform)
+	}
+
+	// The stored package is served without accessing the upstream
+	fetched, err = p.FetchPackage(ctx, u, testNamespace, testName, testVersion, testOS, testArch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fetched {
+		t.Fatal("expected the stored package not to be fetched again")
+	}
+
+	if n := atomic.LoadInt32(&fake.discoveries); n != 1 {
+		t.Fatalf("expected the upstream to be discovered once, got %d times", n)
+	}
+}
+
+func TestFetchPackageSHASumMismatch(t *testing.T) {
+	fake := newFakeUpstream(t)
+	fake.shasum = strings.Repeat("0", 64)
+	u := fake.upstream("registry.example.com")
+	p := newProxy(u)
+	ctx := context.Background()
+
+	if _, err := p.FetchPackage(ctx, u, testNamespace, testName, testVersion, testOS, testArch); err == nil {
+		t.Fatal("expected the package of the mismatched sha256 sum to be refused")
+	}
+
+	if _, err := p.driver.Provider.GetPlatformBinary(ctx, testNamespace, testName, testVersion, testOS, testArch); !errors.Is(err, driver.ErrProviderBinaryNotExist) {
+		t.Fatalf("expected %v, got %v", driver.ErrProviderBinaryNotExist, err)
+	}
+}
+
+func TestFetchPackageTooLarge(t *testing.T) {
+	cases := map[string]func(fake *fakeUpstream) *artifact.Limits{
+		"binary": func(fake *fakeUpstream) *artifact.Limits {
+			return &artifact.Limits{ProviderBinary: int64(len(fake.binary)) - 1}
+		},
+		"SHA256SUMS": func(fake *fakeUpstream) *artifact.Limits {
+			return &artifact.Limits{SHASums: int64(len(fake.sums)) - 1}
+		},
+	}
+
+	for name, limits := range cases {
+		t.Run(name, func(t *testing.T) {
+			fake := newFakeUpstream(t)
+			u := fake.upstream("registry.example.com")
+			p := newProxyWithConfig(&Config{Limits: limits(fake)}, u)
+			ctx := context.Background()
+
+			if _, err := p.FetchPackage(ctx, u, testNamespace, testName, testVersion, testOS, testArch); !errors.Is(err, artifact.ErrTooLarge) {
+				t.Fatalf("expected %v, got %v", artifact.ErrTooLarge, err)
+			}
+
+			if _, err := p.driver.Provider.GetPlatformBinary(ctx, testNamespace, testName, testVersion, testOS, testArch); !errors.Is(err, driver.ErrProviderBinaryNotExist) {
+				t.Fatalf("expected %v, got %v", driver.ErrProviderBinaryNotExist, err)
+			}
+		})
+	}
+}
+
+func TestFetchPackagePublished(t *testing.T) {
+	fake := newFakeUpstream(t)
+	u := fake.upstream("registry.example.com")
+	p := newProxy(u)
+	ctx := context.Background()
+
+	if err := p.driver.Provider.CreateProvider(ctx, testNamespace, testName); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.driver.Provider.CreateProviderVersion(ctx, testNamespace, testName, testVersion, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.driver.Provider.SaveVersionMetadata(ctx, testNamespace, testName, testVersion, &driver.ProviderVersionMetadata{
+		State: driver.VersionStatePublished,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.FetchPackage(ctx, u, testNamespace, testName, testVersion, testOS, testArch); !errors.Is(err, ErrPublished) {
+		t.Fatalf("expected %v, got %v", ErrPublished, err)
+	}
+}
+
+// The caller giving up must not fail the fetch shared with the other callers
+func TestFetchPackageCanceledCaller(t *testing.T) {
+	fake := newFakeUpstream(t)
+	fake.release = make(chan struct{})
+	u := fake.upstream("registry.example.com")
+	p := newProxy(u)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := p.FetchPackage(canceled, u, testNamespace, testName, testVersion, testOS, testArch)
+		first <- err
+	}()
+
+	// Wait for the first caller to start the fetch before the second caller joins it
+	for atomic.LoadInt32(&fake.discoveries) == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var wg sync.WaitGroup
+	var second error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, second = p.FetchPackage(context.Background(), u, testNamespace, testName, testVersion, testOS, testArch)
+	}()
+
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v for the canceled caller, got %v", context.Canceled, err)
+	}
+
+	close(fake.release)
+	wg.Wait()
+	if second != nil {
+		t.Fatalf("expected the fetch to go on for the other caller, got %v", second)
+	}
+
+	rc, err := p.driver.Provider.GetPlatformBinary(context.Background(), testNamespace, testName, testVersion, testOS, testArch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc.Close()
+}
+
+// The discovery of the unavailable upstream must not block the requests to the others
+func TestListAvailableVersionsDiscoveryNotBlocking(t *testing.T) {
+	stuck := newFakeUpstream(t)
+	stuck.release = make(chan struct{})
+	defer close(stuck.release)
+
+	available := newFakeUpstream(t)
+	su := stuck.upstream("stuck.example.com")
+	au := available.upstream("available.example.com")
+	p := newProxy(su, au)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.ListAvailableVersions(ctx, su, testNamespace, testName)
+
+	for atomic.LoadInt32(&stuck.discoveries) == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.ListAvailableVersions(context.Background(), au, testNamespace, testName)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the discovery of the available upstream is blocked by the stuck one")
+	}
+}
+
+func TestListAvailableVersionsCached(t *testing.T) {
+	fake := newFakeUpstream(t)
+	u := fake.upstream("registry.example.com")
+	p := newProxyWithConfig(&Config{ListCacheTTL: time.Minute}, u)
+	now := time.Now()
+	p.nowFunc = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		versions, err := p.ListAvailableVersions(ctx, u, testNamespace, testName)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(versions) != 1 || versions[0].Version != testVersion {
+			t.Fatalf("unexpected versions: %+v", versions)
+		}
+	}
+
+	if n := atomic.LoadInt32(&fake.listings); n != 1 {
+		t.Fatalf("expected the versions to be listed once, got %d times", n)
+	}
+
+	if _, err := p.ListAvailableVersions(ctx, u, testNamespace, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected %v, got %v", ErrNotFound, err)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := p.ListAvailableVersions(ctx, u, testNamespace, testName); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := atomic.LoadInt32(&fake.listings); n != 2 {
+		t.Fatalf("expected the expired versions to be listed again, got %d times", n)
+	}
+
+	if _, ok := p.listings[strings.Join([]string{u.Hostname, testNamespace, "missing"}, "/")]; ok {
+		t.Fatal("expected the expired listing to be removed")
+	}
+}
+
+// The unavailable upstream must not hold the requests longer than the timeout of the listing
+func TestListAvailableVersionsTimeout(t *testing.T) {
+	stuck := newFakeUpstream(t)
+	stuck.release = make(chan struct{})
+	defer close(stuck.release)
+
+	u := stuck.upstream("stuck.example.com")
+	p := newProxyWithConfig(&Config{ListTimeout: 100 * time.Millisecond}, u)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.ListAvailableVersions(context.Background(), u, testNamespace, testName)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the listing of the stuck upstream does not time out")
+	}
+}
@@ -0,0 +1,160 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/kerraform/kegistry/internal/model"
+	"github.com/kerraform/kegistry/internal/model/provider"
+)
+
+var (
+	ErrNotFound  = errors.New("not found in the upstream")
+	ErrPublished = errors.New("version is published to the registry, not fetched from the upstream")
+)
+
+// registry is the provider registry protocol client of the upstream
+type registry struct {
+	client    *http.Client
+	providers *url.URL
+}
+
+type listAvailableVersionsResponse struct {
+	Versions []provider.AvailableVersion `json:"versions"`
+}
+
+// registry returns the client of the upstream, discovering its provider registry at the first time.
+// The discovery is done out of the lock, so that the unavailable upstream does not block the others.
+func (p *Proxy) registry(ctx context.Context, u *Upstream) (*registry, error) {
+	p.mu.Lock()
+	r, ok := p.registries[u.Hostname]
+	p.mu.Unlock()
+	if ok {
+		return r, nil
+	}
+
+	v, err := p.do(ctx, "discovery/"+u.Hostname, func(ctx context.Context) (interface{}, error) {
+		r, err := p.discover(ctx, u)
+		if err != nil {
+			return nil, err
+		}
+
+		p.mu.Lock()
+		p.registries[u.Hostname] = r
+		p.mu.Unlock()
+		return r, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*registry), nil
+}
+
+// discover discovers the provider registry of the upstream
+func (p *Proxy) discover(ctx context.Context, u *Upstream) (*registry, error) {
+	base, err := url.Parse(u.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &registry{
+		client: p.client,
+	}
+
+	// See: https://www.terraform.io/internals/remote-service-discovery
+	var svc model.Service
+	if err := r.getJSON(ctx, base.JoinPath(".well-known", "terraform.json"), &svc); err != nil {
+		return nil, fmt.Errorf("failed to discover the upstream %s: %w", u.Hostname, err)
+	}
+
+	if svc.ProvidersV1 == "" {
+		return nil, fmt.Errorf("upstream %s does not support the provider registry", u.Hostname)
+	}
+
+	r.providers, err = base.Parse(svc.ProvidersV1)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// https://developer.hashicorp.com/terraform/internals/provider-registry-protocol#list-available-versions
+func (r *registry) listAvailableVersions(ctx context.Context, namespace, name string) ([]provider.AvailableVersion, error) {
+	var resp listAvailableVersionsResponse
+	if err := r.getJSON(ctx, r.providers.JoinPath(namespace, name, "versions"), &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Versions, nil
+}
+
+// findPackage finds the package, whose URLs are resolved against the request as they can be relative
+// https://developer.hashicorp.com/terraform/internals/provider-registry-protocol#find-a-provider-package
+func (r *registry) findPackage(ctx context.Context, namespace, name, version, os, arch string) (*provider.Package, error) {
+	u := r.providers.JoinPath(namespace, name, version, "download", os, arch)
+
+	var pkg provider.Package
+	if err := r.getJSON(ctx, u, &pkg); err != nil {
+		return nil, err
+	}
+
+	for _, s := range []*string{&pkg.DownloadURL, &pkg.SHASumsURL, &pkg.SHASumsSigURL} {
+		ref, err := u.Parse(*s)
+		if err != nil {
+			return nil, err
+		}
+		*s = ref.String()
+	}
+
+	return &pkg, nil
+}
+
+// download downloads the file of the package, which is often hosted out of the registry (e.g. GitHub releases)
+func (r *registry) download(ctx context.Context, urlStr string) (io.ReadCloser, error) {
+	resp, err := r.get(ctx, urlStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+func (r *registry) getJSON(ctx context.Context, u *url.URL, v interface{}) error {
+	resp, err := r.get(ctx, u.String())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (r *registry) get(ctx context.Context, urlStr string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlStr, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, urlStr)
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("invalid status code from %s, got: %d", urlStr, resp.StatusCode)
+	}
+}
@@ -0,0 +1,124 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+type Group string
+
+const (
+	GroupLogin        Group = "login"
+	GroupModuleRead   Group = "module-read"
+	GroupProviderRead Group = "provider-read"
+	GroupUpload       Group = "upload"
+)
+
+const (
+	// sweepInterval is the interval to drop the buckets of the idle clients
+	sweepInterval = time.Minute
+)
+
+// Limit is the token bucket configuration.
+// Rate is the number of tokens refilled per second, zero means unlimited.
+// Burst less than one is treated as one.
+type Limit struct {
+	Burst int
+	Rate  float64
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter limits the requests by the token bucket per group and key
+type Limiter struct {
+	limits map[Group]Limit
+
+	mu      sync.Mutex
+	buckets map[Group]map[string]*bucket
+	sweptAt time.Time
+	nowFunc func() time.Time
+}
+
+type Config struct {
+	Limits map[Group]Limit
+}
+
+func New(cfg *Config) *Limiter {
+	limits := make(map[Group]Limit, len(cfg.Limits))
+	for group, limit := range cfg.Limits {
+		if limit.Burst < 1 {
+			limit.Burst = 1
+		}
+		limits[group] = limit
+	}
+
+	return &Limiter{
+		limits:  limits,
+		buckets: map[Group]map[string]*bucket{},
+		nowFunc: time.Now,
+	}
+}
+
+// Allow consumes a token of the key in the group.
+// It returns the duration to wait for the next token if the request is throttled.
+func (l *Limiter) Allow(group Group, key string) (bool, time.Duration) {
+	limit, ok := l.limits[group]
+	if !ok || limit.Rate <= 0 {
+		return true, 0
+	}
+
+	burst := float64(limit.Burst)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.nowFunc()
+	l.sweep(now)
+
+	bs, ok := l.buckets[group]
+	if !ok {
+		bs = map[string]*bucket{}
+		l.buckets[group] = bs
+	}
+
+	b, ok := bs[key]
+	if !ok {
+		b = &bucket{
+			tokens: burst,
+			last:   now,
+		}
+		bs[key] = b
+	}
+
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	return false, wait
+}
+
+// sweep drops the buckets which are refilled to the full, which is same as no bucket
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.sweptAt) < sweepInterval {
+		return
+	}
+	l.sweptAt = now
+
+	for group, bs := range l.buckets {
+		limit := l.limits[group]
+		for key, b := range bs {
+			if b.tokens+now.Sub(b.last).Seconds()*limit.Rate >= float64(limit.Burst) {
+				delete(bs, key)
+			}
+		}
+	}
+}
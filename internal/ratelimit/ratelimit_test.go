@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// clock is the fake clock advanced by the tests
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newLimiter(limit Limit) (*Limiter, *clock) {
+	c := &clock{now: time.Unix(0, 0)}
+	l := New(&Config{
+		Limits: map[Group]Limit{
+			GroupUpload: limit,
+		},
+	})
+	l.nowFunc = c.Now
+	return l, c
+}
+
+func TestAllow(t *testing.T) {
+	l, c := newLimiter(Limit{Burst: 2, Rate: 1})
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.Allow(GroupUpload, "a"); !ok {
+			t.Fatalf("expected the request %d within the burst to be allowed", i)
+		}
+	}
+
+	ok, wait := l.Allow(GroupUpload, "a")
+	if ok {
+		t.Fatal("expected the request over the burst to be throttled")
+	}
+
+	if wait != time.Second {
+		t.Fatalf("expected to wait %v, got %v", time.Second, wait)
+	}
+
+	// The buckets are per key
+	if ok, _ := l.Allow(GroupUpload, "b"); !ok {
+		t.Fatal("expected the request of the other key to be allowed")
+	}
+
+	c.Advance(500 * time.Millisecond)
+	ok, wait = l.Allow(GroupUpload, "a")
+	if ok {
+		t.Fatal("expected the request before the refill to be throttled")
+	}
+
+	if wait != 500*time.Millisecond {
+		t.Fatalf("expected to wait %v, got %v", 500*time.Millisecond, wait)
+	}
+
+	c.Advance(500 * time.Millisecond)
+	if ok, _ := l.Allow(GroupUpload, "a"); !ok {
+		t.Fatal("expected the request after the refill to be allowed")
+	}
+}
+
+func TestAllowUnlimited(t *testing.T) {
+	l, _ := newLimiter(Limit{Burst: 1})
+
+	for i := 0; i < 10; i++ {
+		if ok, _ := l.Allow(GroupUpload, "a"); !ok {
+			t.Fatal("expected the request of the zero rate to be allowed")
+		}
+
+		if ok, _ := l.Allow(GroupModuleRead, "a"); !ok {
+			t.Fatal("expected the request of the unconfigured group to be allowed")
+		}
+	}
+}
+
+func TestAllowZeroBurst(t *testing.T) {
+	l, c := newLimiter(Limit{Rate: 0.001})
+
+	if ok, _ := l.Allow(GroupUpload, "a"); !ok {
+		t.Fatal("expected the first request to be allowed by the burst of one")
+	}
+
+	if ok, _ := l.Allow(GroupUpload, "a"); ok {
+		t.Fatal("expected the request over the burst of one to be throttled")
+	}
+
+	// The bucket not refilled must survive the sweep, otherwise the next request gets the full bucket
+	c.Advance(sweepInterval)
+	if ok, _ := l.Allow(GroupUpload, "a"); ok {
+		t.Fatal("expected the request before the refill to be throttled")
+	}
+}
+
+func TestSweep(t *testing.T) {
+	l, c := newLimiter(Limit{Burst: 2, Rate: 0.01})
+
+	l.Allow(GroupUpload, "idle")
+	c.Advance(sweepInterval)
+	l.Allow(GroupUpload, "active")
+	l.Allow(GroupUpload, "active")
+
+	// The idle bucket is refilled to 1.6 of 2 tokens, which is kept
+	if _, ok := l.buckets[GroupUpload]["idle"]; !ok {
+		t.Fatal("expected the bucket not refilled to the full to be kept")
+	}
+
+	c.Advance(2 * sweepInterval)
+	l.Allow(GroupUpload, "other")
+
+	if _, ok := l.buckets[GroupUpload]["idle"]; ok {
+		t.Fatal("expected the bucket refilled to the full to be dropped")
+	}
+
+	if _, ok := l.buckets[GroupUpload]["active"]; !ok {
+		t.Fatal("expected the bucket not refilled to the full to be kept")
+	}
+}
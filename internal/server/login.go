@@ -0,0 +1,32 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/kerraform/kegistry/internal/middleware"
+	"github.com/kerraform/kegistry/internal/ratelimit"
+)
+
+const (
+	oauthAuthorizationPath = "/oauth/authorization"
+	oauthTokenPath         = "/oauth/token"
+)
+
+func (s *Server) registerLoginHandler() {
+	if s.login == nil {
+		return
+	}
+
+	authorization, token := s.login.Authorization(), s.login.Token()
+
+	// The passwords checked by the basic authentication are throttled per client against guessing them
+	if s.rateLimiter != nil {
+		limit := middleware.RateLimitGroup(s.rateLimiter, s.metric, ratelimit.GroupLogin)
+		authorization, token = limit(authorization), limit(token)
+	}
+
+	// Login Protocol
+	// https://developer.hashicorp.com/terraform/internals/login-protocol
+	s.mux.Methods(http.MethodGet).Path(oauthAuthorizationPath).Handler(authorization)
+	s.mux.Methods(http.MethodPost).Path(oauthTokenPath).Handler(token)
+}
@@ -4,11 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/kerraform/kegistry/internal/grammar"
 	"github.com/kerraform/kegistry/internal/handler"
 	"github.com/kerraform/kegistry/internal/middleware"
 	"github.com/kerraform/kegistry/internal/model"
+	"github.com/kerraform/kegistry/internal/oauth"
+	"github.com/kerraform/kegistry/internal/ratelimit"
 )
 
 const (
@@ -16,8 +19,12 @@ const (
 )
 
 var (
+	v1AuditPath     = "/v1/audit"
+	v1GPGKeysPath   = "/v1/gpg-keys"
+	v1MirrorPath    = "/v1/mirror"
 	v1ModulesPath   = "/v1/modules"
 	v1ProvidersPath = "/v1/providers"
+	v1TokensPath    = "/v1/tokens"
 )
 
 func (s *Server) registerRegistryHandler() {
@@ -30,10 +37,36 @@ func (s *Server) registerRegistryHandler() {
 	s.mux.Methods(http.MethodGet).Path("/.well-known/terraform.json").Handler(s.ServiceDiscovery())
 
 	registry := s.mux.PathPrefix(registryPath).Subrouter()
+	if s.rateLimiter != nil {
+		registry.Use(middleware.RateLimit(s.rateLimiter, s.metric, registryReadGroup))
+	}
+	if s.authenticator != nil {
+		registry.Use(middleware.Authenticate(s.authenticator, s.anonymousRead))
+	}
+	if s.rateLimiter != nil {
+		registry.Use(middleware.RateLimitSubject(s.rateLimiter, s.metric, registryReadGroup))
+	}
 
 	// Add GPG Key
 	// https://www.terraform.io/cloud-docs/api-docs/private-registry/gpg-keys#add-a-gpg-key
 	registry.Methods(http.MethodPost).Path("/v1/gpg-key").Handler(s.v1.AddGPGKey())
+	registry.Methods(http.MethodPost).Path(v1GPGKeysPath).Handler(s.v1.AddGPGKey())
+
+	// Manage GPG keys
+	// Inspired by Terraform Cloud API:
+	// https://developer.hashicorp.com/terraform/cloud-docs/api-docs/private-registry/gpg-keys
+	registry.Methods(http.MethodGet).Path(v1GPGKeysPath + "/{namespace}").Handler(s.v1.ListGPGKeys())
+	registry.Methods(http.MethodGet).Path(v1GPGKeysPath + "/{namespace}/{keyID}").Handler(s.v1.GetGPGKey())
+	registry.Methods(http.MethodPatch).Path(v1GPGKeysPath + "/{namespace}/{keyID}").Handler(s.v1.UpdateGPGKey())
+	registry.Methods(http.MethodDelete).Path(v1GPGKeysPath + "/{namespace}/{keyID}").Handler(s.v1.DeleteGPGKey())
+
+	// Audit events of the namespace
+	registry.Methods(http.MethodGet).Path(v1AuditPath + "/{namespace}").Handler(s.v1.ListAuditEvents())
+
+	// API tokens
+	registry.Methods(http.MethodPost).Path(v1TokensPath).Handler(s.v1.CreateToken())
+	registry.Methods(http.MethodGet).Path(v1TokensPath).Handler(s.v1.ListTokens())
+	registry.Methods(http.MethodDelete).Path(v1TokensPath + "/{id}").Handler(s.v1.RevokeToken())
 
 	module := registry.PathPrefix(v1ModulesPath).Subrouter()
 	module.Use(middleware.Enable(middleware.ModuleRegistryType, s.enableModule))
@@ -49,10 +82,26 @@ func (s *Server) registerRegistryHandler() {
 	// Upload a version
 	module.Methods(http.MethodPut).Path(fmt.Sprintf("/{namespace}/{name}/{provider}/versions/{version:%s}", grammar.Version)).Handler(s.v1.Module.UploadModuleVersion())
 
+	// Delete a version
+	// https://developer.hashicorp.com/terraform/cloud-docs/api-docs/private-registry/modules#delete-a-module
+	module.Methods(http.MethodDelete).Path(fmt.Sprintf("/{namespace}/{name}/{provider}/versions/{version:%s}", grammar.Version)).Handler(s.v1.Module.DeleteModuleVersion())
+
+	// Deprecates and undeprecates a version
+	module.Methods(http.MethodPost).Path(fmt.Sprintf("/{namespace}/{name}/{provider}/versions/{version:%s}/deprecate", grammar.Version)).Handler(s.v1.Module.DeprecateModuleVersion())
+	module.Methods(http.MethodDelete).Path(fmt.Sprintf("/{namespace}/{name}/{provider}/versions/{version:%s}/deprecate", grammar.Version)).Handler(s.v1.Module.UndeprecateModuleVersion())
+
+	// Yanks and unyanks a version
+	module.Methods(http.MethodPost).Path(fmt.Sprintf("/{namespace}/{name}/{provider}/versions/{version:%s}/yank", grammar.Version)).Handler(s.v1.Module.YankModuleVersion())
+	module.Methods(http.MethodDelete).Path(fmt.Sprintf("/{namespace}/{name}/{provider}/versions/{version:%s}/yank", grammar.Version)).Handler(s.v1.Module.UnyankModuleVersion())
+
 	// Create module
 	// https://www.terraform.io/cloud-docs/api-docs/private-registry/modules#create-a-module-with-no-vcs-connection
 	module.Methods(http.MethodPost).Path("/{namespace}").Handler(s.v1.Module.CreateModule())
 
+	// Delete module
+	// https://developer.hashicorp.com/terraform/cloud-docs/api-docs/private-registry/modules#delete-a-module
+	module.Methods(http.MethodDelete).Path("/{namespace}/{name}/{provider}").Handler(s.v1.Module.DeleteModule())
+
 	// Download source code
 	// https://www.terraform.io/internals/module-registry-protocol#download-source-code-for-a-specific-module-version
 	module.Methods(http.MethodGet).Path(fmt.Sprintf("/{namespace}/{name}/{provider}/{version:%s}/download", grammar.Version)).Handler(s.v1.Module.FindSourceCode())
@@ -61,6 +110,17 @@ func (s *Server) registerRegistryHandler() {
 	// https://www.terraform.io/internals/module-registry-protocol#download-source-code-for-a-specific-module-version
 	module.Methods(http.MethodGet).Path(fmt.Sprintf("/{namespace}/{name}/{provider}/{version:%s}/{file}", grammar.Version)).Handler(s.v1.Module.Download())
 
+	mirror := registry.PathPrefix(v1MirrorPath).Subrouter()
+	mirror.Use(middleware.Enable(middleware.ProviderRegistryType, s.enableProvider))
+
+	// Provider Network Mirror Protocol
+	// https://developer.hashicorp.com/terraform/internals/provider-network-mirror-protocol
+	mirror.Methods(http.MethodGet).Path("/{hostname}/{namespace}/{type}/index.json").Handler(s.v1.Provider.MirrorIndex())
+	mirror.Methods(http.MethodGet).Path(fmt.Sprintf("/{hostname}/{namespace}/{type}/{version:%s}.json", grammar.Version)).Handler(s.v1.Provider.MirrorVersion())
+
+	// Downloads a package of the network mirror, which is fetched from the upstream if missing
+	mirror.Methods(http.MethodGet).Path(fmt.Sprintf("/{hostname}/{namespace}/{type}/{version:%s}/download/{os}/{arch}", grammar.Version)).Handler(s.v1.Provider.MirrorDownload())
+
 	provider := registry.PathPrefix(v1ProvidersPath).Subrouter()
 	provider.Use(middleware.Enable(middleware.ProviderRegistryType, s.enableProvider))
 
@@ -97,21 +157,69 @@ func (s *Server) registerRegistryHandler() {
 	provider.Methods(http.MethodPut).Path(fmt.Sprintf("/{namespace}/{registryName}/versions/{version:%s}/shasums-sig", grammar.Version)).Handler(s.v1.Provider.UploadSHASumsSignature())
 	provider.Methods(http.MethodGet).Path(fmt.Sprintf("/{namespace}/{registryName}/versions/{version:%s}/shasums-sig", grammar.Version)).Handler(s.v1.Provider.DownloadSHASumsSignature())
 
+	// Uploads a provider version terraform-registry-manifest.json to declare its protocols
+	provider.Methods(http.MethodPut).Path(fmt.Sprintf("/{namespace}/{registryName}/versions/{version:%s}/manifest", grammar.Version)).Handler(s.v1.Provider.UploadRegistryManifest())
+
+	// Deletes a provider version
+	// Inspired by Terraform Cloud API:
+	// https://developer.hashicorp.com/terraform/cloud-docs/api-docs/private-registry/provider-versions-platforms#delete-a-provider-version
+	provider.Methods(http.MethodDelete).Path(fmt.Sprintf("/{namespace}/{registryName}/versions/{version:%s}", grammar.Version)).Handler(s.v1.Provider.DeleteProviderVersion())
+
+	// Yanks and unyanks a provider version
+	provider.Methods(http.MethodPost).Path(fmt.Sprintf("/{namespace}/{registryName}/versions/{version:%s}/yank", grammar.Version)).Handler(s.v1.Provider.YankProviderVersion())
+	provider.Methods(http.MethodDelete).Path(fmt.Sprintf("/{namespace}/{registryName}/versions/{version:%s}/yank", grammar.Version)).Handler(s.v1.Provider.UnyankProviderVersion())
+
+	// Publishes a draft provider version
+	provider.Methods(http.MethodPost).Path(fmt.Sprintf("/{namespace}/{registryName}/versions/{version:%s}/publish", grammar.Version)).Handler(s.v1.Provider.PublishProviderVersion())
+
+	// Checks the platform metadata of all the provider versions against the binaries, and backfills the missing ones
+	provider.Methods(http.MethodPost).Path("/{namespace}/{registryName}/fsck").Handler(s.v1.Provider.FsckProvider())
+
+	// Re-runs the verification of the SHA256SUMS signature and the platform binaries
+	provider.Methods(http.MethodPost).Path(fmt.Sprintf("/{namespace}/{registryName}/versions/{version:%s}/verify", grammar.Version)).Handler(s.v1.Provider.VerifyProviderVersion())
+
 	// Creates a provider platform
 	// Inspired by Terraform Cloud API:
 	// https://www.terraform.io/cloud-docs/api-docs/private-registry/provider-versions-platforms#create-a-provider-platform
 	provider.Methods(http.MethodPost).Path(fmt.Sprintf("/{namespace}/{registryName}/versions/{version:%s}/platforms", grammar.Version)).Handler(s.v1.Provider.CreateProviderPlatform())
 
+	// Deletes a provider platform
+	// Inspired by Terraform Cloud API:
+	// https://developer.hashicorp.com/terraform/cloud-docs/api-docs/private-registry/provider-versions-platforms#delete-a-provider-platform
+	provider.Methods(http.MethodDelete).Path(fmt.Sprintf("/{namespace}/{registryName}/versions/{version:%s}/platforms/{os}/{arch}", grammar.Version)).Handler(s.v1.Provider.DeleteProviderPlatform())
+
 	// Find a Provider Package
 	// https://www.terraform.io/internals/provider-registry-protocol#find-a-provider-package
 	provider.Methods(http.MethodGet).Path(fmt.Sprintf("/{namespace}/{registryName}/{version:%s}/download/{os}/{arch}", grammar.Version)).Handler(s.v1.Provider.FindPackage())
 }
 
+// registryReadGroup returns the rate limit group of the read request to the registry.
+// The reads of the modules are counted separately from the others, which are mostly of the providers.
+func registryReadGroup(r *http.Request) ratelimit.Group {
+	if strings.HasPrefix(r.URL.Path, registryPath+v1ModulesPath+"/") {
+		return ratelimit.GroupModuleRead
+	}
+
+	return ratelimit.GroupProviderRead
+}
+
 func (s *Server) ServiceDiscovery() http.Handler {
 	return handler.NewHandler(func(w http.ResponseWriter, _ *http.Request) error {
 		resp := &model.Service{
+			GPGKeysV1:   registryPath + v1GPGKeysPath,
 			ModulesV1:   registryPath + v1ModulesPath + "/",
 			ProvidersV1: registryPath + v1ProvidersPath + "/",
+			TokensV1:    registryPath + v1TokensPath,
+		}
+
+		if s.login != nil {
+			resp.LoginV1 = &model.LoginV1{
+				Client:     s.login.ClientID(),
+				GrantTypes: []string{oauth.GrantTypeAuthzCode},
+				Authz:      oauthAuthorizationPath,
+				Token:      oauthTokenPath,
+				Ports:      oauth.Ports,
+			}
 		}
 
 		w.WriteHeader(http.StatusOK)
@@ -2,13 +2,17 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"net"
 	"net/http"
 	"time"
 
+	"github.com/kerraform/kegistry/internal/auth"
 	"github.com/kerraform/kegistry/internal/driver"
 	"github.com/kerraform/kegistry/internal/metric"
 	"github.com/kerraform/kegistry/internal/middleware"
+	"github.com/kerraform/kegistry/internal/oauth"
+	"github.com/kerraform/kegistry/internal/ratelimit"
 	v1 "github.com/kerraform/kegistry/internal/v1"
 	"go.opentelemetry.io/otel/trace"
 
@@ -17,35 +21,50 @@ import (
 )
 
 type Server struct {
+	anonymousRead  bool
+	authenticator  auth.Authenticator
 	driver         *driver.Driver
 	enableModule   bool
 	enableProvider bool
 	logger         *zap.Logger
+	login          *oauth.Server
 	metric         *metric.RegistryMetrics
 	mux            *mux.Router
+	rateLimiter    *ratelimit.Limiter
 	tracer         trace.Tracer
+	tlsConfig      *tls.Config
 	server         *http.Server
 
 	v1 *v1.Handler
 }
 
 type ServerConfig struct {
+	AnonymousRead  bool
+	Authenticator  auth.Authenticator
 	Driver         *driver.Driver
 	EnableModule   bool
 	EnableProvider bool
 	Logger         *zap.Logger
+	Login          *oauth.Server
 	Metric         *metric.RegistryMetrics
+	RateLimiter    *ratelimit.Limiter
+	TLSConfig      *tls.Config
 	Tracer         trace.Tracer
 	V1             *v1.Handler
 }
 
 func NewServer(cfg *ServerConfig) *Server {
 	s := &Server{
+		anonymousRead:  cfg.AnonymousRead,
+		authenticator:  cfg.Authenticator,
 		driver:         cfg.Driver,
 		enableModule:   cfg.EnableModule,
 		enableProvider: cfg.EnableProvider,
 		logger:         cfg.Logger,
+		login:          cfg.Login,
 		metric:         cfg.Metric,
+		rateLimiter:    cfg.RateLimiter,
+		tlsConfig:      cfg.TLSConfig,
 		tracer:         cfg.Tracer,
 		mux:            mux.NewRouter(),
 		v1:             cfg.V1,
@@ -58,6 +77,7 @@ func NewServer(cfg *ServerConfig) *Server {
 	s.metric.RegisterAllMetrics()
 
 	s.registerRegistryHandler()
+	s.registerLoginHandler()
 	s.registerUtilHandler()
 	s.registerMetricsHandler()
 
@@ -74,13 +94,28 @@ func (s *Server) Serve(ctx context.Context, conn net.Listener) error {
 
 	s.metric.Resync(ctx)
 	s.server = server
-	if err := server.Serve(conn); err != nil && err != http.ErrServerClosed {
+
+	var err error
+	if s.tlsConfig != nil {
+		server.TLSConfig = s.tlsConfig
+		// The certificate is served by the TLS config
+		err = server.ServeTLS(conn, "", "")
+	} else {
+		err = server.Serve(conn)
+	}
+
+	if err != nil && err != http.ErrServerClosed {
 		return err
 	}
 
 	return nil
 }
 
+// Handler returns the handler of all the routes, which can be served without Serve (e.g. httptest.NewServer)
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
 func (s *Server) Shutdown(ctx context.Context) error {
 	return s.server.Shutdown(ctx)
 }
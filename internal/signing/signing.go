@@ -0,0 +1,283 @@
+package signing
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/kerraform/kegistry/internal/driver"
+	"go.uber.org/zap"
+)
+
+const (
+	// KeySource is the source of the public key of the signing key saved to the namespace
+	KeySource = "kegistry"
+)
+
+var (
+	ErrNoPrivateKey = errors.New("signing key has no private key")
+)
+
+// keyBits is the size of the generated keys, which the tests make small to generate fast
+var keyBits = 4096
+
+// Signer signs SHA256SUMS of the provider versions on behalf of the publishers.
+// The key is either loaded from the file and shared by all the namespaces,
+// or generated per namespace and stored in the backend encrypted by the passphrase if configured.
+type Signer struct {
+	driver     driver.Provider
+	entity     *openpgp.Entity
+	logger     *zap.Logger
+	passphrase []byte
+
+	mu   sync.Mutex
+	keys map[string]*namespaceKey
+}
+
+// namespaceKey is the signing key of the namespace, which is locked per namespace
+// so that generating the key of a namespace does not block the others
+type namespaceKey struct {
+	mu     sync.Mutex
+	entity *openpgp.Entity
+
+	// saved reports whether the public key is saved as the key of the namespace
+	saved bool
+}
+
+type Config struct {
+	Driver        driver.Provider
+	KeyFile       string
+	KeyPassphrase string
+	Logger        *zap.Logger
+}
+
+func New(cfg *Config) (*Signer, error) {
+	s := &Signer{
+		driver: cfg.Driver,
+		keys:   map[string]*namespaceKey{},
+		logger: cfg.Logger,
+	}
+
+	if cfg.KeyPassphrase != "" {
+		s.passphrase = []byte(cfg.KeyPassphrase)
+	}
+
+	if cfg.KeyFile != "" {
+		b, err := os.ReadFile(cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := readPrivateKey(b, s.passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signing key %s: %w", cfg.KeyFile, err)
+		}
+
+		s.entity = e
+	}
+
+	return s, nil
+}
+
+// KeyID returns the key ID of the signing key of the namespace
+func (s *Signer) KeyID(ctx context.Context, namespace string) (string, error) {
+	e, err := s.key(ctx, namespace)
+	if err != nil {
+		return "", err
+	}
+
+	return e.PrimaryKey.KeyIdString(), nil
+}
+
+// Sign returns the detached signature of the message by the signing key of the namespace
+func (s *Signer) Sign(ctx context.Context, namespace string, message []byte) ([]byte, error) {
+	e, err := s.key(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	sig := new(bytes.Buffer)
+	if err := openpgp.DetachSign(sig, e, bytes.NewReader(message), &packet.Config{
+		DefaultHash: crypto.SHA256,
+	}); err != nil {
+		return nil, err
+	}
+
+	return sig.Bytes(), nil
+}
+
+// key returns the signing key of the namespace, making sure that its public key is saved as the key of the namespace
+func (s *Signer) key(ctx context.Context, namespace string) (*openpgp.Entity, error) {
+	s.mu.Lock()
+	k, ok := s.keys[namespace]
+	if !ok {
+		k = &namespaceKey{
+			entity: s.entity,
+		}
+		s.keys[namespace] = k
+	}
+	s.mu.Unlock()
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.entity == nil {
+		e, err := s.loadOrGenerate(ctx, namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		k.entity = e
+	}
+
+	if !k.saved {
+		if err := s.savePublicKey(ctx, namespace, k.entity); err != nil {
+			return nil, err
+		}
+
+		k.saved = true
+	}
+
+	return k.entity, nil
+}
+
+func (s *Signer) loadOrGenerate(ctx context.Context, namespace string) (*openpgp.Entity, error) {
+	b, err := s.driver.GetSigningKey(ctx, namespace)
+	if err == nil {
+		return readPrivateKey(b, s.passphrase)
+	}
+
+	if !errors.Is(err, driver.ErrSigningKeyNotExist) {
+		return nil, err
+	}
+
+	s.logger.Info("generating signing key", zap.String("namespace", namespace))
+	e, err := openpgp.NewEntity(fmt.Sprintf("kegistry %s", namespace), "provider signing key", "", &packet.Config{
+		Algorithm:   packet.PubKeyAlgoRSA,
+		RSABits:     keyBits,
+		DefaultHash: crypto.SHA256,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.passphrase != nil {
+		if err := encryptPrivateKey(e, s.passphrase); err != nil {
+			return nil, err
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	w, err := armor.Encode(buf, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// The self signatures made by NewEntity are kept, as the encrypted key cannot sign them again
+	if err := e.SerializePrivateWithoutSigning(w, nil); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := s.driver.SaveSigningKey(ctx, namespace, buf.Bytes()); err != nil {
+		return nil, err
+	}
+
+	// Read it back, as the key of another replica may have been saved first
+	b, err = s.driver.GetSigningKey(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return readPrivateKey(b, s.passphrase)
+}
+
+func (s *Signer) savePublicKey(ctx context.Context, namespace string, e *openpgp.Entity) error {
+	keyID := e.PrimaryKey.KeyIdString()
+	_, err := s.driver.GetGPGKey(ctx, namespace, keyID)
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, driver.ErrProviderGPGKeyNotExist) {
+		return err
+	}
+
+	buf := new(bytes.Buffer)
+	w, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := e.Serialize(w); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	s.logger.Info("saving public key of signing key", zap.String("namespace", namespace), zap.String("keyID", keyID))
+	return s.driver.SaveGPGKey(ctx, namespace, &driver.GPGKey{
+		KeyID:      keyID,
+		ASCIIArmor: buf.String(),
+		Source:     KeySource,
+		CreatedAt:  time.Now(),
+	})
+}
+
+// encryptPrivateKey encrypts the private keys of the entity by the passphrase, so that the key is stored encrypted
+func encryptPrivateKey(e *openpgp.Entity, passphrase []byte) error {
+	if err := e.PrivateKey.Encrypt(passphrase); err != nil {
+		return err
+	}
+
+	for _, sk := range e.Subkeys {
+		if sk.PrivateKey != nil {
+			if err := sk.PrivateKey.Encrypt(passphrase); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func readPrivateKey(b, passphrase []byte) (*openpgp.Entity, error) {
+	el, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(el) == 0 || el[0].PrivateKey == nil {
+		return nil, ErrNoPrivateKey
+	}
+
+	e := el[0]
+	if e.PrivateKey.Encrypted {
+		if err := e.PrivateKey.Decrypt(passphrase); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, sk := range e.Subkeys {
+		if sk.PrivateKey != nil && sk.PrivateKey.Encrypted {
+			if err := sk.PrivateKey.Decrypt(passphrase); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return e, nil
+}
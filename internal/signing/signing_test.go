@@ -0,0 +1,152 @@
+package signing
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/kerraform/kegistry/internal/driver"
+	"github.com/kerraform/kegistry/internal/driver/memory"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+func init() {
+	keyBits = 1024
+}
+
+func newTestDriver() *driver.Driver {
+	return memory.NewDriver(&memory.DriverConfig{
+		Logger: zap.NewNop(),
+		Tracer: trace.NewNoopTracerProvider().Tracer(""),
+	})
+}
+
+func TestSignGeneratedKey(t *testing.T) {
+	ctx := context.Background()
+	d := newTestDriver()
+	s, err := New(&Config{
+		Driver:        d.Provider,
+		KeyPassphrase: "secret",
+		Logger:        zap.NewNop(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := []byte("sums")
+	sig, err := s.Sign(ctx, "acme", message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyID, err := s.KeyID(ctx, "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := d.Provider.GetGPGKey(ctx, "acme", keyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key.Source != KeySource {
+		t.Errorf("source = %q, want %q", key.Source, KeySource)
+	}
+
+	el, err := openpgp.ReadArmoredKeyRing(bytes.NewReader([]byte(key.ASCIIArmor)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := openpgp.CheckDetachedSignature(el, bytes.NewReader(message), bytes.NewReader(sig), nil); err != nil {
+		t.Errorf("failed to verify signature: %v", err)
+	}
+
+	b, err := d.Provider.GetSigningKey(ctx, "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	el, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !el[0].PrivateKey.Encrypted {
+		t.Error("stored signing key is not encrypted")
+	}
+
+	cases := map[string]struct {
+		passphrase string
+		wantErr    bool
+	}{
+		"same passphrase": {
+			passphrase: "secret",
+		},
+		"no passphrase": {
+			wantErr: true,
+		},
+		"wrong passphrase": {
+			passphrase: "wrong",
+			wantErr:    true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s, err := New(&Config{
+				Driver:        d.Provider,
+				KeyPassphrase: tc.passphrase,
+				Logger:        zap.NewNop(),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := s.KeyID(ctx, "acme")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+
+			if !tc.wantErr && got != keyID {
+				t.Errorf("key ID = %s, want %s", got, keyID)
+			}
+		})
+	}
+}
+
+func TestKeyPerNamespace(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(&Config{
+		Driver: newTestDriver().Provider,
+		Logger: zap.NewNop(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := s.KeyID(ctx, "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := s.KeyID(ctx, "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a == b {
+		t.Errorf("namespaces share the key %s", a)
+	}
+
+	again, err := s.KeyID(ctx, "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if again != a {
+		t.Errorf("key ID = %s, want %s", again, a)
+	}
+}
@@ -0,0 +1,239 @@
+package token
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kerraform/kegistry/internal/auth"
+	"github.com/kerraform/kegistry/internal/driver"
+	"go.uber.org/zap"
+)
+
+const (
+	// Prefix is the prefix of every API token issued by the registry
+	Prefix = "kgt_"
+
+	// NamespaceAll is the namespace scope which allows every namespace
+	NamespaceAll = "*"
+
+	idBytes     = 8
+	secretBytes = 32
+
+	// lastUsedInterval throttles the updates of the last used time
+	lastUsedInterval = time.Minute
+)
+
+var (
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// Manager issues, lists and revokes the API tokens, and authenticates them
+type Manager struct {
+	driver     *driver.Driver
+	logger     *zap.Logger
+	defaultTTL time.Duration
+	maxTTL     time.Duration
+}
+
+type Config struct {
+	DefaultTTL time.Duration
+	Driver     *driver.Driver
+	Logger     *zap.Logger
+
+	// MaxTTL caps the lifetime of the tokens, no cap if zero
+	MaxTTL time.Duration
+}
+
+var _ auth.Authenticator = (*Manager)(nil)
+
+func New(cfg *Config) *Manager {
+	return &Manager{
+		driver:     cfg.Driver,
+		logger:     cfg.Logger,
+		defaultTTL: cfg.DefaultTTL,
+		maxTTL:     cfg.MaxTTL,
+	}
+}
+
+type CreateOpts struct {
+	Description string
+	Namespace   string
+	Subject     string
+
+	// ExpiredAt defaults to the default TTL from now if zero, and is capped by the max TTL from now
+	ExpiredAt time.Time
+
+	// NotAfter caps the expiry if not zero, e.g. by the expiry of the credential of the caller
+	NotAfter time.Time
+}
+
+// Create issues the new token and returns the token in plain text.
+// The plain text is not stored and cannot be retrieved again.
+func (m *Manager) Create(ctx context.Context, opts *CreateOpts) (string, *driver.APIToken, error) {
+	id, err := randomHex(idBytes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	secret, err := randomHex(secretBytes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now().UTC()
+	expiredAt := opts.ExpiredAt
+	if expiredAt.IsZero() {
+		expiredAt = now.Add(m.defaultTTL)
+	}
+
+	if m.maxTTL > 0 && expiredAt.After(now.Add(m.maxTTL)) {
+		expiredAt = now.Add(m.maxTTL)
+	}
+
+	if !opts.NotAfter.IsZero() && expiredAt.After(opts.NotAfter) {
+		expiredAt = opts.NotAfter
+	}
+
+	if !expiredAt.After(now) {
+		return "", nil, fmt.Errorf("expiry %s is in the past", expiredAt.Format(time.RFC3339))
+	}
+
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = NamespaceAll
+	}
+
+	t := &driver.APIToken{
+		ID:          id,
+		Description: opts.Description,
+		Hash:        hash(secret),
+		Namespace:   namespace,
+		Subject:     opts.Subject,
+		CreatedAt:   now,
+		ExpiredAt:   expiredAt.UTC(),
+	}
+
+	if err := m.driver.Token.SaveToken(ctx, t); err != nil {
+		return "", nil, err
+	}
+
+	m.logger.Info("created token",
+		zap.String("id", t.ID),
+		zap.String("subject", t.Subject),
+		zap.String("namespace", t.Namespace),
+	)
+	return fmt.Sprintf("%s%s_%s", Prefix, id, secret), t, nil
+}
+
+// Get returns the token of the ID, or driver.ErrTokenNotExist if the ID is not the one issued by the registry
+func (m *Manager) Get(ctx context.Context, id string) (*driver.APIToken, error) {
+	if !validID(id) {
+		return nil, driver.ErrTokenNotExist
+	}
+
+	return m.driver.Token.GetToken(ctx, id)
+}
+
+func (m *Manager) List(ctx context.Context) ([]*driver.APIToken, error) {
+	return m.driver.Token.ListTokens(ctx)
+}
+
+func (m *Manager) Revoke(ctx context.Context, id string) error {
+	if !validID(id) {
+		return driver.ErrTokenNotExist
+	}
+
+	if err := m.driver.Token.DeleteToken(ctx, id); err != nil {
+		return err
+	}
+
+	m.logger.Info("revoked token", zap.String("id", id))
+	return nil
+}
+
+// Authenticate authenticates the bearer token issued by the registry
+func (m *Manager) Authenticate(r *http.Request) (*auth.Identity, error) {
+	bearer, ok := auth.BearerToken(r)
+	if !ok || !strings.HasPrefix(bearer, Prefix) {
+		return nil, nil
+	}
+
+	// The ID is validated before any lookup, as the drivers use it as the path of the token
+	id, secret, ok := strings.Cut(strings.TrimPrefix(bearer, Prefix), "_")
+	if !ok || !validID(id) || secret == "" {
+		return nil, auth.ErrInvalidCredentials
+	}
+
+	t, err := m.driver.Token.GetToken(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, driver.ErrTokenNotExist) {
+			return nil, auth.ErrInvalidCredentials
+		}
+
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(t.Hash), []byte(hash(secret))) != 1 {
+		return nil, auth.ErrInvalidCredentials
+	}
+
+	now := time.Now().UTC()
+	if !t.ExpiredAt.After(now) {
+		return nil, fmt.Errorf("%w: %s", auth.ErrInvalidCredentials, ErrTokenExpired)
+	}
+
+	// Only the last used time is saved, as writing back the token would restore the token revoked meanwhile
+	if t.LastUsedAt == nil || now.Sub(*t.LastUsedAt) > lastUsedInterval {
+		if err := m.driver.Token.SaveTokenLastUsed(r.Context(), t.ID, now); err != nil {
+			m.logger.Warn("failed to record the last used time of the token", zap.String("id", t.ID), zap.Error(err))
+		}
+	}
+
+	var namespaces []string
+	if t.Namespace != NamespaceAll {
+		namespaces = []string{t.Namespace}
+	}
+
+	return &auth.Identity{
+		Subject:    t.Subject,
+		Method:     auth.MethodAPIToken,
+		Namespaces: namespaces,
+		ExpiredAt:  t.ExpiredAt,
+	}, nil
+}
+
+// validID reports whether the ID is the lowercase hex of idBytes, as issued by Create
+func validID(id string) bool {
+	if len(id) != 2*idBytes {
+		return false
+	}
+
+	for _, c := range id {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
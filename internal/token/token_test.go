@@ -0,0 +1,257 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kerraform/kegistry/internal/auth"
+	"github.com/kerraform/kegistry/internal/driver"
+	"github.com/kerraform/kegistry/internal/driver/local"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+func newManager(t *testing.T) (*Manager, string) {
+	t.Helper()
+	root := t.TempDir()
+	return New(&Config{
+		DefaultTTL: time.Hour,
+		Driver: local.NewDriver(&local.DriverConfig{
+			RootPath: root,
+			Logger:   zap.NewNop(),
+			Tracer:   trace.NewNoopTracerProvider().Tracer(""),
+		}),
+		Logger: zap.NewNop(),
+	}), root
+}
+
+func authenticate(m *Manager, bearer string) (*auth.Identity, error) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+bearer)
+	return m.Authenticate(r)
+}
+
+func TestCreate(t *testing.T) {
+	m, _ := newManager(t)
+	plain, tok, err := m.Create(context.Background(), &CreateOpts{
+		Namespace: "acme",
+		Subject:   "token:ci",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(plain, Prefix+tok.ID+"_") || !validID(tok.ID) {
+		t.Fatalf("unexpected token %q of the ID %q", plain, tok.ID)
+	}
+
+	// Only the hash of the secret is stored
+	secret := strings.TrimPrefix(plain, Prefix+tok.ID+"_")
+	saved, err := m.Get(context.Background(), tok.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if saved.Hash != hash(secret) || strings.Contains(saved.Hash, secret) {
+		t.Fatalf("expected the hash of the secret to be stored, got %q", saved.Hash)
+	}
+
+	if d := saved.ExpiredAt.Sub(saved.CreatedAt); d != time.Hour {
+		t.Fatalf("expected the default TTL, got %v", d)
+	}
+
+	id, err := authenticate(m, plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id.Subject != "token:ci" || id.Method != auth.MethodAPIToken || len(id.Namespaces) != 1 || id.Namespaces[0] != "acme" {
+		t.Fatalf("unexpected identity %+v", id)
+	}
+
+	if _, _, err := m.Create(context.Background(), &CreateOpts{ExpiredAt: time.Now().Add(-time.Second)}); err == nil {
+		t.Fatal("expected the expiry in the past to be refused")
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	m, _ := newManager(t)
+	ctx := context.Background()
+	plain, tok, err := m.Create(ctx, &CreateOpts{Subject: "token:ci"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := authenticate(m, plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(id.Namespaces) != 0 {
+		t.Fatalf("expected the token of every namespace to be unrestricted, got %v", id.Namespaces)
+	}
+
+	cases := map[string]string{
+		"wrong secret": Prefix + tok.ID + "_" + strings.Repeat("0", 2*secretBytes),
+		"no secret":    Prefix + tok.ID + "_",
+		"no separator": Prefix + tok.ID,
+		"unknown id":   Prefix + strings.Repeat("0", 2*idBytes) + "_secret",
+		"uppercase id": Prefix + strings.ToUpper(tok.ID) + "_secret",
+	}
+
+	for name, bearer := range cases {
+		if _, err := authenticate(m, bearer); !errors.Is(err, auth.ErrInvalidCredentials) {
+			t.Errorf("%s: expected %v, got %v", name, auth.ErrInvalidCredentials, err)
+		}
+	}
+
+	// The other bearer tokens are left to the other authenticators
+	if id, err := authenticate(m, "static-token"); id != nil || err != nil {
+		t.Fatalf("expected no identity, got %v, %v", id, err)
+	}
+}
+
+func TestCreateMaxTTL(t *testing.T) {
+	m, _ := newManager(t)
+	m.maxTTL = 2 * time.Hour
+	ctx := context.Background()
+
+	_, tok, err := m.Create(ctx, &CreateOpts{Subject: "token:ci", ExpiredAt: time.Now().AddDate(100, 0, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := tok.ExpiredAt.Sub(tok.CreatedAt); d != 2*time.Hour {
+		t.Fatalf("expected the expiry capped by the max TTL, got %v", d)
+	}
+
+	notAfter := time.Now().Add(time.Minute).UTC()
+	_, tok, err = m.Create(ctx, &CreateOpts{Subject: "token:ci", NotAfter: notAfter})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !tok.ExpiredAt.Equal(notAfter) {
+		t.Fatalf("expected the expiry capped by %s, got %s", notAfter, tok.ExpiredAt)
+	}
+}
+
+func TestAuthenticateExpired(t *testing.T) {
+	m, _ := newManager(t)
+	ctx := context.Background()
+	plain, tok, err := m.Create(ctx, &CreateOpts{Subject: "token:ci"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tok.ExpiredAt = time.Now().Add(-time.Second)
+	if err := m.driver.Token.SaveToken(ctx, tok); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = authenticate(m, plain)
+	if !errors.Is(err, auth.ErrInvalidCredentials) || !strings.Contains(err.Error(), ErrTokenExpired.Error()) {
+		t.Fatalf("expected the expired token to be refused, got %v", err)
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	m, _ := newManager(t)
+	ctx := context.Background()
+	plain, tok, err := m.Create(ctx, &CreateOpts{Subject: "token:ci"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Revoke(ctx, tok.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := authenticate(m, plain); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Fatalf("expected the revoked token to be refused, got %v", err)
+	}
+
+	if err := m.Revoke(ctx, tok.ID); !errors.Is(err, driver.ErrTokenNotExist) {
+		t.Fatalf("expected %v, got %v", driver.ErrTokenNotExist, err)
+	}
+}
+
+func TestAuthenticateLastUsed(t *testing.T) {
+	m, _ := newManager(t)
+	ctx := context.Background()
+	plain, tok, err := m.Create(ctx, &CreateOpts{Subject: "token:ci"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := authenticate(m, plain); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := m.Get(ctx, tok.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if saved.LastUsedAt == nil {
+		t.Fatal("expected the last used time to be recorded")
+	}
+
+	// The last used time recorded by the request authenticated before the revocation does not restore the token
+	if err := m.Revoke(ctx, tok.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.driver.Token.SaveTokenLastUsed(ctx, tok.ID, time.Now()); !errors.Is(err, driver.ErrTokenNotExist) {
+		t.Fatalf("expected %v, got %v", driver.ErrTokenNotExist, err)
+	}
+
+	if _, err := m.Get(ctx, tok.ID); !errors.Is(err, driver.ErrTokenNotExist) {
+		t.Fatalf("expected the revoked token to stay deleted, got %v", err)
+	}
+}
+
+// The ID is the path of the token on the local driver, which must not reach the file out of the tokens
+func TestAuthenticateTraversal(t *testing.T) {
+	m, root := newManager(t)
+	secret := "secret"
+	b, err := json.Marshal(&driver.APIToken{
+		ID:        "planted",
+		Hash:      hash(secret),
+		Namespace: NamespaceAll,
+		Subject:   "token:admin",
+		CreatedAt: time.Now(),
+		ExpiredAt: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "planted.json"), b, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := authenticate(m, Prefix+"../planted_"+secret); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Fatalf("expected %v, got %v", auth.ErrInvalidCredentials, err)
+	}
+
+	if _, err := m.Get(context.Background(), "../planted"); !errors.Is(err, driver.ErrTokenNotExist) {
+		t.Fatalf("expected %v, got %v", driver.ErrTokenNotExist, err)
+	}
+
+	if err := m.Revoke(context.Background(), "../planted"); !errors.Is(err, driver.ErrTokenNotExist) {
+		t.Fatalf("expected %v, got %v", driver.ErrTokenNotExist, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "planted.json")); err != nil {
+		t.Fatalf("expected the file out of the tokens to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kerraform/kegistry/internal/auth"
+	kerrors "github.com/kerraform/kegistry/internal/errors"
+	"github.com/kerraform/kegistry/internal/handler"
+	"github.com/kerraform/kegistry/internal/logging"
+	"github.com/kerraform/kegistry/internal/policy"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrAuditNotEnabled = errors.New("audit log is not enabled")
+)
+
+func (h *Handler) ListAuditEvents() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		namespace := mux.Vars(r)["namespace"]
+
+		if h.audit == nil {
+			return kerrors.Wrap(ErrAuditNotEnabled, kerrors.WithNotFound())
+		}
+
+		// The policy allows everyone without the policy file, and the events must not be read anonymously
+		if auth.FromCtx(r.Context()) == nil {
+			return kerrors.Wrap(auth.ErrMissingCredentials, kerrors.WithUnauthorized())
+		}
+
+		if err := h.policy.Authorize(r.Context(), namespace, policy.ScopeAdmin); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		l, err := logging.FromCtx(r.Context())
+		if err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		es, err := h.audit.List(r.Context(), namespace)
+		if err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		valid := true
+		if err := h.audit.Verify(namespace, es); err != nil {
+			l.Warn("audit event chain is broken", zap.String("namespace", namespace), zap.Error(err))
+			valid = false
+		}
+
+		data := make([]*AuditEventData, len(es))
+		for i, e := range es {
+			data[i] = &AuditEventData{
+				ID:         e.ID,
+				Type:       DataTypeAuditEvents,
+				Attributes: e,
+			}
+		}
+
+		return json.NewEncoder(w).Encode(&ListAuditEventsResponse{
+			Data: data,
+			Meta: &AuditEventsMeta{
+				ChainValid: valid,
+			},
+		})
+	})
+}
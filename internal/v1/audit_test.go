@@ -0,0 +1,75 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kerraform/kegistry/internal/audit"
+	"github.com/kerraform/kegistry/internal/auth"
+	"github.com/kerraform/kegistry/internal/driver/memory"
+	"github.com/kerraform/kegistry/internal/policy"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+func TestListAuditEvents(t *testing.T) {
+	d := memory.NewDriver(&memory.DriverConfig{
+		Logger: zap.NewNop(),
+		Tracer: trace.NewNoopTracerProvider().Tracer(""),
+	})
+
+	admins := &policy.Policy{
+		Rules: []policy.Rule{
+			{Subjects: []string{"*"}, Namespaces: []string{"*"}, Scope: policy.ScopeRead},
+			{Subjects: []string{"token:admin"}, Namespaces: []string{testNamespace}, Scope: policy.ScopeAdmin},
+		},
+	}
+
+	cases := map[string]struct {
+		policy *policy.Policy
+		caller string
+		want   int
+	}{
+		"anonymous without policy": {
+			want: http.StatusUnauthorized,
+		},
+		"anonymous": {
+			policy: admins,
+			want:   http.StatusUnauthorized,
+		},
+		"non admin": {
+			policy: admins,
+			caller: "token:ci",
+			want:   http.StatusForbidden,
+		},
+		"admin": {
+			policy: admins,
+			caller: "token:admin",
+			want:   http.StatusOK,
+		},
+	}
+
+	for name, tc := range cases {
+		h := New(&HandlerConfig{
+			Audit: audit.New(&audit.Config{
+				Logger: zap.NewNop(),
+				Sink:   audit.NewBackendSink(d.Audit),
+			}),
+			Driver: d,
+			Logger: zap.NewNop(),
+			Policy: tc.policy,
+		})
+
+		r := httptest.NewRequest(http.MethodGet, "/registry/v1/audit/"+testNamespace, nil)
+		if tc.caller != "" {
+			r = r.WithContext(auth.WithIdentity(context.Background(), &auth.Identity{Subject: tc.caller}))
+		}
+
+		w := serve(h.ListAuditEvents(), r, map[string]string{"namespace": testNamespace})
+		if w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d: %s", name, tc.want, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/kerraform/kegistry/internal/audit"
+	"github.com/kerraform/kegistry/internal/driver"
+	kerrors "github.com/kerraform/kegistry/internal/errors"
+	"github.com/kerraform/kegistry/internal/handler"
+	"github.com/kerraform/kegistry/internal/policy"
+	"github.com/kerraform/kegistry/internal/validator"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	ErrGPGKeyExists = errors.New("gpg key of the key ID already exists with the different armor, replace it with overwrite=true by the admin")
+	ErrGPGKeyInUse  = errors.New("gpg key is used by provider versions")
+)
+
+func newGPGKeyData(namespace string, key *driver.GPGKey) *GPGKeyData {
+	return &GPGKeyData{
+		ID:   key.KeyID,
+		Type: DataTypeAddGPGKey,
+		Attributes: &GPGKeyAttributes{
+			ASCIIArmor:     key.ASCIIArmor,
+			CreatedAt:      key.CreatedAt,
+			KeyID:          key.KeyID,
+			Namespace:      namespace,
+			RevokedAt:      key.RevokedAt,
+			Source:         key.Source,
+			SourceURL:      key.SourceURL,
+			TrustSignature: key.TrustSignature,
+		},
+	}
+}
+
+// Inspired by Terraform Cloud API:
+// https://developer.hashicorp.com/terraform/cloud-docs/api-docs/private-registry/gpg-keys#list-gpg-keys
+func (h *Handler) ListGPGKeys() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		namespace := mux.Vars(r)["namespace"]
+
+		if err := h.policy.Authorize(r.Context(), namespace, policy.ScopeRead); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		keys, err := h.driver.Provider.ListGPGKeys(r.Context(), namespace)
+		if err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		data := make([]*GPGKeyData, len(keys))
+		for i, key := range keys {
+			data[i] = newGPGKeyData(namespace, key)
+		}
+
+		return json.NewEncoder(w).Encode(&ListGPGKeysResponse{
+			Data: data,
+		})
+	})
+}
+
+// Inspired by Terraform Cloud API:
+// https://developer.hashicorp.com/terraform/cloud-docs/api-docs/private-registry/gpg-keys#get-gpg-key
+func (h *Handler) GetGPGKey() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		namespace := mux.Vars(r)["namespace"]
+		keyID := mux.Vars(r)["keyID"]
+
+		if err := h.policy.Authorize(r.Context(), namespace, policy.ScopeRead); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		key, err := h.getGPGKey(r, namespace, keyID)
+		if err != nil {
+			return err
+		}
+
+		return json.NewEncoder(w).Encode(&GPGKeyResponse{
+			Data: newGPGKeyData(namespace, key),
+		})
+	})
+}
+
+// Inspired by Terraform Cloud API:
+// https://developer.hashicorp.com/terraform/cloud-docs/api-docs/private-registry/gpg-keys#update-a-gpg-key
+func (h *Handler) UpdateGPGKey() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		namespace := mux.Vars(r)["namespace"]
+		keyID := mux.Vars(r)["keyID"]
+
+		if err := h.policy.Authorize(r.Context(), namespace, policy.ScopePublish); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		var req UpdateGPGKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return kerrors.Wrap(err, kerrors.WithBadRequest())
+		}
+		defer r.Body.Close()
+
+		if req.Data == nil || req.Data.Attributes == nil {
+			return kerrors.Wrap(errors.New("attributes are required"), kerrors.WithBadRequest())
+		}
+
+		if err := validator.Validate.Struct(req); err != nil {
+			return kerrors.Wrap(err, kerrors.WithBadRequest())
+		}
+
+		key, err := h.getGPGKey(r, namespace, keyID)
+		if err != nil {
+			return err
+		}
+
+		attrs := req.Data.Attributes
+		if attrs.Source != nil {
+			key.Source = *attrs.Source
+		}
+
+		if attrs.SourceURL != nil {
+			key.SourceURL = *attrs.SourceURL
+		}
+
+		if attrs.TrustSignature != nil {
+			key.TrustSignature = *attrs.TrustSignature
+		}
+
+		if attrs.Revoked != nil {
+			switch {
+			case *attrs.Revoked && key.RevokedAt == nil:
+				now := time.Now()
+				key.RevokedAt = &now
+			case !*attrs.Revoked && key.RevokedAt != nil:
+				// The revoked key may be compromised, so that only the admin can reinstate it
+				if err := h.policy.Authorize(r.Context(), namespace, policy.ScopeAdmin); err != nil {
+					return kerrors.Wrap(err, kerrors.WithForbidden())
+				}
+
+				key.RevokedAt = nil
+			}
+		}
+
+		if err := h.driver.Provider.SaveGPGKey(r.Context(), namespace, key); err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		h.audit.Record(r.Context(), audit.ActionUpdate, audit.ResourceTypeGPGKey, audit.Resource{
+			Namespace: namespace,
+			KeyID:     key.KeyID,
+		}, nil)
+
+		return json.NewEncoder(w).Encode(&GPGKeyResponse{
+			Data: newGPGKeyData(namespace, key),
+		})
+	})
+}
+
+// Inspired by Terraform Cloud API:
+// https://developer.hashicorp.com/terraform/cloud-docs/api-docs/private-registry/gpg-keys#delete-a-gpg-key
+//
+// The key used by any provider version can only be deleted with `force=true` by the admin,
+// as the version can no longer be verified by Terraform without it.
+func (h *Handler) DeleteGPGKey() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		namespace := mux.Vars(r)["namespace"]
+		keyID := mux.Vars(r)["keyID"]
+
+		var force bool
+		if v := r.URL.Query().Get("force"); v != "" {
+			var err error
+			force, err = strconv.ParseBool(v)
+			if err != nil {
+				return kerrors.Wrap(err, kerrors.WithBadRequest())
+			}
+		}
+
+		scope := policy.ScopePublish
+		if force {
+			scope = policy.ScopeAdmin
+		}
+
+		if err := h.policy.Authorize(r.Context(), namespace, scope); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		if _, err := h.getGPGKey(r, namespace, keyID); err != nil {
+			return err
+		}
+
+		if !force {
+			versions, err := h.gpgKeyVersions(r, namespace, keyID)
+			if err != nil {
+				return kerrors.Wrap(err)
+			}
+
+			if len(versions) > 0 {
+				return kerrors.Wrap(ErrGPGKeyInUse, kerrors.WithConflict(), kerrors.WithDetail(versions))
+			}
+		}
+
+		if err := h.driver.Provider.DeleteGPGKey(r.Context(), namespace, keyID); err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		h.audit.Record(r.Context(), audit.ActionDelete, audit.ResourceTypeGPGKey, audit.Resource{
+			Namespace: namespace,
+			KeyID:     keyID,
+		}, nil)
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+}
+
+func (h *Handler) getGPGKey(r *http.Request, namespace, keyID string) (*driver.GPGKey, error) {
+	key, err := h.driver.Provider.GetGPGKey(r.Context(), namespace, keyID)
+	if err != nil {
+		if errors.Is(err, driver.ErrProviderGPGKeyNotExist) {
+			return nil, kerrors.Wrap(err, kerrors.WithNotFound())
+		}
+
+		return nil, kerrors.Wrap(err)
+	}
+
+	return key, nil
+}
+
+// gpgKeyVersions returns the published provider versions of the namespace bound to the key.
+// The yanked versions are included, as they are still downloaded and verified with the key for the existing lock files,
+// while the drafts are not served until published.
+func (h *Handler) gpgKeyVersions(r *http.Request, namespace, keyID string) ([]string, error) {
+	providers, err := h.driver.Provider.ListProviders(r.Context(), namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	g, ctx := errgroup.WithContext(r.Context())
+	g.SetLimit(driver.MetadataConcurrency)
+
+	var mu sync.Mutex
+	versions := []string{}
+	for _, name := range providers {
+		vs, err := h.driver.Provider.ListAvailableVersions(ctx, namespace, name)
+		if err != nil {
+			if errors.Is(err, driver.ErrProviderNotExist) {
+				continue
+			}
+
+			g.Wait()
+			return nil, err
+		}
+
+		for _, v := range vs {
+			if v.Version == "" {
+				continue
+			}
+
+			name, version := name, v.Version
+			g.Go(func() error {
+				metadata, err := h.driver.Provider.GetVersionMetadata(ctx, namespace, name, version)
+				if err != nil {
+					if errors.Is(err, driver.ErrProviderVersionNotExist) {
+						return nil
+					}
+
+					return err
+				}
+
+				if metadata.KeyID != keyID || metadata.Draft() {
+					return nil
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				versions = append(versions, fmt.Sprintf("%s/%s %s", namespace, name, version))
+				return nil
+			})
+		}
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(versions)
+	return versions, nil
+}
@@ -0,0 +1,335 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/kerraform/kegistry/internal/auth"
+	"github.com/kerraform/kegistry/internal/driver"
+	"github.com/kerraform/kegistry/internal/driver/memory"
+	"github.com/kerraform/kegistry/internal/logging"
+	"github.com/kerraform/kegistry/internal/policy"
+	"github.com/kerraform/kegistry/internal/v1/request"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+const (
+	testNamespace = "acme"
+	testKeyID     = "6B1D1E3EBDBF35AB"
+)
+
+func newTestHandler(t *testing.T) (*Handler, *driver.Driver) {
+	t.Helper()
+	d := memory.NewDriver(&memory.DriverConfig{
+		Logger: zap.NewNop(),
+		Tracer: trace.NewNoopTracerProvider().Tracer(""),
+	})
+
+	return New(&HandlerConfig{
+		Driver: d,
+		Logger: zap.NewNop(),
+	}), d
+}
+
+// serve calls the handler with the logger and the path variables which the router sets
+func serve(h http.Handler, r *http.Request, vars map[string]string) *httptest.ResponseRecorder {
+	r = r.WithContext(context.WithValue(r.Context(), logging.Key, zap.NewNop()))
+	r = mux.SetURLVars(r, vars)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func createKeyVersion(t *testing.T, d *driver.Driver, version string, metadata *driver.ProviderVersionMetadata) {
+	t.Helper()
+	ctx := context.Background()
+	if err := d.Provider.CreateProvider(ctx, testNamespace, "foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := d.Provider.CreateProviderVersion(ctx, testNamespace, "foo", version, false); err != nil {
+		t.Fatal(err)
+	}
+
+	metadata.KeyID = testKeyID
+	if err := d.Provider.SaveVersionMetadata(ctx, testNamespace, "foo", version, metadata); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeleteGPGKey(t *testing.T) {
+	yankedAt := time.Now()
+	cases := map[string]struct {
+		metadata *driver.ProviderVersionMetadata
+		force    bool
+		want     int
+	}{
+		"published": {
+			metadata: &driver.ProviderVersionMetadata{State: driver.VersionStatePublished},
+			want:     http.StatusConflict,
+		},
+		"yanked": {
+			metadata: &driver.ProviderVersionMetadata{State: driver.VersionStatePublished, YankedAt: &yankedAt},
+			want:     http.StatusConflict,
+		},
+		"draft": {
+			metadata: &driver.ProviderVersionMetadata{State: driver.VersionStateDraft},
+			want:     http.StatusNoContent,
+		},
+		"forced": {
+			metadata: &driver.ProviderVersionMetadata{State: driver.VersionStatePublished, YankedAt: &yankedAt},
+			force:    true,
+			want:     http.StatusNoContent,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			h, d := newTestHandler(t)
+			if err := d.Provider.SaveGPGKey(context.Background(), testNamespace, &driver.GPGKey{KeyID: testKeyID, CreatedAt: time.Now()}); err != nil {
+				t.Fatal(err)
+			}
+			createKeyVersion(t, d, "1.0.0", tc.metadata)
+
+			target := "/registry/v1/gpg-keys/acme/" + testKeyID
+			if tc.force {
+				target += "?force=true"
+			}
+
+			w := serve(h.DeleteGPGKey(), httptest.NewRequest(http.MethodDelete, target, nil), map[string]string{
+				"namespace": testNamespace,
+				"keyID":     testKeyID,
+			})
+			if w.Code != tc.want {
+				t.Fatalf("expected %d, got %d: %s", tc.want, w.Code, w.Body)
+			}
+
+			_, err := d.Provider.GetGPGKey(context.Background(), testNamespace, testKeyID)
+			if deleted := err != nil; deleted != (tc.want == http.StatusNoContent) {
+				t.Fatalf("unexpected key state after the delete: %v", err)
+			}
+		})
+	}
+}
+
+// armorKeys returns the armored public keys of the entities, the first of which has the key ID
+func armorKeys(t *testing.T, entities ...*openpgp.Entity) string {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	w, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, e := range entities {
+		if err := e.Serialize(w); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.String()
+}
+
+func TestAddGPGKeyOverwrite(t *testing.T) {
+	key, err := openpgp.NewEntity("key", "", "key@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	extra, err := openpgp.NewEntity("extra", "", "extra@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	original := armorKeys(t, key)
+	appended := armorKeys(t, key, extra)
+	p := &policy.Policy{
+		Rules: []policy.Rule{
+			{Subjects: []string{"token:ci"}, Namespaces: []string{testNamespace}, Scope: policy.ScopePublish},
+			{Subjects: []string{"token:admin"}, Namespaces: []string{testNamespace}, Scope: policy.ScopeAdmin},
+		},
+	}
+
+	cases := map[string]struct {
+		armor     string
+		overwrite bool
+		caller    string
+		want      int
+	}{
+		"same armor": {
+			armor:  original,
+			caller: "token:ci",
+			want:   http.StatusOK,
+		},
+		"different armor": {
+			armor:  appended,
+			caller: "token:ci",
+			want:   http.StatusConflict,
+		},
+		"overwritten by non admin": {
+			armor:     appended,
+			overwrite: true,
+			caller:    "token:ci",
+			want:      http.StatusForbidden,
+		},
+		"overwritten by admin": {
+			armor:     appended,
+			overwrite: true,
+			caller:    "token:admin",
+			want:      http.StatusOK,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			d := memory.NewDriver(&memory.DriverConfig{
+				Logger: zap.NewNop(),
+				Tracer: trace.NewNoopTracerProvider().Tracer(""),
+			})
+			h := New(&HandlerConfig{
+				Driver: d,
+				Logger: zap.NewNop(),
+				Policy: p,
+			})
+
+			keyID := key.PrimaryKey.KeyIdString()
+			if err := d.Provider.SaveGPGKey(context.Background(), testNamespace, &driver.GPGKey{KeyID: keyID, ASCIIArmor: original, CreatedAt: time.Now()}); err != nil {
+				t.Fatal(err)
+			}
+
+			body, err := json.Marshal(&AddGPGKeyRequest{
+				Data: &request.Data[AddGPGKeyRequestAttributes, DataType]{
+					Type: DataTypeAddGPGKey,
+					Attributes: &AddGPGKeyRequestAttributes{
+						Namespace:  testNamespace,
+						ASCIIArmor: tc.armor,
+					},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			target := "/registry/v1/gpg-keys"
+			if tc.overwrite {
+				target += "?overwrite=true"
+			}
+
+			r := httptest.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+			r = r.WithContext(auth.WithIdentity(r.Context(), &auth.Identity{Subject: tc.caller}))
+			w := serve(h.AddGPGKey(), r, nil)
+			if w.Code != tc.want {
+				t.Fatalf("expected %d, got %d: %s", tc.want, w.Code, w.Body)
+			}
+
+			saved, err := d.Provider.GetGPGKey(context.Background(), testNamespace, keyID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := original
+			if tc.want == http.StatusOK {
+				want = tc.armor
+			}
+
+			if saved.ASCIIArmor != want {
+				t.Fatalf("unexpected armor saved:\n%s", saved.ASCIIArmor)
+			}
+		})
+	}
+}
+
+func TestUpdateGPGKeyReinstate(t *testing.T) {
+	p := &policy.Policy{
+		Rules: []policy.Rule{
+			{Subjects: []string{"token:ci"}, Namespaces: []string{testNamespace}, Scope: policy.ScopePublish},
+			{Subjects: []string{"token:admin"}, Namespaces: []string{testNamespace}, Scope: policy.ScopeAdmin},
+		},
+	}
+
+	cases := map[string]struct {
+		revoked bool
+		caller  string
+		want    int
+	}{
+		"revoked by publisher": {
+			revoked: true,
+			caller:  "token:ci",
+			want:    http.StatusOK,
+		},
+		"reinstated by publisher": {
+			caller: "token:ci",
+			want:   http.StatusForbidden,
+		},
+		"reinstated by admin": {
+			caller: "token:admin",
+			want:   http.StatusOK,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			d := memory.NewDriver(&memory.DriverConfig{
+				Logger: zap.NewNop(),
+				Tracer: trace.NewNoopTracerProvider().Tracer(""),
+			})
+			h := New(&HandlerConfig{
+				Driver: d,
+				Logger: zap.NewNop(),
+				Policy: p,
+			})
+
+			revokedAt := time.Now()
+			if err := d.Provider.SaveGPGKey(context.Background(), testNamespace, &driver.GPGKey{KeyID: testKeyID, CreatedAt: time.Now(), RevokedAt: &revokedAt}); err != nil {
+				t.Fatal(err)
+			}
+
+			body, err := json.Marshal(&UpdateGPGKeyRequest{
+				Data: &request.Data[UpdateGPGKeyRequestAttributes, DataType]{
+					Type: DataTypeAddGPGKey,
+					Attributes: &UpdateGPGKeyRequestAttributes{
+						Revoked: &tc.revoked,
+					},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodPatch, "/registry/v1/gpg-keys/acme/"+testKeyID, bytes.NewReader(body))
+			r = r.WithContext(auth.WithIdentity(r.Context(), &auth.Identity{Subject: tc.caller}))
+			w := serve(h.UpdateGPGKey(), r, map[string]string{
+				"namespace": testNamespace,
+				"keyID":     testKeyID,
+			})
+			if w.Code != tc.want {
+				t.Fatalf("expected %d, got %d: %s", tc.want, w.Code, w.Body)
+			}
+
+			saved, err := d.Provider.GetGPGKey(context.Background(), testNamespace, testKeyID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if saved.Revoked() != (tc.revoked || tc.want != http.StatusOK) {
+				t.Fatalf("unexpected revocation: %v", saved.RevokedAt)
+			}
+		})
+	}
+}
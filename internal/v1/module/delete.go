@@ -0,0 +1,79 @@
+package module
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kerraform/kegistry/internal/audit"
+	"github.com/kerraform/kegistry/internal/driver"
+	kerrors "github.com/kerraform/kegistry/internal/errors"
+	"github.com/kerraform/kegistry/internal/handler"
+	"github.com/kerraform/kegistry/internal/policy"
+)
+
+// DeleteModule deletes the module with all of its versions.
+// Inspired by Terraform Cloud API:
+// https://developer.hashicorp.com/terraform/cloud-docs/api-docs/private-registry/modules#delete-a-module
+func (m *Module) DeleteModule() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		namespace := mux.Vars(r)["namespace"]
+		name := mux.Vars(r)["name"]
+		provider := mux.Vars(r)["provider"]
+
+		if err := m.policy.Authorize(r.Context(), namespace, policy.ScopeAdmin); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		if err := m.driver.Module.DeleteModule(r.Context(), namespace, provider, name); err != nil {
+			if errors.Is(err, driver.ErrModuleNotExist) {
+				return kerrors.Wrap(err, kerrors.WithNotFound())
+			}
+
+			return kerrors.Wrap(err)
+		}
+
+		m.audit.Record(r.Context(), audit.ActionDelete, audit.ResourceTypeModule, audit.Resource{
+			Namespace: namespace,
+			Name:      name,
+			Provider:  provider,
+		}, nil)
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+}
+
+// DeleteModuleVersion deletes the version with its package.
+// Inspired by Terraform Cloud API:
+// https://developer.hashicorp.com/terraform/cloud-docs/api-docs/private-registry/modules#delete-a-module
+func (m *Module) DeleteModuleVersion() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		namespace := mux.Vars(r)["namespace"]
+		name := mux.Vars(r)["name"]
+		provider := mux.Vars(r)["provider"]
+		version := mux.Vars(r)["version"]
+
+		if err := m.policy.Authorize(r.Context(), namespace, policy.ScopeAdmin); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		if err := m.driver.Module.DeleteVersion(r.Context(), namespace, provider, name, version); err != nil {
+			if errors.Is(err, driver.ErrModuleVersionNotExist) {
+				return kerrors.Wrap(err, kerrors.WithNotFound())
+			}
+
+			return kerrors.Wrap(err)
+		}
+
+		m.audit.Record(r.Context(), audit.ActionDelete, audit.ResourceTypeModuleVersion, audit.Resource{
+			Namespace: namespace,
+			Name:      name,
+			Provider:  provider,
+			Version:   version,
+		}, nil)
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+}
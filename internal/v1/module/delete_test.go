@@ -0,0 +1,64 @@
+package module
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestDeleteModuleVersion(t *testing.T) {
+	m, d := newTestModule(t)
+	m.policy = testPolicy()
+	uploadTestVersion(t, d, "1.0.0")
+	uploadTestVersion(t, d, "1.1.0")
+
+	tests := []struct {
+		caller string
+		status int
+	}{
+		{caller: "token:ci", status: http.StatusForbidden},
+		{caller: "token:admin", status: http.StatusNoContent},
+		{caller: "token:admin", status: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		r := withCaller(httptest.NewRequest(http.MethodDelete, "/", nil), tc.caller)
+		w := serve(m.DeleteModuleVersion(), r, versionVars("1.1.0"))
+		if w.Code != tc.status {
+			t.Fatalf("%s: status = %d, want %d: %s", tc.caller, w.Code, tc.status, w.Body.String())
+		}
+	}
+
+	if got, want := listTestVersions(t, m), []ListAvailableVersionsModelVersion{{Version: "1.0.0"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("versions = %v, want %v", got, want)
+	}
+}
+
+func TestDeleteModule(t *testing.T) {
+	m, d := newTestModule(t)
+	m.policy = testPolicy()
+	uploadTestVersion(t, d, "1.0.0")
+
+	tests := []struct {
+		caller string
+		status int
+	}{
+		{caller: "token:ci", status: http.StatusForbidden},
+		{caller: "token:admin", status: http.StatusNoContent},
+		{caller: "token:admin", status: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		r := withCaller(httptest.NewRequest(http.MethodDelete, "/", nil), tc.caller)
+		w := serve(m.DeleteModule(), r, versionVars(""))
+		if w.Code != tc.status {
+			t.Fatalf("%s: status = %d, want %d: %s", tc.caller, w.Code, tc.status, w.Body.String())
+		}
+	}
+
+	r := withCaller(httptest.NewRequest(http.MethodGet, "/", nil), "token:ci")
+	if w := serve(m.ListAvailableVersions(), r, versionVars("")); w.Code != http.StatusNotFound {
+		t.Errorf("status of deleted module = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
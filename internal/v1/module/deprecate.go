@@ -0,0 +1,131 @@
+package module
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/kerraform/kegistry/internal/audit"
+	"github.com/kerraform/kegistry/internal/driver"
+	kerrors "github.com/kerraform/kegistry/internal/errors"
+	"github.com/kerraform/kegistry/internal/handler"
+	"github.com/kerraform/kegistry/internal/policy"
+)
+
+type DeprecateModuleVersionRequest struct {
+	Data *DeprecateModuleVersionRequestData `json:"data"`
+}
+
+type DeprecateModuleVersionRequestData struct {
+	Attributes *DeprecateModuleVersionDataAttributes `json:"attributes"`
+	Type       DataType                              `json:"type"`
+}
+
+type DeprecateModuleVersionDataAttributes struct {
+	Reason string `json:"reason"`
+}
+
+// DeprecateModuleVersion marks the version as deprecated.
+// The version is still listed, but with the deprecation, so that Terraform warns it.
+func (m *Module) DeprecateModuleVersion() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		namespace := mux.Vars(r)["namespace"]
+		name := mux.Vars(r)["name"]
+		provider := mux.Vars(r)["provider"]
+		version := mux.Vars(r)["version"]
+
+		if err := m.policy.Authorize(r.Context(), namespace, policy.ScopePublish); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		// The reason is optional
+		var req DeprecateModuleVersionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			return kerrors.Wrap(err, kerrors.WithBadRequest())
+		}
+		defer r.Body.Close()
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		metadata, err := m.versionMetadata(r, namespace, provider, name, version)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		metadata.DeprecatedAt = &now
+		if req.Data != nil && req.Data.Attributes != nil {
+			metadata.DeprecationReason = req.Data.Attributes.Reason
+		}
+
+		if err := m.driver.Module.SaveVersionMetadata(r.Context(), namespace, provider, name, version, metadata); err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		m.audit.Record(r.Context(), audit.ActionDeprecate, audit.ResourceTypeModuleVersion, audit.Resource{
+			Namespace: namespace,
+			Name:      name,
+			Provider:  provider,
+			Version:   version,
+		}, nil)
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+}
+
+// UndeprecateModuleVersion clears the deprecation of the version
+func (m *Module) UndeprecateModuleVersion() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		namespace := mux.Vars(r)["namespace"]
+		name := mux.Vars(r)["name"]
+		provider := mux.Vars(r)["provider"]
+		version := mux.Vars(r)["version"]
+
+		if err := m.policy.Authorize(r.Context(), namespace, policy.ScopePublish); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		metadata, err := m.versionMetadata(r, namespace, provider, name, version)
+		if err != nil {
+			return err
+		}
+
+		metadata.DeprecatedAt = nil
+		metadata.DeprecationReason = ""
+		if err := m.driver.Module.SaveVersionMetadata(r.Context(), namespace, provider, name, version, metadata); err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		m.audit.Record(r.Context(), audit.ActionUndeprecate, audit.ResourceTypeModuleVersion, audit.Resource{
+			Namespace: namespace,
+			Name:      name,
+			Provider:  provider,
+			Version:   version,
+		}, nil)
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+}
+
+// versionMetadata returns the metadata of the version, or 404 if the version does not exist
+func (m *Module) versionMetadata(r *http.Request, namespace, provider, name, version string) (*driver.ModuleVersionMetadata, error) {
+	metadata, err := m.driver.Module.GetVersionMetadata(r.Context(), namespace, provider, name, version)
+	if err != nil {
+		if errors.Is(err, driver.ErrModuleVersionNotExist) {
+			return nil, kerrors.Wrap(err, kerrors.WithNotFound())
+		}
+
+		return nil, kerrors.Wrap(err)
+	}
+
+	return metadata, nil
+}
@@ -0,0 +1,40 @@
+package module
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDeprecateModuleVersion(t *testing.T) {
+	m, d := newTestModule(t)
+	uploadTestVersion(t, d, "1.0.0")
+	uploadTestVersion(t, d, "1.1.0")
+
+	body := strings.NewReader(`{"data":{"type":"registry-module-versions","attributes":{"reason":"use 1.1.0"}}}`)
+	w := serve(m.DeprecateModuleVersion(), httptest.NewRequest(http.MethodPost, "/", body), versionVars("1.0.0"))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+	}
+
+	// The deprecated version is still listed with the reason, which Terraform warns
+	want := []ListAvailableVersionsModelVersion{
+		{Version: "1.0.0", Deprecation: &ListAvailableVersionsModelDeprecation{Reason: "use 1.1.0"}},
+		{Version: "1.1.0"},
+	}
+	if got := listTestVersions(t, m); !reflect.DeepEqual(got, want) {
+		t.Errorf("versions = %+v, want %+v", got, want)
+	}
+
+	w = serve(m.UndeprecateModuleVersion(), httptest.NewRequest(http.MethodDelete, "/", nil), versionVars("1.0.0"))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+	}
+
+	want = []ListAvailableVersionsModelVersion{{Version: "1.0.0"}, {Version: "1.1.0"}}
+	if got := listTestVersions(t, m); !reflect.DeepEqual(got, want) {
+		t.Errorf("versions = %+v, want %+v", got, want)
+	}
+}
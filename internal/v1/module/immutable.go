@@ -0,0 +1,125 @@
+package module
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/kerraform/kegistry/internal/artifact"
+	"github.com/kerraform/kegistry/internal/audit"
+	"github.com/kerraform/kegistry/internal/driver"
+	kerrors "github.com/kerraform/kegistry/internal/errors"
+	"github.com/kerraform/kegistry/internal/policy"
+)
+
+var (
+	ErrArtifactExists = errors.New("module package already exists, overwrite it with overwrite=true by the admin")
+)
+
+// uploadAction returns the audit action of saving the uploaded package over the existing one,
+// and false if the identical package is already saved, so that uploading it again is a no-op.
+//
+// The saved packages are immutable, as the configurations may be pinned to the version.
+// Only the admin can overwrite them with the different content with `overwrite=true`.
+func (m *Module) uploadAction(r *http.Request, namespace string, f *artifact.File, existing io.ReadCloser, err error) (audit.Action, bool, error) {
+	if err != nil {
+		// Any other error, such as the transient one of the backend, must not overwrite the saved package
+		if !errors.Is(err, driver.ErrModulePackageNotExist) {
+			return "", false, kerrors.Wrap(err)
+		}
+
+		return audit.ActionUpload, true, nil
+	}
+	defer existing.Close()
+
+	identical, err := f.Identical(existing)
+	if err != nil {
+		return "", false, kerrors.Wrap(err)
+	}
+
+	if err := f.Rewind(); err != nil {
+		return "", false, kerrors.Wrap(err)
+	}
+
+	if identical {
+		return "", false, nil
+	}
+
+	if err := m.authorizeOverwrite(r, namespace); err != nil {
+		return "", false, err
+	}
+
+	return audit.ActionOverwrite, true, nil
+}
+
+// presignOverwrite checks the existing package before the upload is presigned, which bypasses the registry.
+// It reports whether the package exists, and whether the admin overwrites it with `overwrite=true`, in which case
+// the upload is presigned to overwrite the existing object. Otherwise the backend refuses the upload over any object,
+// including the one uploaded by another URL presigned for the same version.
+func (m *Module) presignOverwrite(r *http.Request, namespace, provider, name, version string) (bool, bool, error) {
+	err := m.driver.Module.IsPackageUploaded(r.Context(), namespace, provider, name, version)
+	if err != nil {
+		if !errors.Is(err, driver.ErrModulePackageNotExist) {
+			return false, false, kerrors.Wrap(err)
+		}
+
+		return false, false, nil
+	}
+
+	overwrite, err := overwriteRequested(r)
+	if err != nil {
+		return false, false, err
+	}
+
+	if !overwrite {
+		return true, false, nil
+	}
+
+	if err := m.authorizeOverwrite(r, namespace); err != nil {
+		return false, false, err
+	}
+
+	return true, true, nil
+}
+
+// authorizeOverwrite returns 409 unless the admin requests to overwrite the existing package with `overwrite=true`
+func (m *Module) authorizeOverwrite(r *http.Request, namespace string) error {
+	overwrite, err := overwriteRequested(r)
+	if err != nil {
+		return err
+	}
+
+	if !overwrite {
+		return kerrors.Wrap(ErrArtifactExists, kerrors.WithConflict(), kerrors.WithDetail(ErrArtifactExists.Error()))
+	}
+
+	if err := m.policy.Authorize(r.Context(), namespace, policy.ScopeAdmin); err != nil {
+		return kerrors.Wrap(err, kerrors.WithForbidden())
+	}
+
+	return nil
+}
+
+// wrapSaveError returns 409 if the package is saved by another upload after checked, which is only created unless overwritten
+func wrapSaveError(err error) error {
+	if errors.Is(err, driver.ErrArtifactExists) {
+		return kerrors.Wrap(ErrArtifactExists, kerrors.WithConflict(), kerrors.WithDetail(ErrArtifactExists.Error()))
+	}
+
+	return kerrors.Wrap(err)
+}
+
+func overwriteRequested(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("overwrite")
+	if v == "" {
+		return false, nil
+	}
+
+	overwrite, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, kerrors.Wrap(err, kerrors.WithBadRequest())
+	}
+
+	return overwrite, nil
+}
@@ -0,0 +1,77 @@
+package module
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUploadModuleVersionImmutable(t *testing.T) {
+	m, d := newTestModule(t)
+	m.policy = testPolicy()
+	uploadTestVersion(t, d, "1.0.0")
+
+	changed := newTestPackage(t, `variable "x" {}`)
+	tests := []struct {
+		name   string
+		target string
+		caller string
+		body   []byte
+		status int
+	}{
+		{
+			name:   "identical package",
+			target: "/",
+			caller: "token:ci",
+			body:   newTestPackage(t, "1.0.0"),
+			status: http.StatusOK,
+		},
+		{
+			name:   "different package",
+			target: "/",
+			caller: "token:ci",
+			body:   changed,
+			status: http.StatusConflict,
+		},
+		{
+			name:   "overwrite by publisher",
+			target: "/?overwrite=true",
+			caller: "token:ci",
+			body:   changed,
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "overwrite by admin",
+			target: "/?overwrite=true",
+			caller: "token:admin",
+			body:   changed,
+			status: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tests {
+		r := withCaller(httptest.NewRequest(http.MethodPut, tc.target, bytes.NewReader(tc.body)), tc.caller)
+		w := serve(m.UploadModuleVersion(), r, versionVars("1.0.0"))
+		if w.Code != tc.status {
+			t.Fatalf("%s: status = %d, want %d: %s", tc.name, w.Code, tc.status, w.Body.String())
+		}
+	}
+
+	f, err := d.Module.GetModule(context.Background(), testNamespace, testProvider, testName, "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	got, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, changed) {
+		t.Error("package is not overwritten by the admin")
+	}
+}
@@ -2,16 +2,21 @@ package module
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
-	"os"
+	"sync"
 
 	"github.com/gorilla/mux"
+	"github.com/kerraform/kegistry/internal/artifact"
+	"github.com/kerraform/kegistry/internal/audit"
 	"github.com/kerraform/kegistry/internal/driver"
 	kerrors "github.com/kerraform/kegistry/internal/errors"
 	"github.com/kerraform/kegistry/internal/handler"
+	"github.com/kerraform/kegistry/internal/policy"
 	"github.com/kerraform/kegistry/internal/validator"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 )
 
 type DataType string
@@ -22,19 +27,31 @@ const (
 )
 
 type Module struct {
+	audit  *audit.Recorder
 	driver *driver.Driver
+	limits *artifact.Limits
 	logger *zap.Logger
+	policy *policy.Policy
+
+	// mu guards the read-modify-write of the version metadata
+	mu sync.Mutex
 }
 
 type Config struct {
+	Audit  *audit.Recorder
 	Driver *driver.Driver
+	Limits *artifact.Limits
 	Logger *zap.Logger
+	Policy *policy.Policy
 }
 
 func New(cfg *Config) *Module {
 	return &Module{
+		audit:  cfg.Audit,
 		driver: cfg.Driver,
+		limits: cfg.Limits,
 		logger: cfg.Logger,
+		policy: cfg.Policy,
 	}
 }
 
@@ -48,8 +65,8 @@ type CreateModuleRequestData struct {
 }
 
 type CreateModuleDataAttributes struct {
-	Name     string `json:"name" validate:"required"`
-	Provider string `json:"provider" validate:"required"`
+	Name     string `json:"name" validate:"required,segment"`
+	Provider string `json:"provider" validate:"required,segment"`
 }
 
 func (m *Module) CreateModule() http.Handler {
@@ -66,10 +83,20 @@ func (m *Module) CreateModule() http.Handler {
 			return kerrors.Wrap(err, kerrors.WithBadRequest())
 		}
 
+		if err := m.policy.Authorize(r.Context(), namespace, policy.ScopePublish); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
 		if err := m.driver.Module.CreateModule(r.Context(), namespace, req.Data.Attributes.Provider, req.Data.Attributes.Name); err != nil {
 			return kerrors.Wrap(err)
 		}
 
+		m.audit.Record(r.Context(), audit.ActionCreate, audit.ResourceTypeModule, audit.Resource{
+			Namespace: namespace,
+			Name:      req.Data.Attributes.Name,
+			Provider:  req.Data.Attributes.Provider,
+		}, nil)
+
 		w.WriteHeader(http.StatusNoContent)
 		return nil
 	})
@@ -85,7 +112,7 @@ type CreateModuleVersionRequestData struct {
 }
 
 type CreateModuleVersionDataAttributes struct {
-	Version string `json:"version"`
+	Version string `json:"version" validate:"required,semver"`
 }
 
 type CreateModuleVersionResponse struct {
@@ -98,6 +125,9 @@ type CreateModuleVersionData struct {
 
 type CreateModuleVersionDataLinks struct {
 	Upload string `json:"upload"`
+
+	// UploadHeaders are the headers to be sent with the upload to the presigned URL
+	UploadHeaders map[string]string `json:"upload-headers,omitempty"`
 }
 
 func (m *Module) CreateModuleVersion() http.Handler {
@@ -106,6 +136,10 @@ func (m *Module) CreateModuleVersion() http.Handler {
 		provider := mux.Vars(r)["provider"]
 		name := mux.Vars(r)["name"]
 
+		if err := m.policy.Authorize(r.Context(), namespace, policy.ScopePublish); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
 		var req CreateModuleVersionRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			return kerrors.Wrap(err, kerrors.WithBadRequest())
@@ -115,15 +149,50 @@ func (m *Module) CreateModuleVersion() http.Handler {
 			return kerrors.Wrap(err, kerrors.WithBadRequest())
 		}
 
-		result, err := m.driver.Module.CreateVersion(r.Context(), namespace, provider, name, req.Data.Attributes.Version)
+		exists, overwrite, err := m.presignOverwrite(r, namespace, provider, name, req.Data.Attributes.Version)
+		if err != nil {
+			return err
+		}
+
+		result, err := m.driver.Module.CreateVersion(r.Context(), namespace, provider, name, req.Data.Attributes.Version, overwrite)
 		if err != nil {
 			return kerrors.Wrap(err)
 		}
 
+		// The upload by the presigned URL bypasses the registry, so the existing package is refused regardless of the content
+		if result.Presigned && exists {
+			if !overwrite {
+				return kerrors.Wrap(ErrArtifactExists, kerrors.WithConflict(), kerrors.WithDetail(ErrArtifactExists.Error()))
+			}
+
+			if err := m.audit.RecordBefore(r.Context(), audit.ActionOverwrite, audit.ResourceTypeModuleVersion, audit.Resource{
+				Namespace: namespace,
+				Name:      name,
+				Provider:  provider,
+				Version:   req.Data.Attributes.Version,
+			}, nil); err != nil {
+				return kerrors.Wrap(err)
+			}
+		}
+
+		if result.Presigned {
+			if err := m.presignPackage(r.Context(), namespace, provider, name, req.Data.Attributes.Version, exists); err != nil {
+				return kerrors.Wrap(err)
+			}
+		}
+
+		m.audit.Record(r.Context(), audit.ActionCreate, audit.ResourceTypeModuleVersion, audit.Resource{
+			Namespace: namespace,
+			Name:      name,
+			Provider:  provider,
+			Version:   req.Data.Attributes.Version,
+		}, nil)
+
 		resp := &CreateModuleVersionResponse{
 			Data: &CreateModuleVersionData{
 				Links: &CreateModuleVersionDataLinks{
-					Upload: result.Upload,
+					Upload:        result.Upload,
+					UploadHeaders: result.UploadHeaders,
 				},
 			},
 		}
@@ -139,9 +208,17 @@ func (m *Module) Download() http.Handler {
 		provider := mux.Vars(r)["provider"]
 		version := mux.Vars(r)["version"]
 
-		f, err := m.driver.Module.GetModule(r.Context(), namespace, name, provider, version)
+		if err := m.policy.Authorize(r.Context(), namespace, policy.ScopeRead); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		if err := m.validatePresigned(r.Context(), namespace, provider, name, version); err != nil {
+			return err
+		}
+
+		f, err := m.driver.Module.GetModule(r.Context(), namespace, provider, name, version)
 		if err != nil {
-			if os.IsNotExist(err) {
+			if errors.Is(err, driver.ErrModulePackageNotExist) {
 				w.WriteHeader(http.StatusNotFound)
 				return driver.ErrModuleNotExist
 			}
@@ -169,9 +246,17 @@ func (m *Module) FindSourceCode() http.Handler {
 		provider := mux.Vars(r)["provider"]
 		version := mux.Vars(r)["version"]
 
+		if err := m.policy.Authorize(r.Context(), namespace, policy.ScopeRead); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		if err := m.validatePresigned(r.Context(), namespace, provider, name, version); err != nil {
+			return err
+		}
+
 		url, err := m.driver.Module.GetDownloadURL(r.Context(), namespace, provider, name, version)
 		if err != nil {
-			if os.IsNotExist(err) {
+			if errors.Is(err, driver.ErrModulePackageNotExist) {
 				w.WriteHeader(http.StatusNotFound)
 				return driver.ErrModuleNotExist
 			}
@@ -195,7 +280,13 @@ type ListAvailableVersionsModel struct {
 }
 
 type ListAvailableVersionsModelVersion struct {
-	Version string `json:"version"`
+	Version     string                                 `json:"version"`
+	Deprecation *ListAvailableVersionsModelDeprecation `json:"deprecation,omitempty"`
+}
+
+// ListAvailableVersionsModelDeprecation is the deprecation of the version, which Terraform shows as the warning
+type ListAvailableVersionsModelDeprecation struct {
+	Reason string `json:"reason"`
 }
 
 // https://www.terraform.io/internals/module-registry-protocol#list-available-versions-for-a-specific-module
@@ -205,16 +296,57 @@ func (m *Module) ListAvailableVersions() http.Handler {
 		name := mux.Vars(r)["name"]
 		provider := mux.Vars(r)["provider"]
 
+		if err := m.policy.Authorize(r.Context(), namespace, policy.ScopeRead); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
 		versions, err := m.driver.Module.ListAvailableVersions(r.Context(), namespace, provider, name)
 		if err != nil {
+			if errors.Is(err, driver.ErrModuleNotExist) {
+				return kerrors.Wrap(err, kerrors.WithNotFound())
+			}
+
 			return kerrors.Wrap(err)
 		}
 
-		vs := make([]ListAvailableVersionsModelVersion, len(versions))
+		metadata := make([]*driver.ModuleVersionMetadata, len(versions))
+		g, ctx := errgroup.WithContext(r.Context())
+		g.SetLimit(driver.MetadataConcurrency)
 		for i, version := range versions {
-			vs[i] = ListAvailableVersionsModelVersion{
+			i, version := i, version
+			g.Go(func() error {
+				md, err := m.driver.Module.GetVersionMetadata(ctx, namespace, provider, name, version)
+				if err != nil {
+					return err
+				}
+
+				metadata[i] = md
+				return nil
+			})
+		}
+
+		if err := g.Wait(); err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		vs := []ListAvailableVersionsModelVersion{}
+		for i, version := range versions {
+			metadata := metadata[i]
+			if metadata.YankedAt != nil {
+				continue
+			}
+
+			v := ListAvailableVersionsModelVersion{
 				Version: version,
 			}
+
+			if metadata.DeprecatedAt != nil {
+				v.Deprecation = &ListAvailableVersionsModelDeprecation{
+					Reason: metadata.DeprecationReason,
+				}
+			}
+
+			vs = append(vs, v)
 		}
 
 		resp := &ListAvailableVersionsResponse{
@@ -236,11 +368,62 @@ func (m *Module) UploadModuleVersion() http.Handler {
 		name := mux.Vars(r)["name"]
 		version := mux.Vars(r)["version"]
 
-		if err := m.driver.Module.SavePackage(r.Context(), namespace, provider, name, version, r.Body); err != nil {
-			return kerrors.Wrap(err)
+		if err := m.policy.Authorize(r.Context(), namespace, policy.ScopePublish); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
 		}
+
+		f, err := spool(r, m.limits.Module)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
 		defer r.Body.Close()
 
+		if err := artifact.ValidateModule(f); err != nil {
+			return kerrors.Wrap(err, kerrors.WithBadRequest(), kerrors.WithDetail(err.Error()))
+		}
+
+		if err := f.Rewind(); err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		existing, err := m.driver.Module.GetModule(r.Context(), namespace, provider, name, version)
+		action, save, err := m.uploadAction(r, namespace, f, existing, err)
+		if err != nil {
+			return err
+		}
+
+		if !save {
+			m.logger.Info("skip saving identical module package")
+			return nil
+		}
+
+		body := audit.NewDigestReader(f)
+		if err := m.driver.Module.SavePackage(r.Context(), namespace, provider, name, version, body, action == audit.ActionOverwrite); err != nil {
+			return wrapSaveError(err)
+		}
+
+		m.audit.Record(r.Context(), action, audit.ResourceTypeModuleVersion, audit.Resource{
+			Namespace: namespace,
+			Name:      name,
+			Provider:  provider,
+			Version:   version,
+		}, body.Digests())
+
 		return nil
 	})
 }
+
+// spool spools the uploaded body to the temporary file within the limit
+func spool(r *http.Request, limit int64) (*artifact.File, error) {
+	f, err := artifact.Spool(r.Body, limit)
+	if err != nil {
+		if errors.Is(err, artifact.ErrTooLarge) {
+			return nil, kerrors.Wrap(err, kerrors.WithRequestEntityTooLarge(), kerrors.WithDetail(err.Error()))
+		}
+
+		return nil, kerrors.Wrap(err)
+	}
+
+	return f, nil
+}
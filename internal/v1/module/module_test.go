@@ -0,0 +1,142 @@
+package module
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/kerraform/kegistry/internal/artifact"
+	"github.com/kerraform/kegistry/internal/auth"
+	"github.com/kerraform/kegistry/internal/driver"
+	"github.com/kerraform/kegistry/internal/driver/memory"
+	"github.com/kerraform/kegistry/internal/logging"
+	"github.com/kerraform/kegistry/internal/policy"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	testNamespace = "acme"
+	testName      = "vpc"
+	testProvider  = "aws"
+)
+
+func newTestModule(t *testing.T) (*Module, *driver.Driver) {
+	t.Helper()
+	d := memory.NewDriver(&memory.DriverConfig{
+		Logger: zap.NewNop(),
+		Tracer: trace.NewNoopTracerProvider().Tracer(""),
+	})
+
+	if err := d.Module.CreateModule(context.Background(), testNamespace, testProvider, testName); err != nil {
+		t.Fatal(err)
+	}
+
+	return New(&Config{
+		Driver: d,
+		Limits: &artifact.Limits{},
+		Logger: zap.NewNop(),
+	}), d
+}
+
+// testPolicy allows token:ci to publish and token:admin to administer the test namespace
+func testPolicy() *policy.Policy {
+	return &policy.Policy{
+		Rules: []policy.Rule{
+			{Subjects: []string{"token:ci"}, Namespaces: []string{testNamespace}, Scope: policy.ScopePublish},
+			{Subjects: []string{"token:admin"}, Namespaces: []string{testNamespace}, Scope: policy.ScopeAdmin},
+		},
+	}
+}
+
+func withCaller(r *http.Request, subject string) *http.Request {
+	return r.WithContext(auth.WithIdentity(r.Context(), &auth.Identity{Subject: subject}))
+}
+
+// serve calls the handler with the logger and the path variables which the router sets
+func serve(h http.Handler, r *http.Request, vars map[string]string) *httptest.ResponseRecorder {
+	r = r.WithContext(context.WithValue(r.Context(), logging.Key, zap.NewNop()))
+	r = mux.SetURLVars(r, vars)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func versionVars(version string) map[string]string {
+	return map[string]string{
+		"namespace": testNamespace,
+		"name":      testName,
+		"provider":  testProvider,
+		"version":   version,
+	}
+}
+
+// newTestPackage returns the module package with main.tf of the body
+func newTestPackage(t *testing.T, body string) []byte {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     "main.tf",
+		Typeflag: tar.TypeReg,
+		Mode:     0644,
+		Size:     int64(len(body)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tw.Write([]byte(body)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// uploadTestVersion creates the version with the package
+func uploadTestVersion(t *testing.T, d *driver.Driver, version string) {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := d.Module.CreateVersion(ctx, testNamespace, testProvider, testName, version, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Module.SavePackage(ctx, testNamespace, testProvider, testName, version, bytes.NewReader(newTestPackage(t, version)), false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// listTestVersions returns the versions listed as available to token:ci
+func listTestVersions(t *testing.T, m *Module) []ListAvailableVersionsModelVersion {
+	t.Helper()
+	r := withCaller(httptest.NewRequest(http.MethodGet, "/", nil), "token:ci")
+	w := serve(m.ListAvailableVersions(), r, versionVars(""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var resp ListAvailableVersionsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resp.Modules) != 1 {
+		t.Fatalf("modules = %d, want 1", len(resp.Modules))
+	}
+
+	return resp.Modules[0].Versions
+}
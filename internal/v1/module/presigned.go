@@ -0,0 +1,147 @@
+package module
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/kerraform/kegistry/internal/artifact"
+	"github.com/kerraform/kegistry/internal/driver"
+	kerrors "github.com/kerraform/kegistry/internal/errors"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidPackage = errors.New("module package uploaded by the presigned URL is invalid, upload a valid one again")
+)
+
+// presignPackage marks the package of the version presigned to upload with the sha256 sum of the package to be replaced,
+// so that the package uploaded bypassing the registry is validated before it is served.
+func (m *Module) presignPackage(ctx context.Context, namespace, provider, name, version string, exists bool) error {
+	presigned := &driver.PresignedPackage{}
+	if exists {
+		sum, err := m.packageSHA256(ctx, namespace, provider, name, version)
+		if err != nil {
+			return err
+		}
+
+		presigned.SHA256 = sum
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	metadata, err := m.driver.Module.GetVersionMetadata(ctx, namespace, provider, name, version)
+	if err != nil {
+		return err
+	}
+
+	metadata.Presigned = presigned
+	return m.driver.Module.SaveVersionMetadata(ctx, namespace, provider, name, version, metadata)
+}
+
+// validatePresigned validates the package uploaded by the presigned URL against the size limit and the module layout,
+// as the upload handler does, and clears the presigned package once valid. The invalid package is refused to be served
+// until a valid one is uploaded again. The package is left as presigned until it differs from the replaced one,
+// as the upload may not be done yet.
+func (m *Module) validatePresigned(ctx context.Context, namespace, provider, name, version string) error {
+	metadata, err := m.driver.Module.GetVersionMetadata(ctx, namespace, provider, name, version)
+	if err != nil {
+		if errors.Is(err, driver.ErrModuleVersionNotExist) {
+			return nil
+		}
+
+		return kerrors.Wrap(err)
+	}
+
+	if metadata.Presigned == nil {
+		return nil
+	}
+
+	presigned := *metadata.Presigned
+	rc, err := m.driver.Module.GetModule(ctx, namespace, provider, name, version)
+	if err != nil {
+		if errors.Is(err, driver.ErrModulePackageNotExist) {
+			return nil
+		}
+
+		return kerrors.Wrap(err)
+	}
+	defer rc.Close()
+
+	f, err := artifact.Spool(rc, m.limits.Module)
+	if err != nil {
+		if errors.Is(err, artifact.ErrTooLarge) {
+			return wrapInvalidPackage(err)
+		}
+
+		return kerrors.Wrap(err)
+	}
+	defer f.Close()
+
+	sum, err := f.SHA256()
+	if err != nil {
+		return kerrors.Wrap(err)
+	}
+
+	if sum == presigned.SHA256 {
+		return nil
+	}
+
+	if err := artifact.ValidateModule(f); err != nil {
+		if errors.Is(err, artifact.ErrInvalidModule) {
+			return wrapInvalidPackage(err)
+		}
+
+		return kerrors.Wrap(err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// The package may be presigned again while validated, which is left to be validated again
+	metadata, err = m.driver.Module.GetVersionMetadata(ctx, namespace, provider, name, version)
+	if err != nil {
+		return kerrors.Wrap(err)
+	}
+
+	if metadata.Presigned == nil || *metadata.Presigned != presigned {
+		return nil
+	}
+
+	metadata.Presigned = nil
+	if err := m.driver.Module.SaveVersionMetadata(ctx, namespace, provider, name, version, metadata); err != nil {
+		return kerrors.Wrap(err)
+	}
+
+	m.logger.Info("validated module package uploaded by presigned url",
+		zap.String("namespace", namespace),
+		zap.String("provider", provider),
+		zap.String("name", name),
+		zap.String("version", version),
+	)
+	return nil
+}
+
+func (m *Module) packageSHA256(ctx context.Context, namespace, provider, name, version string) (string, error) {
+	rc, err := m.driver.Module.GetModule(ctx, namespace, provider, name, version)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, rc); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// wrapInvalidPackage refuses to serve the invalid package uploaded by the presigned URL
+func wrapInvalidPackage(err error) error {
+	return kerrors.Wrap(ErrInvalidPackage, kerrors.WithConflict(), kerrors.WithDetail(fmt.Sprintf("%s: %s", ErrInvalidPackage, err)))
+}
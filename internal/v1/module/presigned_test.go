@@ -0,0 +1,71 @@
+package module
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDownloadPresigned(t *testing.T) {
+	m, d := newTestModule(t)
+	m.limits.Module = 1024
+	ctx := context.Background()
+	uploadTestVersion(t, d, "1.0.0")
+
+	// The package is replaced by the presigned URL, bypassing the validation of the upload handler
+	if err := m.presignPackage(ctx, testNamespace, testProvider, testName, "1.0.0", true); err != nil {
+		t.Fatal(err)
+	}
+
+	download := func() *httptest.ResponseRecorder {
+		t.Helper()
+		return serve(m.Download(), httptest.NewRequest(http.MethodGet, "/", nil), versionVars("1.0.0"))
+	}
+
+	// The replaced package is served until the upload is done
+	if w := download(); w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), newTestPackage(t, "1.0.0")) {
+		t.Fatalf("status = %d, want %d with the replaced package", w.Code, http.StatusOK)
+	}
+
+	// The random body is not compressed within the limit
+	body := make([]byte, 4096)
+	rand.New(rand.NewSource(1)).Read(body)
+	invalid := [][]byte{
+		[]byte("not a gzip"),
+		newTestPackage(t, string(body)),
+	}
+	for _, pkg := range invalid {
+		if err := d.Module.SavePackage(ctx, testNamespace, testProvider, testName, "1.0.0", bytes.NewReader(pkg), true); err != nil {
+			t.Fatal(err)
+		}
+
+		if w := download(); w.Code != http.StatusConflict {
+			t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusConflict, w.Body.String())
+		}
+
+		if w := serve(m.FindSourceCode(), httptest.NewRequest(http.MethodGet, "/", nil), versionVars("1.0.0")); w.Code != http.StatusConflict {
+			t.Fatalf("source code status = %d, want %d: %s", w.Code, http.StatusConflict, w.Body.String())
+		}
+	}
+
+	pkg := newTestPackage(t, "1.0.1")
+	if err := d.Module.SavePackage(ctx, testNamespace, testProvider, testName, "1.0.0", bytes.NewReader(pkg), true); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := download(); w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), pkg) {
+		t.Fatalf("status = %d, want %d with the uploaded package", w.Code, http.StatusOK)
+	}
+
+	metadata, err := d.Module.GetVersionMetadata(ctx, testNamespace, testProvider, testName, "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if metadata.Presigned != nil {
+		t.Errorf("presigned = %v, want cleared once validated", metadata.Presigned)
+	}
+}
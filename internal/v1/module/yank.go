@@ -0,0 +1,116 @@
+package module
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/kerraform/kegistry/internal/audit"
+	kerrors "github.com/kerraform/kegistry/internal/errors"
+	"github.com/kerraform/kegistry/internal/handler"
+	"github.com/kerraform/kegistry/internal/policy"
+)
+
+type YankModuleVersionRequest struct {
+	Data *YankModuleVersionRequestData `json:"data"`
+}
+
+type YankModuleVersionRequestData struct {
+	Attributes *YankModuleVersionDataAttributes `json:"attributes"`
+	Type       DataType                         `json:"type"`
+}
+
+type YankModuleVersionDataAttributes struct {
+	Reason string `json:"reason"`
+}
+
+// YankModuleVersion hides the version from the available versions.
+// The version still can be downloaded, so that the configurations pinned to it keep working.
+func (m *Module) YankModuleVersion() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		namespace := mux.Vars(r)["namespace"]
+		name := mux.Vars(r)["name"]
+		provider := mux.Vars(r)["provider"]
+		version := mux.Vars(r)["version"]
+
+		if err := m.policy.Authorize(r.Context(), namespace, policy.ScopePublish); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		// The reason is optional
+		var req YankModuleVersionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			return kerrors.Wrap(err, kerrors.WithBadRequest())
+		}
+		defer r.Body.Close()
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		metadata, err := m.versionMetadata(r, namespace, provider, name, version)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		metadata.YankedAt = &now
+		if req.Data != nil && req.Data.Attributes != nil {
+			metadata.YankReason = req.Data.Attributes.Reason
+		}
+
+		if err := m.driver.Module.SaveVersionMetadata(r.Context(), namespace, provider, name, version, metadata); err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		m.audit.Record(r.Context(), audit.ActionYank, audit.ResourceTypeModuleVersion, audit.Resource{
+			Namespace: namespace,
+			Name:      name,
+			Provider:  provider,
+			Version:   version,
+		}, nil)
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+}
+
+// UnyankModuleVersion lists the yanked version as available again
+func (m *Module) UnyankModuleVersion() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		namespace := mux.Vars(r)["namespace"]
+		name := mux.Vars(r)["name"]
+		provider := mux.Vars(r)["provider"]
+		version := mux.Vars(r)["version"]
+
+		if err := m.policy.Authorize(r.Context(), namespace, policy.ScopePublish); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		metadata, err := m.versionMetadata(r, namespace, provider, name, version)
+		if err != nil {
+			return err
+		}
+
+		metadata.YankedAt = nil
+		metadata.YankReason = ""
+		if err := m.driver.Module.SaveVersionMetadata(r.Context(), namespace, provider, name, version, metadata); err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		m.audit.Record(r.Context(), audit.ActionUnyank, audit.ResourceTypeModuleVersion, audit.Resource{
+			Namespace: namespace,
+			Name:      name,
+			Provider:  provider,
+			Version:   version,
+		}, nil)
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+}
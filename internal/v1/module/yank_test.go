@@ -0,0 +1,55 @@
+package module
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestYankModuleVersion(t *testing.T) {
+	m, d := newTestModule(t)
+	uploadTestVersion(t, d, "1.0.0")
+	uploadTestVersion(t, d, "1.1.0")
+
+	body := strings.NewReader(`{"data":{"type":"registry-module-versions","attributes":{"reason":"broken"}}}`)
+	w := serve(m.YankModuleVersion(), httptest.NewRequest(http.MethodPost, "/", body), versionVars("1.1.0"))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+	}
+
+	metadata, err := d.Module.GetVersionMetadata(context.Background(), testNamespace, testProvider, testName, "1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if metadata.YankedAt == nil || metadata.YankReason != "broken" {
+		t.Fatalf("yanked at = %v, reason = %q, want yanked for broken", metadata.YankedAt, metadata.YankReason)
+	}
+
+	if got, want := listTestVersions(t, m), []ListAvailableVersionsModelVersion{{Version: "1.0.0"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("versions = %v, want %v", got, want)
+	}
+
+	// The configurations pinned to the yanked version still download it
+	w = serve(m.FindSourceCode(), httptest.NewRequest(http.MethodGet, "/", nil), versionVars("1.1.0"))
+	if w.Code != http.StatusNoContent || w.Header().Get("X-Terraform-Get") == "" {
+		t.Fatalf("status of yanked version = %d, want %d with X-Terraform-Get", w.Code, http.StatusNoContent)
+	}
+
+	w = serve(m.UnyankModuleVersion(), httptest.NewRequest(http.MethodDelete, "/", nil), versionVars("1.1.0"))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+	}
+
+	if got, want := listTestVersions(t, m), []ListAvailableVersionsModelVersion{{Version: "1.0.0"}, {Version: "1.1.0"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("versions = %v, want %v", got, want)
+	}
+
+	w = serve(m.YankModuleVersion(), httptest.NewRequest(http.MethodPost, "/", nil), versionVars("2.0.0"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status of missing version = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kerraform/kegistry/internal/audit"
+	"github.com/kerraform/kegistry/internal/driver"
+	kerrors "github.com/kerraform/kegistry/internal/errors"
+	"github.com/kerraform/kegistry/internal/handler"
+	"github.com/kerraform/kegistry/internal/policy"
+)
+
+// DeleteProviderVersion deletes the version with all of its platforms, SHA256SUMS and its signature.
+// Inspired by Terraform Cloud API:
+// https://developer.hashicorp.com/terraform/cloud-docs/api-docs/private-registry/provider-versions-platforms#delete-a-provider-version
+func (p *Provider) DeleteProviderVersion() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		namespace := mux.Vars(r)["namespace"]
+		registryName := mux.Vars(r)["registryName"]
+		version := mux.Vars(r)["version"]
+
+		if err := p.policy.Authorize(r.Context(), namespace, policy.ScopeAdmin); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		if err := p.driver.Provider.DeleteProviderVersion(r.Context(), namespace, registryName, version); err != nil {
+			if errors.Is(err, driver.ErrProviderVersionNotExist) {
+				return kerrors.Wrap(err, kerrors.WithNotFound())
+			}
+
+			return kerrors.Wrap(err)
+		}
+
+		p.audit.Record(r.Context(), audit.ActionDelete, audit.ResourceTypeProviderVersion, audit.Resource{
+			Namespace: namespace,
+			Name:      registryName,
+			Version:   version,
+		}, nil)
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+}
+
+// DeleteProviderPlatform deletes the platform with its binary.
+// Inspired by Terraform Cloud API:
+// https://developer.hashicorp.com/terraform/cloud-docs/api-docs/private-registry/provider-versions-platforms#delete-a-provider-platform
+func (p *Provider) DeleteProviderPlatform() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		namespace := mux.Vars(r)["namespace"]
+		registryName := mux.Vars(r)["registryName"]
+		version := mux.Vars(r)["version"]
+		os := mux.Vars(r)["os"]
+		arch := mux.Vars(r)["arch"]
+
+		if err := p.policy.Authorize(r.Context(), namespace, policy.ScopeAdmin); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		if err := p.driver.Provider.DeleteProviderPlatform(r.Context(), namespace, registryName, version, os, arch); err != nil {
+			if errors.Is(err, driver.ErrProviderPlatformNotExist) {
+				return kerrors.Wrap(err, kerrors.WithNotFound())
+			}
+
+			return kerrors.Wrap(err)
+		}
+
+		p.audit.Record(r.Context(), audit.ActionDelete, audit.ResourceTypeProviderPlatform, audit.Resource{
+			Namespace: namespace,
+			Name:      registryName,
+			Version:   version,
+			OS:        os,
+			Arch:      arch,
+		}, nil)
+
+		if err := p.undeclarePlatform(r.Context(), namespace, registryName, version, os, arch); err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		registrySigned, err := p.isRegistrySigned(r.Context(), namespace, registryName, version)
+		if err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		if registrySigned {
+			if err := p.signVersion(r.Context(), namespace, registryName, version); err != nil {
+				return kerrors.Wrap(err)
+			}
+		}
+
+		if err := p.reverifyVersion(r.Context(), namespace, registryName, version); err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+}
@@ -0,0 +1,72 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/kerraform/kegistry/internal/driver"
+)
+
+func TestDeleteProviderVersion(t *testing.T) {
+	p, d := newTestProvider(t)
+	p.policy = testPolicy()
+	uploadTestVersion(t, p, d, "1.0.0", "amd64")
+	uploadTestVersion(t, p, d, "1.1.0", "amd64")
+
+	tests := []struct {
+		caller string
+		status int
+	}{
+		{caller: "token:ci", status: http.StatusForbidden},
+		{caller: "token:admin", status: http.StatusNoContent},
+		{caller: "token:admin", status: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		r := withCaller(httptest.NewRequest(http.MethodDelete, "/", nil), tc.caller)
+		w := serve(p.DeleteProviderVersion(), r, versionVars("1.1.0"))
+		if w.Code != tc.status {
+			t.Fatalf("%s: status = %d, want %d: %s", tc.caller, w.Code, tc.status, w.Body.String())
+		}
+	}
+
+	if got, want := listTestVersions(t, p), []string{"1.0.0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("versions = %v, want %v", got, want)
+	}
+
+	if _, err := d.Provider.GetSHASums(context.Background(), testNamespace, testName, "1.1.0"); !errors.Is(err, driver.ErrProviderSHA256SUMSNotExist) {
+		t.Errorf("SHA256SUMS of deleted version: err = %v, want %v", err, driver.ErrProviderSHA256SUMSNotExist)
+	}
+}
+
+func TestDeleteProviderPlatform(t *testing.T) {
+	p, d := newTestProvider(t)
+	p.policy = testPolicy()
+	uploadTestVersion(t, p, d, "1.0.0", "amd64", "arm64")
+
+	r := withCaller(httptest.NewRequest(http.MethodDelete, "/", nil), "token:admin")
+	w := serve(p.DeleteProviderPlatform(), r, platformVars("1.0.0", "linux", "arm64"))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+	}
+
+	metadata := getVersionMetadata(t, d, "1.0.0")
+	if len(metadata.Platforms) != 1 || metadata.Platforms[0].Arch != "amd64" {
+		t.Errorf("platforms = %v, want linux_amd64 only", metadata.Platforms)
+	}
+
+	// SHA256SUMS uploaded by the publisher still lists the deleted platform, which does not fail the verification
+	if metadata.Verification != driver.VerificationStateVerified {
+		t.Errorf("verification = %s, want %s: %s", metadata.Verification, driver.VerificationStateVerified, metadata.VerificationError)
+	}
+
+	r = withCaller(httptest.NewRequest(http.MethodGet, "/", nil), "token:ci")
+	w = serve(p.FindPackage(), r, platformVars("1.0.0", "linux", "arm64"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status of deleted platform = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,391 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	"github.com/kerraform/kegistry/internal/artifact"
+	"github.com/kerraform/kegistry/internal/driver"
+	kerrors "github.com/kerraform/kegistry/internal/errors"
+	"github.com/kerraform/kegistry/internal/handler"
+	model "github.com/kerraform/kegistry/internal/model/provider"
+	"github.com/kerraform/kegistry/internal/policy"
+	"go.uber.org/zap"
+)
+
+type FsckStatus string
+
+const (
+	// FsckStatusOK is the platform whose metadata matches the binary
+	FsckStatusOK FsckStatus = "ok"
+
+	// FsckStatusBackfilled is the platform whose metadata is missing and saved from the binary
+	FsckStatusBackfilled FsckStatus = "backfilled"
+
+	// FsckStatusRepaired is the platform whose metadata does not match the binary and saved again from the binary
+	FsckStatusRepaired FsckStatus = "repaired"
+
+	// FsckStatusMissing is the platform created without the binary uploaded
+	FsckStatusMissing FsckStatus = "missing"
+)
+
+type FsckProviderResponse struct {
+	Data []*FsckProviderResponseData `json:"data"`
+}
+
+type FsckProviderResponseData struct {
+	Attributes *FsckProviderResponseDataAttributes `json:"attributes"`
+	Type       DataType                            `json:"type"`
+}
+
+type FsckProviderResponseDataAttributes struct {
+	Version string     `json:"version"`
+	OS      string     `json:"os"`
+	Arch    string     `json:"arch"`
+	Size    int64      `json:"size,omitempty"`
+	SHA256  string     `json:"sha256,omitempty"`
+	Status  FsckStatus `json:"status"`
+}
+
+// FsckProvider checks the platform metadata of all the versions against the binaries,
+// and saves the metadata computed from the binaries if missing or mismatched.
+func (p *Provider) FsckProvider() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		namespace := mux.Vars(r)["namespace"]
+		registryName := mux.Vars(r)["registryName"]
+
+		if err := p.policy.Authorize(r.Context(), namespace, policy.ScopeAdmin); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		if err := p.driver.Provider.IsProviderCreated(r.Context(), namespace, registryName); err != nil {
+			if errors.Is(err, driver.ErrProviderNotExist) {
+				return kerrors.Wrap(err, kerrors.WithNotFound())
+			}
+
+			return kerrors.Wrap(err)
+		}
+
+		versions, err := p.driver.Provider.ListAvailableVersions(r.Context(), namespace, registryName)
+		if err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		resp := &FsckProviderResponse{
+			Data: []*FsckProviderResponseData{},
+		}
+		for _, v := range versions {
+			if v.Version == "" {
+				continue
+			}
+
+			for _, platform := range v.Platforms {
+				attrs, err := p.fsckPlatform(r.Context(), namespace, registryName, v.Version, platform)
+				if err != nil {
+					return kerrors.Wrap(err)
+				}
+
+				resp.Data = append(resp.Data, &FsckProviderResponseData{
+					Type:       DataTypeRegistryProviderPlatforms,
+					Attributes: attrs,
+				})
+			}
+		}
+
+		sort.Slice(resp.Data, func(i, j int) bool {
+			a, b := resp.Data[i].Attributes, resp.Data[j].Attributes
+			if a.Version != b.Version {
+				return a.Version < b.Version
+			}
+
+			if a.OS != b.OS {
+				return a.OS < b.OS
+			}
+
+			return a.Arch < b.Arch
+		})
+
+		return json.NewEncoder(w).Encode(resp)
+	})
+}
+
+func (p *Provider) fsckPlatform(ctx context.Context, namespace, registryName, version string, platform model.AvailableVersionPlatform) (*FsckProviderResponseDataAttributes, error) {
+	attrs := &FsckProviderResponseDataAttributes{
+		Version: version,
+		OS:      platform.OS,
+		Arch:    platform.Arch,
+	}
+
+	existing, err := p.driver.Provider.GetPlatformMetadata(ctx, namespace, registryName, version, platform.OS, platform.Arch)
+	if err != nil && !errors.Is(err, driver.ErrProviderPlatformMetadataNotExist) {
+		return nil, err
+	}
+
+	metadata, err := p.indexPlatform(ctx, namespace, registryName, version, platform.OS, platform.Arch, existing)
+	if err != nil {
+		if errors.Is(err, driver.ErrProviderBinaryNotExist) {
+			attrs.Status = FsckStatusMissing
+			return attrs, nil
+		}
+
+		return nil, err
+	}
+
+	attrs.Size = metadata.Size
+	attrs.SHA256 = metadata.SHA256
+	switch {
+	case existing == nil:
+		attrs.Status = FsckStatusBackfilled
+	case *existing != *metadata:
+		attrs.Status = FsckStatusRepaired
+	default:
+		attrs.Status = FsckStatusOK
+	}
+
+	return attrs, nil
+}
+
+// findPackage finds the package of the platform from the platform metadata.
+// The metadata is backfilled for the binary uploaded before the metadata is introduced, or uploaded by the presigned URL.
+// The version is verified again first if the platform is presigned, as the saved digests are of the replaced binary until then.
+// The package of the version which failed the verification is refused with ErrVersionFailed, as its artifacts are not trusted.
+func (p *Provider) findPackage(ctx context.Context, namespace, registryName, version, os, arch string) (*model.Package, error) {
+	metadata, err := p.verifyPresigned(ctx, namespace, registryName, version, os, arch)
+	if err != nil {
+		return nil, err
+	}
+
+	if metadata != nil && metadata.Verification == driver.VerificationStateFailed {
+		return nil, fmt.Errorf("%w: %s", ErrVersionFailed, metadata.VerificationError)
+	}
+
+	pkg, err := p.driver.Provider.FindPackage(ctx, namespace, registryName, version, os, arch)
+	if !errors.Is(err, driver.ErrProviderPlatformMetadataNotExist) {
+		return pkg, err
+	}
+
+	if _, err := p.indexPlatform(ctx, namespace, registryName, version, os, arch, nil); err != nil {
+		return nil, err
+	}
+
+	return p.driver.Provider.FindPackage(ctx, namespace, registryName, version, os, arch)
+}
+
+// verifyPresigned verifies the version again if the platform is presigned, and returns the metadata of the version,
+// which is nil for the legacy versions
+func (p *Provider) verifyPresigned(ctx context.Context, namespace, registryName, version, os, arch string) (*driver.ProviderVersionMetadata, error) {
+	unlock := p.lockVersion(namespace, registryName, version)
+	defer unlock()
+
+	metadata, err := p.driver.Provider.GetVersionMetadata(ctx, namespace, registryName, version)
+	if err != nil {
+		if errors.Is(err, driver.ErrProviderVersionNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	if hasPresigned(metadata.Presigned, os, arch) {
+		if err := p.verifyVersion(ctx, namespace, registryName, version, metadata); err != nil {
+			return nil, err
+		}
+	}
+
+	return metadata, nil
+}
+
+// platformMetadata returns the platform metadata, which is backfilled if missing.
+// The binary of the presigned platform is digested again, as it may be replaced without the registry since the metadata is saved.
+func (p *Provider) platformMetadata(ctx context.Context, namespace, registryName, version, os, arch string) (*driver.ProviderPlatformMetadata, error) {
+	versionMetadata, err := p.driver.Provider.GetVersionMetadata(ctx, namespace, registryName, version)
+	if err != nil && !errors.Is(err, driver.ErrProviderVersionNotExist) {
+		return nil, err
+	}
+
+	metadata, err := p.driver.Provider.GetPlatformMetadata(ctx, namespace, registryName, version, os, arch)
+	if err != nil && !errors.Is(err, driver.ErrProviderPlatformMetadataNotExist) {
+		return nil, err
+	}
+
+	if metadata != nil && (versionMetadata == nil || !hasPresigned(versionMetadata.Presigned, os, arch)) {
+		return metadata, nil
+	}
+
+	return p.indexPlatform(ctx, namespace, registryName, version, os, arch, metadata)
+}
+
+// indexPlatform computes the digests of the platform binary, and saves them to the platform metadata unless identical to the existing one
+func (p *Provider) indexPlatform(ctx context.Context, namespace, registryName, version, os, arch string, existing *driver.ProviderPlatformMetadata) (*driver.ProviderPlatformMetadata, error) {
+	rc, err := p.driver.Provider.GetPlatformBinary(ctx, namespace, registryName, version, os, arch)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	f, err := artifact.Spool(rc, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return p.savePlatformDigests(ctx, namespace, registryName, version, os, arch, f, existing)
+}
+
+// savePlatformDigests computes the digests of the spooled platform binary, and saves them to the platform metadata unless identical to the existing one
+func (p *Provider) savePlatformDigests(ctx context.Context, namespace, registryName, version, os, arch string, f *artifact.File, existing *driver.ProviderPlatformMetadata) (*driver.ProviderPlatformMetadata, error) {
+	metadata, err := p.digestPlatform(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil && *existing == *metadata {
+		return metadata, nil
+	}
+
+	if err := p.driver.Provider.SavePlatformMetadata(ctx, namespace, registryName, version, os, arch, metadata); err != nil {
+		return nil, err
+	}
+
+	p.logger.Info("saved platform metadata from provider binary",
+		zap.String("namespace", namespace),
+		zap.String("name", registryName),
+		zap.String("version", version),
+		zap.String("os", os),
+		zap.String("arch", arch),
+	)
+	return metadata, nil
+}
+
+// presignedPlatform returns the platform presigned to upload with the sha256 sum of the binary to be replaced.
+// The saved digests are kept until the upload, as they are still of the binary served until then.
+func (p *Provider) presignedPlatform(ctx context.Context, namespace, registryName, version, os, arch string) (*driver.PresignedPlatform, error) {
+	platform := &driver.PresignedPlatform{
+		OS:   os,
+		Arch: arch,
+	}
+
+	metadata, err := p.driver.Provider.GetPlatformMetadata(ctx, namespace, registryName, version, os, arch)
+	if err != nil {
+		if errors.Is(err, driver.ErrProviderPlatformMetadataNotExist) {
+			return platform, nil
+		}
+
+		return nil, err
+	}
+
+	platform.SHA256 = metadata.SHA256
+	return platform, nil
+}
+
+// digestPresigned saves the digests of the binaries uploaded by the presigned URLs, and removes their platforms from the
+// presigned platforms of the version. It reports whether the metadata of the version is changed to be saved.
+// The platform is left until the binary differs from the replaced one, as the upload may not be done yet.
+// The uploaded binary is checked against the size limit and validated as the upload handler does, as the presigned URL
+// bypasses both. The platform of the invalid binary is left as well, and the reason is returned to fail the version.
+func (p *Provider) digestPresigned(ctx context.Context, namespace, registryName, version string, metadata *driver.ProviderVersionMetadata) (bool, string, error) {
+	presigned := make([]driver.PresignedPlatform, 0, len(metadata.Presigned))
+	reason := ""
+	for _, platform := range metadata.Presigned {
+		invalid, uploaded, err := p.digestPresignedPlatform(ctx, namespace, registryName, version, platform)
+		if err != nil {
+			return false, "", err
+		}
+
+		if invalid != "" && reason == "" {
+			reason = invalid
+		}
+
+		if !uploaded || invalid != "" {
+			presigned = append(presigned, platform)
+		}
+	}
+
+	if len(presigned) == len(metadata.Presigned) {
+		return false, reason, nil
+	}
+
+	metadata.Presigned = presigned
+	return true, reason, nil
+}
+
+// digestPresignedPlatform validates and digests the binary of the presigned platform if uploaded.
+// It returns the reason why the uploaded binary is invalid, whose digests are not saved.
+func (p *Provider) digestPresignedPlatform(ctx context.Context, namespace, registryName, version string, platform driver.PresignedPlatform) (string, bool, error) {
+	rc, err := p.driver.Provider.GetPlatformBinary(ctx, namespace, registryName, version, platform.OS, platform.Arch)
+	if err != nil {
+		if errors.Is(err, driver.ErrProviderBinaryNotExist) {
+			return "", false, nil
+		}
+
+		return "", false, err
+	}
+	defer rc.Close()
+
+	f, err := artifact.Spool(rc, p.limits.ProviderBinary)
+	if err != nil {
+		if errors.Is(err, artifact.ErrTooLarge) {
+			return fmt.Sprintf("binary of %s_%s: %s", platform.OS, platform.Arch, err), true, nil
+		}
+
+		return "", false, err
+	}
+	defer f.Close()
+
+	sum, err := f.SHA256()
+	if err != nil {
+		return "", false, err
+	}
+
+	if sum == platform.SHA256 {
+		return "", false, nil
+	}
+
+	if err := artifact.ValidateProviderBinary(f, f.Size(), registryName, version); err != nil {
+		if errors.Is(err, artifact.ErrInvalidProviderBinary) {
+			return fmt.Sprintf("binary of %s_%s: %s", platform.OS, platform.Arch, err), true, nil
+		}
+
+		return "", false, err
+	}
+
+	existing, err := p.driver.Provider.GetPlatformMetadata(ctx, namespace, registryName, version, platform.OS, platform.Arch)
+	if err != nil && !errors.Is(err, driver.ErrProviderPlatformMetadataNotExist) {
+		return "", false, err
+	}
+
+	if _, err := p.savePlatformDigests(ctx, namespace, registryName, version, platform.OS, platform.Arch, f, existing); err != nil {
+		return "", false, err
+	}
+
+	return "", true, nil
+}
+
+// digestPlatform computes the digests of the platform binary.
+// The "h1:" hash is left empty for the binary which is not a zip, as it is still served by the sha256 sum.
+func (p *Provider) digestPlatform(f *artifact.File) (*driver.ProviderPlatformMetadata, error) {
+	sum, err := f.SHA256()
+	if err != nil {
+		return nil, err
+	}
+
+	h1, err := f.HashV1()
+	if err != nil {
+		if !errors.Is(err, artifact.ErrInvalidProviderBinary) {
+			return nil, err
+		}
+
+		p.logger.Warn("failed to compute h1 hash of provider binary", zap.Error(err))
+	}
+
+	return &driver.ProviderPlatformMetadata{
+		Size:   f.Size(),
+		SHA256: sum,
+		HashV1: h1,
+	}, nil
+}
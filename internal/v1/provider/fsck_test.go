@@ -0,0 +1,162 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/kerraform/kegistry/internal/driver"
+	model "github.com/kerraform/kegistry/internal/model/provider"
+)
+
+func TestFindPackagePresigned(t *testing.T) {
+	p, d := newTestProvider(t)
+	ctx := context.Background()
+	createTestVersion(t, d, "1.0.0", &driver.ProviderVersionMetadata{
+		State:        driver.VersionStatePublished,
+		Verification: driver.VerificationStatePending,
+	})
+
+	if _, err := d.Provider.CreateProviderPlatform(ctx, testNamespace, testName, "1.0.0", "linux", "amd64", false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Provider.SavePlatformBinary(ctx, testNamespace, testName, "1.0.0", "linux", "amd64", bytes.NewReader(newTestBinary(t, "1.0.0", "old")), false); err != nil {
+		t.Fatal(err)
+	}
+
+	// The binary is replaced by the presigned URL after the platform metadata is saved
+	old, err := p.platformMetadata(ctx, testNamespace, testName, "1.0.0", "linux", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadata, err := d.Provider.GetVersionMetadata(ctx, testNamespace, testName, "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadata.Presigned = []driver.PresignedPlatform{{OS: "linux", Arch: "amd64", SHA256: old.SHA256}}
+	if err := d.Provider.SaveVersionMetadata(ctx, testNamespace, testName, "1.0.0", metadata); err != nil {
+		t.Fatal(err)
+	}
+
+	binary := newTestBinary(t, "1.0.0", "new")
+	if err := d.Provider.SavePlatformBinary(ctx, testNamespace, testName, "1.0.0", "linux", "amd64", bytes.NewReader(binary), true); err != nil {
+		t.Fatal(err)
+	}
+
+	w := serve(p.FindPackage(), httptest.NewRequest(http.MethodGet, "/", nil), map[string]string{
+		"namespace":    testNamespace,
+		"registryName": testName,
+		"version":      "1.0.0",
+		"os":           "linux",
+		"arch":         "amd64",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var pkg model.Package
+	if err := json.NewDecoder(w.Body).Decode(&pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256(binary)
+	if want := hex.EncodeToString(sum[:]); pkg.SHASum != want {
+		t.Errorf("shasum = %s, want %s", pkg.SHASum, want)
+	}
+
+	metadata, err = d.Provider.GetVersionMetadata(ctx, testNamespace, testName, "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(metadata.Presigned) != 0 {
+		t.Errorf("presigned = %v, want none", metadata.Presigned)
+	}
+}
+
+func TestFsckProvider(t *testing.T) {
+	p, d := newTestProvider(t)
+	p.policy = testPolicy()
+	ctx := context.Background()
+	uploadTestVersion(t, p, d, "1.0.0", "amd64", "arm64")
+
+	// The binary uploaded before the platform metadata is introduced
+	createTestVersion(t, d, "1.1.0", &driver.ProviderVersionMetadata{
+		State:        driver.VersionStatePublished,
+		Verification: driver.VerificationStatePending,
+	})
+	createTestPlatform(t, p, d, "1.1.0", "linux", "amd64")
+	createTestPlatform(t, p, d, "1.1.0", "linux", "arm64")
+	if err := d.Provider.SavePlatformBinary(ctx, testNamespace, testName, "1.1.0", "linux", "amd64", bytes.NewReader(newTestBinary(t, "1.1.0", "amd64")), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Provider.SavePlatformMetadata(ctx, testNamespace, testName, "1.0.0", "linux", "arm64", &driver.ProviderPlatformMetadata{SHA256: "stale"}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := withCaller(httptest.NewRequest(http.MethodPost, "/", nil), "token:ci")
+	if w := serve(p.FsckProvider(), r, versionVars("")); w.Code != http.StatusForbidden {
+		t.Fatalf("status of publisher = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	r = withCaller(httptest.NewRequest(http.MethodPost, "/", nil), "token:admin")
+	w := serve(p.FsckProvider(), r, versionVars(""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var resp FsckProviderResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]FsckStatus{
+		"1.0.0 linux_amd64": FsckStatusOK,
+		"1.0.0 linux_arm64": FsckStatusRepaired,
+		"1.1.0 linux_amd64": FsckStatusBackfilled,
+		"1.1.0 linux_arm64": FsckStatusMissing,
+	}
+	got := map[string]FsckStatus{}
+	for _, data := range resp.Data {
+		got[data.Attributes.Version+" "+data.Attributes.OS+"_"+data.Attributes.Arch] = data.Attributes.Status
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("statuses = %v, want %v", got, want)
+	}
+
+	metadata, err := d.Provider.GetPlatformMetadata(ctx, testNamespace, testName, "1.0.0", "linux", "arm64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256(newTestBinary(t, "1.0.0", "arm64"))
+	if want := hex.EncodeToString(sum[:]); metadata.SHA256 != want {
+		t.Errorf("repaired shasum = %s, want %s", metadata.SHA256, want)
+	}
+}
+
+func TestFindPackageFailed(t *testing.T) {
+	p, d := newTestProvider(t)
+	uploadTestVersion(t, p, d, "1.0.0", "amd64")
+
+	vars := platformVars("1.0.0", "linux", "amd64")
+	if w := serve(p.FindPackage(), httptest.NewRequest(http.MethodGet, "/", nil), vars); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	failTestVersion(t, d, "1.0.0")
+	if w := serve(p.FindPackage(), httptest.NewRequest(http.MethodGet, "/", nil), vars); w.Code != http.StatusNotFound {
+		t.Errorf("status of failed version = %d, want %d: %s", w.Code, http.StatusNotFound, w.Body.String())
+	}
+}
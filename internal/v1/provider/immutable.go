@@ -0,0 +1,161 @@
+package provider
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/kerraform/kegistry/internal/artifact"
+	"github.com/kerraform/kegistry/internal/audit"
+	"github.com/kerraform/kegistry/internal/driver"
+	kerrors "github.com/kerraform/kegistry/internal/errors"
+	"github.com/kerraform/kegistry/internal/policy"
+)
+
+var (
+	ErrArtifactExists = errors.New("artifact already exists, overwrite it with overwrite=true by the admin")
+)
+
+// uploadAction returns the audit action of saving the uploaded artifact over the existing one,
+// and false if the identical artifact is already saved, so that uploading it again is a no-op.
+//
+// The saved artifacts are immutable, as they may be pinned in the lock files.
+// Only the admin can overwrite them with the different content with `overwrite=true`.
+func (p *Provider) uploadAction(r *http.Request, namespace string, f *artifact.File, existing io.ReadCloser, err error) (audit.Action, bool, error) {
+	if err != nil {
+		if !isNotExist(err) {
+			return "", false, kerrors.Wrap(err)
+		}
+
+		return audit.ActionUpload, true, nil
+	}
+	defer existing.Close()
+
+	identical, err := f.Identical(existing)
+	if err != nil {
+		return "", false, kerrors.Wrap(err)
+	}
+
+	if err := f.Rewind(); err != nil {
+		return "", false, kerrors.Wrap(err)
+	}
+
+	if identical {
+		return "", false, nil
+	}
+
+	if err := p.authorizeOverwrite(r, namespace); err != nil {
+		return "", false, err
+	}
+
+	return audit.ActionOverwrite, true, nil
+}
+
+// presignOverwrite checks the existing artifact before the upload is presigned, which bypasses the registry.
+// It reports whether the artifact exists, and whether the admin overwrites it with `overwrite=true`, in which case
+// the upload is presigned to overwrite the existing object. Otherwise the backend refuses the upload over any object,
+// including the one uploaded by another URL presigned for the same artifact.
+func (p *Provider) presignOverwrite(r *http.Request, namespace string, existing io.ReadCloser, err error) (bool, bool, error) {
+	if err != nil {
+		if !isNotExist(err) {
+			return false, false, kerrors.Wrap(err)
+		}
+
+		return false, false, nil
+	}
+	existing.Close()
+
+	overwrite, err := overwriteRequested(r)
+	if err != nil {
+		return false, false, err
+	}
+
+	if !overwrite {
+		return true, false, nil
+	}
+
+	if err := p.authorizeOverwrite(r, namespace); err != nil {
+		return false, false, err
+	}
+
+	return true, true, nil
+}
+
+// authorizePresign authorizes the upload presigned over the existing artifact.
+// The existing artifact is refused regardless of the content unless the admin overwrites it, which is recorded on presigning.
+func (p *Provider) authorizePresign(r *http.Request, typ audit.ResourceType, res audit.Resource, exists, overwrite bool) error {
+	if !exists {
+		return nil
+	}
+
+	if !overwrite {
+		return kerrors.Wrap(ErrArtifactExists, kerrors.WithConflict(), kerrors.WithDetail(ErrArtifactExists.Error()))
+	}
+
+	if err := p.audit.RecordBefore(r.Context(), audit.ActionOverwrite, typ, res, nil); err != nil {
+		return kerrors.Wrap(err)
+	}
+
+	return nil
+}
+
+// authorizeOverwrite returns 409 unless the admin requests to overwrite the existing artifact with `overwrite=true`
+func (p *Provider) authorizeOverwrite(r *http.Request, namespace string) error {
+	overwrite, err := overwriteRequested(r)
+	if err != nil {
+		return err
+	}
+
+	if !overwrite {
+		return kerrors.Wrap(ErrArtifactExists, kerrors.WithConflict(), kerrors.WithDetail(ErrArtifactExists.Error()))
+	}
+
+	if err := p.policy.Authorize(r.Context(), namespace, policy.ScopeAdmin); err != nil {
+		return kerrors.Wrap(err, kerrors.WithForbidden())
+	}
+
+	return nil
+}
+
+// isNotExist reports whether nothing is saved yet. Any other error, such as the transient one of the backend,
+// must not be taken as the missing artifact, or the published artifact would be overwritten.
+func isNotExist(err error) bool {
+	for _, target := range []error{
+		driver.ErrProviderBinaryNotExist,
+		driver.ErrProviderSHA256SUMSNotExist,
+		driver.ErrProviderSHA256SUMSSigNotExist,
+		driver.ErrProviderPlatformNotExist,
+		driver.ErrProviderVersionNotExist,
+		driver.ErrProviderNotExist,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// wrapSaveError returns 409 if the artifact is saved by another upload after checked, which is only created unless overwritten
+func wrapSaveError(err error) error {
+	if errors.Is(err, driver.ErrArtifactExists) {
+		return kerrors.Wrap(ErrArtifactExists, kerrors.WithConflict(), kerrors.WithDetail(ErrArtifactExists.Error()))
+	}
+
+	return kerrors.Wrap(err)
+}
+
+func overwriteRequested(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("overwrite")
+	if v == "" {
+		return false, nil
+	}
+
+	overwrite, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, kerrors.Wrap(err, kerrors.WithBadRequest())
+	}
+
+	return overwrite, nil
+}
@@ -0,0 +1,121 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+func TestUploadImmutable(t *testing.T) {
+	p, d := newTestProvider(t)
+	p.policy = testPolicy()
+	uploadTestVersion(t, p, d, "1.0.0", "amd64")
+
+	sig, err := p.readSHASumsSig(context.Background(), testNamespace, testName, "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sums, err := p.readSHASums(context.Background(), testNamespace, testName, "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The signature created at another time differs, but is still valid
+	buf := new(bytes.Buffer)
+	if err := openpgp.ArmoredDetachSign(buf, testKey(t), bytes.NewReader(sums), &packet.Config{
+		Time: func() time.Time {
+			return time.Now().Add(-time.Minute)
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	newSig := buf.Bytes()
+	tests := []struct {
+		name    string
+		handler http.Handler
+		vars    map[string]string
+		target  string
+		caller  string
+		body    []byte
+		status  int
+	}{
+		{
+			name:    "identical signature",
+			handler: p.UploadSHASumsSignature(),
+			vars:    versionVars("1.0.0"),
+			target:  "/",
+			caller:  "token:ci",
+			body:    sig,
+			status:  http.StatusOK,
+		},
+		{
+			name:    "different binary",
+			handler: p.UploadPlatformBinary(),
+			vars:    platformVars("1.0.0", "linux", "amd64"),
+			target:  "/",
+			caller:  "token:ci",
+			body:    newTestBinary(t, "1.0.0", "arm64"),
+			status:  http.StatusConflict,
+		},
+		{
+			name:    "different signature",
+			handler: p.UploadSHASumsSignature(),
+			vars:    versionVars("1.0.0"),
+			target:  "/",
+			caller:  "token:ci",
+			body:    newSig,
+			status:  http.StatusConflict,
+		},
+		{
+			name:    "invalid overwrite",
+			handler: p.UploadSHASumsSignature(),
+			vars:    versionVars("1.0.0"),
+			target:  "/?overwrite=maybe",
+			caller:  "token:admin",
+			body:    newSig,
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:    "overwrite by publisher",
+			handler: p.UploadSHASumsSignature(),
+			vars:    versionVars("1.0.0"),
+			target:  "/?overwrite=true",
+			caller:  "token:ci",
+			body:    newSig,
+			status:  http.StatusForbidden,
+		},
+		{
+			name:    "overwrite by admin",
+			handler: p.UploadSHASumsSignature(),
+			vars:    versionVars("1.0.0"),
+			target:  "/?overwrite=true",
+			caller:  "token:admin",
+			body:    newSig,
+			status:  http.StatusOK,
+		},
+	}
+
+	for _, tc := range tests {
+		r := withCaller(httptest.NewRequest(http.MethodPut, tc.target, bytes.NewReader(tc.body)), tc.caller)
+		w := serve(tc.handler, r, tc.vars)
+		if w.Code != tc.status {
+			t.Fatalf("%s: status = %d, want %d: %s", tc.name, w.Code, tc.status, w.Body.String())
+		}
+	}
+
+	got, err := p.readSHASumsSig(context.Background(), testNamespace, testName, "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, newSig) {
+		t.Error("signature is not overwritten by the admin")
+	}
+}
@@ -0,0 +1,114 @@
+package provider
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kerraform/kegistry/internal/artifact"
+	"github.com/kerraform/kegistry/internal/audit"
+	kerrors "github.com/kerraform/kegistry/internal/errors"
+	"github.com/kerraform/kegistry/internal/handler"
+	"github.com/kerraform/kegistry/internal/logging"
+	"github.com/kerraform/kegistry/internal/policy"
+)
+
+// UploadRegistryManifest saves the plugin protocol versions of terraform-registry-manifest.json to the version.
+// The protocols are immutable once saved like the other artifacts.
+func (p *Provider) UploadRegistryManifest() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		namespace := mux.Vars(r)["namespace"]
+		registryName := mux.Vars(r)["registryName"]
+		version := mux.Vars(r)["version"]
+
+		if err := p.policy.Authorize(r.Context(), namespace, policy.ScopePublish); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		l, err := logging.FromCtx(r.Context())
+		if err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		// The manifest is as small as SHA256SUMS
+		f, err := spool(r, p.limits.SHASums)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		defer r.Body.Close()
+
+		b, err := f.ReadAll()
+		if err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		manifest, err := artifact.ParseRegistryManifest(bytes.NewReader(b))
+		if err != nil {
+			return kerrors.Wrap(err, kerrors.WithBadRequest(), kerrors.WithDetail(err.Error()))
+		}
+
+		unlock := p.lockVersion(namespace, registryName, version)
+		defer unlock()
+
+		metadata, err := p.versionMetadata(r, namespace, registryName, version)
+		if err != nil {
+			return err
+		}
+
+		action, save, err := p.protocolsAction(r, namespace, metadata.Protocols, manifest.Metadata.ProtocolVersions)
+		if err != nil {
+			return err
+		}
+
+		if !save {
+			l.Info("skip saving identical protocols")
+			return nil
+		}
+
+		metadata.Protocols = manifest.Metadata.ProtocolVersions
+		if err := p.driver.Provider.SaveVersionMetadata(r.Context(), namespace, registryName, version, metadata); err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		p.audit.Record(r.Context(), action, audit.ResourceTypeProviderManifest, audit.Resource{
+			Namespace: namespace,
+			Name:      registryName,
+			Version:   version,
+		}, audit.Digests(b))
+
+		return nil
+	})
+}
+
+// protocolsAction returns the audit action of saving the protocols over the existing ones,
+// and false if the same protocols are already saved, in the same manner as uploadAction.
+func (p *Provider) protocolsAction(r *http.Request, namespace string, existing, protocols []string) (audit.Action, bool, error) {
+	if len(existing) == 0 {
+		return audit.ActionUpload, true, nil
+	}
+
+	if equalProtocols(existing, protocols) {
+		return "", false, nil
+	}
+
+	if err := p.authorizeOverwrite(r, namespace); err != nil {
+		return "", false, err
+	}
+
+	return audit.ActionOverwrite, true, nil
+}
+
+func equalProtocols(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
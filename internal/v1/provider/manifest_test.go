@@ -0,0 +1,108 @@
+package provider
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	model "github.com/kerraform/kegistry/internal/model/provider"
+)
+
+func TestUploadRegistryManifest(t *testing.T) {
+	p, d := newTestProvider(t)
+	p.policy = testPolicy()
+	uploadTestVersion(t, p, d, "1.0.0", "amd64")
+
+	tests := []struct {
+		name     string
+		target   string
+		caller   string
+		manifest string
+		status   int
+	}{
+		{
+			name:     "unsupported version",
+			target:   "/",
+			caller:   "token:ci",
+			manifest: `{"version":2,"metadata":{"protocol_versions":["6.0"]}}`,
+			status:   http.StatusBadRequest,
+		},
+		{
+			name:     "invalid protocol",
+			target:   "/",
+			caller:   "token:ci",
+			manifest: `{"version":1,"metadata":{"protocol_versions":["6"]}}`,
+			status:   http.StatusBadRequest,
+		},
+		{
+			name:     "manifest",
+			target:   "/",
+			caller:   "token:ci",
+			manifest: `{"version":1,"metadata":{"protocol_versions":["5.0"]}}`,
+			status:   http.StatusOK,
+		},
+		{
+			name:     "identical protocols",
+			target:   "/",
+			caller:   "token:ci",
+			manifest: `{"version":1,"metadata":{"protocol_versions":["5.0"]}}`,
+			status:   http.StatusOK,
+		},
+		{
+			name:     "different protocols",
+			target:   "/",
+			caller:   "token:ci",
+			manifest: `{"version":1,"metadata":{"protocol_versions":["6.0"]}}`,
+			status:   http.StatusConflict,
+		},
+		{
+			name:     "overwrite by admin",
+			target:   "/?overwrite=true",
+			caller:   "token:admin",
+			manifest: `{"version":1,"metadata":{"protocol_versions":["5.0","6.0"]}}`,
+			status:   http.StatusOK,
+		},
+	}
+
+	for _, tc := range tests {
+		r := withCaller(httptest.NewRequest(http.MethodPut, tc.target, strings.NewReader(tc.manifest)), tc.caller)
+		w := serve(p.UploadRegistryManifest(), r, versionVars("1.0.0"))
+		if w.Code != tc.status {
+			t.Fatalf("%s: status = %d, want %d: %s", tc.name, w.Code, tc.status, w.Body.String())
+		}
+	}
+
+	want := []string{"5.0", "6.0"}
+	r := withCaller(httptest.NewRequest(http.MethodGet, "/", nil), "token:ci")
+	w := serve(p.ListAvailableVersions(), r, versionVars(""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var resp ListAvailableVersionsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resp.Versions) != 1 || !reflect.DeepEqual(resp.Versions[0].Protocols, want) {
+		t.Errorf("versions = %+v, want 1.0.0 of the protocols %v", resp.Versions, want)
+	}
+
+	r = withCaller(httptest.NewRequest(http.MethodGet, "/", nil), "token:ci")
+	w = serve(p.FindPackage(), r, platformVars("1.0.0", "linux", "amd64"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var pkg model.Package
+	if err := json.NewDecoder(w.Body).Decode(&pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(pkg.Protocols, want) {
+		t.Errorf("protocols of package = %v, want %v", pkg.Protocols, want)
+	}
+}
@@ -0,0 +1,211 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kerraform/kegistry/internal/artifact"
+	"github.com/kerraform/kegistry/internal/driver"
+	kerrors "github.com/kerraform/kegistry/internal/errors"
+	"github.com/kerraform/kegistry/internal/handler"
+	model "github.com/kerraform/kegistry/internal/model/provider"
+	"github.com/kerraform/kegistry/internal/policy"
+)
+
+// MirrorIndex lists the available versions of the provider for the network mirror.
+// The hostname is not a part of the lookup, so that the providers published under the other hostnames
+// (e.g. registry.terraform.io/hashicorp/aws) are mirrored from the same namespace and type,
+// unless the upstream of the hostname is configured for the proxy.
+// https://developer.hashicorp.com/terraform/internals/provider-network-mirror-protocol#list-available-versions
+func (p *Provider) MirrorIndex() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		hostname := mux.Vars(r)["hostname"]
+		namespace := mux.Vars(r)["namespace"]
+		registryName := mux.Vars(r)["type"]
+
+		if err := p.policy.Authorize(r.Context(), namespace, policy.ScopeRead); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		if p.proxy.Upstream(hostname, namespace) == nil {
+			if err := p.driver.Provider.IsProviderCreated(r.Context(), namespace, registryName); err != nil {
+				if errors.Is(err, driver.ErrProviderNotExist) {
+					return kerrors.Wrap(err, kerrors.WithNotFound())
+				}
+
+				return kerrors.Wrap(err)
+			}
+		}
+
+		versions, err := p.availableVersions(r.Context(), hostname, namespace, registryName)
+		if err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		index := &model.MirrorIndex{
+			Versions: map[string]model.MirrorIndexVersion{},
+		}
+		for _, v := range versions {
+			index.Versions[v.Version] = model.MirrorIndexVersion{}
+		}
+
+		return json.NewEncoder(w).Encode(index)
+	})
+}
+
+// MirrorVersion lists the archives of the version for the network mirror with their "h1:" and "zh:" hashes.
+// Yanked versions are still served like FindPackage, so that the existing lock files keep working, but failed ones are not.
+// The packages missing in the registry are listed with the "zh:" hash only, and fetched from the upstream on download.
+// https://developer.hashicorp.com/terraform/internals/provider-network-mirror-protocol#list-available-installation-packages
+func (p *Provider) MirrorVersion() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		hostname := mux.Vars(r)["hostname"]
+		namespace := mux.Vars(r)["namespace"]
+		registryName := mux.Vars(r)["type"]
+		version := mux.Vars(r)["version"]
+
+		if err := p.policy.Authorize(r.Context(), namespace, policy.ScopeRead); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		platforms, err := p.platforms(r.Context(), namespace, registryName, version)
+		if err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		upstream := p.proxy.Upstream(hostname, namespace)
+		if upstream != nil {
+			upstreamPlatforms, err := p.fetchVersion(r.Context(), upstream, namespace, registryName, version, len(platforms) > 0)
+			if err != nil {
+				return err
+			}
+
+			if len(upstreamPlatforms) > 0 {
+				platforms = upstreamPlatforms
+			}
+		}
+
+		metadata, err := p.driver.Provider.GetVersionMetadata(r.Context(), namespace, registryName, version)
+		if err != nil && !errors.Is(err, driver.ErrProviderVersionNotExist) {
+			return kerrors.Wrap(err)
+		}
+
+		if metadata != nil && metadata.Draft() {
+			return kerrors.Wrap(ErrVersionDraft, kerrors.WithNotFound())
+		}
+
+		if len(platforms) == 0 {
+			return kerrors.Wrap(driver.ErrProviderVersionNotExist, kerrors.WithNotFound())
+		}
+
+		mv := &model.MirrorVersion{
+			Archives: map[string]model.MirrorArchive{},
+		}
+		var sums artifact.SHASums
+		for _, platform := range platforms {
+			pkg, err := p.findPackage(r.Context(), namespace, registryName, version, platform.OS, platform.Arch)
+			if err != nil {
+				if errors.Is(err, ErrVersionFailed) {
+					return kerrors.Wrap(err, kerrors.WithNotFound())
+				}
+
+				if !errors.Is(err, driver.ErrProviderBinaryNotExist) {
+					return kerrors.Wrap(err)
+				}
+
+				if upstream == nil {
+					continue
+				}
+
+				if sums == nil {
+					if sums, err = p.parseSHASums(r.Context(), namespace, registryName, version); err != nil {
+						return kerrors.Wrap(err)
+					}
+				}
+
+				// Relative to the URL of this response
+				archive := model.MirrorArchive{
+					URL: fmt.Sprintf("%s/download/%s/%s", version, platform.OS, platform.Arch),
+				}
+				if sum, ok := sums[binaryFilename(registryName, version, platform.OS, platform.Arch)]; ok {
+					archive.Hashes = []string{artifact.HashZh(sum)}
+				}
+
+				mv.Archives[fmt.Sprintf("%s_%s", platform.OS, platform.Arch)] = archive
+				continue
+			}
+
+			platformMetadata, err := p.platformMetadata(r.Context(), namespace, registryName, version, platform.OS, platform.Arch)
+			if err != nil {
+				return kerrors.Wrap(err)
+			}
+
+			archive := model.MirrorArchive{
+				URL: pkg.DownloadURL,
+			}
+			if platformMetadata.HashV1 != "" {
+				archive.Hashes = append(archive.Hashes, platformMetadata.HashV1)
+			}
+			archive.Hashes = append(archive.Hashes, artifact.HashZh(pkg.SHASum))
+
+			mv.Archives[fmt.Sprintf("%s_%s", platform.OS, platform.Arch)] = archive
+		}
+
+		return json.NewEncoder(w).Encode(mv)
+	})
+}
+
+// MirrorDownload redirects to the package of the platform, which is fetched from the upstream if missing in the registry
+func (p *Provider) MirrorDownload() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		hostname := mux.Vars(r)["hostname"]
+		namespace := mux.Vars(r)["namespace"]
+		registryName := mux.Vars(r)["type"]
+		version := mux.Vars(r)["version"]
+		os := mux.Vars(r)["os"]
+		arch := mux.Vars(r)["arch"]
+
+		if err := p.policy.Authorize(r.Context(), namespace, policy.ScopeRead); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		if err := p.fetchPackage(r.Context(), hostname, namespace, registryName, version, os, arch); err != nil {
+			return err
+		}
+
+		metadata, err := p.driver.Provider.GetVersionMetadata(r.Context(), namespace, registryName, version)
+		if err != nil && !errors.Is(err, driver.ErrProviderVersionNotExist) {
+			return kerrors.Wrap(err)
+		}
+
+		if metadata != nil && metadata.Draft() {
+			return kerrors.Wrap(ErrVersionDraft, kerrors.WithNotFound())
+		}
+
+		pkg, err := p.findPackage(r.Context(), namespace, registryName, version, os, arch)
+		if err != nil {
+			if errors.Is(err, driver.ErrProviderBinaryNotExist) || errors.Is(err, ErrVersionFailed) {
+				return kerrors.Wrap(err, kerrors.WithNotFound())
+			}
+
+			return kerrors.Wrap(err)
+		}
+
+		http.Redirect(w, r, pkg.DownloadURL, http.StatusFound)
+		return nil
+	})
+}
+
+func (p *Provider) parseSHASums(ctx context.Context, namespace, registryName, version string) (artifact.SHASums, error) {
+	sums, err := p.readSHASums(ctx, namespace, registryName, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return artifact.ParseSHASums(bytes.NewReader(sums))
+}
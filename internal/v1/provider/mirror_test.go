@@ -0,0 +1,134 @@
+package provider
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/kerraform/kegistry/internal/artifact"
+	"github.com/kerraform/kegistry/internal/driver"
+	model "github.com/kerraform/kegistry/internal/model/provider"
+)
+
+func mirrorVars(version string) map[string]string {
+	return map[string]string{
+		"hostname":  "registry.terraform.io",
+		"namespace": testNamespace,
+		"type":      testName,
+		"version":   version,
+	}
+}
+
+func TestMirrorIndex(t *testing.T) {
+	p, d := newTestProvider(t)
+	uploadTestVersion(t, p, d, "1.0.0", "amd64")
+	createTestVersion(t, d, "1.1.0", &driver.ProviderVersionMetadata{
+		State:        driver.VersionStateDraft,
+		Verification: driver.VerificationStatePending,
+	})
+
+	w := serve(p.MirrorIndex(), httptest.NewRequest(http.MethodGet, "/", nil), mirrorVars(""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var index model.MirrorIndex
+	if err := json.NewDecoder(w.Body).Decode(&index); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := map[string]model.MirrorIndexVersion{"1.0.0": {}}; !reflect.DeepEqual(index.Versions, want) {
+		t.Errorf("versions = %v, want %v", index.Versions, want)
+	}
+
+	vars := mirrorVars("")
+	vars["type"] = "bar"
+	if w := serve(p.MirrorIndex(), httptest.NewRequest(http.MethodGet, "/", nil), vars); w.Code != http.StatusNotFound {
+		t.Errorf("status of missing provider = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestMirrorVersion(t *testing.T) {
+	p, d := newTestProvider(t)
+	uploadTestVersion(t, p, d, "1.0.0", "amd64", "arm64")
+	createTestVersion(t, d, "1.1.0", &driver.ProviderVersionMetadata{
+		State:        driver.VersionStateDraft,
+		Verification: driver.VerificationStatePending,
+	})
+	uploadTestVersion(t, p, d, "1.2.0", "amd64")
+	failTestVersion(t, d, "1.2.0")
+
+	w := serve(p.MirrorVersion(), httptest.NewRequest(http.MethodGet, "/", nil), mirrorVars("1.0.0"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var mv model.MirrorVersion
+	if err := json.NewDecoder(w.Body).Decode(&mv); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mv.Archives) != 2 {
+		t.Fatalf("archives = %v, want linux_amd64 and linux_arm64", mv.Archives)
+	}
+
+	for _, arch := range []string{"amd64", "arm64"} {
+		binary := newTestBinary(t, "1.0.0", arch)
+		f, err := artifact.Spool(bytes.NewReader(binary), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		h1, err := f.HashV1()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sum := sha256.Sum256(binary)
+		archive := mv.Archives["linux_"+arch]
+		if want := []string{h1, artifact.HashZh(hex.EncodeToString(sum[:]))}; !reflect.DeepEqual(archive.Hashes, want) {
+			t.Errorf("hashes of linux_%s = %v, want %v", arch, archive.Hashes, want)
+		}
+
+		if archive.URL == "" {
+			t.Errorf("url of linux_%s is empty", arch)
+		}
+	}
+
+	for _, version := range []string{"1.1.0", "1.2.0", "2.0.0"} {
+		w := serve(p.MirrorVersion(), httptest.NewRequest(http.MethodGet, "/", nil), mirrorVars(version))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("status of %s = %d, want %d", version, w.Code, http.StatusNotFound)
+		}
+	}
+}
+
+func TestMirrorDownload(t *testing.T) {
+	p, d := newTestProvider(t)
+	uploadTestVersion(t, p, d, "1.0.0", "amd64")
+
+	vars := mirrorVars("1.0.0")
+	vars["os"] = "linux"
+	vars["arch"] = "amd64"
+	w := serve(p.MirrorDownload(), httptest.NewRequest(http.MethodGet, "/", nil), vars)
+	if w.Code != http.StatusFound || w.Header().Get("Location") == "" {
+		t.Fatalf("status = %d, want %d with the location: %s", w.Code, http.StatusFound, w.Body.String())
+	}
+
+	vars["arch"] = "arm64"
+	if w := serve(p.MirrorDownload(), httptest.NewRequest(http.MethodGet, "/", nil), vars); w.Code != http.StatusNotFound {
+		t.Errorf("status of missing platform = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	failTestVersion(t, d, "1.0.0")
+	vars["arch"] = "amd64"
+	if w := serve(p.MirrorDownload(), httptest.NewRequest(http.MethodGet, "/", nil), vars); w.Code != http.StatusNotFound {
+		t.Errorf("status of failed version = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -1,16 +1,24 @@
 package provider
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"sync"
 
 	"github.com/gorilla/mux"
+	"github.com/kerraform/kegistry/internal/artifact"
+	"github.com/kerraform/kegistry/internal/audit"
 	"github.com/kerraform/kegistry/internal/driver"
 	kerrors "github.com/kerraform/kegistry/internal/errors"
 	"github.com/kerraform/kegistry/internal/handler"
 	"github.com/kerraform/kegistry/internal/logging"
+	"github.com/kerraform/kegistry/internal/policy"
+	"github.com/kerraform/kegistry/internal/proxy"
+	"github.com/kerraform/kegistry/internal/signing"
 	"github.com/kerraform/kegistry/internal/validator"
 	"go.uber.org/zap"
 )
@@ -23,29 +31,49 @@ const (
 )
 
 type Provider struct {
+	audit  *audit.Recorder
 	driver *driver.Driver
+	limits *artifact.Limits
 	logger *zap.Logger
+	policy *policy.Policy
+	proxy  *proxy.Proxy
+	signer *signing.Signer
+
+	mu       sync.Mutex
+	versions map[string]*versionLock
 }
 
 type Config struct {
+	Audit  *audit.Recorder
 	Driver *driver.Driver
+	Limits *artifact.Limits
 	Logger *zap.Logger
+	Policy *policy.Policy
+	Proxy  *proxy.Proxy
+	Signer *signing.Signer
 }
 
 func New(cfg *Config) *Provider {
 	return &Provider{
+		audit:  cfg.Audit,
 		driver: cfg.Driver,
+		limits: cfg.Limits,
 		logger: cfg.Logger,
+		policy: cfg.Policy,
+		proxy:  cfg.Proxy,
+		signer: cfg.Signer,
+
+		versions: map[string]*versionLock{},
 	}
 }
 
 // https://www.terraform.io/cloud-docs/api-docs/private-registry/providers#request-body
 type CreateProviderRequestDataAttributes struct {
 	// Name of the provider (e.g. aws)
-	Name string `json:"name" validate:"required"`
+	Name string `json:"name" validate:"required,segment"`
 
 	// Name of the namespace (a.k.a. organization)
-	Namespace string `json:"namespace" validate:"required"`
+	Namespace string `json:"namespace" validate:"required,segment"`
 }
 
 func (p *Provider) CreateProvider() http.Handler {
@@ -69,18 +97,27 @@ func (p *Provider) CreateProvider() http.Handler {
 			)
 		}
 
+		if err := p.policy.Authorize(r.Context(), req.Data.Attributes.Namespace, policy.ScopePublish); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
 		if err := p.driver.Provider.CreateProvider(r.Context(), req.Data.Attributes.Namespace, req.Data.Attributes.Name); err != nil {
 			return kerrors.Wrap(err)
 		}
 
+		p.audit.Record(r.Context(), audit.ActionCreate, audit.ResourceTypeProvider, audit.Resource{
+			Namespace: req.Data.Attributes.Namespace,
+			Name:      req.Data.Attributes.Name,
+		}, nil)
+
 		l.Info("provisioned provider path", zap.String("name", req.Data.Attributes.Name), zap.String("namespace", req.Data.Attributes.Namespace))
 		return nil
 	})
 }
 
 type CreateProviderPlatformRequestDataAttributes struct {
-	OS   string `json:"os" validate:"required"`
-	Arch string `json:"arch" validate:"required"`
+	OS   string `json:"os" validate:"required,segment"`
+	Arch string `json:"arch" validate:"required,segment"`
 }
 
 type CreateProviderPlatformResponseData struct {
@@ -90,6 +127,9 @@ type CreateProviderPlatformResponseData struct {
 
 type CreateProviderPlatformResponseDataLink struct {
 	ProviderBinaryUploads string `json:"provider-binary-upload"`
+
+	// ProviderBinaryUploadHeaders are the headers to be sent with the upload to the presigned URL
+	ProviderBinaryUploadHeaders map[string]string `json:"provider-binary-upload-headers,omitempty"`
 }
 
 func (p *Provider) CreateProviderPlatform() http.Handler {
@@ -98,6 +138,10 @@ func (p *Provider) CreateProviderPlatform() http.Handler {
 		registryName := mux.Vars(r)["registryName"]
 		version := mux.Vars(r)["version"]
 
+		if err := p.policy.Authorize(r.Context(), namespace, policy.ScopePublish); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
 		l, err := logging.FromCtx(r.Context())
 		if err != nil {
 			return kerrors.Wrap(err)
@@ -131,17 +175,48 @@ func (p *Provider) CreateProviderPlatform() http.Handler {
 			return kerrors.Wrap(err)
 		}
 
-		result, err := p.driver.Provider.CreateProviderPlatform(r.Context(), namespace, registryName, version, req.Data.Attributes.OS, req.Data.Attributes.Arch)
+		existing, err := p.driver.Provider.GetPlatformBinary(r.Context(), namespace, registryName, version, req.Data.Attributes.OS, req.Data.Attributes.Arch)
+		exists, overwrite, err := p.presignOverwrite(r, namespace, existing, err)
+		if err != nil {
+			return err
+		}
+
+		result, err := p.driver.Provider.CreateProviderPlatform(r.Context(), namespace, registryName, version, req.Data.Attributes.OS, req.Data.Attributes.Arch, overwrite)
 		if err != nil {
 			return err
 		}
 		defer r.Body.Close()
 
+		if result.Presigned {
+			if err := p.authorizePresign(r, audit.ResourceTypeProviderPlatform, audit.Resource{
+				Namespace: namespace,
+				Name:      registryName,
+				Version:   version,
+				OS:        req.Data.Attributes.OS,
+				Arch:      req.Data.Attributes.Arch,
+			}, exists, overwrite); err != nil {
+				return err
+			}
+		}
+
+		if err := p.declarePlatform(r.Context(), namespace, registryName, version, req.Data.Attributes.OS, req.Data.Attributes.Arch, result.Presigned); err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		p.audit.Record(r.Context(), audit.ActionCreate, audit.ResourceTypeProviderPlatform, audit.Resource{
+			Namespace: namespace,
+			Name:      registryName,
+			Version:   version,
+			OS:        req.Data.Attributes.OS,
+			Arch:      req.Data.Attributes.Arch,
+		}, nil)
+
 		resp := &CreateProviderPlatformResponse{
 			Data: &CreateProviderPlatformResponseData{
 				Type: DataTypeRegistryProviderPlatforms,
 				Links: &CreateProviderPlatformResponseDataLink{
-					ProviderBinaryUploads: result.ProviderBinaryUploads,
+					ProviderBinaryUploads:       result.ProviderBinaryUploads,
+					ProviderBinaryUploadHeaders: result.UploadHeaders,
 				},
 			},
 		}
@@ -155,8 +230,11 @@ type CreateProviderVersionRequestDataAttributes struct {
 	// Version of the provider in semver (e.g. v2.0.1)
 	Version string `json:"version" validate:"required,semver"`
 
-	// Valid gpg-key string
-	KeyID string `json:"key-id" validate:"required"`
+	// Valid gpg-key string, which can be omitted to let the registry sign the version if enabled
+	KeyID string `json:"key-id"`
+
+	// Plugin protocol versions supported by the version (e.g. 5.0), which can be uploaded later as terraform-registry-manifest.json
+	Protocols []string `json:"protocols"`
 }
 
 type CreateProviderVersionResponseData struct {
@@ -167,6 +245,9 @@ type CreateProviderVersionResponseData struct {
 type CreateProviderVersionResponseDataLink struct {
 	SHASumsUpload    string `json:"shasums-upload"`
 	SHASumsSigUpload string `json:"shasums-sig-upload"`
+
+	// UploadHeaders are the headers to be sent with both of the uploads to the presigned URLs
+	UploadHeaders map[string]string `json:"upload-headers,omitempty"`
 }
 
 func (p *Provider) CreateProviderVersion() http.Handler {
@@ -174,6 +255,10 @@ func (p *Provider) CreateProviderVersion() http.Handler {
 		namespace := mux.Vars(r)["namespace"]
 		registryName := mux.Vars(r)["registryName"]
 
+		if err := p.policy.Authorize(r.Context(), namespace, policy.ScopePublish); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
 		l, err := logging.FromCtx(r.Context())
 		if err != nil {
 			return kerrors.Wrap(err)
@@ -197,23 +282,143 @@ func (p *Provider) CreateProviderVersion() http.Handler {
 			return kerrors.Wrap(err)
 		}
 
-		result, err := p.driver.Provider.CreateProviderVersion(r.Context(), namespace, registryName, req.Data.Attributes.Version)
+		protocols := req.Data.Attributes.Protocols
+		if len(protocols) > 0 {
+			if err := artifact.ValidateProtocols(protocols); err != nil {
+				return kerrors.Wrap(err, kerrors.WithBadRequest(), kerrors.WithDetail(err.Error()))
+			}
+		}
+
+		keyID := req.Data.Attributes.KeyID
+		registrySigned := false
+		if keyID == "" {
+			if p.signer == nil {
+				return kerrors.Wrap(ErrKeyIDRequired, kerrors.WithBadRequest(), kerrors.WithDetail(ErrKeyIDRequired.Error()))
+			}
+
+			keyID, err = p.signer.KeyID(r.Context(), namespace)
+			if err != nil {
+				return kerrors.Wrap(err)
+			}
+			registrySigned = true
+		}
+
+		// The key is fixed at the creation, so that the key rotation does not change the key of the existing versions
+		key, err := p.driver.Provider.GetGPGKey(r.Context(), namespace, keyID)
 		if err != nil {
-			l.Error("failed to create provider version")
+			if errors.Is(err, driver.ErrProviderGPGKeyNotExist) {
+				return kerrors.Wrap(err, kerrors.WithBadRequest(), kerrors.WithDetail(fmt.Sprintf("gpg key %s not found", keyID)))
+			}
+
 			return kerrors.Wrap(err)
 		}
 
-		if err := p.driver.Provider.SaveVersionMetadata(r.Context(), namespace, registryName, req.Data.Attributes.Version, req.Data.Attributes.KeyID); err != nil {
-			l.Error("failed to save provider version metadata")
+		if key.Revoked() {
+			err := fmt.Errorf("gpg key %s is revoked", key.KeyID)
+			return kerrors.Wrap(err, kerrors.WithBadRequest(), kerrors.WithDetail(err.Error()))
+		}
+
+		unlock := p.lockVersion(namespace, registryName, req.Data.Attributes.Version)
+		defer unlock()
+
+		// Creating the version again is a no-op, but changing its key or protocols requires overwriting it
+		action := audit.ActionCreate
+		metadata, err := p.driver.Provider.GetVersionMetadata(r.Context(), namespace, registryName, req.Data.Attributes.Version)
+		if err != nil && !errors.Is(err, driver.ErrProviderVersionNotExist) {
 			return kerrors.Wrap(err)
 		}
 
+		if metadata != nil {
+			action = ""
+			protocolsChanged := len(protocols) > 0 && len(metadata.Protocols) > 0 && !equalProtocols(metadata.Protocols, protocols)
+			if metadata.KeyID != keyID || metadata.RegistrySigned != registrySigned || protocolsChanged {
+				if err := p.authorizeOverwrite(r, namespace); err != nil {
+					return err
+				}
+				action = audit.ActionOverwrite
+			} else if len(protocols) > 0 && len(metadata.Protocols) == 0 {
+				action = audit.ActionUpdate
+			}
+		}
+
+		existing, err := p.driver.Provider.GetSHASums(r.Context(), namespace, registryName, req.Data.Attributes.Version)
+		shasumsExists, shasumsOverwrite, err := p.presignOverwrite(r, namespace, existing, err)
+		if err != nil {
+			return err
+		}
+
+		existing, err = p.driver.Provider.GetSHASumsSig(r.Context(), namespace, registryName, req.Data.Attributes.Version)
+		sigExists, sigOverwrite, err := p.presignOverwrite(r, namespace, existing, err)
+		if err != nil {
+			return err
+		}
+
+		result, err := p.driver.Provider.CreateProviderVersion(r.Context(), namespace, registryName, req.Data.Attributes.Version, shasumsOverwrite || sigOverwrite)
+		if err != nil {
+			l.Error("failed to create provider version")
+			return kerrors.Wrap(err)
+		}
+
+		if result.Presigned {
+			if err := p.authorizePresign(r, audit.ResourceTypeProviderSHASums, audit.Resource{
+				Namespace: namespace,
+				Name:      registryName,
+				Version:   req.Data.Attributes.Version,
+			}, shasumsExists, shasumsOverwrite); err != nil {
+				return err
+			}
+
+			if err := p.authorizePresign(r, audit.ResourceTypeProviderSHASumsSignature, audit.Resource{
+				Namespace: namespace,
+				Name:      registryName,
+				Version:   req.Data.Attributes.Version,
+			}, sigExists, sigOverwrite); err != nil {
+				return err
+			}
+		}
+
+		if action != "" {
+			// Adding the protocols keeps the state of the version
+			newMetadata := metadata
+			if action != audit.ActionUpdate {
+				// The version is a draft until published
+				newMetadata = &driver.ProviderVersionMetadata{
+					KeyID:          keyID,
+					RegistrySigned: registrySigned,
+					State:          driver.VersionStateDraft,
+					Verification:   driver.VerificationStatePending,
+				}
+
+				if metadata != nil {
+					newMetadata.Platforms = metadata.Platforms
+					newMetadata.Protocols = metadata.Protocols
+				}
+			}
+
+			if len(protocols) > 0 {
+				newMetadata.Protocols = protocols
+			}
+
+			if err := p.driver.Provider.SaveVersionMetadata(r.Context(), namespace, registryName, req.Data.Attributes.Version, newMetadata); err != nil {
+				l.Error("failed to save provider version metadata")
+				return kerrors.Wrap(err)
+			}
+
+			p.audit.Record(r.Context(), action, audit.ResourceTypeProviderVersion, audit.Resource{
+				Namespace: namespace,
+				Name:      registryName,
+				Version:   req.Data.Attributes.Version,
+				KeyID:     keyID,
+			}, nil)
+		}
+
 		resp := &CreateProviderVersionResponse{
 			Data: &CreateProviderVersionResponseData{
 				Type: DataTypeRegistryProviderVersions,
 				Links: &CreateProviderVersionResponseDataLink{
 					SHASumsUpload:    result.SHASumsUpload,
 					SHASumsSigUpload: result.SHASumsSigUpload,
+					UploadHeaders:    result.UploadHeaders,
 				},
 			},
 		}
@@ -230,6 +435,10 @@ func (p *Provider) DownloadPlatformBinary() http.Handler {
 		os := mux.Vars(r)["os"]
 		arch := mux.Vars(r)["arch"]
 
+		if err := p.policy.Authorize(r.Context(), namespace, policy.ScopeRead); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
 		f, err := p.driver.Provider.GetPlatformBinary(r.Context(), namespace, registryName, version, os, arch)
 		if err != nil {
 			if errors.Is(err, driver.ErrProviderNotExist) ||
@@ -257,6 +466,10 @@ func (p *Provider) DownloadSHASums() http.Handler {
 		registryName := mux.Vars(r)["registryName"]
 		version := mux.Vars(r)["version"]
 
+		if err := p.policy.Authorize(r.Context(), namespace, policy.ScopeRead); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
 		f, err := p.driver.Provider.GetSHASums(r.Context(), namespace, registryName, version)
 		if err != nil {
 			return err
@@ -278,6 +491,10 @@ func (p *Provider) DownloadSHASumsSignature() http.Handler {
 		registryName := mux.Vars(r)["registryName"]
 		version := mux.Vars(r)["version"]
 
+		if err := p.policy.Authorize(r.Context(), namespace, policy.ScopeRead); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
 		f, err := p.driver.Provider.GetSHASumsSig(r.Context(), namespace, registryName, version)
 		if err != nil {
 			return err
@@ -301,11 +518,19 @@ func (p *Provider) FindPackage() http.Handler {
 		os := mux.Vars(r)["os"]
 		arch := mux.Vars(r)["arch"]
 
+		if err := p.policy.Authorize(r.Context(), namespace, policy.ScopeRead); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
 		l, err := logging.FromCtx(r.Context())
 		if err != nil {
 			return kerrors.Wrap(err)
 		}
 
+		if err := p.fetchPackage(r.Context(), "", namespace, registryName, version, os, arch); err != nil {
+			return err
+		}
+
 		if err := p.driver.Provider.IsProviderCreated(r.Context(), namespace, registryName); err != nil {
 			if errors.Is(err, driver.ErrProviderNotExist) {
 				return kerrors.Wrap(err, kerrors.WithNotFound())
@@ -315,7 +540,7 @@ func (p *Provider) FindPackage() http.Handler {
 		}
 
 		if err := p.driver.Provider.IsProviderVersionCreated(r.Context(), namespace, registryName, version); err != nil {
-			if errors.Is(err, driver.ErrProviderNotExist) {
+			if errors.Is(err, driver.ErrProviderVersionNotExist) {
 				l.Error("not provider version found")
 				w.WriteHeader(http.StatusBadRequest)
 				return err
@@ -324,15 +549,29 @@ func (p *Provider) FindPackage() http.Handler {
 			return kerrors.Wrap(err)
 		}
 
-		pkg, err := p.driver.Provider.FindPackage(r.Context(), namespace, registryName, version, os, arch)
+		// Drafts cannot be installed until published
+		metadata, err := p.driver.Provider.GetVersionMetadata(r.Context(), namespace, registryName, version)
+		if err != nil && !errors.Is(err, driver.ErrProviderVersionNotExist) {
+			return kerrors.Wrap(err)
+		}
+
+		if metadata != nil && metadata.Draft() {
+			return kerrors.Wrap(ErrVersionDraft, kerrors.WithNotFound())
+		}
+
+		pkg, err := p.findPackage(r.Context(), namespace, registryName, version, os, arch)
 		if err != nil {
-			if errors.Is(err, driver.ErrProviderBinaryNotExist) {
+			if errors.Is(err, driver.ErrProviderBinaryNotExist) || errors.Is(err, ErrVersionFailed) {
 				return kerrors.Wrap(err, kerrors.WithNotFound())
 			}
 
 			return kerrors.Wrap(err)
 		}
 
+		if metadata != nil {
+			pkg.Protocols = metadata.Protocols
+		}
+
 		return json.NewEncoder(w).Encode(pkg)
 	})
 }
@@ -342,7 +581,11 @@ func (p *Provider) ListAvailableVersions() http.Handler {
 		namespace := mux.Vars(r)["namespace"]
 		registryName := mux.Vars(r)["registryName"]
 
-		versions, err := p.driver.Provider.ListAvailableVersions(r.Context(), namespace, registryName)
+		if err := p.policy.Authorize(r.Context(), namespace, policy.ScopeRead); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		versions, err := p.availableVersions(r.Context(), "", namespace, registryName)
 		if err != nil {
 			return err
 		}
@@ -363,6 +606,10 @@ func (p *Provider) UploadPlatformBinary() http.Handler {
 		os := mux.Vars(r)["os"]
 		arch := mux.Vars(r)["arch"]
 
+		if err := p.policy.Authorize(r.Context(), namespace, policy.ScopePublish); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
 		l, err := logging.FromCtx(r.Context())
 		if err != nil {
 			return kerrors.Wrap(err)
@@ -395,10 +642,75 @@ func (p *Provider) UploadPlatformBinary() http.Handler {
 			return kerrors.Wrap(err)
 		}
 
-		if err := p.driver.Provider.SavePlatformBinary(r.Context(), namespace, registryName, version, os, arch, r.Body); err != nil {
+		f, err := spool(r, p.limits.ProviderBinary)
+		if err != nil {
 			return err
 		}
+		defer f.Close()
 		defer r.Body.Close()
+
+		if err := artifact.ValidateProviderBinary(f, f.Size(), registryName, version); err != nil {
+			return kerrors.Wrap(err, kerrors.WithBadRequest(), kerrors.WithDetail(err.Error()))
+		}
+
+		existing, err := p.driver.Provider.GetPlatformBinary(r.Context(), namespace, registryName, version, os, arch)
+		action, save, err := p.uploadAction(r, namespace, f, existing, err)
+		if err != nil {
+			return err
+		}
+
+		if !save {
+			l.Info("skip saving identical platform binary")
+			return nil
+		}
+
+		platformMetadata, err := p.digestPlatform(f)
+		if err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		registrySigned, err := p.isRegistrySigned(r.Context(), namespace, registryName, version)
+		if err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		// SHA256SUMS signed by the registry is generated again with this binary
+		if !registrySigned {
+			if err := p.checkBinary(r.Context(), namespace, registryName, version, os, arch, platformMetadata.SHA256); err != nil {
+				return wrapVerificationError(err)
+			}
+		}
+
+		if err := f.Rewind(); err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		body := audit.NewDigestReader(f)
+		if err := p.driver.Provider.SavePlatformBinary(r.Context(), namespace, registryName, version, os, arch, body, action == audit.ActionOverwrite); err != nil {
+			return wrapSaveError(err)
+		}
+
+		if err := p.driver.Provider.SavePlatformMetadata(r.Context(), namespace, registryName, version, os, arch, platformMetadata); err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		p.audit.Record(r.Context(), action, audit.ResourceTypeProviderPlatform, audit.Resource{
+			Namespace: namespace,
+			Name:      registryName,
+			Version:   version,
+			OS:        os,
+			Arch:      arch,
+		}, body.Digests())
+
+		if registrySigned {
+			if err := p.signVersion(r.Context(), namespace, registryName, version); err != nil {
+				return kerrors.Wrap(err)
+			}
+		}
+
+		if err := p.reverifyVersion(r.Context(), namespace, registryName, version); err != nil {
+			return kerrors.Wrap(err)
+		}
 		return nil
 	})
 }
@@ -409,6 +721,10 @@ func (p *Provider) UploadSHASums() http.Handler {
 		registryName := mux.Vars(r)["registryName"]
 		version := mux.Vars(r)["version"]
 
+		if err := p.policy.Authorize(r.Context(), namespace, policy.ScopePublish); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
 		l, err := logging.FromCtx(r.Context())
 		if err != nil {
 			return kerrors.Wrap(err)
@@ -423,10 +739,65 @@ func (p *Provider) UploadSHASums() http.Handler {
 			return kerrors.Wrap(err)
 		}
 
-		if err := p.driver.Provider.SaveSHASUMs(r.Context(), namespace, registryName, version, r.Body); err != nil {
+		registrySigned, err := p.isRegistrySigned(r.Context(), namespace, registryName, version)
+		if err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		if registrySigned {
+			return kerrors.Wrap(ErrRegistrySigned, kerrors.WithConflict(), kerrors.WithDetail(ErrRegistrySigned.Error()))
+		}
+
+		f, err := spool(r, p.limits.SHASums)
+		if err != nil {
 			return err
 		}
+		defer f.Close()
 		defer r.Body.Close()
+
+		existing, err := p.driver.Provider.GetSHASums(r.Context(), namespace, registryName, version)
+		action, save, err := p.uploadAction(r, namespace, f, existing, err)
+		if err != nil {
+			return err
+		}
+
+		if !save {
+			l.Info("skip saving identical shasums")
+			return nil
+		}
+
+		sums, err := f.ReadAll()
+		if err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		parsed, err := artifact.ParseSHASums(bytes.NewReader(sums))
+		if err != nil {
+			return wrapVerificationError(err)
+		}
+
+		if err := p.checkSHASums(r.Context(), namespace, registryName, version, sums, parsed); err != nil {
+			return wrapVerificationError(err)
+		}
+
+		if err := f.Rewind(); err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		body := audit.NewDigestReader(f)
+		if err := p.driver.Provider.SaveSHASUMs(r.Context(), namespace, registryName, version, body, action == audit.ActionOverwrite); err != nil {
+			return wrapSaveError(err)
+		}
+
+		p.audit.Record(r.Context(), action, audit.ResourceTypeProviderSHASums, audit.Resource{
+			Namespace: namespace,
+			Name:      registryName,
+			Version:   version,
+		}, body.Digests())
+
+		if err := p.reverifyVersion(r.Context(), namespace, registryName, version); err != nil {
+			return kerrors.Wrap(err)
+		}
 		return nil
 	})
 }
@@ -437,6 +808,10 @@ func (p *Provider) UploadSHASumsSignature() http.Handler {
 		registryName := mux.Vars(r)["registryName"]
 		version := mux.Vars(r)["version"]
 
+		if err := p.policy.Authorize(r.Context(), namespace, policy.ScopePublish); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
 		l, err := logging.FromCtx(r.Context())
 		if err != nil {
 			return kerrors.Wrap(err)
@@ -451,10 +826,74 @@ func (p *Provider) UploadSHASumsSignature() http.Handler {
 			return kerrors.Wrap(err)
 		}
 
-		if err := p.driver.Provider.SaveSHASUMsSig(r.Context(), namespace, registryName, version, r.Body); err != nil {
+		registrySigned, err := p.isRegistrySigned(r.Context(), namespace, registryName, version)
+		if err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		if registrySigned {
+			return kerrors.Wrap(ErrRegistrySigned, kerrors.WithConflict(), kerrors.WithDetail(ErrRegistrySigned.Error()))
+		}
+
+		f, err := spool(r, p.limits.SHASums)
+		if err != nil {
 			return err
 		}
+		defer f.Close()
 		defer r.Body.Close()
+
+		existing, err := p.driver.Provider.GetSHASumsSig(r.Context(), namespace, registryName, version)
+		action, save, err := p.uploadAction(r, namespace, f, existing, err)
+		if err != nil {
+			return err
+		}
+
+		if !save {
+			l.Info("skip saving identical shasums signature")
+			return nil
+		}
+
+		sig, err := f.ReadAll()
+		if err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		if err := p.checkSHASumsSig(r.Context(), namespace, registryName, version, sig); err != nil {
+			return wrapVerificationError(err)
+		}
+
+		if err := f.Rewind(); err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		body := audit.NewDigestReader(f)
+		if err := p.driver.Provider.SaveSHASUMsSig(r.Context(), namespace, registryName, version, body, action == audit.ActionOverwrite); err != nil {
+			return wrapSaveError(err)
+		}
+
+		p.audit.Record(r.Context(), action, audit.ResourceTypeProviderSHASumsSignature, audit.Resource{
+			Namespace: namespace,
+			Name:      registryName,
+			Version:   version,
+		}, body.Digests())
+
+		if err := p.reverifyVersion(r.Context(), namespace, registryName, version); err != nil {
+			return kerrors.Wrap(err)
+		}
 		return nil
 	})
 }
+
+// spool spools the uploaded body to the temporary file within the limit
+func spool(r *http.Request, limit int64) (*artifact.File, error) {
+	f, err := artifact.Spool(r.Body, limit)
+	if err != nil {
+		if errors.Is(err, artifact.ErrTooLarge) {
+			return nil, kerrors.Wrap(err, kerrors.WithRequestEntityTooLarge(), kerrors.WithDetail(err.Error()))
+		}
+
+		return nil, kerrors.Wrap(err)
+	}
+
+	return f, nil
+}
@@ -0,0 +1,346 @@
+package provider
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/gorilla/mux"
+	"github.com/kerraform/kegistry/internal/artifact"
+	"github.com/kerraform/kegistry/internal/auth"
+	"github.com/kerraform/kegistry/internal/driver"
+	"github.com/kerraform/kegistry/internal/driver/memory"
+	"github.com/kerraform/kegistry/internal/logging"
+	"github.com/kerraform/kegistry/internal/policy"
+	"github.com/kerraform/kegistry/internal/v1/request"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	testNamespace = "acme"
+	testName      = "foo"
+)
+
+var (
+	testEntityOnce sync.Once
+	testEntity     *openpgp.Entity
+	testEntityErr  error
+)
+
+func newTestProvider(t *testing.T) (*Provider, *driver.Driver) {
+	t.Helper()
+	d := memory.NewDriver(&memory.DriverConfig{
+		Logger: zap.NewNop(),
+		Tracer: trace.NewNoopTracerProvider().Tracer(""),
+	})
+
+	return New(&Config{
+		Driver: d,
+		Limits: &artifact.Limits{},
+		Logger: zap.NewNop(),
+	}), d
+}
+
+// testPolicy allows token:ci to publish and token:admin to administer the test namespace
+func testPolicy() *policy.Policy {
+	return &policy.Policy{
+		Rules: []policy.Rule{
+			{Subjects: []string{"token:ci"}, Namespaces: []string{testNamespace}, Scope: policy.ScopePublish},
+			{Subjects: []string{"token:admin"}, Namespaces: []string{testNamespace}, Scope: policy.ScopeAdmin},
+		},
+	}
+}
+
+func withCaller(r *http.Request, subject string) *http.Request {
+	return r.WithContext(auth.WithIdentity(r.Context(), &auth.Identity{Subject: subject}))
+}
+
+// serve calls the handler with the logger and the path variables which the router sets
+func serve(h http.Handler, r *http.Request, vars map[string]string) *httptest.ResponseRecorder {
+	r = r.WithContext(context.WithValue(r.Context(), logging.Key, zap.NewNop()))
+	r = mux.SetURLVars(r, vars)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func jsonBody(t *testing.T, v interface{}) io.Reader {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return bytes.NewReader(b)
+}
+
+func versionVars(version string) map[string]string {
+	return map[string]string{
+		"namespace":    testNamespace,
+		"registryName": testName,
+		"version":      version,
+	}
+}
+
+func platformVars(version, os, arch string) map[string]string {
+	vars := versionVars(version)
+	vars["os"] = os
+	vars["arch"] = arch
+	return vars
+}
+
+// testKey returns the key to sign the test versions, which is generated once as it is slow.
+// The key is created an hour ago, so that the signatures can be created at the different times.
+func testKey(t *testing.T) *openpgp.Entity {
+	t.Helper()
+	testEntityOnce.Do(func() {
+		testEntity, testEntityErr = openpgp.NewEntity("test", "", "test@example.com", &packet.Config{
+			Time: func() time.Time {
+				return time.Now().Add(-time.Hour)
+			},
+		})
+	})
+	if testEntityErr != nil {
+		t.Fatal(testEntityErr)
+	}
+
+	return testEntity
+}
+
+func testKeyID(t *testing.T) string {
+	t.Helper()
+	return testKey(t).PrimaryKey.KeyIdString()
+}
+
+func armorTestKey(t *testing.T) string {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	w, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := testKey(t).Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.String()
+}
+
+// signTestSums returns the armored detached signature of SHA256SUMS by the test key
+func signTestSums(t *testing.T, sums []byte) []byte {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	if err := openpgp.ArmoredDetachSign(buf, testKey(t), bytes.NewReader(sums), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// newTestBinary returns the zipped executable of the version, whose content differs by the arch
+func newTestBinary(t *testing.T, version, arch string) []byte {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	w, err := zw.Create(fmt.Sprintf("terraform-provider-%s_v%s", testName, version))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Write([]byte("\x7fELF" + arch)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// newTestSHASums returns SHA256SUMS of the binaries by the arch of linux
+func newTestSHASums(version string, binaries map[string][]byte) []byte {
+	buf := new(bytes.Buffer)
+	for _, arch := range []string{"386", "amd64", "arm", "arm64"} {
+		b, ok := binaries[arch]
+		if !ok {
+			continue
+		}
+
+		sum := sha256.Sum256(b)
+		fmt.Fprintf(buf, "%s  %s\n", hex.EncodeToString(sum[:]), binaryFilename(testName, version, "linux", arch))
+	}
+
+	return buf.Bytes()
+}
+
+// createTestVersion creates the version signed by the test key with the metadata
+func createTestVersion(t *testing.T, d *driver.Driver, version string, metadata *driver.ProviderVersionMetadata) {
+	t.Helper()
+	ctx := context.Background()
+	if err := d.Provider.CreateProvider(ctx, testNamespace, testName); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := d.Provider.CreateProviderVersion(ctx, testNamespace, testName, version, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Provider.SaveGPGKey(ctx, testNamespace, &driver.GPGKey{KeyID: testKeyID(t), ASCIIArmor: armorTestKey(t)}); err != nil {
+		t.Fatal(err)
+	}
+
+	metadata.KeyID = testKeyID(t)
+	if err := d.Provider.SaveVersionMetadata(ctx, testNamespace, testName, version, metadata); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// createTestPlatform declares the platform of the version as CreateProviderPlatform does
+func createTestPlatform(t *testing.T, p *Provider, d *driver.Driver, version, os, arch string) {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := d.Provider.CreateProviderPlatform(ctx, testNamespace, testName, version, os, arch, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.declarePlatform(ctx, testNamespace, testName, version, os, arch, false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func getVersionMetadata(t *testing.T, d *driver.Driver, version string) *driver.ProviderVersionMetadata {
+	t.Helper()
+	metadata, err := d.Provider.GetVersionMetadata(context.Background(), testNamespace, testName, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return metadata
+}
+
+// failTestVersion marks the version as failed the verification, as the artifacts replaced in the backend do
+func failTestVersion(t *testing.T, d *driver.Driver, version string) {
+	t.Helper()
+	metadata := getVersionMetadata(t, d, version)
+	metadata.Verification = driver.VerificationStateFailed
+	metadata.VerificationError = "sha256 sum mismatch"
+	if err := d.Provider.SaveVersionMetadata(context.Background(), testNamespace, testName, version, metadata); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// uploadTestVersion creates the published version with the binaries of linux and the arches, which is verified
+func uploadTestVersion(t *testing.T, p *Provider, d *driver.Driver, version string, archs ...string) {
+	t.Helper()
+	ctx := context.Background()
+	createTestVersion(t, d, version, &driver.ProviderVersionMetadata{
+		State:        driver.VersionStatePublished,
+		Verification: driver.VerificationStatePending,
+	})
+
+	binaries := map[string][]byte{}
+	for _, arch := range archs {
+		createTestPlatform(t, p, d, version, "linux", arch)
+		binaries[arch] = newTestBinary(t, version, arch)
+		if err := d.Provider.SavePlatformBinary(ctx, testNamespace, testName, version, "linux", arch, bytes.NewReader(binaries[arch]), false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sums := newTestSHASums(version, binaries)
+	if err := d.Provider.SaveSHASUMs(ctx, testNamespace, testName, version, bytes.NewReader(sums), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Provider.SaveSHASUMsSig(ctx, testNamespace, testName, version, bytes.NewReader(signTestSums(t, sums)), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.reverifyVersion(ctx, testNamespace, testName, version); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := getVersionMetadata(t, d, version).Verification; got != driver.VerificationStateVerified {
+		t.Fatalf("verification of %s = %s, want %s", version, got, driver.VerificationStateVerified)
+	}
+}
+
+// listTestVersions returns the versions listed as available to token:ci
+func listTestVersions(t *testing.T, p *Provider) []string {
+	t.Helper()
+	r := withCaller(httptest.NewRequest(http.MethodGet, "/", nil), "token:ci")
+	w := serve(p.ListAvailableVersions(), r, versionVars(""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var resp ListAvailableVersionsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	versions := []string{}
+	for _, v := range resp.Versions {
+		versions = append(versions, v.Version)
+	}
+
+	return versions
+}
+
+func TestCreateProviderInvalidName(t *testing.T) {
+	p, d := newTestProvider(t)
+	p.policy = &policy.Policy{
+		Rules: []policy.Rule{
+			{Subjects: []string{"token:ci"}, Namespaces: []string{"team-a*"}, Scope: policy.ScopePublish},
+		},
+	}
+
+	tests := []struct {
+		namespace string
+		name      string
+		status    int
+	}{
+		{namespace: "team-a/../team-b", name: testName, status: http.StatusBadRequest},
+		{namespace: `team-a\..\team-b`, name: testName, status: http.StatusBadRequest},
+		{namespace: "team-a", name: "..", status: http.StatusBadRequest},
+		{namespace: "team-a", name: "foo/bar", status: http.StatusBadRequest},
+		{namespace: "team-b", name: testName, status: http.StatusForbidden},
+		{namespace: "team-a", name: testName, status: http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		body := jsonBody(t, CreateProviderRequest{
+			Data: &request.Data[CreateProviderRequestDataAttributes, DataType]{
+				Attributes: &CreateProviderRequestDataAttributes{Namespace: tc.namespace, Name: tc.name},
+			},
+		})
+		w := serve(p.CreateProvider(), withCaller(httptest.NewRequest(http.MethodPost, "/", body), "token:ci"), nil)
+		if w.Code != tc.status {
+			t.Fatalf("%s/%s: status = %d, want %d: %s", tc.namespace, tc.name, w.Code, tc.status, w.Body.String())
+		}
+	}
+
+	if err := d.Provider.IsProviderCreated(context.Background(), "team-b", testName); err == nil {
+		t.Fatal("provider is created out of the namespace")
+	}
+}
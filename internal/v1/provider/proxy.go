@@ -0,0 +1,213 @@
+package provider
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kerraform/kegistry/internal/driver"
+	kerrors "github.com/kerraform/kegistry/internal/errors"
+	model "github.com/kerraform/kegistry/internal/model/provider"
+	"github.com/kerraform/kegistry/internal/proxy"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+// availableVersions lists the visible versions with their protocols.
+// The versions of the upstream allowing the namespace are merged, unless the same versions are published to
+// the registry or yanked in the registry. The upstream is requested while the metadata of the versions is read.
+func (p *Provider) availableVersions(ctx context.Context, hostname, namespace, registryName string) ([]model.AvailableVersion, error) {
+	upstream := p.proxy.Upstream(hostname, namespace)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(driver.MetadataConcurrency)
+
+	var upstreamVersions []model.AvailableVersion
+	if upstream != nil {
+		g.Go(func() error {
+			vs, err := p.proxy.ListAvailableVersions(ctx, upstream, namespace, registryName)
+			if err != nil {
+				if !errors.Is(err, proxy.ErrNotFound) {
+					p.logger.Warn("failed to list available versions of upstream, serving the versions in the registry", zap.String("upstream", upstream.Hostname), zap.Error(err))
+				}
+
+				return nil
+			}
+
+			upstreamVersions = vs
+			return nil
+		})
+	}
+
+	versions, err := p.driver.Provider.ListAvailableVersions(ctx, namespace, registryName)
+	if err != nil {
+		if !errors.Is(err, driver.ErrProviderNotExist) {
+			return nil, err
+		}
+
+		if upstream == nil {
+			return nil, kerrors.Wrap(err, kerrors.WithNotFound())
+		}
+
+		// The provider is not fetched yet
+		versions = nil
+	}
+
+	metadata := make([]*driver.ProviderVersionMetadata, len(versions))
+	oks := make([]bool, len(versions))
+	for i, v := range versions {
+		if v.Version == "" {
+			continue
+		}
+
+		i, version := i, v.Version
+		g.Go(func() error {
+			m, ok, err := p.isVisible(ctx, namespace, registryName, version)
+			if err != nil {
+				return err
+			}
+
+			metadata[i], oks[i] = m, ok
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	visible := make([]model.AvailableVersion, 0, len(versions))
+	shadowed := map[string]bool{}
+	for i, v := range versions {
+		if v.Version == "" {
+			continue
+		}
+
+		// The versions fetched from the upstream are pending until all the packages are fetched
+		if metadata[i] == nil || metadata[i].Upstream == "" || metadata[i].YankedAt != nil {
+			shadowed[v.Version] = true
+		}
+
+		if !oks[i] {
+			continue
+		}
+
+		if metadata[i] != nil {
+			v.Protocols = metadata[i].Protocols
+		}
+		visible = append(visible, v)
+	}
+
+	if upstreamVersions == nil {
+		return visible, nil
+	}
+
+	merged := make([]model.AvailableVersion, 0, len(upstreamVersions)+len(visible))
+	listed := map[string]bool{}
+	for _, v := range upstreamVersions {
+		if shadowed[v.Version] {
+			continue
+		}
+
+		listed[v.Version] = true
+		merged = append(merged, v)
+	}
+
+	for _, v := range visible {
+		if !listed[v.Version] {
+			merged = append(merged, v)
+		}
+	}
+
+	return merged, nil
+}
+
+// upstreamPlatforms returns the platforms of the version in the upstream, or nil if the version is not found
+func (p *Provider) upstreamPlatforms(ctx context.Context, upstream *proxy.Upstream, namespace, registryName, version string) ([]model.AvailableVersionPlatform, error) {
+	versions, err := p.proxy.ListAvailableVersions(ctx, upstream, namespace, registryName)
+	if err != nil {
+		if errors.Is(err, proxy.ErrNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	for _, v := range versions {
+		if v.Version == version {
+			return v.Platforms, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// fetchVersion fetches SHA256SUMS and its signature of the version from the upstream, and returns the platforms of the version in the upstream.
+// The versions in the registry are served as is when the upstream is unavailable if cached is true.
+func (p *Provider) fetchVersion(ctx context.Context, upstream *proxy.Upstream, namespace, registryName, version string, cached bool) ([]model.AvailableVersionPlatform, error) {
+	platforms, err := p.upstreamPlatforms(ctx, upstream, namespace, registryName, version)
+	if err != nil {
+		if cached {
+			p.logger.Warn("failed to list platforms of upstream, serving the version in the registry", zap.String("upstream", upstream.Hostname), zap.Error(err))
+			return nil, nil
+		}
+
+		return nil, wrapUpstreamError(err)
+	}
+
+	if len(platforms) == 0 {
+		return nil, nil
+	}
+
+	if err := p.proxy.FetchVersion(ctx, upstream, namespace, registryName, version, platforms[0].OS, platforms[0].Arch); err != nil {
+		if errors.Is(err, proxy.ErrPublished) {
+			return nil, nil
+		}
+
+		return nil, wrapUpstreamError(err)
+	}
+
+	return platforms, nil
+}
+
+// fetchPackage fetches the package from the upstream allowing the namespace if it is missing in the registry,
+// and verifies the version again with the package.
+func (p *Provider) fetchPackage(ctx context.Context, hostname, namespace, registryName, version, os, arch string) error {
+	upstream := p.proxy.Upstream(hostname, namespace)
+	if upstream == nil {
+		return nil
+	}
+
+	fetched, err := p.proxy.FetchPackage(ctx, upstream, namespace, registryName, version, os, arch)
+	if err != nil {
+		if errors.Is(err, proxy.ErrPublished) {
+			return nil
+		}
+
+		return wrapUpstreamError(err)
+	}
+
+	if !fetched {
+		return nil
+	}
+
+	if err := p.reverifyVersion(ctx, namespace, registryName, version); err != nil {
+		return kerrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func wrapUpstreamError(err error) error {
+	if errors.Is(err, proxy.ErrNotFound) {
+		return kerrors.Wrap(err, kerrors.WithNotFound())
+	}
+
+	if isVerificationError(err) {
+		return kerrors.Wrap(err, kerrors.WithBadGateway(), kerrors.WithDetail(err.Error()))
+	}
+
+	return kerrors.Wrap(err, kerrors.WithBadGateway())
+}
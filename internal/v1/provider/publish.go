@@ -0,0 +1,238 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/kerraform/kegistry/internal/audit"
+	"github.com/kerraform/kegistry/internal/driver"
+	kerrors "github.com/kerraform/kegistry/internal/errors"
+	"github.com/kerraform/kegistry/internal/handler"
+	model "github.com/kerraform/kegistry/internal/model/provider"
+	"github.com/kerraform/kegistry/internal/policy"
+)
+
+var (
+	ErrVersionDraft  = errors.New("provider version is not published yet")
+	ErrVersionFailed = errors.New("provider version failed the verification")
+)
+
+type PublishProviderVersionResponseData struct {
+	Attributes *PublishProviderVersionResponseDataAttributes `json:"attributes"`
+	Type       DataType                                      `json:"type"`
+}
+
+type PublishProviderVersionResponseDataAttributes struct {
+	Version     string              `json:"version"`
+	State       driver.VersionState `json:"state"`
+	PublishedAt *time.Time          `json:"published-at,omitempty"`
+}
+
+// PublishProviderVersion publishes the draft version, so that it is listed and can be installed.
+// All the declared platforms, SHA256SUMS and its signature must be uploaded and verified,
+// otherwise 409 is returned with the reason in detail.
+func (p *Provider) PublishProviderVersion() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		namespace := mux.Vars(r)["namespace"]
+		registryName := mux.Vars(r)["registryName"]
+		version := mux.Vars(r)["version"]
+
+		if err := p.policy.Authorize(r.Context(), namespace, policy.ScopePublish); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		unlock := p.lockVersion(namespace, registryName, version)
+		defer unlock()
+
+		metadata, err := p.driver.Provider.GetVersionMetadata(r.Context(), namespace, registryName, version)
+		if err != nil {
+			if errors.Is(err, driver.ErrProviderVersionNotExist) {
+				return kerrors.Wrap(err, kerrors.WithNotFound())
+			}
+
+			return kerrors.Wrap(err)
+		}
+
+		// Publishing the published version again is a no-op
+		if metadata.Draft() {
+			reason, err := p.checkPublishable(r.Context(), namespace, registryName, version, metadata)
+			if err != nil {
+				return kerrors.Wrap(err)
+			}
+
+			if reason != "" {
+				err := fmt.Errorf("provider version cannot be published: %s", reason)
+				return kerrors.Wrap(err, kerrors.WithConflict(), kerrors.WithDetail(err.Error()))
+			}
+
+			now := time.Now()
+			metadata.State = driver.VersionStatePublished
+			metadata.PublishedAt = &now
+			if err := p.driver.Provider.SaveVersionMetadata(r.Context(), namespace, registryName, version, metadata); err != nil {
+				return kerrors.Wrap(err)
+			}
+
+			p.audit.Record(r.Context(), audit.ActionPublish, audit.ResourceTypeProviderVersion, audit.Resource{
+				Namespace: namespace,
+				Name:      registryName,
+				Version:   version,
+				KeyID:     metadata.KeyID,
+			}, nil)
+		}
+
+		state := metadata.State
+		if state == "" {
+			state = driver.VersionStatePublished
+		}
+
+		resp := &PublishProviderVersionResponse{
+			Data: &PublishProviderVersionResponseData{
+				Type: DataTypeRegistryProviderVersions,
+				Attributes: &PublishProviderVersionResponseDataAttributes{
+					Version:     version,
+					State:       state,
+					PublishedAt: metadata.PublishedAt,
+				},
+			},
+		}
+
+		return json.NewEncoder(w).Encode(resp)
+	})
+}
+
+// checkPublishable returns the reason why the version cannot be published, or empty if it can be
+func (p *Provider) checkPublishable(ctx context.Context, namespace, registryName, version string, metadata *driver.ProviderVersionMetadata) (string, error) {
+	if len(metadata.Platforms) == 0 {
+		return "no platform declared", nil
+	}
+
+	for _, platform := range metadata.Platforms {
+		rc, err := p.driver.Provider.GetPlatformBinary(ctx, namespace, registryName, version, platform.OS, platform.Arch)
+		if err != nil {
+			if errors.Is(err, driver.ErrProviderBinaryNotExist) {
+				return fmt.Sprintf("binary of %s_%s not uploaded", platform.OS, platform.Arch), nil
+			}
+
+			return "", err
+		}
+		rc.Close()
+	}
+
+	if err := p.verifyVersion(ctx, namespace, registryName, version, metadata); err != nil {
+		return "", err
+	}
+
+	if metadata.Verification != driver.VerificationStateVerified {
+		return metadata.VerificationError, nil
+	}
+
+	return "", nil
+}
+
+// declarePlatform adds the platform to the platforms of the version, which must be uploaded to publish it.
+// The platform presigned to upload is also added to the presigned platforms, as the registry cannot digest the binary at the upload.
+func (p *Provider) declarePlatform(ctx context.Context, namespace, registryName, version, os, arch string, presigned bool) error {
+	unlock := p.lockVersion(namespace, registryName, version)
+	defer unlock()
+
+	metadata, err := p.driver.Provider.GetVersionMetadata(ctx, namespace, registryName, version)
+	if err != nil {
+		if errors.Is(err, driver.ErrProviderVersionNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	changed := false
+	if !hasPlatform(metadata.Platforms, os, arch) {
+		metadata.Platforms = append(metadata.Platforms, model.AvailableVersionPlatform{
+			OS:   os,
+			Arch: arch,
+		})
+		changed = true
+	}
+
+	if presigned {
+		platform, err := p.presignedPlatform(ctx, namespace, registryName, version, os, arch)
+		if err != nil {
+			return err
+		}
+
+		metadata.Presigned = append(removePresigned(metadata.Presigned, os, arch), *platform)
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return p.driver.Provider.SaveVersionMetadata(ctx, namespace, registryName, version, metadata)
+}
+
+// undeclarePlatform removes the platform from the platforms of the version
+func (p *Provider) undeclarePlatform(ctx context.Context, namespace, registryName, version, os, arch string) error {
+	unlock := p.lockVersion(namespace, registryName, version)
+	defer unlock()
+
+	metadata, err := p.driver.Provider.GetVersionMetadata(ctx, namespace, registryName, version)
+	if err != nil {
+		if errors.Is(err, driver.ErrProviderVersionNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	platforms := make([]model.AvailableVersionPlatform, 0, len(metadata.Platforms))
+	for _, platform := range metadata.Platforms {
+		if platform.OS != os || platform.Arch != arch {
+			platforms = append(platforms, platform)
+		}
+	}
+
+	presigned := removePresigned(metadata.Presigned, os, arch)
+	if len(platforms) == len(metadata.Platforms) && len(presigned) == len(metadata.Presigned) {
+		return nil
+	}
+
+	metadata.Platforms = platforms
+	metadata.Presigned = presigned
+	return p.driver.Provider.SaveVersionMetadata(ctx, namespace, registryName, version, metadata)
+}
+
+func hasPlatform(platforms []model.AvailableVersionPlatform, os, arch string) bool {
+	for _, platform := range platforms {
+		if platform.OS == os && platform.Arch == arch {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasPresigned(platforms []driver.PresignedPlatform, os, arch string) bool {
+	for _, platform := range platforms {
+		if platform.OS == os && platform.Arch == arch {
+			return true
+		}
+	}
+
+	return false
+}
+
+func removePresigned(platforms []driver.PresignedPlatform, os, arch string) []driver.PresignedPlatform {
+	removed := make([]driver.PresignedPlatform, 0, len(platforms))
+	for _, platform := range platforms {
+		if platform.OS != os || platform.Arch != arch {
+			removed = append(removed, platform)
+		}
+	}
+
+	return removed
+}
@@ -0,0 +1,170 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kerraform/kegistry/internal/driver"
+	"github.com/kerraform/kegistry/internal/v1/request"
+)
+
+func TestPublishProviderVersion(t *testing.T) {
+	p, d := newTestProvider(t)
+	ctx := context.Background()
+	createTestVersion(t, d, "1.0.0", &driver.ProviderVersionMetadata{})
+
+	// The version created by the API is a draft
+	w := serve(p.CreateProviderVersion(), httptest.NewRequest(http.MethodPost, "/", jsonBody(t, &CreateProviderVersionRequest{
+		Data: &request.Data[CreateProviderVersionRequestDataAttributes, DataType]{
+			Type: DataTypeRegistryProviderVersions,
+			Attributes: &CreateProviderVersionRequestDataAttributes{
+				Version: "1.1.0",
+				KeyID:   testKeyID(t),
+			},
+		},
+	})), versionVars(""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	if !getVersionMetadata(t, d, "1.1.0").Draft() {
+		t.Fatal("created version is not a draft")
+	}
+
+	createTestPlatform(t, p, d, "1.1.0", "linux", "amd64")
+	publish := func() *httptest.ResponseRecorder {
+		return serve(p.PublishProviderVersion(), httptest.NewRequest(http.MethodPost, "/", nil), versionVars("1.1.0"))
+	}
+
+	w = publish()
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "binary of linux_amd64 not uploaded") {
+		t.Fatalf("status = %d, want %d with the missing binary: %s", w.Code, http.StatusConflict, w.Body.String())
+	}
+
+	if got, want := listTestVersions(t, p), []string{"1.0.0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("versions = %v, want %v", got, want)
+	}
+
+	binary := newTestBinary(t, "1.1.0", "amd64")
+	if err := d.Provider.SavePlatformBinary(ctx, testNamespace, testName, "1.1.0", "linux", "amd64", bytes.NewReader(binary), false); err != nil {
+		t.Fatal(err)
+	}
+
+	w = serve(p.FindPackage(), httptest.NewRequest(http.MethodGet, "/", nil), platformVars("1.1.0", "linux", "amd64"))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status of draft package = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	w = publish()
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "SHA256SUMS not uploaded") {
+		t.Fatalf("status = %d, want %d with the missing SHA256SUMS: %s", w.Code, http.StatusConflict, w.Body.String())
+	}
+
+	sums := newTestSHASums("1.1.0", map[string][]byte{"amd64": binary})
+	if err := d.Provider.SaveSHASUMs(ctx, testNamespace, testName, "1.1.0", bytes.NewReader(sums), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Provider.SaveSHASUMsSig(ctx, testNamespace, testName, "1.1.0", bytes.NewReader(signTestSums(t, sums)), false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Publishing the published version again is a no-op
+	for i := 0; i < 2; i++ {
+		w = publish()
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+
+		var resp PublishProviderVersionResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+
+		if attrs := resp.Data.Attributes; attrs.State != driver.VersionStatePublished || attrs.PublishedAt == nil {
+			t.Fatalf("state = %s, published at = %v, want published", attrs.State, attrs.PublishedAt)
+		}
+	}
+
+	if got, want := listTestVersions(t, p), []string{"1.0.0", "1.1.0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("versions = %v, want %v", got, want)
+	}
+
+	w = serve(p.FindPackage(), httptest.NewRequest(http.MethodGet, "/", nil), platformVars("1.1.0", "linux", "amd64"))
+	if w.Code != http.StatusOK {
+		t.Errorf("status of published package = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+}
+
+func TestDeclarePlatformConcurrently(t *testing.T) {
+	p, d := newTestProvider(t)
+	ctx := context.Background()
+	createTestVersion(t, d, "1.0.0", &driver.ProviderVersionMetadata{
+		State:        driver.VersionStateDraft,
+		Verification: driver.VerificationStatePending,
+	})
+
+	// Every change of the metadata made concurrently is kept, none of them saved over by the others.
+	// The metadata is returned slowly, so that the changes overlap unless serialized.
+	p.driver = &driver.Driver{
+		Audit:    d.Audit,
+		Module:   d.Module,
+		Provider: &slowMetadataProvider{Provider: d.Provider},
+		Token:    d.Token,
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 17)
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(arch string) {
+			defer wg.Done()
+			errs <- p.declarePlatform(ctx, testNamespace, testName, "1.0.0", "linux", arch, false)
+		}(fmt.Sprintf("arch%d", i))
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if w := serve(p.YankProviderVersion(), httptest.NewRequest(http.MethodPost, "/", nil), versionVars("1.0.0")); w.Code != http.StatusNoContent {
+			errs <- fmt.Errorf("yank: status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+		}
+	}()
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	metadata := getVersionMetadata(t, d, "1.0.0")
+	if len(metadata.Platforms) != 16 {
+		t.Errorf("platforms = %v, want 16 of them", metadata.Platforms)
+	}
+
+	if metadata.YankedAt == nil {
+		t.Error("yank is saved over by the platforms")
+	}
+}
+
+// slowMetadataProvider returns the version metadata slowly after reading it
+type slowMetadataProvider struct {
+	driver.Provider
+}
+
+func (p *slowMetadataProvider) GetVersionMetadata(ctx context.Context, namespace, registryName, version string) (*driver.ProviderVersionMetadata, error) {
+	metadata, err := p.Provider.GetVersionMetadata(ctx, namespace, registryName, version)
+	time.Sleep(time.Millisecond)
+	return metadata, err
+}
@@ -8,3 +8,4 @@ type CreateProviderPlatformRequest = request.Request[CreateProviderPlatformReque
 
 // https://www.terraform.io/cloud-docs/api-docs/private-registry/providers#request-body
 type CreateProviderVersionRequest = request.Request[CreateProviderVersionRequestDataAttributes, DataType]
+type YankProviderVersionRequest = request.Request[YankProviderVersionRequestDataAttributes, DataType]
@@ -10,6 +10,8 @@ type Response[T any] struct {
 
 type CreateProviderPlatformResponse = Response[CreateProviderPlatformResponseData]
 type CreateProviderVersionResponse = Response[CreateProviderVersionResponseData]
+type PublishProviderVersionResponse = Response[PublishProviderVersionResponseData]
+type VerifyProviderVersionResponse = Response[VerifyProviderVersionResponseData]
 
 type ListAvailableVersionsResponse struct {
 	Versions []model.AvailableVersion `json:"versions"`
@@ -0,0 +1,134 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/kerraform/kegistry/internal/audit"
+	"github.com/kerraform/kegistry/internal/driver"
+)
+
+var (
+	ErrKeyIDRequired  = errors.New("key-id is required as the registry does not sign the provider versions")
+	ErrRegistrySigned = errors.New("SHA256SUMS and its signature of the version are generated by the registry")
+)
+
+// isRegistrySigned reports whether SHA256SUMS of the version is generated and signed by the registry
+func (p *Provider) isRegistrySigned(ctx context.Context, namespace, registryName, version string) (bool, error) {
+	metadata, err := p.driver.Provider.GetVersionMetadata(ctx, namespace, registryName, version)
+	if err != nil {
+		if errors.Is(err, driver.ErrProviderVersionNotExist) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return metadata.RegistrySigned, nil
+}
+
+// versionLock is the lock of the version, which is removed when no one holds or waits for it
+type versionLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lockVersion locks the version, and returns the function to unlock it.
+// It is held while the metadata of the version is read, changed and saved, or SHA256SUMS of the version is signed,
+// so that the concurrent requests on the version never save over the changes of each other.
+func (p *Provider) lockVersion(namespace, registryName, version string) func() {
+	key := fmt.Sprintf("%s/%s/%s", namespace, registryName, version)
+
+	p.mu.Lock()
+	l, ok := p.versions[key]
+	if !ok {
+		l = &versionLock{}
+		p.versions[key] = l
+	}
+	l.refs++
+	p.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		l.refs--
+		if l.refs == 0 {
+			delete(p.versions, key)
+		}
+	}
+}
+
+// signVersion generates SHA256SUMS of the uploaded platform binaries and signs it by the signing key of the namespace.
+// The signing is serialized per version, so that the one signing last reads all the binaries uploaded concurrently
+// instead of replacing SHA256SUMS with the one missing them.
+func (p *Provider) signVersion(ctx context.Context, namespace, registryName, version string) error {
+	unlock := p.lockVersion(namespace, registryName, version)
+	defer unlock()
+
+	return p.signLockedVersion(ctx, namespace, registryName, version)
+}
+
+// signLockedVersion is signVersion for the caller holding the lock of the version
+func (p *Provider) signLockedVersion(ctx context.Context, namespace, registryName, version string) error {
+	if p.signer == nil {
+		return fmt.Errorf("version %s is signed by the registry but the signing is not enabled", version)
+	}
+
+	platforms, err := p.platforms(ctx, namespace, registryName, version)
+	if err != nil {
+		return err
+	}
+
+	lines := []string{}
+	for _, platform := range platforms {
+		sum, err := p.binarySHA256(ctx, namespace, registryName, version, platform.OS, platform.Arch)
+		if err != nil {
+			if errors.Is(err, driver.ErrProviderBinaryNotExist) {
+				continue
+			}
+
+			return err
+		}
+
+		lines = append(lines, fmt.Sprintf("%s  %s\n", sum, binaryFilename(registryName, version, platform.OS, platform.Arch)))
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i][64:] < lines[j][64:]
+	})
+
+	sums := new(bytes.Buffer)
+	for _, line := range lines {
+		sums.WriteString(line)
+	}
+
+	sig, err := p.signer.Sign(ctx, namespace, sums.Bytes())
+	if err != nil {
+		return err
+	}
+
+	// SHA256SUMS generated by the registry is replaced whenever the platforms change
+	if err := p.driver.Provider.SaveSHASUMs(ctx, namespace, registryName, version, bytes.NewReader(sums.Bytes()), true); err != nil {
+		return err
+	}
+
+	if err := p.driver.Provider.SaveSHASUMsSig(ctx, namespace, registryName, version, bytes.NewReader(sig), true); err != nil {
+		return err
+	}
+
+	res := audit.Resource{
+		Namespace: namespace,
+		Name:      registryName,
+		Version:   version,
+	}
+
+	p.audit.Record(ctx, audit.ActionUpload, audit.ResourceTypeProviderSHASums, res, audit.Digests(sums.Bytes()))
+	p.audit.Record(ctx, audit.ActionUpload, audit.ResourceTypeProviderSHASumsSignature, res, audit.Digests(sig))
+	return nil
+}
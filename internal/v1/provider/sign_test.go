@@ -0,0 +1,175 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/kerraform/kegistry/internal/driver"
+	"github.com/kerraform/kegistry/internal/signing"
+	"github.com/kerraform/kegistry/internal/v1/request"
+	"go.uber.org/zap"
+)
+
+// newTestSigner returns the signer by the key file, as generating the key per namespace is slow
+func newTestSigner(t *testing.T, d *driver.Driver) *signing.Signer {
+	t.Helper()
+	e, err := openpgp.NewEntity("test", "", "test@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	w, err := armor.Encode(buf, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.SerializePrivate(w, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "key.asc")
+	if err := os.WriteFile(keyFile, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := signing.New(&signing.Config{
+		Driver:  d.Provider,
+		KeyFile: keyFile,
+		Logger:  zap.NewNop(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func TestSignVersionConcurrent(t *testing.T) {
+	p, d := newTestProvider(t)
+	p.signer = newTestSigner(t, d)
+	ctx := context.Background()
+	createTestVersion(t, d, "1.0.0", &driver.ProviderVersionMetadata{
+		RegistrySigned: true,
+		State:          driver.VersionStateDraft,
+	})
+
+	archs := []string{"386", "amd64", "arm", "arm64", "ppc64le", "s390x"}
+	var wg sync.WaitGroup
+	for _, arch := range archs {
+		arch := arch
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := d.Provider.CreateProviderPlatform(ctx, testNamespace, testName, "1.0.0", "linux", arch, false); err != nil {
+				t.Error(err)
+				return
+			}
+
+			if err := d.Provider.SavePlatformBinary(ctx, testNamespace, testName, "1.0.0", "linux", arch, strings.NewReader(arch), false); err != nil {
+				t.Error(err)
+				return
+			}
+
+			if err := p.signVersion(ctx, testNamespace, testName, "1.0.0"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	sums, err := p.readSHASums(ctx, testNamespace, testName, "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, arch := range archs {
+		if !bytes.Contains(sums, []byte(binaryFilename(testName, "1.0.0", "linux", arch))) {
+			t.Errorf("SHA256SUMS misses linux_%s:\n%s", arch, sums)
+		}
+	}
+
+	if len(p.versions) != 0 {
+		t.Errorf("locks = %d, want 0", len(p.versions))
+	}
+}
+
+func TestCreateRegistrySignedVersion(t *testing.T) {
+	p, d := newTestProvider(t)
+	ctx := context.Background()
+	if err := d.Provider.CreateProvider(ctx, testNamespace, testName); err != nil {
+		t.Fatal(err)
+	}
+
+	create := func() *httptest.ResponseRecorder {
+		return serve(p.CreateProviderVersion(), httptest.NewRequest(http.MethodPost, "/", jsonBody(t, &CreateProviderVersionRequest{
+			Data: &request.Data[CreateProviderVersionRequestDataAttributes, DataType]{
+				Type: DataTypeRegistryProviderVersions,
+				Attributes: &CreateProviderVersionRequestDataAttributes{
+					Version: "1.0.0",
+				},
+			},
+		})), versionVars("1.0.0"))
+	}
+
+	if w := create(); w.Code != http.StatusBadRequest {
+		t.Fatalf("status without signing = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body.String())
+	}
+
+	p.signer = newTestSigner(t, d)
+	if w := create(); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	metadata := getVersionMetadata(t, d, "1.0.0")
+	keyID, err := p.signer.KeyID(ctx, testNamespace)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !metadata.RegistrySigned || metadata.KeyID != keyID {
+		t.Fatalf("registry signed = %t, key ID = %s, want signed by %s", metadata.RegistrySigned, metadata.KeyID, keyID)
+	}
+
+	createTestPlatform(t, p, d, "1.0.0", "linux", "amd64")
+	w := serve(p.UploadPlatformBinary(), httptest.NewRequest(http.MethodPut, "/", bytes.NewReader(newTestBinary(t, "1.0.0", "amd64"))), platformVars("1.0.0", "linux", "amd64"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	sums, err := p.readSHASums(ctx, testNamespace, testName, "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := newTestSHASums("1.0.0", map[string][]byte{"amd64": newTestBinary(t, "1.0.0", "amd64")}); !bytes.Equal(sums, want) {
+		t.Errorf("SHA256SUMS = %q, want %q", sums, want)
+	}
+
+	if got := getVersionMetadata(t, d, "1.0.0").Verification; got != driver.VerificationStateVerified {
+		t.Errorf("verification = %s, want %s", got, driver.VerificationStateVerified)
+	}
+
+	for name, h := range map[string]http.Handler{
+		"shasums":     p.UploadSHASums(),
+		"shasums-sig": p.UploadSHASumsSignature(),
+	} {
+		w := serve(h, httptest.NewRequest(http.MethodPut, "/", strings.NewReader("sums")), versionVars("1.0.0"))
+		if w.Code != http.StatusConflict {
+			t.Errorf("%s: status = %d, want %d", name, w.Code, http.StatusConflict)
+		}
+	}
+}
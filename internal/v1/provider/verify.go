@@ -0,0 +1,424 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kerraform/kegistry/internal/artifact"
+	"github.com/kerraform/kegistry/internal/driver"
+	kerrors "github.com/kerraform/kegistry/internal/errors"
+	"github.com/kerraform/kegistry/internal/handler"
+	model "github.com/kerraform/kegistry/internal/model/provider"
+	"github.com/kerraform/kegistry/internal/policy"
+	"go.uber.org/zap"
+)
+
+type VerifyProviderVersionResponseData struct {
+	Attributes *VerifyProviderVersionResponseDataAttributes `json:"attributes"`
+	Type       DataType                                     `json:"type"`
+}
+
+type VerifyProviderVersionResponseDataAttributes struct {
+	Version           string                   `json:"version"`
+	KeyID             string                   `json:"key-id"`
+	Verification      driver.VerificationState `json:"verification"`
+	VerificationError string                   `json:"verification-error,omitempty"`
+}
+
+// VerifyProviderVersion re-runs the verification of the provider version
+func (p *Provider) VerifyProviderVersion() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		namespace := mux.Vars(r)["namespace"]
+		registryName := mux.Vars(r)["registryName"]
+		version := mux.Vars(r)["version"]
+
+		if err := p.policy.Authorize(r.Context(), namespace, policy.ScopePublish); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		unlock := p.lockVersion(namespace, registryName, version)
+		defer unlock()
+
+		metadata, err := p.driver.Provider.GetVersionMetadata(r.Context(), namespace, registryName, version)
+		if err != nil {
+			if errors.Is(err, driver.ErrProviderVersionNotExist) {
+				return kerrors.Wrap(err, kerrors.WithNotFound())
+			}
+
+			return kerrors.Wrap(err)
+		}
+
+		if err := p.verifyVersion(r.Context(), namespace, registryName, version, metadata); err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		resp := &VerifyProviderVersionResponse{
+			Data: &VerifyProviderVersionResponseData{
+				Type: DataTypeRegistryProviderVersions,
+				Attributes: &VerifyProviderVersionResponseDataAttributes{
+					Version:           version,
+					KeyID:             metadata.KeyID,
+					Verification:      metadata.Verification,
+					VerificationError: metadata.VerificationError,
+				},
+			},
+		}
+
+		return json.NewEncoder(w).Encode(resp)
+	})
+}
+
+func binaryFilename(registryName, version, os, arch string) string {
+	return fmt.Sprintf("terraform-provider-%s_%s_%s_%s.zip", registryName, version, os, arch)
+}
+
+// isVisible reports whether the version is listed as available with its metadata, which is nil for the legacy versions.
+// It only reads the saved verification state, as the listing is open to anyone. The versions are verified on the upload,
+// the publish and VerifyProviderVersion, which also covers the artifacts uploaded by the presigned URLs.
+func (p *Provider) isVisible(ctx context.Context, namespace, registryName, version string) (*driver.ProviderVersionMetadata, bool, error) {
+	metadata, err := p.driver.Provider.GetVersionMetadata(ctx, namespace, registryName, version)
+	if err != nil {
+		if errors.Is(err, driver.ErrProviderVersionNotExist) {
+			return nil, true, nil
+		}
+
+		return nil, false, err
+	}
+
+	return metadata, metadata.Visible(), nil
+}
+
+// verifyVersion verifies the version and saves the result to the metadata if changed.
+// The binaries uploaded by the presigned URLs are digested first, which the verification is the first to see.
+// The version fails without being signed if any of them is invalid, as they bypass the validation of the upload handler.
+// The caller holds the lock of the version from reading the metadata, as the metadata is saved over.
+func (p *Provider) verifyVersion(ctx context.Context, namespace, registryName, version string, metadata *driver.ProviderVersionMetadata) error {
+	digested, invalid, err := p.digestPresigned(ctx, namespace, registryName, version, metadata)
+	if err != nil {
+		return err
+	}
+
+	state, reason := driver.VerificationStateFailed, invalid
+	if invalid == "" {
+		// SHA256SUMS signed by the registry is generated again with the binaries uploaded by the presigned URLs
+		if digested && metadata.RegistrySigned {
+			if err := p.signLockedVersion(ctx, namespace, registryName, version); err != nil {
+				return err
+			}
+		}
+
+		state, reason, err = p.checkVersion(ctx, namespace, registryName, version, metadata.KeyID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !digested && metadata.Verification == state && metadata.VerificationError == reason {
+		return nil
+	}
+
+	metadata.Verification = state
+	metadata.VerificationError = reason
+	if err := p.driver.Provider.SaveVersionMetadata(ctx, namespace, registryName, version, metadata); err != nil {
+		return err
+	}
+
+	p.logger.Info("updated verification of provider version",
+		zap.String("namespace", namespace),
+		zap.String("name", registryName),
+		zap.String("version", version),
+		zap.String("verification", string(state)),
+		zap.String("reason", reason),
+	)
+	return nil
+}
+
+// reverifyVersion verifies the version after the upload, leaving the versions created before the verification as is
+func (p *Provider) reverifyVersion(ctx context.Context, namespace, registryName, version string) error {
+	unlock := p.lockVersion(namespace, registryName, version)
+	defer unlock()
+
+	metadata, err := p.driver.Provider.GetVersionMetadata(ctx, namespace, registryName, version)
+	if err != nil {
+		if errors.Is(err, driver.ErrProviderVersionNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	if metadata.Verification == "" {
+		return nil
+	}
+
+	return p.verifyVersion(ctx, namespace, registryName, version, metadata)
+}
+
+// checkVersion checks the signature of SHA256SUMS and the sha256 sums of all the platform binaries.
+// The version is pending until all of them are uploaded.
+func (p *Provider) checkVersion(ctx context.Context, namespace, registryName, version, keyID string) (driver.VerificationState, string, error) {
+	sums, err := p.readSHASums(ctx, namespace, registryName, version)
+	if err != nil {
+		if errors.Is(err, driver.ErrProviderSHA256SUMSNotExist) {
+			return driver.VerificationStatePending, "SHA256SUMS not uploaded", nil
+		}
+
+		if isVerificationError(err) {
+			return driver.VerificationStateFailed, err.Error(), nil
+		}
+
+		return "", "", err
+	}
+
+	sig, err := p.readSHASumsSig(ctx, namespace, registryName, version)
+	if err != nil {
+		if errors.Is(err, driver.ErrProviderSHA256SUMSSigNotExist) {
+			return driver.VerificationStatePending, "SHA256SUMS signature not uploaded", nil
+		}
+
+		if isVerificationError(err) {
+			return driver.VerificationStateFailed, err.Error(), nil
+		}
+
+		return "", "", err
+	}
+
+	if err := p.verifySignature(ctx, namespace, keyID, sums, sig); err != nil {
+		if isVerificationError(err) {
+			return driver.VerificationStateFailed, err.Error(), nil
+		}
+
+		return "", "", err
+	}
+
+	parsed, err := artifact.ParseSHASums(bytes.NewReader(sums))
+	if err != nil {
+		return driver.VerificationStateFailed, err.Error(), nil
+	}
+
+	platforms, err := p.platforms(ctx, namespace, registryName, version)
+	if err != nil {
+		return "", "", err
+	}
+
+	if len(platforms) == 0 {
+		return driver.VerificationStatePending, "no platform binary uploaded", nil
+	}
+
+	for _, platform := range platforms {
+		sum, err := p.binarySHA256(ctx, namespace, registryName, version, platform.OS, platform.Arch)
+		if err != nil {
+			if errors.Is(err, driver.ErrProviderBinaryNotExist) {
+				return driver.VerificationStatePending, fmt.Sprintf("binary of %s_%s not uploaded", platform.OS, platform.Arch), nil
+			}
+
+			return "", "", err
+		}
+
+		if err := parsed.Verify(binaryFilename(registryName, version, platform.OS, platform.Arch), sum); err != nil {
+			return driver.VerificationStateFailed, err.Error(), nil
+		}
+	}
+
+	return driver.VerificationStateVerified, "", nil
+}
+
+// checkSHASums checks the new SHA256SUMS against the uploaded signature and platform binaries
+func (p *Provider) checkSHASums(ctx context.Context, namespace, registryName, version string, sums []byte, parsed artifact.SHASums) error {
+	unlock := p.lockVersion(namespace, registryName, version)
+	defer unlock()
+
+	metadata, err := p.driver.Provider.GetVersionMetadata(ctx, namespace, registryName, version)
+	if err != nil && !errors.Is(err, driver.ErrProviderVersionNotExist) {
+		return err
+	}
+
+	if metadata != nil {
+		digested, _, err := p.digestPresigned(ctx, namespace, registryName, version, metadata)
+		if err != nil {
+			return err
+		}
+
+		if digested {
+			if err := p.driver.Provider.SaveVersionMetadata(ctx, namespace, registryName, version, metadata); err != nil {
+				return err
+			}
+		}
+
+		sig, err := p.readSHASumsSig(ctx, namespace, registryName, version)
+		if err != nil && !errors.Is(err, driver.ErrProviderSHA256SUMSSigNotExist) {
+			return err
+		}
+
+		if sig != nil {
+			if err := p.verifySignature(ctx, namespace, metadata.KeyID, sums, sig); err != nil {
+				return err
+			}
+		}
+	}
+
+	platforms, err := p.platforms(ctx, namespace, registryName, version)
+	if err != nil {
+		return err
+	}
+
+	for _, platform := range platforms {
+		sum, err := p.binarySHA256(ctx, namespace, registryName, version, platform.OS, platform.Arch)
+		if err != nil {
+			if errors.Is(err, driver.ErrProviderBinaryNotExist) {
+				continue
+			}
+
+			return err
+		}
+
+		if err := parsed.Verify(binaryFilename(registryName, version, platform.OS, platform.Arch), sum); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkSHASumsSig checks the new signature against the uploaded SHA256SUMS
+func (p *Provider) checkSHASumsSig(ctx context.Context, namespace, registryName, version string, sig []byte) error {
+	metadata, err := p.driver.Provider.GetVersionMetadata(ctx, namespace, registryName, version)
+	if err != nil {
+		if errors.Is(err, driver.ErrProviderVersionNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	sums, err := p.readSHASums(ctx, namespace, registryName, version)
+	if err != nil {
+		if errors.Is(err, driver.ErrProviderSHA256SUMSNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	return p.verifySignature(ctx, namespace, metadata.KeyID, sums, sig)
+}
+
+// checkBinary checks the sha256 sum of the new platform binary against the uploaded SHA256SUMS
+func (p *Provider) checkBinary(ctx context.Context, namespace, registryName, version, os, arch, sum string) error {
+	sums, err := p.readSHASums(ctx, namespace, registryName, version)
+	if err != nil {
+		if errors.Is(err, driver.ErrProviderSHA256SUMSNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	parsed, err := artifact.ParseSHASums(bytes.NewReader(sums))
+	if err != nil {
+		return err
+	}
+
+	return parsed.Verify(binaryFilename(registryName, version, os, arch), sum)
+}
+
+func (p *Provider) verifySignature(ctx context.Context, namespace, keyID string, sums, sig []byte) error {
+	key, err := p.driver.Provider.GetGPGKey(ctx, namespace, keyID)
+	if err != nil {
+		if errors.Is(err, driver.ErrProviderGPGKeyNotExist) {
+			return fmt.Errorf("%w: gpg key %s not found", artifact.ErrInvalidSignature, keyID)
+		}
+
+		return err
+	}
+
+	return artifact.VerifySignature([]byte(key.ASCIIArmor), sums, sig)
+}
+
+func (p *Provider) platforms(ctx context.Context, namespace, registryName, version string) ([]model.AvailableVersionPlatform, error) {
+	versions, err := p.driver.Provider.ListAvailableVersions(ctx, namespace, registryName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range versions {
+		if v.Version == version {
+			return v.Platforms, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// binarySHA256 returns the sha256 sum of the platform binary saved at the upload, so that the binary is not read again.
+// The binary is digested only if the platform metadata is missing, which is backfilled, or the platform is presigned.
+func (p *Provider) binarySHA256(ctx context.Context, namespace, registryName, version, os, arch string) (string, error) {
+	metadata, err := p.platformMetadata(ctx, namespace, registryName, version, os, arch)
+	if err != nil {
+		return "", err
+	}
+
+	return metadata.SHA256, nil
+}
+
+// readSHASums reads SHA256SUMS up to the size limit, as it may be uploaded by the presigned URL bypassing the limit
+func (p *Provider) readSHASums(ctx context.Context, namespace, registryName, version string) ([]byte, error) {
+	rc, err := p.driver.Provider.GetSHASums(ctx, namespace, registryName, version)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return readLimited(rc, p.limits.SHASums)
+}
+
+func (p *Provider) readSHASumsSig(ctx context.Context, namespace, registryName, version string) ([]byte, error) {
+	rc, err := p.driver.Provider.GetSHASumsSig(ctx, namespace, registryName, version)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return readLimited(rc, p.limits.SHASums)
+}
+
+// readLimited reads all of r, failing with artifact.ErrTooLarge if it exceeds the limit unless zero
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(b)) > limit {
+		return nil, fmt.Errorf("%w: exceeds %d bytes", artifact.ErrTooLarge, limit)
+	}
+
+	return b, nil
+}
+
+func isVerificationError(err error) bool {
+	return errors.Is(err, artifact.ErrTooLarge) ||
+		errors.Is(err, artifact.ErrInvalidSHASums) ||
+		errors.Is(err, artifact.ErrInvalidSignature) ||
+		errors.Is(err, artifact.ErrSHASumMismatch) ||
+		errors.Is(err, artifact.ErrSHASumNotListed)
+}
+
+// wrapVerificationError rejects the upload inconsistent with the uploaded artifacts
+func wrapVerificationError(err error) error {
+	if isVerificationError(err) {
+		return kerrors.Wrap(err, kerrors.WithBadRequest(), kerrors.WithDetail(err.Error()))
+	}
+
+	return kerrors.Wrap(err)
+}
@@ -0,0 +1,213 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kerraform/kegistry/internal/driver"
+)
+
+func TestUploadVerification(t *testing.T) {
+	p, d := newTestProvider(t)
+	createTestVersion(t, d, "1.0.0", &driver.ProviderVersionMetadata{
+		State:        driver.VersionStateDraft,
+		Verification: driver.VerificationStatePending,
+	})
+	createTestPlatform(t, p, d, "1.0.0", "linux", "amd64")
+
+	binary := newTestBinary(t, "1.0.0", "amd64")
+	sums := newTestSHASums("1.0.0", map[string][]byte{"amd64": binary})
+	tests := []struct {
+		name         string
+		handler      http.Handler
+		vars         map[string]string
+		body         []byte
+		status       int
+		verification driver.VerificationState
+	}{
+		{
+			name:         "binary",
+			handler:      p.UploadPlatformBinary(),
+			vars:         platformVars("1.0.0", "linux", "amd64"),
+			body:         binary,
+			status:       http.StatusOK,
+			verification: driver.VerificationStatePending,
+		},
+		{
+			name:         "shasums of other binary",
+			handler:      p.UploadSHASums(),
+			vars:         versionVars("1.0.0"),
+			body:         newTestSHASums("1.0.0", map[string][]byte{"amd64": newTestBinary(t, "1.0.0", "arm64")}),
+			status:       http.StatusBadRequest,
+			verification: driver.VerificationStatePending,
+		},
+		{
+			name:         "shasums",
+			handler:      p.UploadSHASums(),
+			vars:         versionVars("1.0.0"),
+			body:         sums,
+			status:       http.StatusOK,
+			verification: driver.VerificationStatePending,
+		},
+		{
+			name:         "signature of other shasums",
+			handler:      p.UploadSHASumsSignature(),
+			vars:         versionVars("1.0.0"),
+			body:         signTestSums(t, []byte("other")),
+			status:       http.StatusBadRequest,
+			verification: driver.VerificationStatePending,
+		},
+		{
+			name:         "signature",
+			handler:      p.UploadSHASumsSignature(),
+			vars:         versionVars("1.0.0"),
+			body:         signTestSums(t, sums),
+			status:       http.StatusOK,
+			verification: driver.VerificationStateVerified,
+		},
+	}
+
+	for _, tc := range tests {
+		w := serve(tc.handler, httptest.NewRequest(http.MethodPut, "/", bytes.NewReader(tc.body)), tc.vars)
+		if w.Code != tc.status {
+			t.Fatalf("%s: status = %d, want %d: %s", tc.name, w.Code, tc.status, w.Body.String())
+		}
+
+		if got := getVersionMetadata(t, d, "1.0.0").Verification; got != tc.verification {
+			t.Fatalf("%s: verification = %s, want %s", tc.name, got, tc.verification)
+		}
+	}
+}
+
+func TestVerifyProviderVersion(t *testing.T) {
+	p, d := newTestProvider(t)
+	ctx := context.Background()
+	createTestVersion(t, d, "1.0.0", &driver.ProviderVersionMetadata{
+		State:        driver.VersionStatePublished,
+		Verification: driver.VerificationStatePending,
+	})
+	createTestPlatform(t, p, d, "1.0.0", "linux", "amd64")
+
+	binary := newTestBinary(t, "1.0.0", "amd64")
+	sums := newTestSHASums("1.0.0", map[string][]byte{"amd64": binary})
+	if err := d.Provider.SavePlatformBinary(ctx, testNamespace, testName, "1.0.0", "linux", "amd64", bytes.NewReader(binary), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Provider.SaveSHASUMs(ctx, testNamespace, testName, "1.0.0", bytes.NewReader(sums), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Provider.SaveSHASUMsSig(ctx, testNamespace, testName, "1.0.0", bytes.NewReader(signTestSums(t, sums)), false); err != nil {
+		t.Fatal(err)
+	}
+
+	verify := func() *VerifyProviderVersionResponseDataAttributes {
+		t.Helper()
+		w := serve(p.VerifyProviderVersion(), httptest.NewRequest(http.MethodPost, "/", nil), versionVars("1.0.0"))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+
+		var resp VerifyProviderVersionResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+
+		return resp.Data.Attributes
+	}
+
+	// The artifacts saved bypassing the upload handlers are verified by VerifyProviderVersion
+	if attrs := verify(); attrs.Verification != driver.VerificationStateVerified {
+		t.Fatalf("verification = %s, want %s: %s", attrs.Verification, driver.VerificationStateVerified, attrs.VerificationError)
+	}
+
+	// SHA256SUMS replaced in the backend no longer matches the signature
+	tampered := newTestSHASums("1.0.0", map[string][]byte{"amd64": binary, "arm64": binary})
+	if err := d.Provider.SaveSHASUMs(ctx, testNamespace, testName, "1.0.0", bytes.NewReader(tampered), true); err != nil {
+		t.Fatal(err)
+	}
+
+	attrs := verify()
+	if attrs.Verification != driver.VerificationStateFailed || attrs.VerificationError == "" {
+		t.Fatalf("verification = %s (%s), want %s with the reason", attrs.Verification, attrs.VerificationError, driver.VerificationStateFailed)
+	}
+
+	w := serve(p.ListAvailableVersions(), httptest.NewRequest(http.MethodGet, "/", nil), versionVars("1.0.0"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var resp ListAvailableVersionsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resp.Versions) != 0 {
+		t.Errorf("versions = %v, want the failed version hidden", resp.Versions)
+	}
+}
+
+func TestVerifyPresignedInvalidBinary(t *testing.T) {
+	tests := []struct {
+		name   string
+		binary []byte
+		limit  int64
+	}{
+		{name: "not a zip", binary: []byte("not a zip")},
+		{name: "too large", binary: newTestBinary(t, "1.0.0", "amd64"), limit: 16},
+	}
+
+	for _, tc := range tests {
+		p, d := newTestProvider(t)
+		p.limits.ProviderBinary = tc.limit
+		ctx := context.Background()
+		uploadTestVersion(t, p, d, "1.0.0", "amd64")
+
+		// The binary is replaced by the presigned URL, bypassing the validation of the upload handler
+		metadata := getVersionMetadata(t, d, "1.0.0")
+		old := newTestBinary(t, "1.0.0", "amd64")
+		sum := sha256.Sum256(old)
+		metadata.Presigned = []driver.PresignedPlatform{{OS: "linux", Arch: "amd64", SHA256: hex.EncodeToString(sum[:])}}
+		if err := d.Provider.SaveVersionMetadata(ctx, testNamespace, testName, "1.0.0", metadata); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := d.Provider.SavePlatformBinary(ctx, testNamespace, testName, "1.0.0", "linux", "amd64", bytes.NewReader(tc.binary), true); err != nil {
+			t.Fatal(err)
+		}
+
+		w := serve(p.VerifyProviderVersion(), httptest.NewRequest(http.MethodPost, "/", nil), versionVars("1.0.0"))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want %d: %s", tc.name, w.Code, http.StatusOK, w.Body.String())
+		}
+
+		metadata = getVersionMetadata(t, d, "1.0.0")
+		if metadata.Verification != driver.VerificationStateFailed || metadata.VerificationError == "" {
+			t.Fatalf("%s: verification = %s (%s), want %s with the reason", tc.name, metadata.Verification, metadata.VerificationError, driver.VerificationStateFailed)
+		}
+
+		// The platform is checked again until a valid binary is uploaded
+		if len(metadata.Presigned) != 1 {
+			t.Errorf("%s: presigned = %v, want the platform left", tc.name, metadata.Presigned)
+		}
+
+		// The digests of the invalid binary are not saved to be served
+		invalid := sha256.Sum256(tc.binary)
+		platform, err := d.Provider.GetPlatformMetadata(ctx, testNamespace, testName, "1.0.0", "linux", "amd64")
+		if err != nil && !errors.Is(err, driver.ErrProviderPlatformMetadataNotExist) {
+			t.Fatal(err)
+		}
+
+		if platform != nil && platform.SHA256 == hex.EncodeToString(invalid[:]) {
+			t.Errorf("%s: shasum = %s, want the digest of the invalid binary not saved", tc.name, platform.SHA256)
+		}
+	}
+}
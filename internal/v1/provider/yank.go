@@ -0,0 +1,129 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/kerraform/kegistry/internal/audit"
+	"github.com/kerraform/kegistry/internal/driver"
+	kerrors "github.com/kerraform/kegistry/internal/errors"
+	"github.com/kerraform/kegistry/internal/handler"
+	"github.com/kerraform/kegistry/internal/policy"
+)
+
+type YankProviderVersionRequestDataAttributes struct {
+	Reason string `json:"reason"`
+}
+
+// YankProviderVersion hides the version from the available versions.
+// The version still can be downloaded, so that the existing lock files keep working.
+func (p *Provider) YankProviderVersion() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		namespace := mux.Vars(r)["namespace"]
+		registryName := mux.Vars(r)["registryName"]
+		version := mux.Vars(r)["version"]
+
+		if err := p.policy.Authorize(r.Context(), namespace, policy.ScopePublish); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		// The reason is optional
+		var req YankProviderVersionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			return kerrors.Wrap(err, kerrors.WithBadRequest())
+		}
+		defer r.Body.Close()
+
+		unlock := p.lockVersion(namespace, registryName, version)
+		defer unlock()
+
+		metadata, err := p.versionMetadata(r, namespace, registryName, version)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		metadata.YankedAt = &now
+		if req.Data != nil && req.Data.Attributes != nil {
+			metadata.YankReason = req.Data.Attributes.Reason
+		}
+
+		if err := p.driver.Provider.SaveVersionMetadata(r.Context(), namespace, registryName, version, metadata); err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		p.audit.Record(r.Context(), audit.ActionYank, audit.ResourceTypeProviderVersion, audit.Resource{
+			Namespace: namespace,
+			Name:      registryName,
+			Version:   version,
+		}, nil)
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+}
+
+// UnyankProviderVersion lists the yanked version as available again
+func (p *Provider) UnyankProviderVersion() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		namespace := mux.Vars(r)["namespace"]
+		registryName := mux.Vars(r)["registryName"]
+		version := mux.Vars(r)["version"]
+
+		if err := p.policy.Authorize(r.Context(), namespace, policy.ScopePublish); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		unlock := p.lockVersion(namespace, registryName, version)
+		defer unlock()
+
+		metadata, err := p.versionMetadata(r, namespace, registryName, version)
+		if err != nil {
+			return err
+		}
+
+		metadata.YankedAt = nil
+		metadata.YankReason = ""
+		if err := p.driver.Provider.SaveVersionMetadata(r.Context(), namespace, registryName, version, metadata); err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		p.audit.Record(r.Context(), audit.ActionUnyank, audit.ResourceTypeProviderVersion, audit.Resource{
+			Namespace: namespace,
+			Name:      registryName,
+			Version:   version,
+		}, nil)
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+}
+
+// versionMetadata returns the metadata of the version, which is empty for the versions created before the metadata is introduced
+func (p *Provider) versionMetadata(r *http.Request, namespace, registryName, version string) (*driver.ProviderVersionMetadata, error) {
+	metadata, err := p.driver.Provider.GetVersionMetadata(r.Context(), namespace, registryName, version)
+	if err == nil {
+		return metadata, nil
+	}
+
+	if !errors.Is(err, driver.ErrProviderVersionNotExist) {
+		return nil, kerrors.Wrap(err)
+	}
+
+	vs, err := p.driver.Provider.ListAvailableVersions(r.Context(), namespace, registryName)
+	if err != nil {
+		return nil, kerrors.Wrap(err)
+	}
+
+	for _, v := range vs {
+		if v.Version == version {
+			return &driver.ProviderVersionMetadata{}, nil
+		}
+	}
+
+	return nil, kerrors.Wrap(driver.ErrProviderVersionNotExist, kerrors.WithNotFound())
+}
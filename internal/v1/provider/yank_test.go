@@ -0,0 +1,50 @@
+package provider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestYankProviderVersion(t *testing.T) {
+	p, d := newTestProvider(t)
+	uploadTestVersion(t, p, d, "1.0.0", "amd64")
+	uploadTestVersion(t, p, d, "1.1.0", "amd64")
+
+	body := strings.NewReader(`{"data":{"type":"registry-provider-versions","attributes":{"reason":"broken"}}}`)
+	w := serve(p.YankProviderVersion(), httptest.NewRequest(http.MethodPost, "/", body), versionVars("1.1.0"))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+	}
+
+	metadata := getVersionMetadata(t, d, "1.1.0")
+	if metadata.YankedAt == nil || metadata.YankReason != "broken" {
+		t.Fatalf("yanked at = %v, reason = %q, want yanked for broken", metadata.YankedAt, metadata.YankReason)
+	}
+
+	if got, want := listTestVersions(t, p), []string{"1.0.0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("versions = %v, want %v", got, want)
+	}
+
+	// The yanked version is still installed by the existing lock files
+	w = serve(p.FindPackage(), httptest.NewRequest(http.MethodGet, "/", nil), platformVars("1.1.0", "linux", "amd64"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status of yanked package = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	w = serve(p.UnyankProviderVersion(), httptest.NewRequest(http.MethodDelete, "/", nil), versionVars("1.1.0"))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+	}
+
+	if got, want := listTestVersions(t, p), []string{"1.0.0", "1.1.0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("versions = %v, want %v", got, want)
+	}
+
+	w = serve(p.YankProviderVersion(), httptest.NewRequest(http.MethodPost, "/", nil), versionVars("2.0.0"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status of missing version = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
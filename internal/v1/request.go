@@ -1,10 +1,47 @@
 package v1
 
-import "github.com/kerraform/kegistry/internal/v1/request"
+import (
+	"time"
+
+	"github.com/kerraform/kegistry/internal/v1/request"
+)
 
 type AddGPGKeyRequestAttributes struct {
-	Namespace  string `json:"namespace" validate:"required"`
+	Namespace  string `json:"namespace" validate:"required,segment"`
 	ASCIIArmor string `json:"ascii-armor" validate:"required"`
+
+	// Source of the key (e.g. the organization), and the URL describing it
+	Source    string `json:"source"`
+	SourceURL string `json:"source-url"`
+
+	// TrustSignature is the armored signature of the key by a trusted key
+	TrustSignature string `json:"trust-signature"`
 }
 
 type AddGPGKeyRequest = request.Request[AddGPGKeyRequestAttributes, DataType]
+
+type CreateTokenRequestAttributes struct {
+	Description string `json:"description"`
+
+	// Namespace which the token can act on, `*` allows every namespace
+	Namespace string `json:"namespace" validate:"required"`
+
+	// Subject of the token, defaults to the caller itself. It is prefixed by `token:` unless already prefixed.
+	Subject   string    `json:"subject"`
+	ExpiredAt time.Time `json:"expired-at"`
+}
+
+type CreateTokenRequest = request.Request[CreateTokenRequestAttributes, DataType]
+
+// UpdateGPGKeyRequestAttributes updates only the given attributes of the key
+type UpdateGPGKeyRequestAttributes struct {
+	Source         *string `json:"source"`
+	SourceURL      *string `json:"source-url"`
+	TrustSignature *string `json:"trust-signature"`
+
+	// Revoked revokes the key, so that it cannot be used for the new provider versions.
+	// Only the admin can reinstate the revoked key by false.
+	Revoked *bool `json:"revoked"`
+}
+
+type UpdateGPGKeyRequest = request.Request[UpdateGPGKeyRequestAttributes, DataType]
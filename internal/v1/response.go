@@ -0,0 +1,74 @@
+package v1
+
+import (
+	"time"
+
+	"github.com/kerraform/kegistry/internal/driver"
+)
+
+type TokenResponse struct {
+	Data *TokenData `json:"data"`
+}
+
+type ListTokensResponse struct {
+	Data []*TokenData `json:"data"`
+}
+
+type TokenData struct {
+	ID         string           `json:"id"`
+	Type       DataType         `json:"type"`
+	Attributes *TokenAttributes `json:"attributes"`
+}
+
+type TokenAttributes struct {
+	Description string     `json:"description"`
+	Namespace   string     `json:"namespace"`
+	Subject     string     `json:"subject"`
+	CreatedAt   time.Time  `json:"created-at"`
+	ExpiredAt   time.Time  `json:"expired-at"`
+	LastUsedAt  *time.Time `json:"last-used-at"`
+
+	// Token is only returned on creation
+	Token string `json:"token,omitempty"`
+}
+
+type GPGKeyResponse struct {
+	Data *GPGKeyData `json:"data"`
+}
+
+type ListGPGKeysResponse struct {
+	Data []*GPGKeyData `json:"data"`
+}
+
+type GPGKeyData struct {
+	ID         string            `json:"id"`
+	Type       DataType          `json:"type"`
+	Attributes *GPGKeyAttributes `json:"attributes"`
+}
+
+type GPGKeyAttributes struct {
+	ASCIIArmor     string     `json:"ascii-armor"`
+	CreatedAt      time.Time  `json:"created-at"`
+	KeyID          string     `json:"key-id"`
+	Namespace      string     `json:"namespace"`
+	RevokedAt      *time.Time `json:"revoked-at"`
+	Source         string     `json:"source"`
+	SourceURL      string     `json:"source-url"`
+	TrustSignature string     `json:"trust-signature"`
+}
+
+type ListAuditEventsResponse struct {
+	Data []*AuditEventData `json:"data"`
+	Meta *AuditEventsMeta  `json:"meta"`
+}
+
+type AuditEventData struct {
+	ID         string             `json:"id"`
+	Type       DataType           `json:"type"`
+	Attributes *driver.AuditEvent `json:"attributes"`
+}
+
+type AuditEventsMeta struct {
+	// ChainValid reports whether the hash chain of the events is intact
+	ChainValid bool `json:"chain-valid"`
+}
@@ -0,0 +1,185 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kerraform/kegistry/internal/audit"
+	"github.com/kerraform/kegistry/internal/auth"
+	"github.com/kerraform/kegistry/internal/driver"
+	kerrors "github.com/kerraform/kegistry/internal/errors"
+	"github.com/kerraform/kegistry/internal/handler"
+	"github.com/kerraform/kegistry/internal/logging"
+	"github.com/kerraform/kegistry/internal/policy"
+	"github.com/kerraform/kegistry/internal/token"
+	"github.com/kerraform/kegistry/internal/validator"
+	"go.uber.org/zap"
+)
+
+func (h *Handler) CreateToken() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		id := auth.FromCtx(r.Context())
+		if id == nil {
+			return kerrors.Wrap(auth.ErrMissingCredentials, kerrors.WithUnauthorized())
+		}
+
+		var req CreateTokenRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return kerrors.Wrap(err, kerrors.WithBadRequest())
+		}
+		defer r.Body.Close()
+
+		if req.Data == nil || req.Data.Type != DataTypeAuthenticationTokens {
+			return kerrors.Wrap(fmt.Errorf("data type is not %s", DataTypeAuthenticationTokens), kerrors.WithBadRequest())
+		}
+
+		if err := validator.Validate.Struct(req); err != nil {
+			return kerrors.Wrap(err, kerrors.WithBadRequest())
+		}
+
+		attrs := req.Data.Attributes
+		subject := attrs.Subject
+		if subject != "" {
+			subject = auth.TokenSubject(subject)
+		}
+
+		scope := policy.ScopeRead
+		if subject == "" || subject == id.Subject {
+			subject = id.Subject
+		} else {
+			// Issuing the token on behalf of another subject, which no one is allowed to without the policy
+			// granting the admin scope, otherwise every caller could act as any subject
+			if h.policy == nil {
+				return kerrors.Wrap(fmt.Errorf("%w: token for another subject requires the policy", policy.ErrForbidden), kerrors.WithForbidden())
+			}
+			scope = policy.ScopeAdmin
+		}
+
+		if err := h.policy.Authorize(r.Context(), attrs.Namespace, scope); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		// The token does not outlive the credential of the caller, otherwise the short-lived credential (e.g. the ID token
+		// of the CI job) would be exchanged for the token lasting longer
+		plain, t, err := h.token.Create(r.Context(), &token.CreateOpts{
+			Description: attrs.Description,
+			Namespace:   attrs.Namespace,
+			Subject:     subject,
+			ExpiredAt:   attrs.ExpiredAt,
+			NotAfter:    id.ExpiredAt,
+		})
+		if err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		h.audit.Record(r.Context(), audit.ActionCreate, audit.ResourceTypeToken, tokenResource(t), nil)
+
+		data := newTokenData(t)
+		data.Attributes.Token = plain
+
+		w.WriteHeader(http.StatusCreated)
+		return json.NewEncoder(w).Encode(&TokenResponse{
+			Data: data,
+		})
+	})
+}
+
+func (h *Handler) ListTokens() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		id := auth.FromCtx(r.Context())
+		if id == nil {
+			return kerrors.Wrap(auth.ErrMissingCredentials, kerrors.WithUnauthorized())
+		}
+
+		ts, err := h.token.List(r.Context())
+		if err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		resp := &ListTokensResponse{
+			Data: []*TokenData{},
+		}
+		for _, t := range ts {
+			if h.authorizeToken(r, id, t) != nil {
+				continue
+			}
+			resp.Data = append(resp.Data, newTokenData(t))
+		}
+
+		return json.NewEncoder(w).Encode(resp)
+	})
+}
+
+func (h *Handler) RevokeToken() http.Handler {
+	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		tokenID := mux.Vars(r)["id"]
+
+		l, err := logging.FromCtx(r.Context())
+		if err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		id := auth.FromCtx(r.Context())
+		if id == nil {
+			return kerrors.Wrap(auth.ErrMissingCredentials, kerrors.WithUnauthorized())
+		}
+
+		t, err := h.token.Get(r.Context(), tokenID)
+		if err != nil {
+			if errors.Is(err, driver.ErrTokenNotExist) {
+				return kerrors.Wrap(err, kerrors.WithNotFound())
+			}
+
+			return kerrors.Wrap(err)
+		}
+
+		if err := h.authorizeToken(r, id, t); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
+		if err := h.token.Revoke(r.Context(), t.ID); err != nil {
+			return kerrors.Wrap(err)
+		}
+		h.audit.Record(r.Context(), audit.ActionRevoke, audit.ResourceTypeToken, tokenResource(t), nil)
+
+		l.Info("revoked token", zap.String("id", t.ID))
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+}
+
+// authorizeToken allows the subject of the token and the admins of the token namespace
+func (h *Handler) authorizeToken(r *http.Request, id *auth.Identity, t *driver.APIToken) error {
+	if t.Subject == id.Subject {
+		return h.policy.Authorize(r.Context(), t.Namespace, policy.ScopeRead)
+	}
+
+	return h.policy.Authorize(r.Context(), t.Namespace, policy.ScopeAdmin)
+}
+
+// tokenResource is the audited resource of the token, which is recorded under the namespace of the token
+func tokenResource(t *driver.APIToken) audit.Resource {
+	return audit.Resource{
+		Namespace: t.Namespace,
+		Name:      t.ID,
+		Subject:   t.Subject,
+	}
+}
+
+func newTokenData(t *driver.APIToken) *TokenData {
+	return &TokenData{
+		ID:   t.ID,
+		Type: DataTypeAuthenticationTokens,
+		Attributes: &TokenAttributes{
+			Description: t.Description,
+			Namespace:   t.Namespace,
+			Subject:     t.Subject,
+			CreatedAt:   t.CreatedAt,
+			ExpiredAt:   t.ExpiredAt,
+			LastUsedAt:  t.LastUsedAt,
+		},
+	}
+}
@@ -0,0 +1,136 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kerraform/kegistry/internal/auth"
+	"github.com/kerraform/kegistry/internal/driver/memory"
+	"github.com/kerraform/kegistry/internal/policy"
+	"github.com/kerraform/kegistry/internal/token"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+func newTokenHandler(p *policy.Policy) *Handler {
+	d := memory.NewDriver(&memory.DriverConfig{
+		Logger: zap.NewNop(),
+		Tracer: trace.NewNoopTracerProvider().Tracer(""),
+	})
+
+	return New(&HandlerConfig{
+		Driver: d,
+		Logger: zap.NewNop(),
+		Policy: p,
+		Token: token.New(&token.Config{
+			DefaultTTL: time.Hour,
+			Driver:     d,
+			Logger:     zap.NewNop(),
+		}),
+	})
+}
+
+func TestCreateToken(t *testing.T) {
+	admins := &policy.Policy{
+		Rules: []policy.Rule{
+			{Subjects: []string{"*"}, Namespaces: []string{"*"}, Scope: policy.ScopeRead},
+			{Subjects: []string{"token:admin"}, Namespaces: []string{"acme"}, Scope: policy.ScopeAdmin},
+		},
+	}
+
+	cases := map[string]struct {
+		policy  *policy.Policy
+		caller  string
+		subject string
+		want    int
+		wantSub string
+	}{
+		"own subject by default": {
+			caller:  "oidc:repo:acme/x",
+			want:    http.StatusCreated,
+			wantSub: "oidc:repo:acme/x",
+		},
+		"own subject": {
+			caller:  "token:ci",
+			subject: "ci",
+			want:    http.StatusCreated,
+			wantSub: "token:ci",
+		},
+		"other subject without policy": {
+			caller:  "token:ci",
+			subject: "admin",
+			want:    http.StatusForbidden,
+		},
+		"other subject by admin": {
+			policy:  admins,
+			caller:  "token:admin",
+			subject: "ci",
+			want:    http.StatusCreated,
+			wantSub: "token:ci",
+		},
+		"other subject by non admin": {
+			policy:  admins,
+			caller:  "token:ci",
+			subject: "admin",
+			want:    http.StatusForbidden,
+		},
+	}
+
+	for name, tc := range cases {
+		h := newTokenHandler(tc.policy)
+		body := `{"data":{"type":"authentication-tokens","attributes":{"namespace":"acme","subject":"` + tc.subject + `"}}}`
+		r := httptest.NewRequest(http.MethodPost, "/registry/v1/tokens", strings.NewReader(body))
+		r = r.WithContext(auth.WithIdentity(context.Background(), &auth.Identity{Subject: tc.caller}))
+
+		w := serve(h.CreateToken(), r, nil)
+		if w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d: %s", name, tc.want, w.Code, w.Body)
+			continue
+		}
+
+		if tc.want != http.StatusCreated {
+			continue
+		}
+
+		var resp TokenResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.Data.Attributes.Subject != tc.wantSub || resp.Data.Attributes.Token == "" {
+			t.Errorf("%s: unexpected token %+v", name, resp.Data.Attributes)
+		}
+	}
+}
+
+func TestCreateTokenExpiry(t *testing.T) {
+	h := newTokenHandler(nil)
+	expiredAt := time.Now().Add(10 * time.Minute).UTC().Truncate(time.Second)
+	body := `{"data":{"type":"authentication-tokens","attributes":{"namespace":"acme","expired-at":"2100-01-01T00:00:00Z"}}}`
+	r := httptest.NewRequest(http.MethodPost, "/registry/v1/tokens", strings.NewReader(body))
+	r = r.WithContext(auth.WithIdentity(context.Background(), &auth.Identity{
+		Subject:   "oidc:repo:acme/x",
+		Method:    auth.MethodOIDC,
+		ExpiredAt: expiredAt,
+	}))
+
+	w := serve(h.CreateToken(), r, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+
+	var resp TokenResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	// The token does not outlive the ID token of the caller
+	if !resp.Data.Attributes.ExpiredAt.Equal(expiredAt) {
+		t.Fatalf("expected the expiry %s, got %s", expiredAt, resp.Data.Attributes.ExpiredAt)
+	}
+}
@@ -5,12 +5,21 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/kerraform/kegistry/internal/artifact"
+	"github.com/kerraform/kegistry/internal/audit"
 	"github.com/kerraform/kegistry/internal/driver"
 	kerrors "github.com/kerraform/kegistry/internal/errors"
 	"github.com/kerraform/kegistry/internal/handler"
 	"github.com/kerraform/kegistry/internal/logging"
+	"github.com/kerraform/kegistry/internal/policy"
+	"github.com/kerraform/kegistry/internal/proxy"
+	"github.com/kerraform/kegistry/internal/signing"
+	"github.com/kerraform/kegistry/internal/token"
 	"github.com/kerraform/kegistry/internal/v1/module"
 	"github.com/kerraform/kegistry/internal/v1/provider"
 	"github.com/kerraform/kegistry/internal/validator"
@@ -23,41 +32,66 @@ import (
 type DataType string
 
 const (
-	DataTypeAddGPGKey DataType = "gpg-keys"
+	DataTypeAddGPGKey            DataType = "gpg-keys"
+	DataTypeAuditEvents          DataType = "audit-events"
+	DataTypeAuthenticationTokens DataType = "authentication-tokens"
 )
 
 type Handler struct {
+	audit  *audit.Recorder
 	logger *zap.Logger
 	driver *driver.Driver
+	policy *policy.Policy
+	token  *token.Manager
 
 	Module   *module.Module
 	Provider *provider.Provider
 }
 
 type HandlerConfig struct {
+	Audit  *audit.Recorder
 	Driver *driver.Driver
+	Limits *artifact.Limits
 	Logger *zap.Logger
+	Policy *policy.Policy
+	Proxy  *proxy.Proxy
+	Signer *signing.Signer
+	Token  *token.Manager
 }
 
 func New(cfg *HandlerConfig) *Handler {
 	module := module.New(&module.Config{
+		Audit:  cfg.Audit,
 		Driver: cfg.Driver,
+		Limits: cfg.Limits,
 		Logger: cfg.Logger.Named("v1.module"),
+		Policy: cfg.Policy,
 	})
 
 	provider := provider.New(&provider.Config{
+		Audit:  cfg.Audit,
 		Driver: cfg.Driver,
+		Limits: cfg.Limits,
 		Logger: cfg.Logger.Named("v1.provider"),
+		Policy: cfg.Policy,
+		Proxy:  cfg.Proxy,
+		Signer: cfg.Signer,
 	})
 
 	return &Handler{
+		audit:    cfg.Audit,
 		driver:   cfg.Driver,
 		logger:   cfg.Logger.Named("v1"),
+		policy:   cfg.Policy,
+		token:    cfg.Token,
 		Module:   module,
 		Provider: provider,
 	}
 }
 
+// AddGPGKey adds the key of the namespace. Adding the same key again updates its metadata,
+// while the different armor of the existing key ID can only replace it with `overwrite=true` by the admin,
+// as the provider versions are verified by the saved key.
 func (h *Handler) AddGPGKey() http.Handler {
 	return handler.NewHandler(func(w http.ResponseWriter, r *http.Request) error {
 		var req AddGPGKeyRequest
@@ -79,6 +113,10 @@ func (h *Handler) AddGPGKey() http.Handler {
 			return kerrors.Wrap(err, kerrors.WithBadRequest())
 		}
 
+		if err := h.policy.Authorize(r.Context(), req.Data.Attributes.Namespace, policy.ScopePublish); err != nil {
+			return kerrors.Wrap(err, kerrors.WithForbidden())
+		}
+
 		b := bytes.NewBufferString(req.Data.Attributes.ASCIIArmor)
 		block, err := armor.Decode(b)
 		if err != nil {
@@ -104,10 +142,94 @@ func (h *Handler) AddGPGKey() http.Handler {
 			zap.String("keyID", pgpKey.KeyIdString()),
 		)
 
-		if err := h.driver.Provider.SaveGPGKey(r.Context(), req.Data.Attributes.Namespace, pgpKey.KeyIdString(), []byte(req.Data.Attributes.ASCIIArmor)); err != nil {
+		key := &driver.GPGKey{
+			KeyID:          pgpKey.KeyIdString(),
+			ASCIIArmor:     req.Data.Attributes.ASCIIArmor,
+			Source:         req.Data.Attributes.Source,
+			SourceURL:      req.Data.Attributes.SourceURL,
+			TrustSignature: req.Data.Attributes.TrustSignature,
+			CreatedAt:      time.Now(),
+		}
+
+		// Adding the same key again keeps its creation and revocation
+		action := audit.ActionCreate
+		existing, err := h.driver.Provider.GetGPGKey(r.Context(), req.Data.Attributes.Namespace, key.KeyID)
+		if err != nil && !errors.Is(err, driver.ErrProviderGPGKeyNotExist) {
 			return kerrors.Wrap(err)
 		}
-		defer r.Body.Close()
+
+		if existing != nil {
+			if !sameArmor(existing.ASCIIArmor, key.ASCIIArmor) {
+				if err := h.authorizeKeyOverwrite(r, req.Data.Attributes.Namespace); err != nil {
+					return err
+				}
+			}
+
+			action = audit.ActionOverwrite
+			if !existing.CreatedAt.IsZero() {
+				key.CreatedAt = existing.CreatedAt
+			}
+			key.RevokedAt = existing.RevokedAt
+		}
+
+		if err := h.driver.Provider.SaveGPGKey(r.Context(), req.Data.Attributes.Namespace, key); err != nil {
+			return kerrors.Wrap(err)
+		}
+
+		h.audit.Record(r.Context(), action, audit.ResourceTypeGPGKey, audit.Resource{
+			Namespace: req.Data.Attributes.Namespace,
+			KeyID:     pgpKey.KeyIdString(),
+		}, audit.Digests([]byte(req.Data.Attributes.ASCIIArmor)))
 		return nil
 	})
 }
+
+// authorizeKeyOverwrite returns 409 unless the admin requests to replace the armor of the existing key with `overwrite=true`
+func (h *Handler) authorizeKeyOverwrite(r *http.Request, namespace string) error {
+	var overwrite bool
+	if v := r.URL.Query().Get("overwrite"); v != "" {
+		var err error
+		overwrite, err = strconv.ParseBool(v)
+		if err != nil {
+			return kerrors.Wrap(err, kerrors.WithBadRequest())
+		}
+	}
+
+	if !overwrite {
+		return kerrors.Wrap(ErrGPGKeyExists, kerrors.WithConflict(), kerrors.WithDetail(ErrGPGKeyExists.Error()))
+	}
+
+	if err := h.policy.Authorize(r.Context(), namespace, policy.ScopeAdmin); err != nil {
+		return kerrors.Wrap(err, kerrors.WithForbidden())
+	}
+
+	return nil
+}
+
+// sameArmor reports whether the armored keys have the same packets regardless of the armor headers and the line breaks
+func sameArmor(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	ab, err := dearmor(a)
+	if err != nil {
+		return false
+	}
+
+	bb, err := dearmor(b)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(ab, bb)
+}
+
+func dearmor(s string) ([]byte, error) {
+	block, err := armor.Decode(bytes.NewBufferString(s))
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(block.Body)
+}
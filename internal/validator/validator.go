@@ -1,9 +1,23 @@
 package validator
 
-import "github.com/go-playground/validator/v10"
+import (
+	"regexp"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/kerraform/kegistry/internal/grammar"
+)
 
 var Validate *validator.Validate
 
+var segmentRegex = regexp.MustCompile(`^` + grammar.Segment + `$`)
+
 func init() {
 	Validate = validator.New()
+
+	// segment is the name used in the path of the storage, e.g. the namespace
+	if err := Validate.RegisterValidation("segment", func(fl validator.FieldLevel) bool {
+		return segmentRegex.MatchString(fl.Field().String())
+	}); err != nil {
+		panic(err)
+	}
 }
@@ -1,20 +1,37 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/kerraform/kegistry/internal/artifact"
+	"github.com/kerraform/kegistry/internal/audit"
+	"github.com/kerraform/kegistry/internal/auth"
+	"github.com/kerraform/kegistry/internal/auth/oidc"
+	"github.com/kerraform/kegistry/internal/certificate"
 	"github.com/kerraform/kegistry/internal/config"
 	"github.com/kerraform/kegistry/internal/driver"
+	"github.com/kerraform/kegistry/internal/driver/azure"
+	"github.com/kerraform/kegistry/internal/driver/gcs"
 	"github.com/kerraform/kegistry/internal/driver/local"
+	"github.com/kerraform/kegistry/internal/driver/memory"
 	"github.com/kerraform/kegistry/internal/driver/s3"
 	"github.com/kerraform/kegistry/internal/logging"
 	"github.com/kerraform/kegistry/internal/metric"
+	"github.com/kerraform/kegistry/internal/oauth"
+	"github.com/kerraform/kegistry/internal/policy"
+	"github.com/kerraform/kegistry/internal/proxy"
+	"github.com/kerraform/kegistry/internal/ratelimit"
 	"github.com/kerraform/kegistry/internal/server"
+	"github.com/kerraform/kegistry/internal/signing"
+	"github.com/kerraform/kegistry/internal/token"
 	"github.com/kerraform/kegistry/internal/trace"
 	v1 "github.com/kerraform/kegistry/internal/v1"
 	"github.com/kerraform/kegistry/internal/version"
@@ -97,9 +114,40 @@ func run(args []string) error {
 	}
 	t := tp.Tracer(cfg.Trace.Name)
 
+	limits := &artifact.Limits{
+		Module:         cfg.Upload.ModuleMaxSize,
+		ProviderBinary: cfg.Upload.ProviderBinaryMaxSize,
+		SHASums:        cfg.Upload.SHASumsMaxSize,
+	}
+
 	logger.Info("setup backend", zap.String("backend", cfg.Backend.Type), zap.String("rootPath", cfg.Backend.RootPath))
 	var d *driver.Driver
 	switch driver.DriverType(cfg.Backend.Type) {
+	case driver.DriverTypeAzure:
+		d, err = azure.NewDriver(logger, &azure.DriverOpts{
+			AccountKey:       cfg.Backend.Azure.AccountKey,
+			AccountName:      cfg.Backend.Azure.AccountName,
+			ConnectionString: cfg.Backend.Azure.ConnectionString,
+			Container:        cfg.Backend.Azure.Container,
+			Endpoint:         cfg.Backend.Azure.Endpoint,
+			Tracer:           t,
+		})
+
+		if err != nil {
+			return err
+		}
+	case driver.DriverTypeGCS:
+		d, err = gcs.NewDriver(logger, &gcs.DriverOpts{
+			Bucket:          cfg.Backend.GCS.Bucket,
+			CredentialsFile: cfg.Backend.GCS.CredentialsFile,
+			Endpoint:        cfg.Backend.GCS.Endpoint,
+			Limits:          limits,
+			Tracer:          t,
+		})
+
+		if err != nil {
+			return err
+		}
 	case driver.DriverTypeS3:
 		d, err = s3.NewDriver(logger, &s3.DriverOpts{
 			AccessKey:    cfg.Backend.S3.AccessKey,
@@ -119,25 +167,276 @@ func run(args []string) error {
 			Tracer:   t,
 			RootPath: cfg.Backend.RootPath,
 		})
+	case driver.DriverTypeMemory:
+		d = memory.NewDriver(&memory.DriverConfig{
+			Logger: logger,
+			Tracer: t,
+		})
 	default:
 		return fmt.Errorf("backend type %s not supported", cfg.Backend.Type)
 	}
 
+	tokenManager := token.New(&token.Config{
+		DefaultTTL: cfg.Auth.TokenTTL,
+		Driver:     d,
+		Logger:     logger.Named("token"),
+		MaxTTL:     cfg.Auth.TokenMaxTTL,
+	})
+
+	// The verified client certificates would identify no one without the authentication
+	if !cfg.Auth.Enable && cfg.TLS.ClientAuth != string(certificate.ClientAuthTypeNone) {
+		return fmt.Errorf("auth enable is required for tls client auth %s", cfg.TLS.ClientAuth)
+	}
+
+	// The client certificates are never requested over the plain HTTP
+	if !cfg.TLS.Enabled() && cfg.TLS.ClientAuth != string(certificate.ClientAuthTypeNone) {
+		return fmt.Errorf("tls cert and key files are required for tls client auth %s", cfg.TLS.ClientAuth)
+	}
+
+	var authenticator auth.Authenticator
+	if cfg.Auth.Enable {
+		logger.Info("setup authentication",
+			zap.Int("staticTokens", len(cfg.Auth.Tokens)),
+			zap.Bool("anonymousRead", cfg.Auth.AnonymousRead),
+		)
+		chain := auth.Chain{
+			auth.NewStaticTokens(cfg.Auth.Tokens),
+			tokenManager,
+		}
+
+		if cfg.Auth.OIDC.Enable {
+			var keySet *oidc.KeySet
+			switch {
+			case cfg.Auth.OIDC.JWKSFile != "":
+				keySet = oidc.NewFileKeySet(cfg.Auth.OIDC.JWKSFile, logger)
+			case cfg.Auth.OIDC.JWKSURL != "":
+				keySet = oidc.NewRemoteKeySet(cfg.Auth.OIDC.JWKSURL, nil, logger)
+			default:
+				return fmt.Errorf("either jwks url or jwks file is required for oidc")
+			}
+
+			if cfg.Auth.OIDC.Issuer == "" {
+				return fmt.Errorf("issuer is required for oidc")
+			}
+
+			// The ID tokens issued to any other service by the issuer would be accepted without the audience
+			if cfg.Auth.OIDC.Audience == "" {
+				return fmt.Errorf("audience is required for oidc")
+			}
+
+			// Every subject of the issuer would be allowed on every namespace without either of them
+			if cfg.Auth.OIDC.NamespaceClaim == "" && cfg.Policy.File == "" {
+				return fmt.Errorf("either namespace claim or policy file is required for oidc")
+			}
+
+			logger.Info("setup oidc authentication",
+				zap.String("issuer", cfg.Auth.OIDC.Issuer),
+				zap.String("subjectClaim", cfg.Auth.OIDC.SubjectClaim),
+			)
+			chain = append(chain, oidc.New(&oidc.Config{
+				Audience:       cfg.Auth.OIDC.Audience,
+				Issuer:         cfg.Auth.OIDC.Issuer,
+				KeySet:         keySet,
+				NamespaceClaim: cfg.Auth.OIDC.NamespaceClaim,
+				SubjectClaim:   cfg.Auth.OIDC.SubjectClaim,
+			}))
+		}
+		if cfg.TLS.ClientAuth != string(certificate.ClientAuthTypeNone) {
+			chain = append(chain, &auth.ClientCertificate{})
+		}
+		authenticator = chain
+	}
+
+	var tlsConfig *tls.Config
+	if cfg.TLS.Enabled() {
+		tlsConfig, err = certificate.NewTLSConfig(&certificate.Config{
+			CertFile:     cfg.TLS.CertFile,
+			ClientAuth:   certificate.ClientAuthType(cfg.TLS.ClientAuth),
+			ClientCAFile: cfg.TLS.ClientCAFile,
+			KeyFile:      cfg.TLS.KeyFile,
+			Logger:       logger.Named("certificate"),
+		})
+		if err != nil {
+			logger.Error("failed to setup tls", zap.Error(err))
+			return err
+		}
+
+		logger.Info("setup tls", zap.String("certFile", cfg.TLS.CertFile), zap.String("clientAuth", cfg.TLS.ClientAuth))
+	}
+
+	var p *policy.Policy
+	if cfg.Policy.File != "" {
+		p, err = policy.Load(cfg.Policy.File)
+		if err != nil {
+			logger.Error("failed to load the policy", zap.Error(err))
+			return err
+		}
+
+		logger.Info("setup authorization policy", zap.String("file", cfg.Policy.File), zap.Int("rules", len(p.Rules)))
+	}
+
+	var recorder *audit.Recorder
+	if cfg.Audit.Enable {
+		var sink audit.Sink
+		switch audit.SinkType(cfg.Audit.Sink) {
+		case audit.SinkTypeBackend:
+			sink = audit.NewBackendSink(d.Audit)
+		case audit.SinkTypeFile:
+			if cfg.Audit.File == "" {
+				err := fmt.Errorf("audit file is required for %s sink", audit.SinkTypeFile)
+				logger.Error("failed to setup audit", zap.Error(err))
+				return err
+			}
+
+			fileSink, err := audit.NewFileSink(cfg.Audit.File)
+			if err != nil {
+				logger.Error("failed to setup audit", zap.Error(err))
+				return err
+			}
+			defer fileSink.Close()
+			sink = fileSink
+		default:
+			err := fmt.Errorf("audit sink %s not supported", cfg.Audit.Sink)
+			logger.Error("failed to setup audit", zap.Error(err))
+			return err
+		}
+
+		var key []byte
+		if cfg.Audit.KeyFile != "" {
+			b, err := os.ReadFile(cfg.Audit.KeyFile)
+			if err != nil {
+				logger.Error("failed to read audit key", zap.Error(err))
+				return err
+			}
+
+			key = bytes.TrimSpace(b)
+			if len(key) == 0 {
+				err := fmt.Errorf("audit key file %s is empty", cfg.Audit.KeyFile)
+				logger.Error("failed to read audit key", zap.Error(err))
+				return err
+			}
+		} else {
+			logger.Warn("audit event chain is not keyed, which anyone able to write the audit events can forge")
+		}
+
+		logger.Info("setup audit", zap.String("sink", cfg.Audit.Sink), zap.Bool("keyed", key != nil))
+		recorder = audit.New(&audit.Config{
+			Key:    key,
+			Logger: logger.Named("audit"),
+			Sink:   sink,
+		})
+	}
+
+	var login *oauth.Server
+	if cfg.Login.Enable {
+		if !cfg.Auth.Enable {
+			logger.Warn("login is enabled but the tokens are not accepted without authentication enabled")
+		}
+
+		logger.Info("setup login", zap.String("clientID", cfg.Login.ClientID), zap.Int("users", len(cfg.Login.Users)))
+		login = oauth.New(&oauth.Config{
+			Audit:    recorder,
+			ClientID: cfg.Login.ClientID,
+			Logger:   logger.Named("oauth"),
+			Token:    tokenManager,
+			Users:    cfg.Login.Users,
+		})
+	}
+
+	var rateLimiter *ratelimit.Limiter
+	if cfg.RateLimit.Enable {
+		logger.Info("setup rate limit",
+			zap.Float64("loginRate", cfg.RateLimit.Login.Rate),
+			zap.Float64("moduleReadRate", cfg.RateLimit.ModuleRead.Rate),
+			zap.Float64("providerReadRate", cfg.RateLimit.ProviderRead.Rate),
+			zap.Float64("uploadRate", cfg.RateLimit.Upload.Rate),
+		)
+		rateLimiter = ratelimit.New(&ratelimit.Config{
+			Limits: map[ratelimit.Group]ratelimit.Limit{
+				ratelimit.GroupLogin: {
+					Burst: cfg.RateLimit.Login.Burst,
+					Rate:  cfg.RateLimit.Login.Rate,
+				},
+				ratelimit.GroupModuleRead: {
+					Burst: cfg.RateLimit.ModuleRead.Burst,
+					Rate:  cfg.RateLimit.ModuleRead.Rate,
+				},
+				ratelimit.GroupProviderRead: {
+					Burst: cfg.RateLimit.ProviderRead.Burst,
+					Rate:  cfg.RateLimit.ProviderRead.Rate,
+				},
+				ratelimit.GroupUpload: {
+					Burst: cfg.RateLimit.Upload.Burst,
+					Rate:  cfg.RateLimit.Upload.Rate,
+				},
+			},
+		})
+	}
+
+	var signer *signing.Signer
+	if cfg.Signing.Enable {
+		logger.Info("setup signing", zap.Bool("keyFile", cfg.Signing.KeyFile != ""))
+		signer, err = signing.New(&signing.Config{
+			Driver:        d.Provider,
+			KeyFile:       cfg.Signing.KeyFile,
+			KeyPassphrase: cfg.Signing.KeyPassphrase,
+			Logger:        logger.Named("signing"),
+		})
+		if err != nil {
+			logger.Error("failed to setup signing", zap.Error(err))
+			return err
+		}
+	}
+
+	var px *proxy.Proxy
+	if cfg.Proxy.File != "" {
+		upstreams, err := proxy.Load(cfg.Proxy.File)
+		if err != nil {
+			logger.Error("failed to load the proxy upstreams", zap.Error(err))
+			return err
+		}
+
+		logger.Info("setup proxy", zap.String("file", cfg.Proxy.File), zap.Int("upstreams", len(upstreams)))
+		px = proxy.New(&proxy.Config{
+			Driver: d,
+			HTTPClient: &http.Client{
+				Timeout: cfg.Proxy.Timeout,
+			},
+			Limits:       limits,
+			ListCacheTTL: cfg.Proxy.ListCacheTTL,
+			ListTimeout:  cfg.Proxy.ListTimeout,
+			Logger:       logger.Named("proxy"),
+			Timeout:      cfg.Proxy.Timeout,
+			Upstreams:    upstreams,
+		})
+	}
+
 	metrics := metric.New(logger, d)
 
 	wg, ctx := errgroup.WithContext(ctx)
 
 	v1 := v1.New(&v1.HandlerConfig{
+		Audit:  recorder,
 		Driver: d,
+		Limits: limits,
 		Logger: logger,
+		Policy: p,
+		Proxy:  px,
+		Signer: signer,
+		Token:  tokenManager,
 	})
 
 	svr := server.NewServer(&server.ServerConfig{
+		AnonymousRead:  cfg.Auth.AnonymousRead,
+		Authenticator:  authenticator,
 		Driver:         d,
 		EnableModule:   cfg.EnableModule,
 		EnableProvider: cfg.EnableProvider,
 		Logger:         logger,
+		Login:          login,
 		Metric:         metrics,
+		RateLimiter:    rateLimiter,
+		TLSConfig:      tlsConfig,
 		Tracer:         t,
 		V1:             v1,
 	})
@@ -147,7 +446,7 @@ func run(args []string) error {
 		return err
 	}
 
-	logger.Info("server started", zap.Int("port", cfg.Port))
+	logger.Info("server started", zap.Int("port", cfg.Port), zap.Bool("tls", tlsConfig != nil))
 	wg.Go(func() error {
 		return svr.Serve(ctx, conn)
 	})